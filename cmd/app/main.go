@@ -1,31 +1,39 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/tashunc/nugenesis-wallet-backend/config"
+	"github.com/tashunc/nugenesis-wallet-backend/external/admin"
+	"github.com/tashunc/nugenesis-wallet-backend/external/apikey"
 	"github.com/tashunc/nugenesis-wallet-backend/external/auth"
 	"github.com/tashunc/nugenesis-wallet-backend/external/data"
 	"github.com/tashunc/nugenesis-wallet-backend/static"
+	"github.com/tashunc/nugenesis-wallet-backend/static/staticServices"
 
 	"github.com/tashunc/nugenesis-wallet-backend/external/user"
 	"github.com/tashunc/nugenesis-wallet-backend/pkg/logger"
-	"net/http"
 	"time"
 )
 
 func main() {
-	cfg := config.LoadConfig()
+	cfg := config.Get()
+	// Exits on an insecure JWT_SECRET before anything is served
+	auth.GetTokenService()
 
 	router := gin.Default()
 	corsConfig := cors.DefaultConfig()
 	corsConfig.AllowAllOrigins = true
-	corsConfig.AllowHeaders = []string{"Origin", "Content-Length", "Content-Type", "X-Nonce", "x-nonce-timestamp", "x-nonce-hash"}
+	corsConfig.AllowHeaders = []string{"Origin", "Content-Length", "Content-Type", "Authorization", "X-API-Key", "X-Admin-Key", "X-Nonce", "x-nonce-timestamp", "x-nonce-hash"}
 	router.Use(cors.New(corsConfig))
 	router.Use(logger.GinLogger())
 
-	//nonceStore := staticServices.NewNonceStore()
+	// Expired nonces are swept in the background, used ones are kept until then to block replays
+	nonceStore := staticServices.GetNonceStore()
+	staticServices.StartNonceCleanup(context.Background(), nonceStore, time.Minute)
+
 	// API group
 	api := router.Group("/api")
 	{
@@ -33,46 +41,13 @@ func main() {
 		data.RegisterRoutes(api)
 		auth.RegisterRoutes(api)
 		static.RegisterRoutes(api)
-		//middleware.RegisterRoutes(api, nonceStore)
 
+		adminGroup := admin.Group(api)
+		admin.RegisterRoutes(adminGroup)
+		apikey.RegisterRoutes(adminGroup)
+		static.RegisterAdminRoutes(adminGroup)
+		user.RegisterAdminRoutes(adminGroup)
 	}
-	router.GET("/api/nonce/info", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{
-			"nonce_length_min": 32,
-			"ttl_seconds":      300, // 5 minutes
-			"hash_algorithm":   "SHA256",
-			"format":           "nonce + timestamp -> SHA256",
-		})
-	})
-
-	router.POST("/api/nonce/register", func(c *gin.Context) {
-		var request struct {
-			Nonce     string `json:"nonce" binding:"required"`
-			Timestamp int64  `json:"timestamp" binding:"required"`
-		}
-
-		if err := c.ShouldBindJSON(&request); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
-			return
-		}
-
-		timestamp := time.Unix(request.Timestamp, 0)
-
-		// Check if timestamp is reasonable (not too old, not too far in future)
-		now := time.Now()
-		if timestamp.Before(now.Add(-1*time.Minute)) || timestamp.After(now.Add(1*time.Minute)) {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid timestamp"})
-			return
-		}
-
-		//hash := nonceStore.RegisterNonce(request.Nonce, timestamp)
-
-		//c.JSON(http.StatusOK, gin.H{
-		//	"hash":      hash,
-		//	"timestamp": request.Timestamp,
-		//	"expires":   timestamp.Add(5 * time.Minute).Unix(),
-		//})
-	})
 
 	err := router.Run(":" + cfg.Port)
 	if err != nil {
@@ -3,13 +3,55 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
+	// JWTSecret signs sessions, the server refuses to start without one
 	JWTSecret string
 	Port      string
+	// AccessTokenTTL and RefreshTokenTTL bound the lifetime of issued sessions
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+
+	GoogleClientID     string
+	GoogleClientSecret string
+	GoogleRedirectURL  string
+
+	// SIWEDomain is the domain sign-in messages must be issued for, wallet sign-in is disabled when empty
+	SIWEDomain   string
+	SIWEChainIDs []int64
+
+	// NonceTTL is how long a registered nonce stays valid, NonceClockSkew how far a client clock may drift
+	NonceTTL       time.Duration
+	NonceClockSkew time.Duration
+
+	// AdminAPIKey authorizes the admin endpoints without a session
+	AdminAPIKey string
+	// AdminSubjects are the session subjects granted the admin role, e.g. google:1234
+	AdminSubjects []string
+
+	// LogVerificationTokens prints email verification tokens to the log while no mailer is configured,
+	// for local development only
+	LogVerificationTokens bool
+}
+
+var (
+	shared     *Config
+	sharedOnce sync.Once
+)
+
+// Get returns the configuration loaded once at first use, handlers and services read it from here
+func Get() *Config {
+	sharedOnce.Do(func() {
+		shared = LoadConfig()
+	})
+	return shared
 }
 
 func LoadConfig() *Config {
@@ -19,8 +61,25 @@ func LoadConfig() *Config {
 	}
 
 	return &Config{
-		JWTSecret: getEnv("JWT_SECRET", "supersecretkey"),
-		Port:      getEnv("PORT", "8080"),
+		JWTSecret:       getEnv("JWT_SECRET", ""),
+		Port:            getEnv("PORT", "8080"),
+		AccessTokenTTL:  time.Duration(getEnvInt("ACCESS_TOKEN_TTL_MINUTES", 15)) * time.Minute,
+		RefreshTokenTTL: time.Duration(getEnvInt("REFRESH_TOKEN_TTL_HOURS", 720)) * time.Hour,
+
+		GoogleClientID:     getEnv("GOOGLE_CLIENT_ID", ""),
+		GoogleClientSecret: getEnv("GOOGLE_CLIENT_SECRET", ""),
+		GoogleRedirectURL:  getEnv("GOOGLE_REDIRECT_URL", "http://localhost:8080/api/auth/callback"),
+
+		SIWEDomain:   getEnv("SIWE_DOMAIN", ""),
+		SIWEChainIDs: getEnvInt64List("SIWE_CHAIN_IDS", []int64{1, 10, 56, 137, 8453, 42161, 43114}),
+
+		NonceTTL:       time.Duration(getEnvInt("NONCE_TTL_SECONDS", 300)) * time.Second,
+		NonceClockSkew: time.Duration(getEnvInt("NONCE_CLOCK_SKEW_SECONDS", 60)) * time.Second,
+
+		AdminAPIKey:   getEnv("ADMIN_API_KEY", ""),
+		AdminSubjects: getEnvList("ADMIN_SUBJECTS"),
+
+		LogVerificationTokens: getEnvBool("LOG_VERIFICATION_TOKENS"),
 	}
 }
 
@@ -30,3 +89,46 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getEnvInt(key string, defaultValue int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil && value > 0 {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvBool(key string) bool {
+	value, _ := strconv.ParseBool(os.Getenv(key))
+	return value
+}
+
+func getEnvList(key string) []string {
+	var list []string
+	for _, part := range strings.Split(os.Getenv(key), ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			list = append(list, part)
+		}
+	}
+	return list
+}
+
+func getEnvInt64List(key string, defaultValue []int64) []int64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	var list []int64
+	for _, part := range strings.Split(value, ",") {
+		n, err := strconv.ParseInt(strings.TrimSpace(part), 10, 64)
+		if err != nil {
+			log.Printf("Ignoring invalid %s entry %q", key, part)
+			continue
+		}
+		list = append(list, n)
+	}
+	if len(list) == 0 {
+		return defaultValue
+	}
+	return list
+}
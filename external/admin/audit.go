@@ -0,0 +1,171 @@
+package admin
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tashunc/nugenesis-wallet-backend/pkg/database"
+)
+
+// AuditRecord is one admin action, BeforeCount and AfterCount are the asset ID mapping counts
+// for actions that touch them
+type AuditRecord struct {
+	ID          int64
+	Actor       string
+	RemoteAddr  string
+	Action      string
+	Target      string
+	BeforeCount *int
+	AfterCount  *int
+	Success     bool
+	Error       string
+	CreatedAt   time.Time
+}
+
+// AuditStore persists audit records, newest first when listed
+type AuditStore interface {
+	Record(ctx context.Context, record *AuditRecord) error
+	List(ctx context.Context, limit int) ([]*AuditRecord, error)
+}
+
+var (
+	auditStore     AuditStore
+	auditStoreOnce sync.Once
+)
+
+// GetAuditStore returns the shared audit store, backed by Postgres when DATABASE_URL is configured
+func GetAuditStore() AuditStore {
+	auditStoreOnce.Do(func() {
+		db, err := database.GetDB()
+		if err == nil {
+			auditStore = NewPostgresAuditStore(db)
+			return
+		}
+		if !errors.Is(err, database.ErrNotConfigured) {
+			log.Printf("Warning: admin audit records are kept in memory: %v", err)
+		}
+		auditStore = NewMemoryAuditStore()
+	})
+	return auditStore
+}
+
+// currentAuditStore returns the store RequireAdmin set for the request
+func currentAuditStore(c *gin.Context) AuditStore {
+	if value, exists := c.Get(auditContextKey); exists {
+		if store, ok := value.(AuditStore); ok {
+			return store
+		}
+	}
+	return GetAuditStore()
+}
+
+// Audit records an admin action of the current request, failures to record are logged
+// so that the audit trail never blocks the action itself
+func Audit(c *gin.Context, action string, target string, before *int, after *int, actionErr error) {
+	record := &AuditRecord{
+		Actor:       CurrentActor(c),
+		RemoteAddr:  c.ClientIP(),
+		Action:      action,
+		Target:      target,
+		BeforeCount: before,
+		AfterCount:  after,
+		Success:     actionErr == nil,
+		CreatedAt:   time.Now(),
+	}
+	if actionErr != nil {
+		record.Error = actionErr.Error()
+	}
+
+	log.Printf("admin audit: actor=%s action=%s target=%s success=%v", record.Actor, action, target, record.Success)
+	if err := currentAuditStore(c).Record(c.Request.Context(), record); err != nil {
+		log.Printf("admin audit: failed to record %s by %s: %v", action, record.Actor, err)
+	}
+}
+
+type MemoryAuditStore struct {
+	records []*AuditRecord
+	mutex   sync.Mutex
+}
+
+func NewMemoryAuditStore() *MemoryAuditStore {
+	return &MemoryAuditStore{}
+}
+
+func (s *MemoryAuditStore) Record(ctx context.Context, record *AuditRecord) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	stored := *record
+	stored.ID = int64(len(s.records) + 1)
+	s.records = append(s.records, &stored)
+	return nil
+}
+
+func (s *MemoryAuditStore) List(ctx context.Context, limit int) ([]*AuditRecord, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var records []*AuditRecord
+	for i := len(s.records) - 1; i >= 0 && len(records) < limit; i-- {
+		copied := *s.records[i]
+		records = append(records, &copied)
+	}
+	return records, nil
+}
+
+// PostgresAuditStore keeps audit records in admin_audit_log
+type PostgresAuditStore struct {
+	db *sql.DB
+}
+
+func NewPostgresAuditStore(db *sql.DB) *PostgresAuditStore {
+	return &PostgresAuditStore{db: db}
+}
+
+func (s *PostgresAuditStore) Record(ctx context.Context, record *AuditRecord) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO admin_audit_log (actor, remote_addr, action, target, before_count, after_count, success, error, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		record.Actor, record.RemoteAddr, record.Action, record.Target, record.BeforeCount, record.AfterCount,
+		record.Success, record.Error, record.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to write audit record: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresAuditStore) List(ctx context.Context, limit int) ([]*AuditRecord, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, actor, remote_addr, action, target, before_count, after_count, success, error, created_at
+		FROM admin_audit_log ORDER BY created_at DESC, id DESC LIMIT $1`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit records: %w", err)
+	}
+	defer rows.Close()
+
+	var records []*AuditRecord
+	for rows.Next() {
+		record := &AuditRecord{}
+		var before, after sql.NullInt64
+		if err := rows.Scan(&record.ID, &record.Actor, &record.RemoteAddr, &record.Action, &record.Target,
+			&before, &after, &record.Success, &record.Error, &record.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to read audit record: %w", err)
+		}
+		if before.Valid {
+			count := int(before.Int64)
+			record.BeforeCount = &count
+		}
+		if after.Valid {
+			count := int(after.Int64)
+			record.AfterCount = &count
+		}
+		records = append(records, record)
+	}
+	return records, rows.Err()
+}
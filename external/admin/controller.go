@@ -0,0 +1,56 @@
+package admin
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tashunc/nugenesis-wallet-backend/external/models"
+)
+
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+// ListAudit returns the most recent audit records
+func ListAudit(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultAuditLimit)))
+	if err != nil || limit <= 0 || limit > maxAuditLimit {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+		return
+	}
+
+	records, err := currentAuditStore(c).List(c.Request.Context(), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.AuditLogControllerResponse{
+			Success: false,
+			Message: "Failed to list audit records",
+			Error: &models.SendRawTransactionError{
+				Code:    http.StatusInternalServerError,
+				Message: err.Error(),
+			},
+		})
+		return
+	}
+
+	response := models.AuditLogControllerResponse{
+		Success: true,
+		Records: make([]models.AuditRecord, 0, len(records)),
+	}
+	for _, record := range records {
+		response.Records = append(response.Records, models.AuditRecord{
+			ID:          record.ID,
+			Actor:       record.Actor,
+			RemoteAddr:  record.RemoteAddr,
+			Action:      record.Action,
+			Target:      record.Target,
+			BeforeCount: record.BeforeCount,
+			AfterCount:  record.AfterCount,
+			Success:     record.Success,
+			Error:       record.Error,
+			CreatedAt:   record.CreatedAt,
+		})
+	}
+	c.JSON(http.StatusOK, response)
+}
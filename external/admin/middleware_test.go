@@ -0,0 +1,70 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tashunc/nugenesis-wallet-backend/external/auth"
+)
+
+func TestRequireAdmin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tokens, err := auth.NewTokenService([]byte("test-secret"), 15*time.Minute, time.Hour, auth.NewMemoryRevocationStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokens.SetAdminSubjects([]string{"google:1", "google:3"})
+	audit := NewMemoryAuditStore()
+
+	router := gin.New()
+	router.POST("/admin", requireAdmin("secret", tokens, audit), func(c *gin.Context) {
+		Audit(c, "test.action", "target", nil, nil, nil)
+		c.String(http.StatusOK, CurrentActor(c))
+	})
+
+	adminSession, _ := tokens.IssueTokenPair(auth.Identity{Subject: "google:1"})
+	userSession, _ := tokens.IssueTokenPair(auth.Identity{Subject: "google:2"})
+	formerAdminSession, _ := tokens.IssueTokenPair(auth.Identity{Subject: "google:3"})
+	// google:3 still holds a token with the admin role after losing it
+	tokens.SetAdminSubjects([]string{"google:1"})
+
+	for _, test := range []struct {
+		name    string
+		headers map[string]string
+		status  int
+		actor   string
+	}{
+		{"no credentials", nil, http.StatusUnauthorized, ""},
+		{"wrong key", map[string]string{KeyHeaderName: "nope"}, http.StatusUnauthorized, ""},
+		{"admin key", map[string]string{KeyHeaderName: "secret"}, http.StatusOK, KeyActor},
+		{"non admin session", map[string]string{"Authorization": "Bearer " + userSession.AccessToken}, http.StatusForbidden, ""},
+		{"former admin session", map[string]string{"Authorization": "Bearer " + formerAdminSession.AccessToken}, http.StatusForbidden, ""},
+		{"admin session", map[string]string{"Authorization": "Bearer " + adminSession.AccessToken}, http.StatusOK, "google:1"},
+	} {
+		request := httptest.NewRequest(http.MethodPost, "/admin", nil)
+		for key, value := range test.headers {
+			request.Header.Set(key, value)
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+
+		if recorder.Code != test.status {
+			t.Errorf("%s: expected status %d, got %d", test.name, test.status, recorder.Code)
+		}
+		if test.actor != "" && recorder.Body.String() != test.actor {
+			t.Errorf("%s: expected actor %s, got %s", test.name, test.actor, recorder.Body.String())
+		}
+	}
+
+	records, err := audit.List(context.Background(), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[0].Actor != "google:1" || records[1].Actor != KeyActor {
+		t.Errorf("Expected an audit record per admin request, got %+v", records)
+	}
+}
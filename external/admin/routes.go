@@ -0,0 +1,12 @@
+package admin
+
+import "github.com/gin-gonic/gin"
+
+// Group creates the /admin group every admin endpoint is registered on
+func Group(rg *gin.RouterGroup) *gin.RouterGroup {
+	return rg.Group("/admin", RequireAdmin())
+}
+
+func RegisterRoutes(rg *gin.RouterGroup) {
+	rg.GET("/audit", ListAudit)
+}
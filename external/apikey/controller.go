@@ -0,0 +1,98 @@
+package apikey
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tashunc/nugenesis-wallet-backend/external/admin"
+	"github.com/tashunc/nugenesis-wallet-backend/external/models"
+)
+
+func info(key *Key) models.APIKeyInfo {
+	return models.APIKeyInfo{
+		ID:         key.ID,
+		Name:       key.Name,
+		Scopes:     key.Scopes,
+		DailyQuota: key.DailyQuota,
+		CreatedAt:  key.CreatedAt,
+		RevokedAt:  key.RevokedAt,
+	}
+}
+
+func respondError(c *gin.Context, status int, message string, err error) {
+	c.JSON(status, models.APIKeyControllerResponse{
+		Success: false,
+		Message: message,
+		Error: &models.SendRawTransactionError{
+			Code:    status,
+			Message: err.Error(),
+		},
+	})
+}
+
+// Mint creates a key, the response is the only time the plaintext key is shown
+func Mint(c *gin.Context) {
+	var request models.MintAPIKeyControllerRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondError(c, http.StatusBadRequest, "Invalid request format", err)
+		return
+	}
+
+	plaintext, key, err := GetService().Mint(c.Request.Context(), request.Name, request.Scopes, request.DailyQuota)
+	target := request.Name
+	if key != nil {
+		target = key.ID
+	}
+	admin.Audit(c, "api_key.mint", target, nil, nil, err)
+	if errors.Is(err, ErrUnknownScope) {
+		respondError(c, http.StatusBadRequest, "Invalid scopes", err)
+		return
+	}
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "Failed to mint API key", err)
+		return
+	}
+
+	keyInfo := info(key)
+	c.JSON(http.StatusCreated, models.APIKeyControllerResponse{
+		Success: true,
+		Key:     plaintext,
+		APIKey:  &keyInfo,
+		Message: "Store this key now, it cannot be shown again",
+	})
+}
+
+func List(c *gin.Context) {
+	keys, err := GetService().List(c.Request.Context())
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "Failed to list API keys", err)
+		return
+	}
+
+	infos := make([]models.APIKeyInfo, 0, len(keys))
+	for _, key := range keys {
+		infos = append(infos, info(key))
+	}
+	c.JSON(http.StatusOK, models.APIKeyControllerResponse{
+		Success: true,
+		APIKeys: infos,
+	})
+}
+
+func Revoke(c *gin.Context) {
+	err := GetService().Revoke(c.Request.Context(), c.Param("keyId"))
+	admin.Audit(c, "api_key.revoke", c.Param("keyId"), nil, nil, err)
+	if errors.Is(err, ErrKeyNotFound) {
+		respondError(c, http.StatusNotFound, "API key not found", err)
+		return
+	}
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "Failed to revoke API key", err)
+		return
+	}
+	c.JSON(http.StatusOK, models.APIKeyControllerResponse{
+		Success: true,
+		Message: "API key revoked successfully",
+	})
+}
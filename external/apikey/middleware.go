@@ -0,0 +1,88 @@
+package apikey
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HeaderName carries the partner API key
+const HeaderName = "X-API-Key"
+
+// keyContextKey holds the *Key of the authenticated request in the gin context
+const keyContextKey = "apikey.key"
+
+// RequireScope rejects requests without an API key holding scope and counts the rest against the key's daily quota.
+// Register it once on the route group of the endpoints sharing the scope, so no route of the group is left open.
+func RequireScope(scope string) gin.HandlerFunc {
+	return requireScope(GetService(), scope)
+}
+
+// RequireKey is RequireScope for endpoints any valid key may use, whatever its scopes
+func RequireKey() gin.HandlerFunc {
+	return requireScope(GetService(), "")
+}
+
+func requireScope(service *Service, scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		plaintext := c.GetHeader(HeaderName)
+		if plaintext == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing API key (" + HeaderName + " header required)"})
+			c.Abort()
+			return
+		}
+
+		key, err := service.Authenticate(c.Request.Context(), plaintext)
+		if err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, ErrInvalidKey) || errors.Is(err, ErrKeyRevoked) {
+				status = http.StatusUnauthorized
+			}
+			c.JSON(status, gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
+
+		if scope != "" && !key.HasScope(scope) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "API key is missing the " + scope + " scope"})
+			c.Abort()
+			return
+		}
+
+		usage, err := service.Consume(c.Request.Context(), key)
+		if usage != nil && !usage.Unlimited {
+			remaining := usage.Limit - usage.Used
+			if remaining < 0 {
+				remaining = 0
+			}
+			c.Header("X-RateLimit-Limit", strconv.Itoa(usage.Limit))
+			c.Header("X-RateLimit-Remaining", strconv.Itoa(remaining))
+			c.Header("X-RateLimit-Reset", strconv.FormatInt(usage.ResetsAt.Unix(), 10))
+		}
+		if errors.Is(err, ErrQuotaExceeded) {
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check API key quota"})
+			c.Abort()
+			return
+		}
+
+		c.Set(keyContextKey, key)
+		c.Next()
+	}
+}
+
+// CurrentKey returns the API key stored by RequireScope
+func CurrentKey(c *gin.Context) (*Key, bool) {
+	value, exists := c.Get(keyContextKey)
+	if !exists {
+		return nil, false
+	}
+	key, ok := value.(*Key)
+	return key, ok
+}
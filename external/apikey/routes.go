@@ -0,0 +1,11 @@
+package apikey
+
+import "github.com/gin-gonic/gin"
+
+// RegisterRoutes mounts key management on the admin group
+func RegisterRoutes(adminGroup *gin.RouterGroup) {
+	keyGroup := adminGroup.Group("/api-keys")
+	keyGroup.POST("/", Mint)
+	keyGroup.GET("/", List)
+	keyGroup.DELETE("/:keyId", Revoke)
+}
@@ -0,0 +1,118 @@
+package apikey
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Usage is the quota state of a key after counting a request
+type Usage struct {
+	Limit     int
+	Used      int
+	ResetsAt  time.Time
+	Unlimited bool
+}
+
+// Service mints keys and authenticates the requests made with them
+type Service struct {
+	store Store
+	now   func() time.Time
+}
+
+var (
+	keyService     *Service
+	keyServiceOnce sync.Once
+)
+
+// GetService returns the shared API key service
+func GetService() *Service {
+	keyServiceOnce.Do(func() {
+		keyService = NewService(NewStore())
+	})
+	return keyService
+}
+
+func NewService(store Store) *Service {
+	return &Service{store: store, now: time.Now}
+}
+
+// Mint creates a key and returns its plaintext, which is not stored and cannot be shown again.
+// A daily quota of 0 means unlimited.
+func (s *Service) Mint(ctx context.Context, name string, scopes []string, dailyQuota int) (string, *Key, error) {
+	scopes, err := validateScopes(scopes)
+	if err != nil {
+		return "", nil, err
+	}
+	if dailyQuota < 0 {
+		return "", nil, fmt.Errorf("daily quota must not be negative")
+	}
+
+	plaintext, id, err := generateKey()
+	if err != nil {
+		return "", nil, err
+	}
+	key := &Key{
+		ID:         id,
+		Name:       strings.TrimSpace(name),
+		Hash:       hashKey(plaintext),
+		Scopes:     scopes,
+		DailyQuota: dailyQuota,
+		CreatedAt:  s.now(),
+	}
+	if err := s.store.Create(ctx, key); err != nil {
+		return "", nil, err
+	}
+	return plaintext, key, nil
+}
+
+// Authenticate returns the active key a plaintext key belongs to
+func (s *Service) Authenticate(ctx context.Context, plaintext string) (*Key, error) {
+	id, err := parseKeyID(plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := s.store.Get(ctx, id)
+	if errors.Is(err, ErrKeyNotFound) {
+		return nil, ErrInvalidKey
+	}
+	if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(key.Hash), []byte(hashKey(plaintext))) != 1 {
+		return nil, ErrInvalidKey
+	}
+	if key.RevokedAt != nil {
+		return nil, ErrKeyRevoked
+	}
+	return key, nil
+}
+
+// Consume counts a request against the daily quota of the key, ErrQuotaExceeded is returned with the usage
+func (s *Service) Consume(ctx context.Context, key *Key) (*Usage, error) {
+	now := s.now().UTC()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	used, err := s.store.IncrementUsage(ctx, key.ID, day)
+	if err != nil {
+		return nil, err
+	}
+	usage := &Usage{Limit: key.DailyQuota, Used: used, ResetsAt: day.AddDate(0, 0, 1), Unlimited: key.DailyQuota == 0}
+	if !usage.Unlimited && used > key.DailyQuota {
+		return usage, ErrQuotaExceeded
+	}
+	return usage, nil
+}
+
+func (s *Service) List(ctx context.Context) ([]*Key, error) {
+	return s.store.List(ctx)
+}
+
+func (s *Service) Revoke(ctx context.Context, id string) error {
+	return s.store.Revoke(ctx, id)
+}
@@ -0,0 +1,102 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestServiceMintAndAuthenticate(t *testing.T) {
+	ctx := context.Background()
+	service := NewService(NewMemoryStore())
+
+	if _, _, err := service.Mint(ctx, "partner", []string{"everything"}, 0); !errors.Is(err, ErrUnknownScope) {
+		t.Errorf("Expected unknown scope to be rejected, got %v", err)
+	}
+
+	plaintext, key, err := service.Mint(ctx, "partner", []string{ScopeHistoryRead, ScopeHistoryRead}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key.Hash == plaintext || len(key.Scopes) != 1 {
+		t.Errorf("Unexpected key %+v", key)
+	}
+
+	if _, err := service.Authenticate(ctx, plaintext); err != nil {
+		t.Errorf("Expected key to authenticate, got %v", err)
+	}
+	if _, err := service.Authenticate(ctx, plaintext[:len(plaintext)-1]+"0"); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Expected altered key to fail, got %v", err)
+	}
+
+	for i := 1; i <= 3; i++ {
+		usage, err := service.Consume(ctx, key)
+		if i <= 2 && err != nil {
+			t.Errorf("Request %d: expected to be within quota, got %v", i, err)
+		}
+		if i == 3 && !errors.Is(err, ErrQuotaExceeded) {
+			t.Errorf("Expected quota to be exceeded, got %v", err)
+		}
+		if usage.Used != i {
+			t.Errorf("Expected %d used, got %d", i, usage.Used)
+		}
+	}
+
+	// Counters are per UTC day
+	service.now = func() time.Time { return time.Now().Add(24 * time.Hour) }
+	if _, err := service.Consume(ctx, key); err != nil {
+		t.Errorf("Expected a new day to reset the quota, got %v", err)
+	}
+
+	if err := service.Revoke(ctx, key.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.Authenticate(ctx, plaintext); !errors.Is(err, ErrKeyRevoked) {
+		t.Errorf("Expected revoked key to fail, got %v", err)
+	}
+}
+
+func TestRequireScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	service := NewService(NewMemoryStore())
+	plaintext, _, err := service.Mint(context.Background(), "partner", []string{ScopeHistoryRead}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router := gin.New()
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router.GET("/history", requireScope(service, ScopeHistoryRead), ok)
+	router.POST("/send", requireScope(service, ScopeTxSend), ok)
+	router.GET("/nonce", requireScope(service, ""), ok)
+
+	cases := []struct {
+		method string
+		path   string
+		key    string
+		status int
+	}{
+		{http.MethodGet, "/history", "", http.StatusUnauthorized},
+		{http.MethodGet, "/history", plaintext, http.StatusOK},
+		{http.MethodGet, "/history", "ngk_bad_key", http.StatusUnauthorized},
+		{http.MethodPost, "/send", plaintext, http.StatusForbidden},
+		{http.MethodGet, "/nonce", "", http.StatusUnauthorized},
+		{http.MethodGet, "/nonce", plaintext, http.StatusOK},
+	}
+	for _, tc := range cases {
+		request := httptest.NewRequest(tc.method, tc.path, nil)
+		if tc.key != "" {
+			request.Header.Set(HeaderName, tc.key)
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		if recorder.Code != tc.status {
+			t.Errorf("%s %s key=%q: expected %d, got %d", tc.method, tc.path, tc.key, tc.status, recorder.Code)
+		}
+	}
+}
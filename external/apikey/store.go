@@ -0,0 +1,189 @@
+package apikey
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/tashunc/nugenesis-wallet-backend/pkg/database"
+)
+
+// Store persists keys and their daily usage counters
+type Store interface {
+	Create(ctx context.Context, key *Key) error
+	Get(ctx context.Context, id string) (*Key, error)
+	List(ctx context.Context) ([]*Key, error)
+	Revoke(ctx context.Context, id string) error
+	// IncrementUsage counts a request for the UTC day and returns the count so far
+	IncrementUsage(ctx context.Context, id string, day time.Time) (int, error)
+}
+
+// NewStore uses Postgres when DATABASE_URL is configured and falls back to memory otherwise,
+// keys minted in memory are lost on restart
+func NewStore() Store {
+	db, err := database.GetDB()
+	if err == nil {
+		return NewPostgresStore(db)
+	}
+	if !errors.Is(err, database.ErrNotConfigured) {
+		log.Printf("Warning: API keys are kept in memory: %v", err)
+	}
+	return NewMemoryStore()
+}
+
+type MemoryStore struct {
+	keys  map[string]*Key
+	usage map[string]int
+	mutex sync.Mutex
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		keys:  make(map[string]*Key),
+		usage: make(map[string]int),
+	}
+}
+
+func (s *MemoryStore) Create(ctx context.Context, key *Key) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	stored := *key
+	s.keys[key.ID] = &stored
+	return nil
+}
+
+func (s *MemoryStore) Get(ctx context.Context, id string) (*Key, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	key, exists := s.keys[id]
+	if !exists {
+		return nil, ErrKeyNotFound
+	}
+	copied := *key
+	return &copied, nil
+}
+
+func (s *MemoryStore) List(ctx context.Context) ([]*Key, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	keys := make([]*Key, 0, len(s.keys))
+	for _, key := range s.keys {
+		copied := *key
+		keys = append(keys, &copied)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt.Before(keys[j].CreatedAt) })
+	return keys, nil
+}
+
+func (s *MemoryStore) Revoke(ctx context.Context, id string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	key, exists := s.keys[id]
+	if !exists {
+		return ErrKeyNotFound
+	}
+	if key.RevokedAt == nil {
+		now := time.Now()
+		key.RevokedAt = &now
+	}
+	return nil
+}
+
+func (s *MemoryStore) IncrementUsage(ctx context.Context, id string, day time.Time) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	counter := id + "/" + day.Format(time.DateOnly)
+	s.usage[counter]++
+	return s.usage[counter], nil
+}
+
+// PostgresStore keeps keys in api_keys and counters in api_key_usage
+type PostgresStore struct {
+	db *sql.DB
+}
+
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+const keyColumns = `id, name, key_hash, scopes, daily_quota, created_at, revoked_at`
+
+func scanKey(row interface{ Scan(...interface{}) error }) (*Key, error) {
+	key := &Key{}
+	var scopes pq.StringArray
+	err := row.Scan(&key.ID, &key.Name, &key.Hash, &scopes, &key.DailyQuota, &key.CreatedAt, &key.RevokedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrKeyNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read API key: %w", err)
+	}
+	key.Scopes = scopes
+	return key, nil
+}
+
+func (s *PostgresStore) Create(ctx context.Context, key *Key) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO api_keys (id, name, key_hash, scopes, daily_quota, created_at) VALUES ($1, $2, $3, $4, $5, $6)`,
+		key.ID, key.Name, key.Hash, pq.Array(key.Scopes), key.DailyQuota, key.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to store API key: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresStore) Get(ctx context.Context, id string) (*Key, error) {
+	return scanKey(s.db.QueryRowContext(ctx, `SELECT `+keyColumns+` FROM api_keys WHERE id = $1`, id))
+}
+
+func (s *PostgresStore) List(ctx context.Context) ([]*Key, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+keyColumns+` FROM api_keys ORDER BY created_at`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list API keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []*Key
+	for rows.Next() {
+		key, err := scanKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+func (s *PostgresStore) Revoke(ctx context.Context, id string) error {
+	result, err := s.db.ExecContext(ctx, `UPDATE api_keys SET revoked_at = COALESCE(revoked_at, now()) WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to revoke API key: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return ErrKeyNotFound
+	}
+	return nil
+}
+
+func (s *PostgresStore) IncrementUsage(ctx context.Context, id string, day time.Time) (int, error) {
+	var count int
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO api_key_usage (key_id, day, count) VALUES ($1, $2, 1)
+		ON CONFLICT (key_id, day) DO UPDATE SET count = api_key_usage.count + 1
+		RETURNING count`, id, day.Format(time.DateOnly)).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count API key usage: %w", err)
+	}
+	return count, nil
+}
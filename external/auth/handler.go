@@ -1,41 +1,81 @@
 package auth
 
 import (
+	"crypto/subtle"
 	"encoding/json"
-)
-import (
-	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/tashunc/nugenesis-wallet-backend/config"
+	"github.com/tashunc/nugenesis-wallet-backend/external/models"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
 )
 
-var oauthConf = &oauth2.Config{
-	ClientID:     "YOUR_GOOGLE_CLIENT_ID",
-	ClientSecret: "YOUR_GOOGLE_CLIENT_SECRET",
-	RedirectURL:  "http://localhost:8080/api/auth/callback",
-	Scopes:       []string{"https://www.googleapis.com/auth/userinfo.profile"},
-	Endpoint:     google.Endpoint,
+var (
+	oauthConf     *oauth2.Config
+	oauthConfOnce sync.Once
+)
+
+// oauthStateCookie binds the state of a Google login to the browser that started it
+const oauthStateCookie = "oauth_state"
+
+// oauthStateTTL is how long a started Google login can be completed
+const oauthStateTTL = 10 * time.Minute
+
+func getOAuthConfig() *oauth2.Config {
+	oauthConfOnce.Do(func() {
+		cfg := config.Get()
+		oauthConf = &oauth2.Config{
+			ClientID:     cfg.GoogleClientID,
+			ClientSecret: cfg.GoogleClientSecret,
+			RedirectURL:  cfg.GoogleRedirectURL,
+			Scopes:       []string{"https://www.googleapis.com/auth/userinfo.profile", "https://www.googleapis.com/auth/userinfo.email"},
+			Endpoint:     google.Endpoint,
+		}
+	})
+	return oauthConf
 }
 
+// Login redirects to Google with a fresh state, which is also set in a short lived cookie for Callback to compare
 func Login(c *gin.Context) {
-	url := oauthConf.AuthCodeURL("state-token", oauth2.AccessTypeOffline)
-	c.Redirect(http.StatusTemporaryRedirect, url)
+	state, err := randomID()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start login"})
+		return
+	}
+
+	conf := getOAuthConfig()
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oauthStateCookie, state, int(oauthStateTTL.Seconds()), "/", "", strings.HasPrefix(conf.RedirectURL, "https://"), true)
+	c.Redirect(http.StatusTemporaryRedirect, conf.AuthCodeURL(state, oauth2.AccessTypeOffline))
 }
 
+// Callback checks the state against the login cookie, exchanges the Google code and starts a session for the
+// Google account
 func Callback(c *gin.Context) {
+	expected, _ := c.Cookie(oauthStateCookie)
+	// The state is single use, the cookie is cleared whatever the outcome
+	c.SetCookie(oauthStateCookie, "", -1, "/", "", strings.HasPrefix(getOAuthConfig().RedirectURL, "https://"), true)
+	if expected == "" || subtle.ConstantTimeCompare([]byte(expected), []byte(c.Query("state"))) != 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid OAuth state"})
+		return
+	}
+
 	code := c.Query("code")
 
-	token, err := oauthConf.Exchange(context.Background(), code)
+	token, err := getOAuthConfig().Exchange(c.Request.Context(), code)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to exchange token"})
 		return
 	}
 
-	// Example: Fetch user profile
-	client := oauthConf.Client(context.Background(), token)
+	client := getOAuthConfig().Client(c.Request.Context(), token)
 	resp, err := client.Get("https://www.googleapis.com/oauth2/v2/userinfo")
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user info"})
@@ -49,5 +89,95 @@ func Callback(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"user": userInfo})
+	googleID, _ := userInfo["id"].(string)
+	if googleID == "" {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Google profile has no id"})
+		return
+	}
+	email, _ := userInfo["email"].(string)
+
+	pair, err := GetTokenService().IssueTokenPair(Identity{Subject: "google:" + googleID, Email: email})
+	if err != nil {
+		respondSessionError(c, http.StatusInternalServerError, "Failed to create session", err)
+		return
+	}
+	RespondSession(c, pair, userInfo, "Logged in successfully")
+}
+
+// Refresh rotates a refresh token, the presented token cannot be used again
+func Refresh(c *gin.Context) {
+	var request models.RefreshTokenControllerRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondSessionError(c, http.StatusBadRequest, "Invalid request format", err)
+		return
+	}
+
+	pair, err := GetTokenService().Refresh(c.Request.Context(), request.RefreshToken)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, ErrInvalidToken) || errors.Is(err, ErrTokenRevoked) || errors.Is(err, ErrRefreshTokenReused) {
+			status = http.StatusUnauthorized
+		}
+		respondSessionError(c, status, "Failed to refresh session", err)
+		return
+	}
+	RespondSession(c, pair, nil, "Session refreshed successfully")
+}
+
+// Logout revokes the current session, requires RequireAuth
+func Logout(c *gin.Context) {
+	claims, ok := CurrentClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+		return
+	}
+
+	if err := GetTokenService().Logout(c.Request.Context(), claims); err != nil {
+		respondSessionError(c, http.StatusInternalServerError, "Failed to log out", err)
+		return
+	}
+	c.JSON(http.StatusOK, models.SessionControllerResponse{
+		Success: true,
+		Message: "Logged out successfully",
+	})
+}
+
+// Me returns the identity of the current session, requires RequireAuth
+func Me(c *gin.Context) {
+	claims, ok := CurrentClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"subject":   claims.Subject,
+		"email":     claims.Email,
+		"roles":     claims.Roles,
+		"expiresAt": claims.ExpiresAt.Unix(),
+	})
+}
+
+func RespondSession(c *gin.Context, pair *TokenPair, user map[string]interface{}, message string) {
+	now := time.Now()
+	c.JSON(http.StatusOK, models.SessionControllerResponse{
+		Success:          true,
+		AccessToken:      pair.AccessToken,
+		RefreshToken:     pair.RefreshToken,
+		TokenType:        "Bearer",
+		ExpiresIn:        int64(pair.AccessExpiresAt.Sub(now).Seconds()),
+		RefreshExpiresIn: int64(pair.RefreshExpiresAt.Sub(now).Seconds()),
+		User:             user,
+		Message:          message,
+	})
+}
+
+func respondSessionError(c *gin.Context, status int, message string, err error) {
+	c.JSON(status, models.SessionControllerResponse{
+		Success: false,
+		Message: message,
+		Error: &models.SendRawTransactionError{
+			Code:    status,
+			Message: fmt.Sprint(err),
+		},
+	})
 }
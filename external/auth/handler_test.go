@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tashunc/nugenesis-wallet-backend/external/models/general"
+)
+
+func TestCallbackRequiresLoginState(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/login", Login)
+	router.GET("/callback", Callback)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/login", nil))
+	location, err := url.Parse(recorder.Header().Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	state := location.Query().Get("state")
+	cookies := recorder.Result().Cookies()
+	if state == "" || len(cookies) != 1 || cookies[0].Value != state || !cookies[0].HttpOnly {
+		t.Fatalf("Expected the login state in the redirect and an HttpOnly cookie, got %q %+v", state, cookies)
+	}
+
+	for _, test := range []struct {
+		name   string
+		query  string
+		cookie *http.Cookie
+	}{
+		{"no cookie", "?state=" + state, nil},
+		{"other state", "?state=forged", cookies[0]},
+		{"no state", "", cookies[0]},
+	} {
+		request := httptest.NewRequest(http.MethodGet, "/callback"+test.query, nil)
+		if test.cookie != nil {
+			request.AddCookie(test.cookie)
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		if recorder.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected the callback to be rejected, got %d", test.name, recorder.Code)
+		}
+	}
+}
+
+func TestWalletSignInRequiresConfiguredDomain(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	raw := signInMessage("Ethereum", "1", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed")
+
+	for _, test := range []struct {
+		name   string
+		domain string
+		status int
+	}{
+		{"not configured", "", http.StatusServiceUnavailable},
+		// The message names the request host, which is not the configured domain
+		{"other domain", "wallet.example.org", http.StatusUnauthorized},
+	} {
+		recorder := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(recorder)
+		c.Request = httptest.NewRequest(http.MethodPost, "http://example.com/api/auth/wallet/verify", nil)
+
+		verifyWalletSignIn(c, test.domain, general.Ethereum, raw, "0x00")
+		if recorder.Code != test.status {
+			t.Errorf("%s: expected status %d, got %d %s", test.name, test.status, recorder.Code, recorder.Body.String())
+		}
+	}
+}
@@ -1,19 +1,309 @@
 package auth
 
 import (
-	"github.com/golang-jwt/jwt/v5"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
 	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/tashunc/nugenesis-wallet-backend/config"
+)
+
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+
+	// RoleAdmin grants access to the /api/admin endpoints
+	RoleAdmin = "admin"
+
+	// insecureJWTSecret is the secret older configurations shipped with, it is public and never accepted
+	insecureJWTSecret = "supersecretkey"
+)
+
+var (
+	// ErrInsecureSecret means JWT_SECRET is empty or the well known former default
+	ErrInsecureSecret = errors.New("JWT_SECRET must be set to a private value")
+	ErrInvalidToken   = errors.New("invalid token")
+	ErrTokenRevoked   = errors.New("token has been revoked")
+	// ErrRefreshTokenReused means a rotated refresh token was presented again, its whole session is revoked
+	ErrRefreshTokenReused = errors.New("refresh token reuse detected")
+)
+
+// Identity is the authenticated principal a session is issued for
+type Identity struct {
+	// Subject is unique per user and prefixed with the login method, e.g. google:1234
+	Subject string
+	Email   string
+	// Stamp identifies the credential the session was started with, revoking it ends every session started with it
+	Stamp string
+}
+
+// Claims are carried by both access and refresh tokens
+type Claims struct {
+	jwt.RegisteredClaims
+	Email     string `json:"email,omitempty"`
+	TokenType string `json:"typ"`
+	// Family is shared by all refresh tokens rotated from the same login
+	Family string   `json:"fam"`
+	Stamp  string   `json:"stp,omitempty"`
+	Roles  []string `json:"roles,omitempty"`
+}
+
+// HasRole reports whether the session was granted role
+func (c *Claims) HasRole(role string) bool {
+	for _, granted := range c.Roles {
+		if granted == role {
+			return true
+		}
+	}
+	return false
+}
+
+// TokenPair is a new session or a rotated one
+type TokenPair struct {
+	AccessToken      string
+	RefreshToken     string
+	AccessExpiresAt  time.Time
+	RefreshExpiresAt time.Time
+}
+
+// TokenService issues and validates HS256 signed sessions.
+// Refresh tokens are single use: every refresh revokes the presented token, and presenting it again revokes the family.
+type TokenService struct {
+	secret     []byte
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+	store      RevocationStore
+	now        func() time.Time
+	// adminSubjects are the subjects IsAdmin accepts, see rolesFor
+	adminSubjects map[string]bool
+}
+
+var (
+	tokenService     *TokenService
+	tokenServiceOnce sync.Once
 )
 
-var jwtSecret = []byte("supersecretkey") // Ideally from an .env
+// GetTokenService returns the shared token service configured from the environment.
+// It exits when JWT_SECRET is insecure, main calls it at startup so the server refuses to start.
+func GetTokenService() *TokenService {
+	tokenServiceOnce.Do(func() {
+		cfg := config.Get()
+		service, err := NewTokenService([]byte(cfg.JWTSecret), cfg.AccessTokenTTL, cfg.RefreshTokenTTL, NewRevocationStore())
+		if err != nil {
+			log.Fatalf("Failed to configure sessions: %v", err)
+		}
+		service.SetAdminSubjects(cfg.AdminSubjects)
+		tokenService = service
+	})
+	return tokenService
+}
+
+// NewTokenService fails with ErrInsecureSecret for an empty or well known secret
+func NewTokenService(secret []byte, accessTTL, refreshTTL time.Duration, store RevocationStore) (*TokenService, error) {
+	if len(secret) == 0 || string(secret) == insecureJWTSecret {
+		return nil, ErrInsecureSecret
+	}
+	return &TokenService{
+		secret:        secret,
+		accessTTL:     accessTTL,
+		refreshTTL:    refreshTTL,
+		store:         store,
+		now:           time.Now,
+		adminSubjects: make(map[string]bool),
+	}, nil
+}
+
+// SetAdminSubjects replaces the subjects granted RoleAdmin
+func (s *TokenService) SetAdminSubjects(subjects []string) {
+	s.adminSubjects = make(map[string]bool)
+	for _, subject := range subjects {
+		s.adminSubjects[subject] = true
+	}
+}
+
+// IsAdmin reports whether subject is currently granted RoleAdmin, admin endpoints check it on every request
+func (s *TokenService) IsAdmin(subject string) bool {
+	return s.adminSubjects[subject]
+}
+
+// rolesFor returns the roles written into new tokens of subject. They tell clients what the session may do,
+// but they are only as current as the token, so authorization goes through IsAdmin instead.
+func (s *TokenService) rolesFor(subject string) []string {
+	if s.IsAdmin(subject) {
+		return []string{RoleAdmin}
+	}
+	return nil
+}
+
+// IssueTokenPair starts a new session for the identity
+func (s *TokenService) IssueTokenPair(identity Identity) (*TokenPair, error) {
+	family, err := randomID()
+	if err != nil {
+		return nil, err
+	}
+	return s.issue(identity, family)
+}
+
+func (s *TokenService) issue(identity Identity, family string) (*TokenPair, error) {
+	now := s.now()
+	pair := &TokenPair{
+		AccessExpiresAt:  now.Add(s.accessTTL),
+		RefreshExpiresAt: now.Add(s.refreshTTL),
+	}
+
+	var err error
+	if pair.AccessToken, err = s.sign(identity, TokenTypeAccess, family, now, pair.AccessExpiresAt); err != nil {
+		return nil, err
+	}
+	if pair.RefreshToken, err = s.sign(identity, TokenTypeRefresh, family, now, pair.RefreshExpiresAt); err != nil {
+		return nil, err
+	}
+	return pair, nil
+}
 
-func GenerateJWT(email string) (string, error) {
-	claims := jwt.MapClaims{
-		"email": email,
-		"exp":   time.Now().Add(24 * time.Hour).Unix(),
-		"iat":   time.Now().Unix(),
+func (s *TokenService) sign(identity Identity, tokenType string, family string, issuedAt time.Time, expiresAt time.Time) (string, error) {
+	id, err := randomID()
+	if err != nil {
+		return "", err
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtSecret)
+	claims := Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        id,
+			Subject:   identity.Subject,
+			IssuedAt:  jwt.NewNumericDate(issuedAt),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+		Email:     identity.Email,
+		TokenType: tokenType,
+		Family:    family,
+		Stamp:     identity.Stamp,
+		Roles:     s.rolesFor(identity.Subject),
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.secret)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign token: %w", err)
+	}
+	return token, nil
+}
+
+// parse verifies signature, expiry and token type
+func (s *TokenService) parse(tokenString string, tokenType string) (*Claims, error) {
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return s.secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithTimeFunc(s.now), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if claims.TokenType != tokenType || claims.ID == "" || claims.Subject == "" {
+		return nil, fmt.Errorf("%w: not an %s token", ErrInvalidToken, tokenType)
+	}
+	return claims, nil
+}
+
+// ValidateAccessToken returns the claims of a valid access token whose session has not been logged out
+func (s *TokenService) ValidateAccessToken(ctx context.Context, tokenString string) (*Claims, error) {
+	claims, err := s.parse(tokenString, TokenTypeAccess)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkRevoked(ctx, claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// Refresh rotates a refresh token into a new pair of the same session
+func (s *TokenService) Refresh(ctx context.Context, refreshToken string) (*TokenPair, error) {
+	claims, err := s.parse(refreshToken, TokenTypeRefresh)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkSessionRevoked(ctx, claims); err != nil {
+		return nil, err
+	}
+
+	// Revoking is the check: only the first of concurrent refreshes with the same token wins
+	rotated, err := s.store.RevokeOnce(ctx, claims.ID, claims.ExpiresAt.Time)
+	if err != nil {
+		return nil, err
+	}
+	if !rotated {
+		// The token was already rotated, so either the client or an attacker holds a stolen copy
+		if err := s.store.Revoke(ctx, familyKey(claims.Family), s.now().Add(s.refreshTTL)); err != nil {
+			return nil, err
+		}
+		return nil, ErrRefreshTokenReused
+	}
+	return s.issue(Identity{Subject: claims.Subject, Email: claims.Email, Stamp: claims.Stamp}, claims.Family)
+}
+
+// Logout revokes the session of the access token, including all of its refresh tokens
+func (s *TokenService) Logout(ctx context.Context, claims *Claims) error {
+	if err := s.store.Revoke(ctx, claims.ID, claims.ExpiresAt.Time); err != nil {
+		return err
+	}
+	return s.store.Revoke(ctx, familyKey(claims.Family), s.now().Add(s.refreshTTL))
+}
+
+// RevokeCredential ends every session of subject started with the credential stamp, e.g. after a password change
+func (s *TokenService) RevokeCredential(ctx context.Context, subject string, stamp string) error {
+	return s.store.Revoke(ctx, stampKey(subject, stamp), s.now().Add(s.refreshTTL))
+}
+
+func (s *TokenService) checkRevoked(ctx context.Context, claims *Claims) error {
+	return s.checkKeys(ctx, append(sessionKeys(claims), claims.ID))
+}
+
+// checkSessionRevoked checks the revocations shared by all tokens of the session
+func (s *TokenService) checkSessionRevoked(ctx context.Context, claims *Claims) error {
+	return s.checkKeys(ctx, sessionKeys(claims))
+}
+
+func (s *TokenService) checkKeys(ctx context.Context, keys []string) error {
+	for _, key := range keys {
+		revoked, err := s.store.IsRevoked(ctx, key)
+		if err != nil {
+			return err
+		}
+		if revoked {
+			return ErrTokenRevoked
+		}
+	}
+	return nil
+}
+
+func sessionKeys(claims *Claims) []string {
+	keys := []string{familyKey(claims.Family)}
+	if claims.Stamp != "" {
+		keys = append(keys, stampKey(claims.Subject, claims.Stamp))
+	}
+	return keys
+}
+
+// familyKey keeps session revocations apart from single token ids in the revocation store
+func familyKey(family string) string {
+	return "family:" + family
+}
+
+func stampKey(subject string, stamp string) string {
+	return "stamp:" + subject + ":" + stamp
+}
+
+func randomID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate token id: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
 }
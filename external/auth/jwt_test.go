@@ -0,0 +1,151 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestTokenService() *TokenService {
+	service, _ := NewTokenService([]byte("test-secret"), 15*time.Minute, time.Hour, NewMemoryRevocationStore())
+	return service
+}
+
+func TestNewTokenServiceRejectsInsecureSecret(t *testing.T) {
+	for _, secret := range []string{"", insecureJWTSecret} {
+		if _, err := NewTokenService([]byte(secret), time.Minute, time.Hour, NewMemoryRevocationStore()); !errors.Is(err, ErrInsecureSecret) {
+			t.Errorf("%q: expected ErrInsecureSecret, got %v", secret, err)
+		}
+	}
+}
+
+func TestConcurrentRefreshRotatesOnce(t *testing.T) {
+	ctx := context.Background()
+	service := newTestTokenService()
+	pair, _ := service.IssueTokenPair(Identity{Subject: "google:42"})
+
+	var wg sync.WaitGroup
+	results := make([]error, 8)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, results[i] = service.Refresh(ctx, pair.RefreshToken)
+		}(i)
+	}
+	wg.Wait()
+
+	rotated := 0
+	for _, err := range results {
+		if err == nil {
+			rotated++
+		} else if !errors.Is(err, ErrRefreshTokenReused) && !errors.Is(err, ErrTokenRevoked) {
+			t.Errorf("Unexpected error %v", err)
+		}
+	}
+	if rotated != 1 {
+		t.Errorf("Expected exactly one refresh to win, got %d", rotated)
+	}
+}
+
+func TestRefreshRotation(t *testing.T) {
+	ctx := context.Background()
+	service := newTestTokenService()
+
+	pair, err := service.IssueTokenPair(Identity{Subject: "google:42", Email: "user@example.com"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	claims, err := service.ValidateAccessToken(ctx, pair.AccessToken)
+	if err != nil {
+		t.Fatalf("Expected valid access token, got %v", err)
+	}
+	if claims.Subject != "google:42" || claims.Email != "user@example.com" {
+		t.Errorf("Unexpected claims %+v", claims)
+	}
+
+	if _, err := service.ValidateAccessToken(ctx, pair.RefreshToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Expected a refresh token to be rejected as access token, got %v", err)
+	}
+
+	rotated, err := service.Refresh(ctx, pair.RefreshToken)
+	if err != nil {
+		t.Fatalf("Expected refresh to succeed, got %v", err)
+	}
+
+	// Presenting the old refresh token again revokes the whole session
+	if _, err := service.Refresh(ctx, pair.RefreshToken); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Errorf("Expected reuse to be detected, got %v", err)
+	}
+	if _, err := service.Refresh(ctx, rotated.RefreshToken); !errors.Is(err, ErrTokenRevoked) {
+		t.Errorf("Expected rotated token to be revoked with its family, got %v", err)
+	}
+	if _, err := service.ValidateAccessToken(ctx, rotated.AccessToken); !errors.Is(err, ErrTokenRevoked) {
+		t.Errorf("Expected access token of the revoked family to be rejected, got %v", err)
+	}
+}
+
+func TestLogout(t *testing.T) {
+	ctx := context.Background()
+	service := newTestTokenService()
+
+	pair, _ := service.IssueTokenPair(Identity{Subject: "google:42"})
+	claims, err := service.ValidateAccessToken(ctx, pair.AccessToken)
+	if err != nil {
+		t.Fatalf("Expected valid access token, got %v", err)
+	}
+
+	if err := service.Logout(ctx, claims); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := service.ValidateAccessToken(ctx, pair.AccessToken); !errors.Is(err, ErrTokenRevoked) {
+		t.Errorf("Expected access token to be revoked, got %v", err)
+	}
+	if _, err := service.Refresh(ctx, pair.RefreshToken); !errors.Is(err, ErrTokenRevoked) {
+		t.Errorf("Expected refresh token to be revoked, got %v", err)
+	}
+}
+
+func TestRevokeCredential(t *testing.T) {
+	ctx := context.Background()
+	service := newTestTokenService()
+
+	first, _ := service.IssueTokenPair(Identity{Subject: "user:1", Stamp: "old"})
+	rotated, err := service.Refresh(ctx, first.RefreshToken)
+	if err != nil {
+		t.Fatalf("Expected refresh to succeed, got %v", err)
+	}
+	other, _ := service.IssueTokenPair(Identity{Subject: "user:1", Stamp: "new"})
+
+	if err := service.RevokeCredential(ctx, "user:1", "old"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := service.ValidateAccessToken(ctx, rotated.AccessToken); !errors.Is(err, ErrTokenRevoked) {
+		t.Errorf("Expected rotated session to keep its stamp and be revoked, got %v", err)
+	}
+	if _, err := service.Refresh(ctx, rotated.RefreshToken); !errors.Is(err, ErrTokenRevoked) {
+		t.Errorf("Expected refresh of a revoked credential to fail, got %v", err)
+	}
+	if _, err := service.ValidateAccessToken(ctx, other.AccessToken); err != nil {
+		t.Errorf("Expected session of the new credential to stay valid, got %v", err)
+	}
+}
+
+func TestExpiredAndForeignTokens(t *testing.T) {
+	ctx := context.Background()
+	service := newTestTokenService()
+	pair, _ := service.IssueTokenPair(Identity{Subject: "google:42"})
+
+	service.now = func() time.Time { return time.Now().Add(time.Hour) }
+	if _, err := service.ValidateAccessToken(ctx, pair.AccessToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Expected expired token to be rejected, got %v", err)
+	}
+
+	other, _ := NewTokenService([]byte("other-secret"), time.Minute, time.Hour, NewMemoryRevocationStore())
+	if _, err := other.ValidateAccessToken(ctx, pair.AccessToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Expected token signed with another secret to be rejected, got %v", err)
+	}
+}
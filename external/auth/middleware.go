@@ -0,0 +1,64 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// claimsContextKey holds the *Claims of the authenticated request in the gin context
+const claimsContextKey = "auth.claims"
+
+// ErrMissingToken means the request has no bearer token
+var ErrMissingToken = errors.New("missing bearer token")
+
+// ClaimsFromRequest validates the bearer access token of the request with the shared token service
+func ClaimsFromRequest(c *gin.Context) (*Claims, error) {
+	return GetTokenService().ClaimsFromRequest(c)
+}
+
+// ClaimsFromRequest validates the bearer access token of the request
+func (s *TokenService) ClaimsFromRequest(c *gin.Context) (*Claims, error) {
+	token, found := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !found || token == "" {
+		return nil, ErrMissingToken
+	}
+	return s.ValidateAccessToken(c.Request.Context(), token)
+}
+
+// RequireAuth rejects requests without a valid bearer access token and stores the identity in the context
+func RequireAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, err := ClaimsFromRequest(c)
+		if errors.Is(err, ErrMissingToken) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing bearer token"})
+			c.Abort()
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
+
+		SetClaims(c, claims)
+		c.Next()
+	}
+}
+
+// SetClaims stores the identity of an authenticated request for CurrentClaims
+func SetClaims(c *gin.Context, claims *Claims) {
+	c.Set(claimsContextKey, claims)
+}
+
+// CurrentClaims returns the identity stored by RequireAuth
+func CurrentClaims(c *gin.Context) (*Claims, bool) {
+	value, exists := c.Get(claimsContextKey)
+	if !exists {
+		return nil, false
+	}
+	claims, ok := value.(*Claims)
+	return claims, ok
+}
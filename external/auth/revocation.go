@@ -0,0 +1,157 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/tashunc/nugenesis-wallet-backend/pkg/database"
+)
+
+// RevocationStore tracks revoked token ids and session families until the tokens would have expired anyway
+type RevocationStore interface {
+	Revoke(ctx context.Context, key string, expiresAt time.Time) error
+	// RevokeOnce revokes key and reports whether this call did, false means it was revoked already
+	RevokeOnce(ctx context.Context, key string, expiresAt time.Time) (bool, error)
+	IsRevoked(ctx context.Context, key string) (bool, error)
+}
+
+// NewRevocationStore uses Postgres when DATABASE_URL is configured, so revocations are shared between replicas,
+// and falls back to memory otherwise
+func NewRevocationStore() RevocationStore {
+	db, err := database.GetDB()
+	if err == nil {
+		return NewPostgresRevocationStore(db)
+	}
+	if !errors.Is(err, database.ErrNotConfigured) {
+		log.Printf("Warning: token revocations are kept in memory: %v", err)
+	}
+	return NewMemoryRevocationStore()
+}
+
+// sweepInterval is how often expired revocations are dropped
+const sweepInterval = time.Minute
+
+type MemoryRevocationStore struct {
+	revoked   map[string]time.Time
+	lastSweep time.Time
+	mutex     sync.RWMutex
+}
+
+func NewMemoryRevocationStore() *MemoryRevocationStore {
+	return &MemoryRevocationStore{
+		revoked:   make(map[string]time.Time),
+		lastSweep: time.Now(),
+	}
+}
+
+func (s *MemoryRevocationStore) Revoke(ctx context.Context, key string, expiresAt time.Time) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if until, exists := s.revoked[key]; !exists || expiresAt.After(until) {
+		s.revoked[key] = expiresAt
+	}
+	s.sweep()
+	return nil
+}
+
+func (s *MemoryRevocationStore) RevokeOnce(ctx context.Context, key string, expiresAt time.Time) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, exists := s.revoked[key]; exists {
+		return false, nil
+	}
+	s.revoked[key] = expiresAt
+	s.sweep()
+	return true, nil
+}
+
+// sweep drops expired revocations at most once per sweepInterval, the caller holds the lock
+func (s *MemoryRevocationStore) sweep() {
+	if now := time.Now(); now.Sub(s.lastSweep) > sweepInterval {
+		for revokedKey, until := range s.revoked {
+			if now.After(until) {
+				delete(s.revoked, revokedKey)
+			}
+		}
+		s.lastSweep = now
+	}
+}
+
+func (s *MemoryRevocationStore) IsRevoked(ctx context.Context, key string) (bool, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	_, revoked := s.revoked[key]
+	return revoked, nil
+}
+
+// PostgresRevocationStore keeps revocations in the revoked_tokens table
+type PostgresRevocationStore struct {
+	db        *sql.DB
+	lastSweep time.Time
+	mutex     sync.Mutex
+}
+
+func NewPostgresRevocationStore(db *sql.DB) *PostgresRevocationStore {
+	return &PostgresRevocationStore{db: db, lastSweep: time.Now()}
+}
+
+func (s *PostgresRevocationStore) Revoke(ctx context.Context, key string, expiresAt time.Time) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO revoked_tokens (key, expires_at) VALUES ($1, $2)
+		ON CONFLICT (key) DO UPDATE SET expires_at = GREATEST(revoked_tokens.expires_at, EXCLUDED.expires_at)`,
+		key, expiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to revoke token: %w", err)
+	}
+	s.sweep(ctx)
+	return nil
+}
+
+func (s *PostgresRevocationStore) RevokeOnce(ctx context.Context, key string, expiresAt time.Time) (bool, error) {
+	result, err := s.db.ExecContext(ctx, `
+		INSERT INTO revoked_tokens (key, expires_at) VALUES ($1, $2)
+		ON CONFLICT (key) DO NOTHING`,
+		key, expiresAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke token: %w", err)
+	}
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke token: %w", err)
+	}
+	s.sweep(ctx)
+	return inserted == 1, nil
+}
+
+// sweep drops expired revocations at most once per sweepInterval
+func (s *PostgresRevocationStore) sweep(ctx context.Context) {
+	s.mutex.Lock()
+	due := time.Since(s.lastSweep) > sweepInterval
+	if due {
+		s.lastSweep = time.Now()
+	}
+	s.mutex.Unlock()
+
+	if due {
+		if _, err := s.db.ExecContext(ctx, `DELETE FROM revoked_tokens WHERE expires_at < now()`); err != nil {
+			log.Printf("failed to sweep revoked tokens: %v", err)
+		}
+	}
+}
+
+func (s *PostgresRevocationStore) IsRevoked(ctx context.Context, key string) (bool, error) {
+	var revoked bool
+	err := s.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE key = $1)`, key).Scan(&revoked)
+	if err != nil {
+		return false, fmt.Errorf("failed to check token revocation: %w", err)
+	}
+	return revoked, nil
+}
@@ -2,6 +2,7 @@ package auth
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/tashunc/nugenesis-wallet-backend/pkg/httpclient"
 	"net/http"
 	"time"
 )
@@ -10,11 +11,24 @@ func RegisterRoutes(rg *gin.RouterGroup) {
 	auth := rg.Group("/auth")
 	auth.GET("/login", Login)
 	auth.GET("/callback", Callback)
+	auth.POST("/refresh", Refresh)
+	auth.POST("/logout", RequireAuth(), Logout)
+	auth.GET("/me", RequireAuth(), Me)
+	auth.GET("/siwe/nonce", WalletNonce)
+	auth.POST("/siwe/verify", SIWEVerify)
+	auth.GET("/wallet/nonce", WalletNonce)
+	auth.POST("/wallet/verify", WalletVerify)
 
+	// The server stays up while a provider circuit is open, so it reports degraded rather than failing the probe
 	rg.GET("/health", func(c *gin.Context) {
+		status := "ok"
+		if !httpclient.Healthy() {
+			status = "degraded"
+		}
 		c.JSON(http.StatusOK, gin.H{
-			"status": "ok",
-			"time":   time.Now().Unix(),
+			"status":    status,
+			"time":      time.Now().Unix(),
+			"upstreams": httpclient.Statuses(),
 		})
 	})
 }
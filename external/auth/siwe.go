@@ -0,0 +1,251 @@
+package auth
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"golang.org/x/crypto/sha3"
+)
+
+var (
+	ErrInvalidSignInMessage = errors.New("invalid sign-in message")
+	ErrInvalidSignature     = errors.New("invalid signature")
+
+	// signInHeader matches the first line of EIP-4361 and of its CAIP-122 variants for other chains
+	signInHeader = regexp.MustCompile(`^(\S+) wants you to sign in with your (\w+) account:$`)
+)
+
+// SignInMessage is a parsed EIP-4361 style sign-in message.
+// Account is the chain named in the header, e.g. Ethereum, Solana or Bitcoin.
+type SignInMessage struct {
+	Domain         string
+	Account        string
+	Address        string
+	Statement      string
+	URI            string
+	Version        string
+	ChainID        string
+	Nonce          string
+	IssuedAt       time.Time
+	ExpirationTime *time.Time
+	NotBefore      *time.Time
+	RequestID      string
+	Resources      []string
+}
+
+// ParseSignInMessage parses the plain text message the wallet signed
+func ParseSignInMessage(message string) (*SignInMessage, error) {
+	lines := strings.Split(strings.ReplaceAll(message, "\r\n", "\n"), "\n")
+	if len(lines) < 2 {
+		return nil, fmt.Errorf("%w: missing header", ErrInvalidSignInMessage)
+	}
+	header := signInHeader.FindStringSubmatch(lines[0])
+	if header == nil {
+		return nil, fmt.Errorf("%w: missing header", ErrInvalidSignInMessage)
+	}
+
+	parsed := &SignInMessage{
+		Domain:  header[1],
+		Account: header[2],
+		Address: strings.TrimSpace(lines[1]),
+	}
+	if parsed.Address == "" {
+		return nil, fmt.Errorf("%w: missing address", ErrInvalidSignInMessage)
+	}
+
+	// The optional statement sits between blank lines before the first field
+	i := 2
+	for i < len(lines) && lines[i] == "" {
+		i++
+	}
+	if i < len(lines) && !strings.HasPrefix(lines[i], "URI: ") {
+		parsed.Statement = lines[i]
+		i++
+	}
+
+	inResources := false
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if line == "" {
+			continue
+		}
+		if inResources {
+			if resource, found := strings.CutPrefix(line, "- "); found {
+				parsed.Resources = append(parsed.Resources, resource)
+				continue
+			}
+			return nil, fmt.Errorf("%w: unexpected line %q", ErrInvalidSignInMessage, line)
+		}
+		if line == "Resources:" {
+			inResources = true
+			continue
+		}
+
+		key, value, found := strings.Cut(line, ": ")
+		if !found {
+			return nil, fmt.Errorf("%w: unexpected line %q", ErrInvalidSignInMessage, line)
+		}
+
+		var err error
+		switch key {
+		case "URI":
+			parsed.URI = value
+		case "Version":
+			parsed.Version = value
+		case "Chain ID":
+			parsed.ChainID = value
+		case "Nonce":
+			parsed.Nonce = value
+		case "Issued At":
+			parsed.IssuedAt, err = time.Parse(time.RFC3339, value)
+		case "Expiration Time":
+			var t time.Time
+			t, err = time.Parse(time.RFC3339, value)
+			parsed.ExpirationTime = &t
+		case "Not Before":
+			var t time.Time
+			t, err = time.Parse(time.RFC3339, value)
+			parsed.NotBefore = &t
+		case "Request ID":
+			parsed.RequestID = value
+		default:
+			return nil, fmt.Errorf("%w: unknown field %q", ErrInvalidSignInMessage, key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid %s: %v", ErrInvalidSignInMessage, key, err)
+		}
+	}
+
+	if parsed.URI == "" || parsed.Version == "" || len(parsed.Nonce) < 8 || parsed.IssuedAt.IsZero() {
+		return nil, fmt.Errorf("%w: missing required field", ErrInvalidSignInMessage)
+	}
+	return parsed, nil
+}
+
+// Validate checks the message was meant for this server and is still within its validity window.
+// Chain specific fields such as the chain ID are left to the MessageVerifier.
+func (m *SignInMessage) Validate(domain string, now time.Time) error {
+	if m.Version != "1" {
+		return fmt.Errorf("%w: unsupported version %s", ErrInvalidSignInMessage, m.Version)
+	}
+	if !strings.EqualFold(m.Domain, domain) {
+		return fmt.Errorf("%w: domain %s does not match %s", ErrInvalidSignInMessage, m.Domain, domain)
+	}
+	if m.ExpirationTime != nil && !now.Before(*m.ExpirationTime) {
+		return fmt.Errorf("%w: message has expired", ErrInvalidSignInMessage)
+	}
+	if m.NotBefore != nil && now.Before(*m.NotBefore) {
+		return fmt.Errorf("%w: message is not valid yet", ErrInvalidSignInMessage)
+	}
+	return nil
+}
+
+// EthereumVerifier checks EIP-4361 messages signed with personal_sign
+type EthereumVerifier struct {
+	chainIDs map[int64]bool
+}
+
+func NewEthereumVerifier(chainIDs []int64) *EthereumVerifier {
+	verifier := &EthereumVerifier{chainIDs: make(map[int64]bool)}
+	for _, id := range chainIDs {
+		verifier.chainIDs[id] = true
+	}
+	return verifier
+}
+
+func (v *EthereumVerifier) Account() string {
+	return "Ethereum"
+}
+
+func (v *EthereumVerifier) Namespace() string {
+	return "eip155"
+}
+
+func (v *EthereumVerifier) Verify(message *SignInMessage, raw string, signature string) (string, error) {
+	if !isHexAddress(message.Address) {
+		return "", fmt.Errorf("%w: invalid address", ErrInvalidSignInMessage)
+	}
+	chainID, err := strconv.ParseInt(message.ChainID, 10, 64)
+	if err != nil || !v.chainIDs[chainID] {
+		return "", fmt.Errorf("%w: chain ID %q is not allowed", ErrInvalidSignInMessage, message.ChainID)
+	}
+
+	address, err := RecoverPersonalSignAddress(raw, signature)
+	if err != nil {
+		return "", err
+	}
+	if !strings.EqualFold(address, message.Address) {
+		return "", fmt.Errorf("%w: signer does not match the message address", ErrInvalidSignature)
+	}
+	return strings.ToLower(address), nil
+}
+
+// RecoverPersonalSignAddress returns the checksummed address that produced a personal_sign signature of message.
+// The signature is the 65 byte r || s || v hex string returned by wallets, v may be 0/1 or 27/28.
+func RecoverPersonalSignAddress(message string, signature string) (string, error) {
+	sig, err := hex.DecodeString(strings.TrimPrefix(signature, "0x"))
+	if err != nil || len(sig) != 65 {
+		return "", fmt.Errorf("%w: expected 65 hex encoded bytes", ErrInvalidSignature)
+	}
+
+	v := sig[64]
+	if v >= 27 {
+		v -= 27
+	}
+	if v > 1 {
+		return "", fmt.Errorf("%w: invalid recovery id", ErrInvalidSignature)
+	}
+
+	// btcec expects the recovery header first, 27 + id for an uncompressed key
+	compact := make([]byte, 65)
+	compact[0] = 27 + v
+	copy(compact[1:], sig[:64])
+
+	publicKey, _, err := ecdsa.RecoverCompact(compact, personalMessageHash(message))
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+
+	address := keccak256(publicKey.SerializeUncompressed()[1:])[12:]
+	return ChecksumAddress(hex.EncodeToString(address)), nil
+}
+
+// personalMessageHash is the EIP-191 hash signed by personal_sign
+func personalMessageHash(message string) []byte {
+	prefix := fmt.Sprintf("\x19Ethereum Signed Message:\n%d", len(message))
+	return keccak256([]byte(prefix + message))
+}
+
+// ChecksumAddress applies the EIP-55 mixed-case checksum to a hex address
+func ChecksumAddress(address string) string {
+	lower := strings.ToLower(strings.TrimPrefix(strings.TrimPrefix(address, "0x"), "0X"))
+	hash := hex.EncodeToString(keccak256([]byte(lower)))
+
+	result := []byte(lower)
+	for i, c := range result {
+		if c >= 'a' && c <= 'f' && hash[i] >= '8' {
+			result[i] = c - 32
+		}
+	}
+	return "0x" + string(result)
+}
+
+func isHexAddress(address string) bool {
+	if len(address) != 42 || !strings.HasPrefix(address, "0x") {
+		return false
+	}
+	_, err := hex.DecodeString(address[2:])
+	return err == nil
+}
+
+func keccak256(data []byte) []byte {
+	hasher := sha3.NewLegacyKeccak256()
+	hasher.Write(data)
+	return hasher.Sum(nil)
+}
@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tashunc/nugenesis-wallet-backend/config"
+	"github.com/tashunc/nugenesis-wallet-backend/external/models"
+	"github.com/tashunc/nugenesis-wallet-backend/external/models/general"
+	"github.com/tashunc/nugenesis-wallet-backend/static/staticServices"
+)
+
+// ErrSignInDomainNotConfigured disables wallet sign-in until SIWE_DOMAIN names the domain messages must be issued for.
+// The domain is never taken from the request, whose Host header the client controls.
+var ErrSignInDomainNotConfigured = errors.New("SIWE_DOMAIN is not configured")
+
+// WalletNonce issues a single use nonce the client embeds in its sign-in message
+func WalletNonce(c *gin.Context) {
+	nonce, expiresAt, err := staticServices.GetNonceStore().IssueNonce(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to issue nonce"})
+		return
+	}
+	c.JSON(http.StatusOK, models.SIWENonceControllerResponse{
+		Nonce:     nonce,
+		ExpiresAt: expiresAt.Unix(),
+	})
+}
+
+// WalletVerify checks a signed sign-in message with the verifier of the requested chain and starts a session
+func WalletVerify(c *gin.Context) {
+	var request models.WalletSignInControllerRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondSessionError(c, http.StatusBadRequest, "Invalid request format", err)
+		return
+	}
+	verifyWalletSignIn(c, config.Get().SIWEDomain, general.CoinType(request.CoinType), request.Message, request.Signature)
+}
+
+// SIWEVerify checks a signed EIP-4361 message and starts a session for the recovered address
+func SIWEVerify(c *gin.Context) {
+	var request models.SIWEVerifyControllerRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondSessionError(c, http.StatusBadRequest, "Invalid request format", err)
+		return
+	}
+	verifyWalletSignIn(c, config.Get().SIWEDomain, general.Ethereum, request.Message, request.Signature)
+}
+
+// verifyWalletSignIn accepts messages issued for domain only, see ErrSignInDomainNotConfigured
+func verifyWalletSignIn(c *gin.Context, domain string, coinType general.CoinType, raw string, signature string) {
+	verifier, ok := GetVerifier(coinType)
+	if !ok {
+		respondSessionError(c, http.StatusBadRequest, "Unsupported chain", fmt.Errorf("%w: %s", ErrUnsupportedChain, coinType))
+		return
+	}
+
+	message, err := ParseSignInMessage(raw)
+	if err != nil {
+		respondSessionError(c, http.StatusBadRequest, "Invalid sign-in message", err)
+		return
+	}
+	if message.Account != verifier.Account() {
+		respondSessionError(c, http.StatusBadRequest, "Invalid sign-in message",
+			fmt.Errorf("%w: expected a %s account message", ErrInvalidSignInMessage, verifier.Account()))
+		return
+	}
+
+	if domain == "" {
+		respondSessionError(c, http.StatusServiceUnavailable, "Wallet sign-in is not available", ErrSignInDomainNotConfigured)
+		return
+	}
+	if err := message.Validate(domain, time.Now()); err != nil {
+		respondSessionError(c, http.StatusUnauthorized, "Sign-in message rejected", err)
+		return
+	}
+
+	address, err := verifier.Verify(message, raw, signature)
+	if err != nil {
+		status := http.StatusUnauthorized
+		if errors.Is(err, ErrInvalidSignInMessage) {
+			status = http.StatusBadRequest
+		}
+		respondSessionError(c, status, "Sign-in message rejected", err)
+		return
+	}
+
+	// The nonce is consumed last so a malformed attempt cannot burn the nonce of a pending login
+	if consumed, err := staticServices.GetNonceStore().ConsumeIssuedNonce(c.Request.Context(), message.Nonce); err != nil {
+		respondSessionError(c, http.StatusInternalServerError, "Failed to check nonce", err)
+		return
+	} else if !consumed {
+		respondSessionError(c, http.StatusUnauthorized, "Sign-in message rejected", errors.New("nonce is unknown, expired or already used"))
+		return
+	}
+
+	pair, err := GetTokenService().IssueTokenPair(Identity{Subject: verifier.Namespace() + ":" + address})
+	if err != nil {
+		respondSessionError(c, http.StatusInternalServerError, "Failed to create session", err)
+		return
+	}
+	RespondSession(c, pair, map[string]interface{}{
+		"coinType": coinType,
+		"address":  address,
+		"chainId":  message.ChainID,
+	}, "Logged in successfully")
+}
@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+)
+
+func signInMessage(account string, chainID string, address string) string {
+	return "example.com wants you to sign in with your " + account + " account:\n" +
+		address + "\n\n" +
+		"Sign in to NuGenesis\n\n" +
+		"URI: https://example.com/login\n" +
+		"Version: 1\n" +
+		"Chain ID: " + chainID + "\n" +
+		"Nonce: abcdef123456\n" +
+		"Issued At: 2024-01-01T00:00:00Z\n" +
+		"Expiration Time: 2024-01-01T00:10:00Z\n" +
+		"Resources:\n" +
+		"- https://example.com/terms"
+}
+
+func TestChecksumAddress(t *testing.T) {
+	// Test vectors from EIP-55
+	for _, address := range []string{
+		"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+		"0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359",
+		"0xdbF03B407c01E7cD3CBea99509d93f8DDDC8C6FB",
+	} {
+		if got := ChecksumAddress(strings.ToLower(address)); got != address {
+			t.Errorf("Expected %s, got %s", address, got)
+		}
+	}
+}
+
+func TestParseSignInMessage(t *testing.T) {
+	parsed, err := ParseSignInMessage(signInMessage("Ethereum", "1", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Account != "Ethereum" || parsed.Statement != "Sign in to NuGenesis" || parsed.Nonce != "abcdef123456" || len(parsed.Resources) != 1 {
+		t.Errorf("Unexpected message %+v", parsed)
+	}
+
+	now := time.Date(2024, 1, 1, 0, 5, 0, 0, time.UTC)
+	if err := parsed.Validate("example.com", now); err != nil {
+		t.Errorf("Expected message to be valid, got %v", err)
+	}
+	if err := parsed.Validate("evil.com", now); !errors.Is(err, ErrInvalidSignInMessage) {
+		t.Errorf("Expected domain mismatch, got %v", err)
+	}
+	if err := parsed.Validate("example.com", now.Add(time.Hour)); err == nil {
+		t.Error("Expected expired message to be rejected")
+	}
+}
+
+func TestEthereumVerifier(t *testing.T) {
+	privateKey, err := btcec.NewPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := ChecksumAddress(hex.EncodeToString(keccak256(privateKey.PubKey().SerializeUncompressed()[1:])[12:]))
+	raw := signInMessage("Ethereum", "1", address)
+	message, _ := ParseSignInMessage(raw)
+
+	// Wallets return r || s || v with v = 27 + recovery id, btcec puts the header first
+	compact, err := ecdsa.SignCompact(privateKey, personalMessageHash(raw), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature := "0x" + hex.EncodeToString(append(append([]byte{}, compact[1:]...), compact[0]))
+
+	recovered, err := NewEthereumVerifier([]int64{1}).Verify(message, raw, signature)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recovered != strings.ToLower(address) {
+		t.Errorf("Expected signer %s, got %s", address, recovered)
+	}
+
+	if _, err := NewEthereumVerifier([]int64{137}).Verify(message, raw, signature); !errors.Is(err, ErrInvalidSignInMessage) {
+		t.Errorf("Expected chain ID to be rejected, got %v", err)
+	}
+	if _, err := NewEthereumVerifier([]int64{1}).Verify(message, raw+" ", signature); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Expected a different message to fail, got %v", err)
+	}
+}
@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/tashunc/nugenesis-wallet-backend/config"
+	"github.com/tashunc/nugenesis-wallet-backend/external/models/general"
+)
+
+var ErrUnsupportedChain = errors.New("wallet sign-in is not supported for this chain")
+
+// MessageVerifier proves a sign-in message was signed by the key behind its address
+type MessageVerifier interface {
+	// Account is the chain named in the message header, e.g. "Ethereum"
+	Account() string
+	// Namespace prefixes the session subject, e.g. "eip155" gives eip155:<address>
+	Namespace() string
+	// Verify checks the chain specific fields and the signature over raw, and returns the canonical address
+	Verify(message *SignInMessage, raw string, signature string) (string, error)
+}
+
+type verifierRegistry struct {
+	verifiers map[general.CoinType]MessageVerifier
+	mutex     sync.RWMutex
+}
+
+var (
+	verifiers     *verifierRegistry
+	verifiersOnce sync.Once
+)
+
+func getVerifierRegistry() *verifierRegistry {
+	verifiersOnce.Do(func() {
+		cfg := config.Get()
+		verifiers = &verifierRegistry{verifiers: map[general.CoinType]MessageVerifier{
+			general.Ethereum: NewEthereumVerifier(cfg.SIWEChainIDs),
+			general.Solana:   NewSolanaVerifier(),
+			general.Bitcoin:  NewBitcoinVerifier(),
+		}}
+	})
+	return verifiers
+}
+
+// RegisterVerifier adds or replaces the sign-in verifier of a chain
+func RegisterVerifier(coinType general.CoinType, verifier MessageVerifier) {
+	registry := getVerifierRegistry()
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	registry.verifiers[coinType] = verifier
+}
+
+// GetVerifier returns the sign-in verifier of a chain
+func GetVerifier(coinType general.CoinType) (MessageVerifier, bool) {
+	registry := getVerifierRegistry()
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+	verifier, ok := registry.verifiers[coinType]
+	return verifier, ok
+}
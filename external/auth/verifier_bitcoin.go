@@ -0,0 +1,220 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// bitcoinMainnetChainID is the CAIP-2 reference of Bitcoin mainnet, the first 32 hex digits of its genesis block hash
+const bitcoinMainnetChainID = "000000000019d6689c085ae165831e93"
+
+// BitcoinVerifier checks mainnet sign-ins. Legacy signmessage (BIP-137) signatures are accepted for P2PKH,
+// P2SH-P2WPKH and P2WPKH addresses, BIP-322 simple signatures for P2WPKH and P2TR addresses.
+type BitcoinVerifier struct {
+	network *chaincfg.Params
+}
+
+func NewBitcoinVerifier() *BitcoinVerifier {
+	return &BitcoinVerifier{network: &chaincfg.MainNetParams}
+}
+
+func (v *BitcoinVerifier) Account() string {
+	return "Bitcoin"
+}
+
+func (v *BitcoinVerifier) Namespace() string {
+	return "bitcoin"
+}
+
+func (v *BitcoinVerifier) Verify(message *SignInMessage, raw string, signature string) (string, error) {
+	if message.ChainID != bitcoinMainnetChainID && message.ChainID != "bip122:"+bitcoinMainnetChainID {
+		return "", fmt.Errorf("%w: chain ID %q is not Bitcoin mainnet", ErrInvalidSignInMessage, message.ChainID)
+	}
+	address, err := v.decodeAddress(message.Address)
+	if err != nil {
+		return "", err
+	}
+	if err := VerifyBitcoinMessage(address, raw, signature); err != nil {
+		return "", err
+	}
+	return address.EncodeAddress(), nil
+}
+
+func (v *BitcoinVerifier) decodeAddress(encoded string) (btcutil.Address, error) {
+	address, err := btcutil.DecodeAddress(encoded, v.network)
+	if err != nil || !address.IsForNet(v.network) {
+		return nil, fmt.Errorf("%w: not a Bitcoin mainnet address", ErrInvalidSignInMessage)
+	}
+	switch address.(type) {
+	case *btcutil.AddressPubKeyHash, *btcutil.AddressScriptHash, *btcutil.AddressWitnessPubKeyHash, *btcutil.AddressTaproot:
+		return address, nil
+	default:
+		return nil, fmt.Errorf("%w: only P2PKH, P2SH-P2WPKH, P2WPKH and P2TR addresses are supported", ErrInvalidSignInMessage)
+	}
+}
+
+// VerifyBitcoinMessage checks a base64 signature of message by address.
+// A 65 byte signature is a legacy compact signature, anything else is read as a BIP-322 simple witness.
+func VerifyBitcoinMessage(address btcutil.Address, message string, signature string) error {
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("%w: signature is not base64", ErrInvalidSignature)
+	}
+	if len(sig) == 65 {
+		return verifyLegacyMessage(address, message, sig)
+	}
+	return verifyBIP322Simple(address, message, sig)
+}
+
+// verifyLegacyMessage recovers the key of a Bitcoin Core signmessage signature.
+// Headers 27-34 are P2PKH and 35-42 the BIP-137 segwit variants, which always use compressed keys.
+// A P2SH address can only be checked as P2SH-P2WPKH, other scripts cannot be derived from the key.
+func verifyLegacyMessage(address btcutil.Address, message string, sig []byte) error {
+	compact := append([]byte{}, sig...)
+	switch header := compact[0]; {
+	case header >= 39 && header <= 42:
+		compact[0] -= 8
+	case header >= 35 && header <= 38:
+		compact[0] -= 4
+	case header < 27 || header > 42:
+		return fmt.Errorf("%w: invalid signature header", ErrInvalidSignature)
+	}
+
+	var buf bytes.Buffer
+	_ = wire.WriteVarString(&buf, 0, "Bitcoin Signed Message:\n")
+	_ = wire.WriteVarString(&buf, 0, message)
+
+	publicKey, compressed, err := ecdsa.RecoverCompact(compact, chainhash.DoubleHashB(buf.Bytes()))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+
+	var signer []byte
+	if compressed {
+		signer = btcutil.Hash160(publicKey.SerializeCompressed())
+	} else {
+		signer = btcutil.Hash160(publicKey.SerializeUncompressed())
+	}
+
+	var expected []byte
+	switch a := address.(type) {
+	case *btcutil.AddressPubKeyHash:
+		expected = a.Hash160()[:]
+	case *btcutil.AddressWitnessPubKeyHash:
+		if !compressed {
+			return fmt.Errorf("%w: segwit signatures must use a compressed key", ErrInvalidSignature)
+		}
+		expected = a.WitnessProgram()
+	case *btcutil.AddressScriptHash:
+		if !compressed {
+			return fmt.Errorf("%w: segwit signatures must use a compressed key", ErrInvalidSignature)
+		}
+		// The redeem script of P2SH-P2WPKH is the P2WPKH program of the key
+		redeemScript, err := txscript.NewScriptBuilder().AddOp(txscript.OP_0).AddData(signer).Script()
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+		}
+		signer = btcutil.Hash160(redeemScript)
+		expected = a.Hash160()[:]
+	default:
+		return fmt.Errorf("%w: legacy signatures are not supported for %T addresses, use BIP-322", ErrInvalidSignature, address)
+	}
+
+	if !bytes.Equal(signer, expected) {
+		return fmt.Errorf("%w: signature does not match the message address", ErrInvalidSignature)
+	}
+	return nil
+}
+
+// verifyBIP322Simple runs the BIP-322 virtual to_sign transaction through the script engine.
+// The simple format carries only a witness, so it cannot prove P2PKH or P2SH-P2WPKH, whose spends need a scriptSig.
+func verifyBIP322Simple(address btcutil.Address, message string, sig []byte) error {
+	switch address.(type) {
+	case *btcutil.AddressWitnessPubKeyHash, *btcutil.AddressTaproot:
+	default:
+		return fmt.Errorf("%w: BIP-322 simple signatures are only supported for P2WPKH and P2TR addresses", ErrInvalidSignature)
+	}
+
+	witness, err := parseWitness(sig)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+
+	pkScript, err := txscript.PayToAddrScript(address)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+
+	toSign, err := bip322ToSign(pkScript, message)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+	toSign.TxIn[0].Witness = witness
+
+	fetcher := txscript.NewCannedPrevOutputFetcher(pkScript, 0)
+	engine, err := txscript.NewEngine(pkScript, toSign, 0, txscript.StandardVerifyFlags, nil,
+		txscript.NewTxSigHashes(toSign, fetcher), 0, fetcher)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+	if err := engine.Execute(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+	return nil
+}
+
+// bip322ToSign builds the unsigned to_sign transaction spending the to_spend commitment to message
+func bip322ToSign(pkScript []byte, message string) (*wire.MsgTx, error) {
+	messageHash := chainhash.TaggedHash([]byte("BIP0322-signed-message"), []byte(message))
+	scriptSig, err := txscript.NewScriptBuilder().AddOp(txscript.OP_0).AddData(messageHash[:]).Script()
+	if err != nil {
+		return nil, err
+	}
+
+	toSpend := wire.NewMsgTx(0)
+	toSpend.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: wire.OutPoint{Index: 0xffffffff},
+		SignatureScript:  scriptSig,
+		Sequence:         0,
+	})
+	toSpend.AddTxOut(wire.NewTxOut(0, pkScript))
+
+	toSign := wire.NewMsgTx(0)
+	toSign.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: wire.OutPoint{Hash: toSpend.TxHash(), Index: 0},
+		Sequence:         0,
+	})
+	toSign.AddTxOut(wire.NewTxOut(0, []byte{txscript.OP_RETURN}))
+	return toSign, nil
+}
+
+// parseWitness reads the consensus encoded witness stack BIP-322 uses as the signature
+func parseWitness(data []byte) (wire.TxWitness, error) {
+	reader := bytes.NewReader(data)
+	count, err := wire.ReadVarInt(reader, 0)
+	if err != nil {
+		return nil, err
+	}
+	if count == 0 || count > uint64(len(data)) {
+		return nil, fmt.Errorf("invalid witness item count %d", count)
+	}
+
+	witness := make(wire.TxWitness, count)
+	for i := range witness {
+		if witness[i], err = wire.ReadVarBytes(reader, 0, wire.MaxMessagePayload, "witness item"); err != nil {
+			return nil, err
+		}
+	}
+	if reader.Len() != 0 {
+		return nil, fmt.Errorf("unexpected trailing witness data")
+	}
+	return witness, nil
+}
@@ -0,0 +1,70 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil/base58"
+)
+
+// solanaMainnetGenesis is the CAIP-2 reference of Solana mainnet-beta
+const solanaMainnetGenesis = "5eykt4UsFv8P8NJdTREpY1vzqKqZKvdp"
+
+// solanaMainnetChainIDs are the spellings of mainnet wallets put into the chain field, Sign-In With Solana names the
+// cluster and CAIP-122 the genesis reference
+var solanaMainnetChainIDs = map[string]bool{
+	"mainnet":                        true,
+	"solana:mainnet":                 true,
+	solanaMainnetGenesis:             true,
+	"solana:" + solanaMainnetGenesis: true,
+}
+
+// SolanaVerifier checks ed25519 signatures made with signMessage against the base58 public key address
+type SolanaVerifier struct{}
+
+func NewSolanaVerifier() *SolanaVerifier {
+	return &SolanaVerifier{}
+}
+
+func (v *SolanaVerifier) Account() string {
+	return "Solana"
+}
+
+func (v *SolanaVerifier) Namespace() string {
+	return "solana"
+}
+
+func (v *SolanaVerifier) Verify(message *SignInMessage, raw string, signature string) (string, error) {
+	if !solanaMainnetChainIDs[message.ChainID] {
+		return "", fmt.Errorf("%w: chain ID %q is not Solana mainnet", ErrInvalidSignInMessage, message.ChainID)
+	}
+	publicKey := base58.Decode(message.Address)
+	if len(publicKey) != ed25519.PublicKeySize {
+		return "", fmt.Errorf("%w: invalid address", ErrInvalidSignInMessage)
+	}
+
+	sig := decodeSolanaSignature(signature)
+	if len(sig) != ed25519.SignatureSize {
+		return "", fmt.Errorf("%w: expected a 64 byte base58, base64 or hex signature", ErrInvalidSignature)
+	}
+	if !ed25519.Verify(publicKey, []byte(raw), sig) {
+		return "", fmt.Errorf("%w: signature does not match the message address", ErrInvalidSignature)
+	}
+	return message.Address, nil
+}
+
+// decodeSolanaSignature accepts the encodings wallet adapters commonly hand back, base58 first
+func decodeSolanaSignature(signature string) []byte {
+	if strings.HasPrefix(signature, "0x") {
+		sig, _ := hex.DecodeString(signature[2:])
+		return sig
+	}
+	if sig := base58.Decode(signature); len(sig) == ed25519.SignatureSize {
+		return sig
+	}
+	sig, _ := base64.StdEncoding.DecodeString(signature)
+	return sig
+}
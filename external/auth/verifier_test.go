@@ -0,0 +1,198 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/base58"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+func TestSolanaVerifier(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw := signInMessage("Solana", "mainnet", base58.Encode(publicKey))
+	message, _ := ParseSignInMessage(raw)
+	sig := ed25519.Sign(privateKey, []byte(raw))
+
+	for _, signature := range []string{base58.Encode(sig), base64.StdEncoding.EncodeToString(sig), "0x" + hex.EncodeToString(sig)} {
+		if _, err := NewSolanaVerifier().Verify(message, raw, signature); err != nil {
+			t.Errorf("Expected %s to verify, got %v", signature, err)
+		}
+	}
+
+	devnet, _ := ParseSignInMessage(strings.Replace(raw, "Chain ID: mainnet", "Chain ID: devnet", 1))
+	if _, err := NewSolanaVerifier().Verify(devnet, raw, base58.Encode(sig)); !errors.Is(err, ErrInvalidSignInMessage) {
+		t.Errorf("Expected a devnet message to be rejected, got %v", err)
+	}
+
+	otherKey, _, _ := ed25519.GenerateKey(nil)
+	message.Address = base58.Encode(otherKey)
+	if _, err := NewSolanaVerifier().Verify(message, raw, base58.Encode(sig)); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Expected signature of another key to fail, got %v", err)
+	}
+}
+
+func TestVerifyBitcoinMessageBIP322Vector(t *testing.T) {
+	// Test vector from BIP-322
+	address, _ := btcutil.DecodeAddress("bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l", &chaincfg.MainNetParams)
+	signature := "AkcwRAIgZRfIY3p7/DoVTty6YZbWS71bc5Vct9p9Fia83eRmw2QCICK/ENGfwLtptFluMGs2KsqoNSk89pO7F29zJLUx9a/sASECx/EgAxlkQpQ9hYjgGu6EBCPMVPwVIVJqO4XCsMvViHI="
+
+	if err := VerifyBitcoinMessage(address, "Hello World", signature); err != nil {
+		t.Errorf("Expected vector to verify, got %v", err)
+	}
+	if err := VerifyBitcoinMessage(address, "Hello World!", signature); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Expected altered message to fail, got %v", err)
+	}
+}
+
+func TestBitcoinVerifier(t *testing.T) {
+	privateKey, err := btcec.NewPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyHash := btcutil.Hash160(privateKey.PubKey().SerializeCompressed())
+	p2pkh, _ := btcutil.NewAddressPubKeyHash(keyHash, &chaincfg.MainNetParams)
+	p2wpkh, _ := btcutil.NewAddressWitnessPubKeyHash(keyHash, &chaincfg.MainNetParams)
+
+	// Legacy signmessage from a P2PKH address
+	raw := signInMessage("Bitcoin", "bip122:"+bitcoinMainnetChainID, p2pkh.EncodeAddress())
+	var buf bytes.Buffer
+	_ = wire.WriteVarString(&buf, 0, "Bitcoin Signed Message:\n")
+	_ = wire.WriteVarString(&buf, 0, raw)
+	compact, err := ecdsa.SignCompact(privateKey, chainhash.DoubleHashB(buf.Bytes()), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	message, _ := ParseSignInMessage(raw)
+	if address, err := NewBitcoinVerifier().Verify(message, raw, base64.StdEncoding.EncodeToString(compact)); err != nil || address != p2pkh.EncodeAddress() {
+		t.Errorf("Expected legacy signature to verify, got %s %v", address, err)
+	}
+
+	// BIP-322 simple from a P2WPKH address
+	raw = signInMessage("Bitcoin", "bip122:"+bitcoinMainnetChainID, p2wpkh.EncodeAddress())
+	pkScript, _ := txscript.PayToAddrScript(p2wpkh)
+	toSign, err := bip322ToSign(pkScript, raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fetcher := txscript.NewCannedPrevOutputFetcher(pkScript, 0)
+	witness, err := txscript.WitnessSignature(toSign, txscript.NewTxSigHashes(toSign, fetcher), 0, 0, pkScript, txscript.SigHashAll, privateKey, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	message, _ = ParseSignInMessage(raw)
+	if _, err := NewBitcoinVerifier().Verify(message, raw, encodeWitness(witness)); err != nil {
+		t.Errorf("Expected BIP-322 signature to verify, got %v", err)
+	}
+	if _, err := NewBitcoinVerifier().Verify(message, raw+" ", encodeWitness(witness)); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Expected altered message to fail, got %v", err)
+	}
+}
+
+// encodeWitness serializes a witness stack into a BIP-322 simple signature
+func encodeWitness(witness wire.TxWitness) string {
+	var encoded bytes.Buffer
+	_ = wire.WriteVarInt(&encoded, 0, uint64(len(witness)))
+	for _, item := range witness {
+		_ = wire.WriteVarBytes(&encoded, 0, item)
+	}
+	return base64.StdEncoding.EncodeToString(encoded.Bytes())
+}
+
+// legacySignature signs message like Bitcoin Core signmessage, with the BIP-137 header offset of the address type
+func legacySignature(t *testing.T, privateKey *btcec.PrivateKey, message string, headerOffset byte) string {
+	t.Helper()
+	var buf bytes.Buffer
+	_ = wire.WriteVarString(&buf, 0, "Bitcoin Signed Message:\n")
+	_ = wire.WriteVarString(&buf, 0, message)
+	compact, err := ecdsa.SignCompact(privateKey, chainhash.DoubleHashB(buf.Bytes()), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compact[0] += headerOffset
+	return base64.StdEncoding.EncodeToString(compact)
+}
+
+func TestBitcoinVerifierAddressTypes(t *testing.T) {
+	privateKey, err := btcec.NewPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	chainID := "bip122:" + bitcoinMainnetChainID
+	keyHash := btcutil.Hash160(privateKey.PubKey().SerializeCompressed())
+
+	// P2SH-P2WPKH signs with the BIP-137 header range 35-38
+	redeemScript, _ := txscript.NewScriptBuilder().AddOp(txscript.OP_0).AddData(keyHash).Script()
+	p2sh, _ := btcutil.NewAddressScriptHash(redeemScript, &chaincfg.MainNetParams)
+	raw := signInMessage("Bitcoin", chainID, p2sh.EncodeAddress())
+	message, _ := ParseSignInMessage(raw)
+	if _, err := NewBitcoinVerifier().Verify(message, raw, legacySignature(t, privateKey, raw, 4)); err != nil {
+		t.Errorf("Expected P2SH-P2WPKH legacy signature to verify, got %v", err)
+	}
+	if _, err := NewBitcoinVerifier().Verify(message, raw, encodeWitness(wire.TxWitness{{1}, {2}})); err == nil || !strings.Contains(err.Error(), "P2WPKH and P2TR") {
+		t.Errorf("Expected BIP-322 for P2SH to be rejected with a clear error, got %v", err)
+	}
+
+	// BIP-322 simple from a P2TR key path spend
+	outputKey := txscript.ComputeTaprootKeyNoScript(privateKey.PubKey())
+	p2tr, _ := btcutil.NewAddressTaproot(schnorr.SerializePubKey(outputKey), &chaincfg.MainNetParams)
+	raw = signInMessage("Bitcoin", chainID, p2tr.EncodeAddress())
+	pkScript, _ := txscript.PayToAddrScript(p2tr)
+	toSign, err := bip322ToSign(pkScript, raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fetcher := txscript.NewCannedPrevOutputFetcher(pkScript, 0)
+	witness, err := txscript.TaprootWitnessSignature(toSign, txscript.NewTxSigHashes(toSign, fetcher), 0, 0, pkScript, txscript.SigHashDefault, privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	message, _ = ParseSignInMessage(raw)
+	if _, err := NewBitcoinVerifier().Verify(message, raw, encodeWitness(witness)); err != nil {
+		t.Errorf("Expected P2TR BIP-322 signature to verify, got %v", err)
+	}
+	if _, err := NewBitcoinVerifier().Verify(message, raw+" ", encodeWitness(witness)); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Expected altered P2TR message to fail, got %v", err)
+	}
+}
+
+func TestBitcoinVerifierRejectsOtherNetworks(t *testing.T) {
+	privateKey, err := btcec.NewPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyHash := btcutil.Hash160(privateKey.PubKey().SerializeCompressed())
+	testnet, _ := btcutil.NewAddressPubKeyHash(keyHash, &chaincfg.TestNet3Params)
+	mainnet, _ := btcutil.NewAddressPubKeyHash(keyHash, &chaincfg.MainNetParams)
+
+	for _, test := range []struct {
+		name    string
+		chainID string
+		address string
+	}{
+		{"testnet address", "bip122:" + bitcoinMainnetChainID, testnet.EncodeAddress()},
+		{"EVM chain ID", "1", mainnet.EncodeAddress()},
+		{"testnet chain ID", "bip122:000000000933ea01ad0ee984209779ba", mainnet.EncodeAddress()},
+	} {
+		raw := signInMessage("Bitcoin", test.chainID, test.address)
+		message, _ := ParseSignInMessage(raw)
+		if _, err := NewBitcoinVerifier().Verify(message, raw, legacySignature(t, privateKey, raw, 0)); !errors.Is(err, ErrInvalidSignInMessage) {
+			t.Errorf("%s: expected the message to be rejected, got %v", test.name, err)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package pricing
+
+import (
+	"container/list"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tashunc/nugenesis-wallet-backend/external/data/fmv/thrirdParty/coingecko"
+)
+
+const (
+	DefaultCurrency = "usd"
+	// SpotTTL is how long the price of the current day is reused before asking CoinGecko again
+	SpotTTL = 5 * time.Minute
+	// FailureTTL stops a failing (coin, day) lookup from hitting CoinGecko on every transaction of a page
+	FailureTTL = time.Minute
+	// MaxCachedPrices bounds the cache, the least recently used prices are evicted first
+	MaxCachedPrices = 10_000
+)
+
+// priceKey identifies one cached price
+type priceKey struct {
+	coin     string
+	day      string
+	currency string
+}
+
+type cachedPrice struct {
+	price     float64
+	fetchedAt time.Time
+	failed    bool
+}
+
+type cacheEntry struct {
+	key   priceKey
+	value cachedPrice
+}
+
+// Service values crypto amounts in fiat at a point in time.
+// Past days come from CoinGecko coins/{id}/history and never change, so they are kept until evicted by newer
+// lookups; the current day uses the spot price and is refreshed after SpotTTL.
+type Service struct {
+	coingecko *coingecko.Service
+	cache     map[priceKey]*list.Element
+	recent    *list.List
+	maxSize   int
+	mutex     sync.Mutex
+	now       func() time.Time
+}
+
+var (
+	priceService     *Service
+	priceServiceOnce sync.Once
+)
+
+// GetPriceService returns the shared price service used by all transaction mappers
+func GetPriceService() *Service {
+	priceServiceOnce.Do(func() {
+		priceService = NewService(coingecko.NewService())
+	})
+	return priceService
+}
+
+func NewService(coingeckoService *coingecko.Service) *Service {
+	return &Service{
+		coingecko: coingeckoService,
+		cache:     make(map[priceKey]*list.Element),
+		recent:    list.New(),
+		maxSize:   MaxCachedPrices,
+		now:       time.Now,
+	}
+}
+
+// NormalizeCurrency lowercases a fiat currency code and falls back to USD
+func NormalizeCurrency(currency string) string {
+	currency = strings.ToLower(strings.TrimSpace(currency))
+	if currency == "" {
+		return DefaultCurrency
+	}
+	return currency
+}
+
+// PriceAt returns the price of one unit of the coin (CoinGecko ID) in currency at the given time
+func (s *Service) PriceAt(coinID string, at time.Time, currency string) (float64, error) {
+	currency = NormalizeCurrency(currency)
+	now := s.now()
+	if at.IsZero() || at.After(now) {
+		at = now
+	}
+
+	isToday := sameDay(at, now)
+	key := priceKey{coin: coinID, day: at.UTC().Format("2006-01-02"), currency: currency}
+
+	if price, ok, err := s.lookup(key, isToday, now); ok {
+		return price, err
+	}
+
+	if isToday {
+		return s.fetchSpot(key, now)
+	}
+	return s.fetchHistorical(key, at, now)
+}
+
+// lookup reports ok when the cache can answer, including a recently failed lookup
+func (s *Service) lookup(key priceKey, isToday bool, now time.Time) (float64, bool, error) {
+	s.mutex.Lock()
+	element, exists := s.cache[key]
+	if exists {
+		s.recent.MoveToFront(element)
+	}
+	s.mutex.Unlock()
+
+	if !exists {
+		return 0, false, nil
+	}
+	cached := element.Value.(*cacheEntry).value
+	if cached.failed {
+		if now.Sub(cached.fetchedAt) < FailureTTL {
+			return 0, true, fmt.Errorf("price for %s on %s in %s is unavailable", key.coin, key.day, key.currency)
+		}
+		return 0, false, nil
+	}
+	if isToday && now.Sub(cached.fetchedAt) >= SpotTTL {
+		return 0, false, nil
+	}
+	return cached.price, true, nil
+}
+
+func (s *Service) fetchSpot(key priceKey, now time.Time) (float64, error) {
+	prices, err := s.coingecko.GetPrices(key.coin, key.currency)
+	if err != nil {
+		s.store(key, cachedPrice{fetchedAt: now, failed: true})
+		return 0, err
+	}
+
+	price, exists := prices[key.coin][key.currency]
+	if !exists {
+		s.store(key, cachedPrice{fetchedAt: now, failed: true})
+		return 0, fmt.Errorf("no %s price for %s", key.currency, key.coin)
+	}
+
+	s.store(key, cachedPrice{price: price, fetchedAt: now})
+	return price, nil
+}
+
+func (s *Service) fetchHistorical(key priceKey, at time.Time, now time.Time) (float64, error) {
+	prices, err := s.coingecko.GetHistoricalPrices(key.coin, at)
+	if err != nil {
+		s.store(key, cachedPrice{fetchedAt: now, failed: true})
+		return 0, err
+	}
+
+	// One history call answers every currency of that day, keep them all
+	for currency, price := range prices {
+		s.store(priceKey{coin: key.coin, day: key.day, currency: currency}, cachedPrice{price: price, fetchedAt: now})
+	}
+
+	price, exists := prices[key.currency]
+	if !exists {
+		s.store(key, cachedPrice{fetchedAt: now, failed: true})
+		return 0, fmt.Errorf("no %s price for %s on %s", key.currency, key.coin, key.day)
+	}
+	return price, nil
+}
+
+func (s *Service) store(key priceKey, value cachedPrice) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if element, exists := s.cache[key]; exists {
+		element.Value.(*cacheEntry).value = value
+		s.recent.MoveToFront(element)
+		return
+	}
+	s.cache[key] = s.recent.PushFront(&cacheEntry{key: key, value: value})
+	for s.recent.Len() > s.maxSize {
+		oldest := s.recent.Back()
+		s.recent.Remove(oldest)
+		delete(s.cache, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// Value converts an amount of the token with the given symbol into fiat at the given time
+func (s *Service) Value(symbol string, amount float64, at time.Time, currency string) (float64, error) {
+	if amount == 0 {
+		return 0, nil
+	}
+	price, err := s.PriceAt(coingecko.GetCoinGeckoID(symbol), at, currency)
+	if err != nil {
+		return 0, err
+	}
+	return amount * price, nil
+}
+
+// FormatValue values an amount and formats it for display.
+// An empty string is returned when no price is known rather than guessing one.
+func (s *Service) FormatValue(symbol string, amount float64, at time.Time, currency string) string {
+	value, err := s.Value(symbol, amount, at, currency)
+	if err != nil {
+		return ""
+	}
+	return FormatFiat(value, currency)
+}
+
+var currencySymbols = map[string]string{
+	"usd": "$",
+	"eur": "€",
+	"gbp": "£",
+	"jpy": "¥",
+	"inr": "₹",
+	"krw": "₩",
+	"aud": "A$",
+	"cad": "C$",
+}
+
+// FormatFiat formats a fiat amount, e.g. $12.34 or 12.34 CHF for currencies without a symbol
+func FormatFiat(value float64, currency string) string {
+	currency = NormalizeCurrency(currency)
+	if symbol, exists := currencySymbols[currency]; exists {
+		return fmt.Sprintf("%s%.2f", symbol, value)
+	}
+	return fmt.Sprintf("%.2f %s", value, strings.ToUpper(currency))
+}
+
+func sameDay(a, b time.Time) bool {
+	return a.UTC().Format("2006-01-02") == b.UTC().Format("2006-01-02")
+}
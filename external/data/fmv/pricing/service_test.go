@@ -0,0 +1,138 @@
+package pricing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/tashunc/nugenesis-wallet-backend/external/data/fmv/thrirdParty/coingecko"
+	"github.com/tashunc/nugenesis-wallet-backend/external/models"
+)
+
+func TestService_PriceAt_CachesHistoricalDay(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/coins/bitcoin/history" {
+			t.Errorf("Expected path /coins/bitcoin/history, got %s", r.URL.Path)
+		}
+		if r.URL.Query().Get("date") != "15-03-2024" {
+			t.Errorf("Expected date=15-03-2024, got %s", r.URL.Query().Get("date"))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"bitcoin","symbol":"btc","market_data":{"current_price":{"usd":68000,"eur":62000}}}`))
+	}))
+	defer server.Close()
+
+	service := NewService(coingecko.NewServiceWithBaseURL(server.URL))
+	service.now = func() time.Time { return time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC) }
+
+	blockTime := time.Date(2024, 3, 15, 8, 30, 0, 0, time.UTC)
+
+	usd, err := service.PriceAt("bitcoin", blockTime, "USD")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if usd != 68000 {
+		t.Errorf("Expected usd price 68000, got %f", usd)
+	}
+
+	// A later transaction on the same day in another currency must be served from the cache
+	eur, err := service.PriceAt("bitcoin", blockTime.Add(5*time.Hour), "eur")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if eur != 62000 {
+		t.Errorf("Expected eur price 62000, got %f", eur)
+	}
+
+	if requests != 1 {
+		t.Errorf("Expected 1 request to CoinGecko, got %d", requests)
+	}
+
+	if value := service.FormatValue("BTC", 0.5, blockTime, "eur"); value != "€31000.00" {
+		t.Errorf("Expected €31000.00, got %s", value)
+	}
+}
+
+func TestService_FormatValue_UnknownPrice(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	service := NewService(coingecko.NewServiceWithBaseURL(server.URL))
+
+	if value := service.FormatValue("BTC", 1, time.Now(), "usd"); value != "" {
+		t.Errorf("Expected empty value when the price is unavailable, got %s", value)
+	}
+}
+
+func TestService_Fill_LooksEachDayUpOnce(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"bitcoin","symbol":"btc","market_data":{"current_price":{"usd":50000}}}`))
+	}))
+	defer server.Close()
+
+	service := NewService(coingecko.NewServiceWithBaseURL(server.URL))
+	service.now = func() time.Time { return time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC) }
+
+	blockTime := time.Date(2024, 3, 15, 8, 30, 0, 0, time.UTC)
+	transactions := []models.Transaction{
+		{ValueQuote: Quote("BTC", 0.5, blockTime), FeeQuote: Quote("BTC", 0, blockTime)},
+		{ValueQuote: Quote("BTC", 0.1, blockTime.Add(time.Hour))},
+		{Value: "kept"},
+	}
+	service.FillTransactions(context.Background(), transactions, "usd")
+
+	if transactions[0].Value != "$25000.00" || transactions[0].Fee != "$0.00" || transactions[1].Value != "$5000.00" || transactions[2].Value != "kept" {
+		t.Errorf("Unexpected values %+v", transactions)
+	}
+	if requests != 1 {
+		t.Errorf("Expected 1 request to CoinGecko, got %d", requests)
+	}
+}
+
+func TestService_Fill_LeavesValuesEmptyAfterDeadline(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	service := NewService(coingecko.NewServiceWithBaseURL(server.URL))
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	transactions := []models.Transaction{{ValueQuote: Quote("BTC", 1, time.Now())}}
+	service.FillTransactions(ctx, transactions, "usd")
+	if transactions[0].Value != "" {
+		t.Errorf("Expected no value once the deadline passed, got %s", transactions[0].Value)
+	}
+}
+
+func TestService_CacheEvictsLeastRecentlyUsed(t *testing.T) {
+	service := NewService(nil)
+	service.maxSize = 2
+	now := time.Now()
+
+	first := priceKey{coin: "bitcoin", day: "2024-01-01", currency: "usd"}
+	second := priceKey{coin: "bitcoin", day: "2024-01-02", currency: "usd"}
+	service.store(first, cachedPrice{price: 1, fetchedAt: now})
+	service.store(second, cachedPrice{price: 2, fetchedAt: now})
+	service.lookup(first, false, now)
+	service.store(priceKey{coin: "bitcoin", day: "2024-01-03", currency: "usd"}, cachedPrice{price: 3, fetchedAt: now})
+
+	if _, ok, _ := service.lookup(second, false, now); ok {
+		t.Error("Expected the least recently used price to be evicted")
+	}
+	if price, ok, _ := service.lookup(first, false, now); !ok || price != 1 {
+		t.Error("Expected the recently read price to stay cached")
+	}
+}
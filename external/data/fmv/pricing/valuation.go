@@ -0,0 +1,113 @@
+package pricing
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/tashunc/nugenesis-wallet-backend/external/data/fmv/thrirdParty/coingecko"
+	"github.com/tashunc/nugenesis-wallet-backend/external/models"
+)
+
+const (
+	// ValuationTimeout bounds how long a page of history waits for prices once it has been fetched
+	ValuationTimeout = 3 * time.Second
+	// valuationWorkers limits the concurrent CoinGecko lookups of one page
+	valuationWorkers = 4
+)
+
+// Valuation is a fiat field filled in from the price of its quote
+type Valuation struct {
+	Target *string
+	Quote  *models.Quote
+}
+
+// Quote defers valuing an amount of the token with the given symbol to Fill
+func Quote(symbol string, amount float64, at time.Time) *models.Quote {
+	return &models.Quote{CoinID: coingecko.GetCoinGeckoID(symbol), Amount: amount, At: at}
+}
+
+// TransactionValuations lists the quoted values and fees of transactions
+func TransactionValuations(transactions []models.Transaction) []Valuation {
+	valuations := make([]Valuation, 0, 2*len(transactions))
+	for i := range transactions {
+		if quote := transactions[i].ValueQuote; quote != nil {
+			valuations = append(valuations, Valuation{Target: &transactions[i].Value, Quote: quote})
+		}
+		if quote := transactions[i].FeeQuote; quote != nil {
+			valuations = append(valuations, Valuation{Target: &transactions[i].Fee, Quote: quote})
+		}
+	}
+	return valuations
+}
+
+// FillTransactions prices the quoted values and fees of a page of transactions
+func (s *Service) FillTransactions(ctx context.Context, transactions []models.Transaction, currency string) {
+	s.Fill(ctx, TransactionValuations(transactions), currency)
+}
+
+// Fill looks every coin and day up once and writes the formatted values. Lookups still running when ctx ends
+// finish in the background to warm the cache, and their fields stay empty.
+func (s *Service) Fill(ctx context.Context, valuations []Valuation, currency string) {
+	currency = NormalizeCurrency(currency)
+
+	type lookup struct {
+		coin string
+		day  string
+	}
+	pending := make(map[lookup]time.Time)
+	for _, valuation := range valuations {
+		if valuation.Quote.Amount != 0 {
+			pending[lookup{valuation.Quote.CoinID, valuation.Quote.At.UTC().Format("2006-01-02")}] = valuation.Quote.At
+		}
+	}
+
+	var mutex sync.Mutex
+	prices := make(map[lookup]float64, len(pending))
+	jobs := make(chan lookup, len(pending))
+	for key := range pending {
+		jobs <- key
+	}
+	close(jobs)
+
+	var wg sync.WaitGroup
+	for i := 0; i < min(valuationWorkers, len(pending)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for key := range jobs {
+				if ctx.Err() != nil {
+					return
+				}
+				if price, err := s.PriceAt(key.coin, pending[key], currency); err == nil {
+					mutex.Lock()
+					prices[key] = price
+					mutex.Unlock()
+				}
+			}
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	for _, valuation := range valuations {
+		quote := valuation.Quote
+		if quote.Amount == 0 {
+			*valuation.Target = FormatFiat(0, currency)
+			continue
+		}
+		if price, ok := prices[lookup{quote.CoinID, quote.At.UTC().Format("2006-01-02")}]; ok {
+			*valuation.Target = FormatFiat(quote.Amount*price, currency)
+		}
+	}
+}
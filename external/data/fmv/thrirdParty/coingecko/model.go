@@ -3,6 +3,15 @@ package coingecko
 // PriceResponse represents the response from CoinGecko simple/price endpoint
 type PriceResponse map[string]map[string]float64
 
+// CoinHistoryResponse represents the response from CoinGecko coins/{id}/history endpoint
+type CoinHistoryResponse struct {
+	ID         string `json:"id"`
+	Symbol     string `json:"symbol"`
+	MarketData *struct {
+		CurrentPrice map[string]float64 `json:"current_price"`
+	} `json:"market_data,omitempty"`
+}
+
 // symbolToCoinGeckoID maps common token symbols to their CoinGecko IDs
 var SymbolToCoinGeckoID = map[string]string{
 	"BTC":    "bitcoin",
@@ -5,9 +5,11 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-resty/resty/v2"
 	"github.com/tashunc/nugenesis-wallet-backend/external/models"
+	"github.com/tashunc/nugenesis-wallet-backend/pkg/httpclient"
 )
 
 // Service handles CoinGecko API interactions
@@ -15,11 +17,29 @@ type Service struct {
 	client *resty.Client
 }
 
+const (
+	BaseURL = "https://api.coingecko.com/api/v3"
+	Timeout = 30 * time.Second
+)
+
 // NewService creates a new CoinGecko service instance
 func NewService() *Service {
+	return NewServiceWithBaseURL(BaseURL)
+}
+
+// NewServiceWithBaseURL creates a CoinGecko service against a custom API root
+func NewServiceWithBaseURL(baseURL string) *Service {
 	return &Service{
-		client: resty.New().
-			SetHostURL("https://api.coingecko.com/api/v3").
+		// The public API allows about 30 calls per minute
+		client: resty.NewWithClient(httpclient.New(httpclient.Config{
+			Name:          "coingecko",
+			Timeout:       Timeout,
+			RatePerSecond: 0.5,
+			Burst:         5,
+			// Valuing a history page must not queue behind the quota, an unknown price is better than a slow page
+			MaxWait: time.Second,
+		})).
+			SetHostURL(baseURL).
 			SetHeader("Accept", "application/json"),
 	}
 }
@@ -47,6 +67,35 @@ func (s *Service) GetPrices(ids, vsCurrencies string) (PriceResponse, error) {
 	return result, nil
 }
 
+// GetHistoricalPrices fetches the daily price of a coin in every fiat currency CoinGecko knows
+// Uses /coins/{id}/history which expects the date as dd-mm-yyyy (UTC)
+func (s *Service) GetHistoricalPrices(id string, date time.Time) (map[string]float64, error) {
+	var result CoinHistoryResponse
+
+	resp, err := s.client.R().
+		SetPathParam("id", id).
+		SetQueryParams(map[string]string{
+			"date":         date.UTC().Format("02-01-2006"),
+			"localization": "false",
+		}).
+		SetResult(&result).
+		Get("/coins/{id}/history")
+
+	if err != nil {
+		return nil, fmt.Errorf("API request failed: %w", err)
+	}
+
+	if resp.StatusCode() != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode())
+	}
+
+	if result.MarketData == nil || len(result.MarketData.CurrentPrice) == 0 {
+		return nil, fmt.Errorf("no market data for %s on %s", id, date.UTC().Format("2006-01-02"))
+	}
+
+	return result.MarketData.CurrentPrice, nil
+}
+
 // GetCoinGeckoID returns the CoinGecko ID for a given token symbol
 func GetCoinGeckoID(symbol string) string {
 	upperSymbol := strings.ToUpper(symbol)
@@ -0,0 +1,143 @@
+package historical
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tashunc/nugenesis-wallet-backend/external/data/fmv/pricing"
+	"github.com/tashunc/nugenesis-wallet-backend/external/models"
+)
+
+const (
+	DefaultLimit = 50
+	MaxLimit     = 100
+)
+
+// NextCursorHeader carries the next page cursor of legacy responses, whose bodies have no room for it
+const NextCursorHeader = "X-Next-Cursor"
+
+// LegacyShape is the body a chain's /address route returned before the paged v2 envelope
+type LegacyShape int
+
+const (
+	// LegacyList is a bare list of transactions
+	LegacyList LegacyShape = iota
+	// LegacyObject wraps the transactions with their count, as the Esplora-backed chains returned them
+	LegacyObject
+)
+
+// Controller serves the unified address history endpoint for one chain
+type Controller struct {
+	provider *FailoverProvider
+	legacy   LegacyShape
+}
+
+func NewController(provider *FailoverProvider, legacy LegacyShape) *Controller {
+	return &Controller{
+		provider: provider,
+		legacy:   legacy,
+	}
+}
+
+// EnvelopeRequested reports whether the caller opted into the v2 response envelope with ?version=2
+func EnvelopeRequested(ctx *gin.Context) bool {
+	return ctx.Query("version") == "2"
+}
+
+// RequestCursor reads the page cursor, accepting the pageKey name older clients send
+func RequestCursor(ctx *gin.Context) string {
+	if cursor := ctx.Query("cursor"); cursor != "" {
+		return cursor
+	}
+	return ctx.Query("pageKey")
+}
+
+// FillValues prices a fetched page under its own deadline, so slow prices never fail a page over to another provider
+func FillValues(ctx context.Context, transactions []models.Transaction, currency string) {
+	ctx, cancel := context.WithTimeout(ctx, pricing.ValuationTimeout)
+	defer cancel()
+	pricing.GetPriceService().FillTransactions(ctx, transactions, currency)
+}
+
+// WriteLegacyPage renders transactions as a bare list and hands the next cursor over in NextCursorHeader
+func WriteLegacyPage(ctx *gin.Context, transactions []models.Transaction, cursor string) {
+	if cursor != "" {
+		ctx.Header(NextCursorHeader, cursor)
+	}
+	if transactions == nil {
+		transactions = []models.Transaction{}
+	}
+	ctx.JSON(http.StatusOK, transactions)
+}
+
+// GetAddressHistory returns one page of history from the first healthy provider of the chain
+func (c *Controller) GetAddressHistory(ctx *gin.Context) {
+	address := ctx.Param("address")
+	if address == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "address parameter is required"})
+		return
+	}
+
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", strconv.Itoa(DefaultLimit)))
+	if err != nil || limit <= 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit parameter"})
+		return
+	}
+	if limit > MaxLimit {
+		limit = MaxLimit
+	}
+
+	currency := pricing.NormalizeCurrency(ctx.Query("currency"))
+	page, err := c.provider.GetHistory(ctx.Request.Context(), HistoryRequest{
+		Address:  address,
+		Limit:    limit,
+		Cursor:   RequestCursor(ctx),
+		Until:    ctx.Query("until"),
+		Currency: currency,
+	})
+	if err != nil {
+		if errors.Is(err, ErrInvalidCursor) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusBadGateway, gin.H{
+			"error":     err.Error(),
+			"providers": c.provider.Providers(),
+		})
+		return
+	}
+
+	FillValues(ctx.Request.Context(), page.Transactions, currency)
+
+	if !EnvelopeRequested(ctx) {
+		if c.legacy == LegacyList {
+			WriteLegacyPage(ctx, page.Transactions, page.Cursor)
+			return
+		}
+		if page.Cursor != "" {
+			ctx.Header(NextCursorHeader, page.Cursor)
+		}
+		transactions := page.Transactions
+		if transactions == nil {
+			transactions = []models.Transaction{}
+		}
+		ctx.JSON(http.StatusOK, models.LegacyTransactionHistoryResponse{
+			Transactions: transactions,
+			TotalCount:   len(transactions),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.TransactionHistoryResponse{
+		Success:      true,
+		Address:      address,
+		Provider:     page.Provider,
+		Transactions: page.Transactions,
+		TotalCount:   len(page.Transactions),
+		Cursor:       page.Cursor,
+		HasMore:      page.Cursor != "",
+	})
+}
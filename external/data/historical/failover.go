@@ -0,0 +1,140 @@
+package historical
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/tashunc/nugenesis-wallet-backend/pkg/deadline"
+)
+
+// DefaultProviderTimeout bounds a single provider call before failing over to the next one
+const DefaultProviderTimeout = 15 * time.Second
+
+// cursorSeparator splits the provider name from the provider specific cursor
+const cursorSeparator = ":"
+
+var (
+	ErrNoProviders   = errors.New("no history providers configured")
+	ErrInvalidCursor = errors.New("invalid cursor")
+	// ErrNotServed is returned by a provider that does not serve the request by design, e.g. an index that has not
+	// synced the address yet. The failover moves on without reporting it as a failure.
+	ErrNotServed = errors.New("not served by this provider")
+)
+
+// FailoverProvider serves history from a priority-ordered list of providers.
+// The first provider that answers within the timeout wins; errors and timeouts move on to the next one.
+type FailoverProvider struct {
+	providers []HistoryProvider
+	timeout   time.Duration
+}
+
+// NewFailoverProvider creates a FailoverProvider, providers are tried in the given order
+func NewFailoverProvider(timeout time.Duration, providers ...HistoryProvider) *FailoverProvider {
+	if timeout <= 0 {
+		timeout = DefaultProviderTimeout
+	}
+	return &FailoverProvider{
+		providers: providers,
+		timeout:   timeout,
+	}
+}
+
+// Providers returns the provider names in priority order
+func (f *FailoverProvider) Providers() []string {
+	names := make([]string, 0, len(f.providers))
+	for _, provider := range f.providers {
+		names = append(names, provider.Name())
+	}
+	return names
+}
+
+// Name implements HistoryProvider
+func (f *FailoverProvider) Name() string {
+	return "failover"
+}
+
+// GetHistory implements HistoryProvider.
+// The returned cursor is prefixed with the serving provider name, so follow-up pages go back to the same provider:
+// cursors are not portable between vendors, so a pinned provider that fails is reported instead of failing over.
+func (f *FailoverProvider) GetHistory(ctx context.Context, request HistoryRequest) (*HistoryPage, error) {
+	if len(f.providers) == 0 {
+		return nil, ErrNoProviders
+	}
+
+	candidates := f.providers
+	if request.Cursor != "" {
+		name, cursor, err := DecodeCursor(request.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		provider := f.find(name)
+		if provider == nil {
+			return nil, fmt.Errorf("%w: unknown provider %q", ErrInvalidCursor, name)
+		}
+		candidates = []HistoryProvider{provider}
+		request.Cursor = cursor
+	}
+
+	var errs []error
+	for _, provider := range candidates {
+		page, err := f.call(ctx, provider, request)
+		if err != nil {
+			if !errors.Is(err, ErrNotServed) {
+				log.Printf("history provider %s failed for %s: %v", provider.Name(), request.Address, err)
+			}
+			errs = append(errs, fmt.Errorf("%s: %w", provider.Name(), err))
+
+			// The client went away, there is no point in trying the remaining providers
+			if ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+
+		if page.Cursor != "" {
+			page.Cursor = EncodeCursor(provider.Name(), page.Cursor)
+		}
+		page.Provider = provider.Name()
+		return page, nil
+	}
+
+	return nil, fmt.Errorf("all history providers failed: %w", errors.Join(errs...))
+}
+
+// call runs a single provider with the failover timeout applied
+func (f *FailoverProvider) call(ctx context.Context, provider HistoryProvider, request HistoryRequest) (*HistoryPage, error) {
+	page, err := deadline.Call(ctx, f.timeout, func(ctx context.Context) (*HistoryPage, error) {
+		return provider.GetHistory(ctx, request)
+	})
+	if err == nil && page == nil {
+		return nil, errors.New("provider returned no page")
+	}
+	return page, err
+}
+
+func (f *FailoverProvider) find(name string) HistoryProvider {
+	for _, provider := range f.providers {
+		if provider.Name() == name {
+			return provider
+		}
+	}
+	return nil
+}
+
+// EncodeCursor prefixes a provider specific cursor with the provider name
+func EncodeCursor(provider, cursor string) string {
+	return provider + cursorSeparator + cursor
+}
+
+// DecodeCursor splits a cursor produced by EncodeCursor
+func DecodeCursor(cursor string) (string, string, error) {
+	name, rest, found := strings.Cut(cursor, cursorSeparator)
+	if !found || name == "" || rest == "" {
+		return "", "", fmt.Errorf("%w: %q", ErrInvalidCursor, cursor)
+	}
+	return name, rest, nil
+}
@@ -0,0 +1,141 @@
+package historical
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tashunc/nugenesis-wallet-backend/external/models"
+)
+
+type fakeProvider struct {
+	name    string
+	delay   time.Duration
+	err     error
+	cursor  string
+	calls   int
+	lastReq HistoryRequest
+}
+
+func (p *fakeProvider) Name() string {
+	return p.name
+}
+
+func (p *fakeProvider) GetHistory(ctx context.Context, request HistoryRequest) (*HistoryPage, error) {
+	p.calls++
+	p.lastReq = request
+	if p.delay > 0 {
+		time.Sleep(p.delay)
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	return &HistoryPage{
+		Transactions: []models.Transaction{{ID: p.name}},
+		Cursor:       p.cursor,
+	}, nil
+}
+
+func TestFailoverProvider_FailsOverOnError(t *testing.T) {
+	primary := &fakeProvider{name: "primary", err: errors.New("outage")}
+	secondary := &fakeProvider{name: "secondary", cursor: "25"}
+
+	failover := NewFailoverProvider(time.Second, primary, secondary)
+	page, err := failover.GetHistory(context.Background(), HistoryRequest{Address: "addr", Limit: 10})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if page.Provider != "secondary" {
+		t.Errorf("Expected provider secondary, got %s", page.Provider)
+	}
+
+	if page.Cursor != "secondary:25" {
+		t.Errorf("Expected cursor secondary:25, got %s", page.Cursor)
+	}
+}
+
+func TestFailoverProvider_FailsOverOnTimeout(t *testing.T) {
+	slow := &fakeProvider{name: "slow", delay: 200 * time.Millisecond}
+	fast := &fakeProvider{name: "fast"}
+
+	failover := NewFailoverProvider(20*time.Millisecond, slow, fast)
+	page, err := failover.GetHistory(context.Background(), HistoryRequest{Address: "addr", Limit: 10})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if page.Provider != "fast" {
+		t.Errorf("Expected provider fast, got %s", page.Provider)
+	}
+}
+
+func TestFailoverProvider_CursorPinsProvider(t *testing.T) {
+	primary := &fakeProvider{name: "primary"}
+	secondary := &fakeProvider{name: "secondary"}
+
+	failover := NewFailoverProvider(time.Second, primary, secondary)
+	_, err := failover.GetHistory(context.Background(), HistoryRequest{Address: "addr", Limit: 10, Cursor: "secondary:abc"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if primary.calls != 0 {
+		t.Errorf("Expected primary not to be called, got %d calls", primary.calls)
+	}
+
+	if secondary.lastReq.Cursor != "abc" {
+		t.Errorf("Expected provider cursor abc, got %s", secondary.lastReq.Cursor)
+	}
+}
+
+func TestFailoverProvider_AllProvidersFail(t *testing.T) {
+	failover := NewFailoverProvider(time.Second,
+		&fakeProvider{name: "a", err: errors.New("down")},
+		&fakeProvider{name: "b", err: errors.New("down")},
+	)
+
+	_, err := failover.GetHistory(context.Background(), HistoryRequest{Address: "addr", Limit: 10})
+	if err == nil {
+		t.Fatal("Expected an error, got nil")
+	}
+
+	_, err = failover.GetHistory(context.Background(), HistoryRequest{Address: "addr", Limit: 10, Cursor: "unknown:1"})
+	if !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("Expected ErrInvalidCursor, got %v", err)
+	}
+}
+
+func TestController_KeepsLegacyShapeUnlessVersioned(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	provider := &fakeProvider{name: "first", cursor: "next"}
+	controller := NewController(NewFailoverProvider(time.Second, provider), LegacyList)
+
+	get := func(query string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(recorder)
+		ctx.Request = httptest.NewRequest(http.MethodGet, "/address/abc"+query, nil)
+		ctx.Params = gin.Params{{Key: "address", Value: "abc"}}
+		controller.GetAddressHistory(ctx)
+		return recorder
+	}
+
+	legacy := get("?pageKey=first:older")
+	var transactions []models.Transaction
+	if err := json.Unmarshal(legacy.Body.Bytes(), &transactions); err != nil || len(transactions) != 1 {
+		t.Fatalf("Expected a bare transaction list, got %s", legacy.Body.String())
+	}
+	if legacy.Header().Get(NextCursorHeader) != "first:next" || provider.lastReq.Cursor != "older" {
+		t.Errorf("Expected pageKey to page and the next cursor in a header, got %q and %q", provider.lastReq.Cursor, legacy.Header().Get(NextCursorHeader))
+	}
+
+	var envelope models.TransactionHistoryResponse
+	if err := json.Unmarshal(get("?version=2").Body.Bytes(), &envelope); err != nil || envelope.Cursor != "first:next" || !envelope.HasMore {
+		t.Errorf("Expected the v2 envelope, got %+v", envelope)
+	}
+}
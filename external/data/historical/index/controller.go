@@ -0,0 +1,41 @@
+package index
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Controller lets callers subscribe addresses to the index
+type Controller struct {
+	store Store
+}
+
+func NewController(store Store) *Controller {
+	return &Controller{store: store}
+}
+
+// Watch subscribes the :address of chain :id for WatchTTL, subscribing again extends the subscription.
+// History is served from the index once the worker has backfilled the address.
+func (c *Controller) Watch(ctx *gin.Context) {
+	coinType := ctx.Param("id")
+	address := ctx.Param("address")
+	if address == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "address parameter is required"})
+		return
+	}
+
+	if err := c.store.Watch(ctx.Request.Context(), coinType, address, WatchTTL); err != nil {
+		log.Printf("history index: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to watch address"})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"coinType":  coinType,
+		"address":   NormalizeAddress(address),
+		"expiresAt": time.Now().Add(WatchTTL).UTC().Format(time.RFC3339),
+	})
+}
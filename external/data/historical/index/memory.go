@@ -0,0 +1,196 @@
+package index
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/tashunc/nugenesis-wallet-backend/external/models"
+)
+
+// indexedTransaction is a stored transaction with the time it is ordered by
+type indexedTransaction struct {
+	transaction models.Transaction
+	occurredAt  time.Time
+}
+
+// memoryWatch is a watched address with its subscription expiry
+type memoryWatch struct {
+	address   WatchedAddress
+	expiresAt time.Time
+}
+
+// MemoryStore keeps the index in process, for tests and single instance setups
+type MemoryStore struct {
+	transactions map[string]map[string]indexedTransaction
+	watched      map[string]*memoryWatch
+	mutex        sync.RWMutex
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		transactions: make(map[string]map[string]indexedTransaction),
+		watched:      make(map[string]*memoryWatch),
+	}
+}
+
+func addressKey(coinType string, address string) string {
+	return coinType + "/" + NormalizeAddress(address)
+}
+
+func (s *MemoryStore) UpsertTransactions(ctx context.Context, coinType string, address string, provider string, transactions []models.Transaction) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	key := addressKey(coinType, address)
+	if s.transactions[key] == nil {
+		s.transactions[key] = make(map[string]indexedTransaction)
+	}
+
+	known := 0
+	for _, transaction := range transactions {
+		if !indexable(transaction) {
+			continue
+		}
+		at, _ := occurredAt(transaction)
+
+		legKey := transaction.ID + cursorSeparator + transaction.Leg
+		if _, exists := s.transactions[key][legKey]; exists {
+			known++
+		}
+		s.transactions[key][legKey] = indexedTransaction{transaction: transaction, occurredAt: at}
+	}
+	return known, nil
+}
+
+func (s *MemoryStore) ListTransactions(ctx context.Context, coinType string, address string, cursor string, limit int) ([]models.Transaction, string, error) {
+	var before time.Time
+	var beforeID, beforeLeg string
+	if cursor != "" {
+		var err error
+		if before, beforeID, beforeLeg, err = DecodeCursor(cursor); err != nil {
+			return nil, "", err
+		}
+	}
+
+	s.mutex.RLock()
+	rows := make([]indexedTransaction, 0, len(s.transactions[addressKey(coinType, address)]))
+	for _, row := range s.transactions[addressKey(coinType, address)] {
+		rows = append(rows, row)
+	}
+	s.mutex.RUnlock()
+
+	// Same order as the Postgres keyset: occurred_at, tx_id, leg, all descending
+	newer := func(a indexedTransaction, b indexedTransaction) bool {
+		if !a.occurredAt.Equal(b.occurredAt) {
+			return a.occurredAt.After(b.occurredAt)
+		}
+		if a.transaction.ID != b.transaction.ID {
+			return a.transaction.ID > b.transaction.ID
+		}
+		return a.transaction.Leg > b.transaction.Leg
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		return newer(rows[i], rows[j])
+	})
+
+	position := indexedTransaction{occurredAt: before, transaction: models.Transaction{ID: beforeID, Leg: beforeLeg}}
+	page := make([]indexedTransaction, 0, limit)
+	for _, row := range rows {
+		if cursor != "" && !newer(position, row) {
+			continue
+		}
+		if len(page) == limit {
+			last := page[len(page)-1]
+			return transactionsOf(page), EncodeCursor(last.occurredAt, last.transaction.ID, last.transaction.Leg), nil
+		}
+		page = append(page, row)
+	}
+	return transactionsOf(page), "", nil
+}
+
+func transactionsOf(rows []indexedTransaction) []models.Transaction {
+	transactions := make([]models.Transaction, 0, len(rows))
+	for _, row := range rows {
+		transactions = append(transactions, row.transaction)
+	}
+	return transactions
+}
+
+func (s *MemoryStore) Watch(ctx context.Context, coinType string, address string, ttl time.Duration) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	expiresAt := time.Now().Add(ttl)
+	key := addressKey(coinType, address)
+	if watch, exists := s.watched[key]; exists {
+		if expiresAt.After(watch.expiresAt) {
+			watch.expiresAt = expiresAt
+		}
+		return nil
+	}
+	s.watched[key] = &memoryWatch{
+		address:   WatchedAddress{CoinType: coinType, Address: NormalizeAddress(address)},
+		expiresAt: expiresAt,
+	}
+	return nil
+}
+
+func (s *MemoryStore) IsBackfilled(ctx context.Context, coinType string, address string) (bool, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	watch, exists := s.watched[addressKey(coinType, address)]
+	return exists && time.Now().Before(watch.expiresAt) && watch.address.Backfilled, nil
+}
+
+func (s *MemoryStore) WatchedAddresses(ctx context.Context) ([]WatchedAddress, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	now := time.Now()
+	var watched []WatchedAddress
+	for _, watch := range s.watched {
+		if now.Before(watch.expiresAt) {
+			watched = append(watched, watch.address)
+		}
+	}
+	sort.Slice(watched, func(i, j int) bool {
+		a, b := watched[i].LastSyncedAt, watched[j].LastSyncedAt
+		return a == nil && b != nil || a != nil && b != nil && a.Before(*b)
+	})
+	return watched, nil
+}
+
+func (s *MemoryStore) MarkSynced(ctx context.Context, address WatchedAddress, syncErr error) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	watch, exists := s.watched[addressKey(address.CoinType, address.Address)]
+	if !exists || syncErr != nil {
+		return nil
+	}
+	now := time.Now()
+	watch.address.BackfillCursor = address.BackfillCursor
+	watch.address.GapCursor = address.GapCursor
+	watch.address.Backfilled = address.Backfilled
+	watch.address.LastSyncedAt = &now
+	return nil
+}
+
+func (s *MemoryStore) PruneExpired(ctx context.Context) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	pruned := 0
+	for key, watch := range s.watched {
+		if !now.Before(watch.expiresAt) {
+			delete(s.watched, key)
+			delete(s.transactions, key)
+			pruned++
+		}
+	}
+	return pruned, nil
+}
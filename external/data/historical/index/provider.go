@@ -0,0 +1,55 @@
+package index
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tashunc/nugenesis-wallet-backend/external/data/fmv/pricing"
+	"github.com/tashunc/nugenesis-wallet-backend/external/data/historical"
+)
+
+const ProviderName = "index"
+
+// ErrNotIndexed makes the failover provider fall through to the live providers
+var ErrNotIndexed = fmt.Errorf("%w: address history is not indexed yet", historical.ErrNotServed)
+
+// HistoryProvider serves history from the Postgres index through historical.HistoryProvider.
+// It must come first in a chain's failover list, addresses it cannot serve yet are answered by the live providers.
+type HistoryProvider struct {
+	store    Store
+	coinType string
+}
+
+func NewHistoryProvider(store Store, coinType string) *HistoryProvider {
+	return &HistoryProvider{store: store, coinType: coinType}
+}
+
+func (p *HistoryProvider) Name() string {
+	return ProviderName
+}
+
+// GetHistory serves fully backfilled addresses. Addresses are only synced once subscribed, see Controller.Watch.
+// Values are indexed in the default currency only, other currencies and until bounded pages always go live.
+func (p *HistoryProvider) GetHistory(ctx context.Context, request historical.HistoryRequest) (*historical.HistoryPage, error) {
+	if pricing.NormalizeCurrency(request.Currency) != pricing.DefaultCurrency || request.Until != "" {
+		return nil, ErrNotIndexed
+	}
+
+	backfilled, err := p.store.IsBackfilled(ctx, p.coinType, request.Address)
+	if err != nil {
+		return nil, err
+	}
+	if !backfilled {
+		return nil, ErrNotIndexed
+	}
+
+	transactions, cursor, err := p.store.ListTransactions(ctx, p.coinType, request.Address, request.Cursor, request.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &historical.HistoryPage{
+		Transactions: transactions,
+		Cursor:       cursor,
+	}, nil
+}
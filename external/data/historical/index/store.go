@@ -0,0 +1,307 @@
+package index
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tashunc/nugenesis-wallet-backend/external/data/historical"
+	"github.com/tashunc/nugenesis-wallet-backend/external/models"
+)
+
+// cursorSeparator splits the unix time, transaction id and leg in a keyset cursor
+const cursorSeparator = "_"
+
+// WatchTTL is how long a subscription keeps an address synced, subscribing again extends it
+const WatchTTL = 30 * 24 * time.Hour
+
+// WatchedAddress is an address kept up to date by the sync worker
+type WatchedAddress struct {
+	CoinType string
+	Address  string
+	// BackfillCursor is the provider cursor to resume the initial backfill from, empty once history is exhausted
+	BackfillCursor string
+	// GapCursor resumes new transactions that did not fit into one run, empty once they reach the indexed ones
+	GapCursor    string
+	Backfilled   bool
+	LastSyncedAt *time.Time
+}
+
+// Store persists normalized transactions keyed by (coin type, address, transaction id, leg)
+type Store interface {
+	// UpsertTransactions stores a page of transactions and returns how many of them were indexed before.
+	// Known transactions are updated, so a pending transaction picks up its confirmed status.
+	UpsertTransactions(ctx context.Context, coinType string, address string, provider string, transactions []models.Transaction) (int, error)
+	// ListTransactions returns one page newest first and the cursor of the next page, empty on the last page
+	ListTransactions(ctx context.Context, coinType string, address string, cursor string, limit int) ([]models.Transaction, string, error)
+	// Watch registers an address for syncing until ttl from now, a longer existing subscription is kept
+	Watch(ctx context.Context, coinType string, address string, ttl time.Duration) error
+	// IsBackfilled reports whether the full history of a watched, unexpired address is indexed
+	IsBackfilled(ctx context.Context, coinType string, address string) (bool, error)
+	// WatchedAddresses returns the unexpired watched addresses, least recently synced first
+	WatchedAddresses(ctx context.Context) ([]WatchedAddress, error)
+	// MarkSynced records the outcome of a sync run. A failed run keeps the previous sync state.
+	MarkSynced(ctx context.Context, address WatchedAddress, syncErr error) error
+	// PruneExpired drops expired addresses with their transactions and returns how many addresses were dropped
+	PruneExpired(ctx context.Context) (int, error)
+}
+
+// NormalizeAddress lowercases EVM addresses, other chains use case-sensitive encodings and are kept as is
+func NormalizeAddress(address string) string {
+	address = strings.TrimSpace(address)
+	if strings.HasPrefix(address, "0x") || strings.HasPrefix(address, "0X") {
+		return strings.ToLower(address)
+	}
+	return address
+}
+
+// occurredAt rebuilds the transaction time from the minute precision Date and Time fields written by the mappers.
+// Transactions without a time, e.g. pending ones, are not indexed until a later sync sees them confirmed.
+func occurredAt(tx models.Transaction) (time.Time, bool) {
+	t, err := time.ParseInLocation("2006-01-02 15:04", tx.Date+" "+tx.Time, time.Local)
+	return t, err == nil
+}
+
+// indexable reports whether a transaction can be stored
+func indexable(tx models.Transaction) bool {
+	_, ok := occurredAt(tx)
+	return ok && tx.ID != ""
+}
+
+// PostgresStore keeps the index in the transactions and watched_addresses tables
+type PostgresStore struct {
+	db *sql.DB
+}
+
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+func (s *PostgresStore) UpsertTransactions(ctx context.Context, coinType string, address string, provider string, transactions []models.Transaction) (int, error) {
+	address = NormalizeAddress(address)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO transactions (coin_type, wallet_address, tx_id, leg, hash, type, category, status, token, amount,
+			value, tx_address, to_address, date, time, fee, occurred_at, provider)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
+		ON CONFLICT (coin_type, wallet_address, tx_id, leg) DO UPDATE SET
+			status = EXCLUDED.status,
+			value = EXCLUDED.value,
+			fee = EXCLUDED.fee,
+			date = EXCLUDED.date,
+			time = EXCLUDED.time,
+			occurred_at = EXCLUDED.occurred_at
+		RETURNING (xmax = 0)`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare upsert: %w", err)
+	}
+	defer stmt.Close()
+
+	known := 0
+	for _, transaction := range transactions {
+		if !indexable(transaction) {
+			continue
+		}
+		at, _ := occurredAt(transaction)
+
+		var isNew bool
+		err := stmt.QueryRowContext(ctx, coinType, address, transaction.ID, transaction.Leg, transaction.Hash,
+			transaction.Type, transaction.Category, transaction.Status, transaction.Token, transaction.Amount,
+			transaction.Value, transaction.Address, transaction.ToAddress, transaction.Date, transaction.Time,
+			transaction.Fee, at, provider).Scan(&isNew)
+		if err != nil {
+			return 0, fmt.Errorf("failed to upsert transaction %s: %w", transaction.ID, err)
+		}
+		if !isNew {
+			known++
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transactions: %w", err)
+	}
+	return known, nil
+}
+
+func (s *PostgresStore) ListTransactions(ctx context.Context, coinType string, address string, cursor string, limit int) ([]models.Transaction, string, error) {
+	address = NormalizeAddress(address)
+
+	query := `
+		SELECT tx_id, leg, hash, type, category, status, token, amount, value, tx_address, to_address, date, time, fee,
+			occurred_at
+		FROM transactions
+		WHERE coin_type = $1 AND wallet_address = $2`
+	args := []interface{}{coinType, address}
+
+	if cursor != "" {
+		before, txID, leg, err := DecodeCursor(cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		query += ` AND (occurred_at, tx_id, leg) < ($3, $4, $5)`
+		args = append(args, before, txID, leg)
+	}
+	query += fmt.Sprintf(` ORDER BY occurred_at DESC, tx_id DESC, leg DESC LIMIT %d`, limit+1)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to query transactions: %w", err)
+	}
+	defer rows.Close()
+
+	transactions := make([]models.Transaction, 0, limit)
+	var lastOccurredAt time.Time
+	for rows.Next() {
+		if len(transactions) == limit {
+			// The extra row only tells us there is another page
+			last := transactions[len(transactions)-1]
+			return transactions, EncodeCursor(lastOccurredAt, last.ID, last.Leg), rows.Close()
+		}
+
+		var transaction models.Transaction
+		if err := rows.Scan(&transaction.ID, &transaction.Leg, &transaction.Hash, &transaction.Type,
+			&transaction.Category, &transaction.Status, &transaction.Token, &transaction.Amount, &transaction.Value,
+			&transaction.Address, &transaction.ToAddress, &transaction.Date, &transaction.Time, &transaction.Fee,
+			&lastOccurredAt); err != nil {
+			return nil, "", fmt.Errorf("failed to scan transaction: %w", err)
+		}
+		transactions = append(transactions, transaction)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", fmt.Errorf("failed to read transactions: %w", err)
+	}
+	return transactions, "", nil
+}
+
+func (s *PostgresStore) Watch(ctx context.Context, coinType string, address string, ttl time.Duration) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO watched_addresses (coin_type, address, expires_at) VALUES ($1, $2, $3)
+		ON CONFLICT (coin_type, address) DO UPDATE SET
+			expires_at = GREATEST(watched_addresses.expires_at, EXCLUDED.expires_at)`,
+		coinType, NormalizeAddress(address), time.Now().Add(ttl))
+	if err != nil {
+		return fmt.Errorf("failed to watch address: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresStore) IsBackfilled(ctx context.Context, coinType string, address string) (bool, error) {
+	var backfilled bool
+	err := s.db.QueryRowContext(ctx, `
+		SELECT backfilled FROM watched_addresses WHERE coin_type = $1 AND address = $2 AND expires_at > now()`,
+		coinType, NormalizeAddress(address)).Scan(&backfilled)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read watched address: %w", err)
+	}
+	return backfilled, nil
+}
+
+func (s *PostgresStore) WatchedAddresses(ctx context.Context) ([]WatchedAddress, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT coin_type, address, backfill_cursor, gap_cursor, backfilled, last_synced_at
+		FROM watched_addresses
+		WHERE expires_at > now()
+		ORDER BY last_synced_at ASC NULLS FIRST`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query watched addresses: %w", err)
+	}
+	defer rows.Close()
+
+	var watched []WatchedAddress
+	for rows.Next() {
+		var address WatchedAddress
+		var lastSyncedAt sql.NullTime
+		if err := rows.Scan(&address.CoinType, &address.Address, &address.BackfillCursor, &address.GapCursor,
+			&address.Backfilled, &lastSyncedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan watched address: %w", err)
+		}
+		if lastSyncedAt.Valid {
+			address.LastSyncedAt = &lastSyncedAt.Time
+		}
+		watched = append(watched, address)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read watched addresses: %w", err)
+	}
+	return watched, nil
+}
+
+func (s *PostgresStore) MarkSynced(ctx context.Context, address WatchedAddress, syncErr error) error {
+	var err error
+	if syncErr != nil {
+		_, err = s.db.ExecContext(ctx, `
+			UPDATE watched_addresses SET last_error = $3
+			WHERE coin_type = $1 AND address = $2`,
+			address.CoinType, address.Address, syncErr.Error())
+	} else {
+		_, err = s.db.ExecContext(ctx, `
+			UPDATE watched_addresses
+			SET backfill_cursor = $3, gap_cursor = $4, backfilled = $5, last_synced_at = now(), last_error = ''
+			WHERE coin_type = $1 AND address = $2`,
+			address.CoinType, address.Address, address.BackfillCursor, address.GapCursor, address.Backfilled)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update watched address: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresStore) PruneExpired(ctx context.Context) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM transactions t
+		USING watched_addresses w
+		WHERE t.coin_type = w.coin_type AND t.wallet_address = w.address AND w.expires_at <= now()`); err != nil {
+		return 0, fmt.Errorf("failed to prune transactions: %w", err)
+	}
+	result, err := tx.ExecContext(ctx, `DELETE FROM watched_addresses WHERE expires_at <= now()`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune watched addresses: %w", err)
+	}
+	pruned, _ := result.RowsAffected()
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit prune: %w", err)
+	}
+	return int(pruned), nil
+}
+
+// EncodeCursor builds a keyset cursor from the last row of a page
+func EncodeCursor(occurredAt time.Time, txID string, leg string) string {
+	return strconv.FormatInt(occurredAt.Unix(), 10) + cursorSeparator + txID + cursorSeparator + leg
+}
+
+// DecodeCursor splits a cursor produced by EncodeCursor
+func DecodeCursor(cursor string) (time.Time, string, string, error) {
+	parts := strings.SplitN(cursor, cursorSeparator, 3)
+	if len(parts) != 3 || parts[1] == "" {
+		return time.Time{}, "", "", fmt.Errorf("%w: %q", historical.ErrInvalidCursor, cursor)
+	}
+	unix, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return time.Time{}, "", "", fmt.Errorf("%w: %q", historical.ErrInvalidCursor, cursor)
+	}
+	return time.Unix(unix, 0), parts[1], parts[2], nil
+}
@@ -0,0 +1,137 @@
+package index
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/tashunc/nugenesis-wallet-backend/external/data/historical"
+	"github.com/tashunc/nugenesis-wallet-backend/external/models"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	at := time.Date(2024, 3, 9, 14, 5, 0, 0, time.UTC)
+	cursor := EncodeCursor(at, "0xabc", "internal:0_1")
+
+	decodedAt, txID, leg, err := DecodeCursor(cursor)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !decodedAt.Equal(at) || txID != "0xabc" || leg != "internal:0_1" {
+		t.Errorf("Expected %s 0xabc internal:0_1, got %s %s %s", at, decodedAt, txID, leg)
+	}
+
+	for _, invalid := range []string{"", "1710000000", "1710000000_0xabc", "abc_0xabc_", "1710000000__"} {
+		if _, _, _, err := DecodeCursor(invalid); !errors.Is(err, historical.ErrInvalidCursor) {
+			t.Errorf("%q: expected ErrInvalidCursor, got %v", invalid, err)
+		}
+	}
+}
+
+func TestNormalizeAddress(t *testing.T) {
+	if address := NormalizeAddress(" 0xAbCd "); address != "0xabcd" {
+		t.Errorf("Expected EVM address to be lowercased, got %s", address)
+	}
+	if address := NormalizeAddress("9WzDXwBbmkg8ZTbNMqUxvQRAyrZzDsGYdLVL9zYtAWWM"); address != "9WzDXwBbmkg8ZTbNMqUxvQRAyrZzDsGYdLVL9zYtAWWM" {
+		t.Errorf("Expected base58 address to keep its case, got %s", address)
+	}
+}
+
+func TestOccurredAt(t *testing.T) {
+	at, ok := occurredAt(models.Transaction{Date: "2024-03-09", Time: "14:05"})
+	expected := time.Date(2024, 3, 9, 14, 5, 0, 0, time.Local)
+	if !ok || !at.Equal(expected) {
+		t.Errorf("Expected %s, got %s", expected, at)
+	}
+
+	if _, ok := occurredAt(models.Transaction{}); ok {
+		t.Error("Expected a transaction without time to have no occurrence")
+	}
+}
+
+func transaction(id string, leg string, minute int) models.Transaction {
+	at := time.Date(2024, 3, 9, 14, minute, 0, 0, time.Local)
+	return models.Transaction{ID: id, Leg: leg, Hash: id, Date: at.Format("2006-01-02"), Time: at.Format("15:04")}
+}
+
+func TestMemoryStoreKeysByLeg(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	page := []models.Transaction{
+		transaction("swap", "0", 5),
+		transaction("swap", "1", 5),
+		{ID: "pending", Status: "pending"},
+	}
+	known, err := store.UpsertTransactions(ctx, "60", "0xABC", "test", page)
+	if err != nil || known != 0 {
+		t.Fatalf("Expected a new page, got %d known (%v)", known, err)
+	}
+	if known, _ := store.UpsertTransactions(ctx, "60", "0xabc", "test", page); known != 2 {
+		t.Errorf("Expected both legs to be known, got %d", known)
+	}
+
+	transactions, _, _ := store.ListTransactions(ctx, "60", "0xabc", "", 10)
+	if len(transactions) != 2 || transactions[0].Leg != "1" || transactions[1].Leg != "0" {
+		t.Errorf("Expected both legs without the untimed transaction, got %+v", transactions)
+	}
+}
+
+func TestMemoryStorePagesNewestFirst(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	_, _ = store.UpsertTransactions(ctx, "0", "bc1q", "test", []models.Transaction{
+		transaction("a", "", 1),
+		transaction("c", "", 3),
+		transaction("b", "", 3),
+	})
+
+	var ids []string
+	cursor := ""
+	for {
+		page, next, err := store.ListTransactions(ctx, "0", "bc1q", cursor, 2)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		for _, transaction := range page {
+			ids = append(ids, transaction.ID)
+		}
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+	if len(ids) != 3 || ids[0] != "c" || ids[1] != "b" || ids[2] != "a" {
+		t.Errorf("Expected c b a, got %v", ids)
+	}
+}
+
+func TestMemoryStoreWatchExpires(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	_ = store.Watch(ctx, "0", "bc1q", -time.Minute)
+	_, _ = store.UpsertTransactions(ctx, "0", "bc1q", "test", []models.Transaction{transaction("a", "", 1)})
+	_ = store.MarkSynced(ctx, WatchedAddress{CoinType: "0", Address: "bc1q", Backfilled: true}, nil)
+
+	if watched, _ := store.WatchedAddresses(ctx); len(watched) != 0 {
+		t.Errorf("Expected no watched addresses, got %+v", watched)
+	}
+	if backfilled, _ := store.IsBackfilled(ctx, "0", "bc1q"); backfilled {
+		t.Error("Expected an expired address not to be served")
+	}
+	if pruned, _ := store.PruneExpired(ctx); pruned != 1 {
+		t.Errorf("Expected the expired address to be pruned, got %d", pruned)
+	}
+	if transactions, _, _ := store.ListTransactions(ctx, "0", "bc1q", "", 10); len(transactions) != 0 {
+		t.Errorf("Expected the transactions to be pruned with the address, got %+v", transactions)
+	}
+
+	// Subscribing again keeps the later expiry
+	_ = store.Watch(ctx, "0", "bc1q", time.Hour)
+	_ = store.Watch(ctx, "0", "bc1q", time.Minute)
+	if pruned, _ := store.PruneExpired(ctx); pruned != 0 {
+		t.Errorf("Expected the subscription to be kept, got %d pruned", pruned)
+	}
+}
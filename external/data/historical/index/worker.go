@@ -0,0 +1,163 @@
+package index
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/tashunc/nugenesis-wallet-backend/external/data/fmv/pricing"
+	"github.com/tashunc/nugenesis-wallet-backend/external/data/historical"
+)
+
+const (
+	DefaultSyncInterval = time.Minute
+	// syncPageSize is requested from the live providers, some use a fixed page size instead
+	syncPageSize = 100
+	// maxPagesPerRun bounds the vendor calls of one pull, a longer pull resumes next run
+	maxPagesPerRun = 10
+)
+
+// Worker periodically pulls new transactions of watched addresses from the live providers into the index
+type Worker struct {
+	store    Store
+	sources  map[string]historical.HistoryProvider
+	interval time.Duration
+}
+
+// NewWorker creates a worker, sources are the live providers keyed by coin type and must not include the index
+func NewWorker(store Store, sources map[string]historical.HistoryProvider, interval time.Duration) *Worker {
+	if interval <= 0 {
+		interval = DefaultSyncInterval
+	}
+	return &Worker{
+		store:    store,
+		sources:  sources,
+		interval: interval,
+	}
+}
+
+// Start runs sync passes until ctx is cancelled
+func (w *Worker) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+
+		for {
+			w.SyncOnce(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// SyncOnce drops expired addresses and syncs every watched address once, least recently synced first
+func (w *Worker) SyncOnce(ctx context.Context) {
+	if _, err := w.store.PruneExpired(ctx); err != nil {
+		log.Printf("history index: %v", err)
+	}
+
+	watched, err := w.store.WatchedAddresses(ctx)
+	if err != nil {
+		log.Printf("history index: %v", err)
+		return
+	}
+
+	for _, address := range watched {
+		if ctx.Err() != nil {
+			return
+		}
+
+		syncErr := w.syncAddress(ctx, &address)
+		if syncErr != nil {
+			log.Printf("history index: sync of %s %s failed: %v", address.CoinType, address.Address, syncErr)
+		}
+		if err := w.store.MarkSynced(ctx, address, syncErr); err != nil {
+			log.Printf("history index: %v", err)
+		}
+	}
+}
+
+// syncAddress pulls the transactions newer than the index, then continues an unfinished gap and backfill.
+// The first run of an address is the start of its backfill. The address is only served once neither is left.
+func (w *Worker) syncAddress(ctx context.Context, address *WatchedAddress) error {
+	source, ok := w.sources[address.CoinType]
+	if !ok {
+		return fmt.Errorf("no history source for coin type %s", address.CoinType)
+	}
+
+	if address.LastSyncedAt == nil {
+		cursor, err := w.pull(ctx, source, address, "", false)
+		if err != nil {
+			return err
+		}
+		address.BackfillCursor = cursor
+		address.Backfilled = cursor == ""
+		return nil
+	}
+
+	cursor, err := w.pull(ctx, source, address, "", true)
+	if err != nil {
+		return err
+	}
+	// More new pages than one run covers, the rest is pulled until it reaches the indexed transactions
+	if cursor != "" {
+		if address.GapCursor == "" {
+			address.GapCursor = cursor
+		} else {
+			// A second gap opened before the first closed, a full walk from the newer one covers both
+			address.BackfillCursor = cursor
+			address.GapCursor = ""
+		}
+	}
+
+	if address.GapCursor != "" {
+		cursor, err := w.pull(ctx, source, address, address.GapCursor, true)
+		if err != nil {
+			return err
+		}
+		address.GapCursor = cursor
+	}
+
+	if address.BackfillCursor != "" {
+		cursor, err := w.pull(ctx, source, address, address.BackfillCursor, false)
+		if err != nil {
+			return err
+		}
+		address.BackfillCursor = cursor
+	}
+	address.Backfilled = address.BackfillCursor == "" && address.GapCursor == ""
+	return nil
+}
+
+// pull stores pages starting at cursor and returns the cursor to resume from, empty when history is exhausted.
+// With stopAtKnown it stops at the first page that contains an already indexed transaction.
+func (w *Worker) pull(ctx context.Context, source historical.HistoryProvider, address *WatchedAddress, cursor string, stopAtKnown bool) (string, error) {
+	for page := 0; page < maxPagesPerRun; page++ {
+		result, err := source.GetHistory(ctx, historical.HistoryRequest{
+			Address:  address.Address,
+			Limit:    syncPageSize,
+			Cursor:   cursor,
+			Currency: pricing.DefaultCurrency,
+		})
+		if err != nil {
+			return "", err
+		}
+		historical.FillValues(ctx, result.Transactions, pricing.DefaultCurrency)
+
+		known, err := w.store.UpsertTransactions(ctx, address.CoinType, address.Address, result.Provider, result.Transactions)
+		if err != nil {
+			return "", err
+		}
+
+		if result.Cursor == "" || (stopAtKnown && known > 0) {
+			return "", nil
+		}
+		cursor = result.Cursor
+	}
+	return cursor, nil
+}
@@ -0,0 +1,141 @@
+package index
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/tashunc/nugenesis-wallet-backend/external/data/historical"
+	"github.com/tashunc/nugenesis-wallet-backend/external/models"
+)
+
+// fakeSource serves its transactions newest first, one per page. Cursors point past a transaction id, like the
+// vendor cursors do, so new transactions at the head do not shift them.
+type fakeSource struct {
+	transactions []models.Transaction
+}
+
+func (s *fakeSource) Name() string {
+	return "fake"
+}
+
+func (s *fakeSource) GetHistory(ctx context.Context, request historical.HistoryRequest) (*historical.HistoryPage, error) {
+	start := 0
+	if request.Cursor != "" {
+		for i, transaction := range s.transactions {
+			if transaction.ID == request.Cursor {
+				start = i + 1
+			}
+		}
+	}
+	page := &historical.HistoryPage{Transactions: []models.Transaction{}}
+	if start < len(s.transactions) {
+		page.Transactions = append(page.Transactions, s.transactions[start])
+	}
+	if start+1 < len(s.transactions) {
+		page.Cursor = s.transactions[start].ID
+	}
+	return page, nil
+}
+
+// prepend adds count transactions newer than the existing ones
+func (s *fakeSource) prepend(prefix string, count int) {
+	newer := make([]models.Transaction, 0, count+len(s.transactions))
+	for i := count; i > 0; i-- {
+		at := time.Date(2024, 3, 10, 0, 0, 0, 0, time.Local).Add(time.Duration(len(s.transactions)+i) * time.Minute)
+		newer = append(newer, models.Transaction{
+			ID:   fmt.Sprintf("%s%d", prefix, i),
+			Date: at.Format("2006-01-02"),
+			Time: at.Format("15:04"),
+		})
+	}
+	s.transactions = append(newer, s.transactions...)
+}
+
+func newTestWorker(t *testing.T, source *fakeSource) (*Worker, *MemoryStore) {
+	t.Helper()
+	store := NewMemoryStore()
+	if err := store.Watch(context.Background(), "0", "bc1q", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	return NewWorker(store, map[string]historical.HistoryProvider{"0": source}, time.Minute), store
+}
+
+func indexedCount(t *testing.T, store *MemoryStore) int {
+	t.Helper()
+	transactions, _, err := store.ListTransactions(context.Background(), "0", "bc1q", "", 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(transactions)
+}
+
+func TestWorkerBackfillsAcrossRuns(t *testing.T) {
+	source := &fakeSource{}
+	source.prepend("old", maxPagesPerRun+5)
+	worker, store := newTestWorker(t, source)
+	ctx := context.Background()
+
+	worker.SyncOnce(ctx)
+	if backfilled, _ := store.IsBackfilled(ctx, "0", "bc1q"); backfilled || indexedCount(t, store) != maxPagesPerRun {
+		t.Fatalf("Expected a partial backfill of %d pages, got %d", maxPagesPerRun, indexedCount(t, store))
+	}
+
+	worker.SyncOnce(ctx)
+	if backfilled, _ := store.IsBackfilled(ctx, "0", "bc1q"); !backfilled || indexedCount(t, store) != maxPagesPerRun+5 {
+		t.Errorf("Expected the backfill to finish, got %d transactions", indexedCount(t, store))
+	}
+}
+
+func TestWorkerRetriesGapOfNewTransactions(t *testing.T) {
+	source := &fakeSource{}
+	source.prepend("old", 3)
+	worker, store := newTestWorker(t, source)
+	ctx := context.Background()
+
+	worker.SyncOnce(ctx)
+	if backfilled, _ := store.IsBackfilled(ctx, "0", "bc1q"); !backfilled {
+		t.Fatal("Expected the short history to be backfilled")
+	}
+
+	// More new transactions than the newest pull and the gap pull of one run cover
+	source.prepend("new", 2*maxPagesPerRun+5)
+	worker.SyncOnce(ctx)
+	if backfilled, _ := store.IsBackfilled(ctx, "0", "bc1q"); backfilled {
+		t.Fatal("Expected the address not to be served while new transactions are missing")
+	}
+
+	worker.SyncOnce(ctx)
+	if backfilled, _ := store.IsBackfilled(ctx, "0", "bc1q"); !backfilled || indexedCount(t, store) != len(source.transactions) {
+		t.Errorf("Expected the gap to be closed, got %d of %d transactions", indexedCount(t, store), len(source.transactions))
+	}
+}
+
+func TestWorkerSkipsUntimedTransactions(t *testing.T) {
+	source := &fakeSource{}
+	source.prepend("old", 2)
+	source.transactions = append([]models.Transaction{{ID: "pending", Status: "pending"}}, source.transactions...)
+	worker, store := newTestWorker(t, source)
+
+	worker.SyncOnce(context.Background())
+	if count := indexedCount(t, store); count != 2 {
+		t.Errorf("Expected the pending transaction to be skipped, got %d transactions", count)
+	}
+}
+
+func TestWorkerDropsExpiredAddresses(t *testing.T) {
+	source := &fakeSource{}
+	source.prepend("old", 2)
+	worker, store := newTestWorker(t, source)
+	ctx := context.Background()
+	_ = store.Watch(ctx, "0", "expired", -time.Minute)
+
+	worker.SyncOnce(ctx)
+	if watched, _ := store.WatchedAddresses(ctx); len(watched) != 1 || watched[0].Address != "bc1q" {
+		t.Errorf("Expected only the subscribed address to be synced, got %+v", watched)
+	}
+	if transactions, _, _ := store.ListTransactions(ctx, "0", "expired", "", 10); len(transactions) != 0 {
+		t.Errorf("Expected nothing indexed for the expired address, got %+v", transactions)
+	}
+}
@@ -0,0 +1,151 @@
+package historical
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+)
+
+// StreamPosition is where a stream of a merged feed resumes: the provider token of the page being read,
+// empty for the first page, and how many items of that page were already consumed
+type StreamPosition struct {
+	Page   string `json:"k,omitempty"`
+	Offset int    `json:"o,omitempty"`
+	Done   bool   `json:"d,omitempty"`
+}
+
+// MergeCursor is the opaque composite cursor of a merged feed. PageSize is kept so that every page token
+// is replayed with the page size it was issued for.
+type MergeCursor struct {
+	Streams  map[string]StreamPosition `json:"s"`
+	PageSize int                       `json:"n"`
+}
+
+func EncodeMergeCursor(cursor MergeCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeMergeCursor fails with ErrInvalidCursor for anything EncodeMergeCursor did not produce
+// with a page size up to maxPageSize
+func DecodeMergeCursor(encoded string, maxPageSize int) (MergeCursor, error) {
+	var cursor MergeCursor
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return cursor, fmt.Errorf("%w: malformed cursor", ErrInvalidCursor)
+	}
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.PageSize <= 0 || cursor.PageSize > maxPageSize {
+		return cursor, fmt.Errorf("%w: malformed cursor", ErrInvalidCursor)
+	}
+	for _, position := range cursor.Streams {
+		if position.Offset < 0 {
+			return cursor, fmt.Errorf("%w: malformed cursor", ErrInvalidCursor)
+		}
+	}
+	return cursor, nil
+}
+
+// StreamPage is one page of a stream, Next is the token of the following page and empty on the last one
+type StreamPage[T any] struct {
+	Items []T
+	Next  string
+}
+
+// Stream reads one source of a merged feed newest first, page by page
+type Stream[T any] struct {
+	Name string
+	// Fetch returns the page of token, it must return the same items whenever the token is replayed
+	Fetch func(token string, pageSize int) (StreamPage[T], error)
+	// Skip drops items that are served by another stream
+	Skip func(T) bool
+
+	pageSize int
+	position StreamPosition
+	page     StreamPage[T]
+	loaded   bool
+}
+
+// peek returns the next item without consuming it, nil once the stream is exhausted
+func (s *Stream[T]) peek() (*T, error) {
+	for !s.position.Done {
+		if !s.loaded {
+			page, err := s.Fetch(s.position.Page, s.pageSize)
+			if err != nil {
+				return nil, err
+			}
+			s.page = page
+			s.loaded = true
+		}
+
+		if s.position.Offset < len(s.page.Items) {
+			item := &s.page.Items[s.position.Offset]
+			if s.Skip != nil && s.Skip(*item) {
+				s.position.Offset++
+				continue
+			}
+			return item, nil
+		}
+		s.nextPage()
+	}
+	return nil, nil
+}
+
+// nextPage moves to the following page once the loaded one is consumed
+func (s *Stream[T]) nextPage() {
+	if s.page.Next == "" {
+		s.position = StreamPosition{Done: true}
+	} else {
+		s.position = StreamPosition{Page: s.page.Next}
+	}
+	s.loaded = false
+}
+
+// resume returns the position to continue from, without fetching a page just to learn it is over
+func (s *Stream[T]) resume() StreamPosition {
+	if s.loaded && s.position.Offset >= len(s.page.Items) {
+		s.nextPage()
+	}
+	return s.position
+}
+
+// MergeStreams returns exactly limit items across the streams, ordered by before, and the composite cursor
+// of the next page, which is empty once every stream is exhausted. Items that tie keep the order of streams.
+func MergeStreams[T any](streams []*Stream[T], cursor MergeCursor, limit int, before func(a, b T) bool) ([]T, string, error) {
+	for _, stream := range streams {
+		stream.pageSize = cursor.PageSize
+		stream.position = cursor.Streams[stream.Name]
+		stream.loaded = false
+	}
+
+	items := make([]T, 0, limit)
+	for len(items) < limit {
+		var newest *Stream[T]
+		var newestItem *T
+		for _, stream := range streams {
+			item, err := stream.peek()
+			if err != nil {
+				return nil, "", fmt.Errorf("failed to fetch %s: %w", stream.Name, err)
+			}
+			if item != nil && (newestItem == nil || before(*item, *newestItem)) {
+				newest, newestItem = stream, item
+			}
+		}
+		if newest == nil {
+			return items, "", nil
+		}
+		items = append(items, *newestItem)
+		newest.position.Offset++
+	}
+
+	next := MergeCursor{Streams: make(map[string]StreamPosition, len(streams)), PageSize: cursor.PageSize}
+	exhausted := true
+	for _, stream := range streams {
+		position := stream.resume()
+		next.Streams[stream.Name] = position
+		exhausted = exhausted && position.Done
+	}
+	if exhausted {
+		return items, "", nil
+	}
+	return items, EncodeMergeCursor(next), nil
+}
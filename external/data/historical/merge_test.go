@@ -0,0 +1,105 @@
+package historical
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+)
+
+// item is a feed entry at a block
+type item struct {
+	Block int
+	ID    string
+}
+
+// pagedFetch serves items newest first, using the offset as page token like an opaque provider key
+func pagedFetch(items []item) func(token string, pageSize int) (StreamPage[item], error) {
+	return func(token string, pageSize int) (StreamPage[item], error) {
+		start, _ := strconv.Atoi(token)
+		end := min(start+pageSize, len(items))
+		page := StreamPage[item]{Items: items[start:end]}
+		if end < len(items) {
+			page.Next = strconv.Itoa(end)
+		}
+		return page, nil
+	}
+}
+
+func itemBefore(a, b item) bool {
+	return a.Block > b.Block
+}
+
+func TestMergeStreamsPaginatesWithoutGapsOrRepeats(t *testing.T) {
+	sources := map[string][]item{
+		"a": {{90, "a90"}, {70, "a70"}, {40, "a40"}, {10, "a10"}},
+		"b": {{95, "b95"}, {70, "b70"}, {70, "shared"}, {20, "b20"}, {5, "b5"}},
+		"c": {{70, "shared"}, {60, "c60"}},
+	}
+
+	var seen []string
+	cursor := MergeCursor{PageSize: 2}
+	for page := 0; page < 10; page++ {
+		streams := []*Stream[item]{
+			{Name: "a", Fetch: pagedFetch(sources["a"])},
+			{Name: "b", Fetch: pagedFetch(sources["b"])},
+			{Name: "c", Fetch: pagedFetch(sources["c"]), Skip: func(i item) bool { return i.ID == "shared" }},
+		}
+
+		items, next, err := MergeStreams(streams, cursor, 3, itemBefore)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if next != "" && len(items) != 3 {
+			t.Errorf("Expected full pages before the last one, got %d items", len(items))
+		}
+		for _, item := range items {
+			seen = append(seen, item.ID)
+		}
+		if next == "" {
+			break
+		}
+		if cursor, err = DecodeMergeCursor(next, 10); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Ties keep the order of the streams, skipped items are served once by the stream that owns them
+	expected := []string{"b95", "a90", "a70", "b70", "shared", "c60", "a40", "b20", "a10", "b5"}
+	if fmt.Sprint(seen) != fmt.Sprint(expected) {
+		t.Errorf("Expected %v, got %v", expected, seen)
+	}
+}
+
+func TestMergeStreamsDoesNotFetchPastTheLastPage(t *testing.T) {
+	fetches := 0
+	stream := &Stream[item]{Name: "a", Fetch: func(token string, pageSize int) (StreamPage[item], error) {
+		fetches++
+		return StreamPage[item]{Items: []item{{2, "a2"}, {1, "a1"}}}, nil
+	}}
+
+	items, next, err := MergeStreams([]*Stream[item]{stream}, MergeCursor{PageSize: 2}, 2, itemBefore)
+	if err != nil || len(items) != 2 || next != "" || fetches != 1 {
+		t.Errorf("Expected one fetch and no cursor, got %d items, cursor %q, %d fetches, %v", len(items), next, fetches, err)
+	}
+}
+
+func TestMergeStreamsReportsTheFailingStream(t *testing.T) {
+	failure := errors.New("upstream down")
+	streams := []*Stream[item]{
+		{Name: "a", Fetch: pagedFetch([]item{{1, "a1"}})},
+		{Name: "b", Fetch: func(string, int) (StreamPage[item], error) { return StreamPage[item]{}, failure }},
+	}
+	if _, _, err := MergeStreams(streams, MergeCursor{PageSize: 2}, 2, itemBefore); !errors.Is(err, failure) {
+		t.Errorf("Expected the stream error, got %v", err)
+	}
+}
+
+func TestDecodeMergeCursorRejectsGarbage(t *testing.T) {
+	negative := EncodeMergeCursor(MergeCursor{Streams: map[string]StreamPosition{"a": {Offset: -1}}, PageSize: 2})
+	for _, cursor := range []string{"not base64!", EncodeMergeCursor(MergeCursor{}), "e30", EncodeMergeCursor(MergeCursor{PageSize: 11}), negative} {
+		if _, err := DecodeMergeCursor(cursor, 10); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("Expected %q to be rejected, got %v", cursor, err)
+		}
+	}
+}
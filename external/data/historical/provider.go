@@ -0,0 +1,36 @@
+package historical
+
+import (
+	"context"
+
+	"github.com/tashunc/nugenesis-wallet-backend/external/models"
+)
+
+// HistoryRequest describes a single page of address history to fetch from a provider
+type HistoryRequest struct {
+	Address string
+	Limit   int
+	// Cursor is the provider specific cursor returned by a previous page, empty for the first page
+	Cursor string
+	// Until stops the page before this provider specific position, e.g. a Solana signature. Only providers that
+	// understand it honor it, empty reads to the end of history.
+	Until string
+	// Currency is the fiat currency used to value transactions, e.g. usd
+	Currency string
+}
+
+// HistoryPage is one page of normalized transactions plus the cursor for the next page
+type HistoryPage struct {
+	Transactions []models.Transaction
+	// Cursor is empty when there are no more pages
+	Cursor string
+	// Provider is the name of the provider that served the page, set by FailoverProvider
+	Provider string
+}
+
+// HistoryProvider is implemented by every third-party history backend
+type HistoryProvider interface {
+	// Name identifies the provider in cursors, logs and responses
+	Name() string
+	GetHistory(ctx context.Context, request HistoryRequest) (*HistoryPage, error)
+}
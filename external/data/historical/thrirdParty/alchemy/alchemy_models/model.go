@@ -117,7 +117,9 @@ type AssetTransfersResponse struct {
 
 // AssetTransfer represents a single asset transfer
 type AssetTransfer struct {
-	BlockNum        string            `json:"blockNum"`
+	BlockNum string `json:"blockNum"`
+	// UniqueId identifies the transfer within its transaction, e.g. 0xhash:log:5 or 0xhash:external
+	UniqueId        string            `json:"uniqueId"`
 	Hash            string            `json:"hash"`
 	From            string            `json:"from"`
 	To              *string           `json:"to"`
@@ -0,0 +1,102 @@
+package alchemy
+
+import (
+	"context"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/tashunc/nugenesis-wallet-backend/external/data/fmv/thrirdParty/coingecko"
+	"github.com/tashunc/nugenesis-wallet-backend/external/data/historical/thrirdParty/alchemy/alchemy_models"
+	"github.com/tashunc/nugenesis-wallet-backend/external/models"
+)
+
+// BalanceProvider serves token balances of one network from the Alchemy Portfolio API through portfolio.BalanceProvider
+type BalanceProvider struct {
+	service *Service
+	network string
+}
+
+// BalanceProvider returns a balance provider for an Alchemy network name such as eth-mainnet.
+// The controller must be created with the data API BaseURL.
+func (c *Controller) BalanceProvider(network string) *BalanceProvider {
+	return &BalanceProvider{service: c.service, network: network}
+}
+
+func (p *BalanceProvider) Name() string {
+	return ProviderName
+}
+
+// GetBalances returns native and token balances with Alchemy prices, missing prices are filled from CoinGecko
+func (p *BalanceProvider) GetBalances(ctx context.Context, address string) ([]models.WalletTokenBalance, error) {
+	if !ValidateEthereumAddress(address) {
+		return nil, ErrInvalidAddress
+	}
+
+	response, err := p.service.GetTokensByAddress(ctx, []alchemy_models.AddressRequest{
+		{Address: address, Networks: []string{p.network}},
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	balances := make([]models.WalletTokenBalance, 0, len(response.Data.Tokens))
+	for _, token := range response.Data.Tokens {
+		if balance, ok := MapTokenToBalance(token, p.network); ok {
+			balances = append(balances, balance)
+		}
+	}
+
+	return coingecko.NewService().EnrichBalancesWithPrices(balances), nil
+}
+
+// MapTokenToBalance converts a Portfolio API token, zero balances are skipped
+func MapTokenToBalance(token alchemy_models.TokenInfo, network string) (models.WalletTokenBalance, bool) {
+	raw, ok := new(big.Int).SetString(strings.TrimPrefix(token.TokenBalance, "0x"), 16)
+	if !ok || raw.Sign() == 0 {
+		return models.WalletTokenBalance{}, false
+	}
+
+	balance := models.WalletTokenBalance{
+		NativeToken: token.TokenAddress == nil,
+		BalanceRaw:  raw.String(),
+		Chain:       network,
+	}
+
+	decimals := 18
+	if token.TokenMetadata.Decimals != nil {
+		decimals = *token.TokenMetadata.Decimals
+	}
+	balance.Decimals = strconv.Itoa(decimals)
+
+	if token.TokenAddress != nil {
+		balance.TokenAddress = *token.TokenAddress
+	}
+	if token.TokenMetadata.Symbol != nil {
+		balance.Symbol = *token.TokenMetadata.Symbol
+	}
+	if token.TokenMetadata.Name != nil {
+		balance.Name = *token.TokenMetadata.Name
+	}
+	if token.TokenMetadata.Logo != nil {
+		balance.Logo = *token.TokenMetadata.Logo
+	}
+	if balance.NativeToken && balance.Symbol == "" {
+		native := NativeTokenFor(network)
+		balance.Symbol, balance.Name = native.Symbol, native.Name
+	}
+
+	amount, _ := new(big.Float).Quo(new(big.Float).SetInt(raw), new(big.Float).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil))).Float64()
+	balance.Balance = strconv.FormatFloat(amount, 'f', -1, 64)
+
+	for _, price := range token.TokenPrices {
+		if strings.EqualFold(price.Currency, "usd") {
+			if usdPrice, err := strconv.ParseFloat(price.Value, 64); err == nil {
+				balance.UsdPrice = usdPrice
+				balance.UsdValue = amount * usdPrice
+			}
+		}
+	}
+
+	return balance, true
+}
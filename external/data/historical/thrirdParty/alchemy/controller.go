@@ -1,10 +1,8 @@
 package alchemy
 
 import (
-	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/tashunc/nugenesis-wallet-backend/external/data/historical/thrirdParty/alchemy/alchemy_models"
-	"github.com/tashunc/nugenesis-wallet-backend/external/models"
 	"net/http"
 	"os"
 	"strconv"
@@ -50,7 +48,7 @@ func (c *Controller) GetTokensByAddress(ctx *gin.Context) {
 		}
 	}
 
-	response, err := c.service.GetTokensByAddress(addresses, limitPtr)
+	response, err := c.service.GetTokensByAddress(ctx.Request.Context(), addresses, limitPtr)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -71,7 +69,7 @@ func (c *Controller) GetTokensByMultipleAddresses(ctx *gin.Context) {
 		return
 	}
 
-	response, err := c.service.GetTokensByAddress(request.Addresses, request.Limit)
+	response, err := c.service.GetTokensByAddress(ctx.Request.Context(), request.Addresses, request.Limit)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -109,7 +107,7 @@ func (c *Controller) GetTokensByAddressQuery(ctx *gin.Context) {
 		}
 	}
 
-	response, err := c.service.GetTokensByAddress(addressRequests, limitPtr)
+	response, err := c.service.GetTokensByAddress(ctx.Request.Context(), addressRequests, limitPtr)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -157,7 +155,7 @@ func (c *Controller) GetTransactionHistoryByAddress(ctx *gin.Context) {
 		afterPtr = &after
 	}
 
-	response, err := c.service.GetTransactionHistory(addresses, beforePtr, afterPtr, limitPtr)
+	response, err := c.service.GetTransactionHistory(ctx.Request.Context(), addresses, beforePtr, afterPtr, limitPtr)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -178,7 +176,7 @@ func (c *Controller) GetTransactionHistoryByMultipleAddresses(ctx *gin.Context)
 		return
 	}
 
-	response, err := c.service.GetTransactionHistory(request.Addresses, request.Before, request.After, request.Limit)
+	response, err := c.service.GetTransactionHistory(ctx.Request.Context(), request.Addresses, request.Before, request.After, request.Limit)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -186,84 +184,3 @@ func (c *Controller) GetTransactionHistoryByMultipleAddresses(ctx *gin.Context)
 
 	ctx.JSON(http.StatusOK, response)
 }
-
-func (c *Controller) GetAssetTransfers(ctx *gin.Context) {
-	address := ctx.Param("address")
-	if address == "" {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "address parameter is required"})
-		return
-	}
-
-	// Parse pagination parameters
-	limitStr := ctx.DefaultQuery("limit", "100")
-	pageKey := ctx.Query("pageKey")
-
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit parameter"})
-		return
-	}
-
-	// Convert limit to hex string for Alchemy API (divide by 2 since we're making 2 requests)
-	maxCount := fmt.Sprintf("0x%x", limit/2)
-
-	categories := []string{"external", "internal", "erc20", "erc721", "erc1155"}
-
-	// Request 1: Get transactions sent FROM the address
-	fromRequest := &alchemy_models.AssetTransfersRequest{
-		FromAddress:  &address,
-		Category:     categories,
-		MaxCount:     &maxCount,
-		WithMetadata: func() *bool { b := true; return &b }(),
-	}
-
-	if pageKey != "" {
-		fromRequest.PageKey = &pageKey
-	}
-
-	fromResponse, err := c.service.GetAssetTransfers(fromRequest)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch sent transactions: " + err.Error()})
-		return
-	}
-
-	// Request 2: Get transactions sent TO the address
-	toRequest := &alchemy_models.AssetTransfersRequest{
-		ToAddress:    &address,
-		Category:     categories,
-		MaxCount:     &maxCount,
-		WithMetadata: func() *bool { b := true; return &b }(),
-	}
-
-	if pageKey != "" {
-		toRequest.PageKey = &pageKey
-	}
-
-	toResponse, err := c.service.GetAssetTransfers(toRequest)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch received transactions: " + err.Error()})
-		return
-	}
-
-	// Combine both responses
-	allTransfers := append(fromResponse.Transfers, toResponse.Transfers...)
-
-	// Map to standardized Transaction model
-	var mappedTxs []models.Transaction
-	for _, transfer := range allTransfers {
-		mapped := MapAssetTransferToTransaction(transfer, address)
-		mappedTxs = append(mappedTxs, mapped)
-	}
-
-	// Sort by block number (descending - most recent first)
-	// Simple bubble sort for demonstration - you might want to use a better sorting algorithm
-	for i := 0; i < len(mappedTxs)-1; i++ {
-		for j := 0; j < len(mappedTxs)-i-1; j++ {
-			if mappedTxs[j].Date < mappedTxs[j+1].Date {
-				mappedTxs[j], mappedTxs[j+1] = mappedTxs[j+1], mappedTxs[j]
-			}
-		}
-	}
-
-	ctx.JSON(http.StatusOK, mappedTxs)
-}
@@ -0,0 +1,80 @@
+package alchemy
+
+import (
+	"net/url"
+	"strings"
+)
+
+// NativeToken describes the gas token of an Alchemy network
+type NativeToken struct {
+	Symbol   string
+	Name     string
+	Decimals int
+}
+
+var ether = NativeToken{Symbol: "ETH", Name: "Ethereum", Decimals: 18}
+
+// nativeTokens names the gas token of each Alchemy network family, keyed by the network prefix, e.g. eth in eth-mainnet
+var nativeTokens = map[string]NativeToken{
+	"eth":          ether,
+	"opt":          ether,
+	"arb":          ether,
+	"arbnova":      ether,
+	"base":         ether,
+	"blast":        ether,
+	"linea":        ether,
+	"scroll":       ether,
+	"polygonzkevm": ether,
+	"polygon":      {Symbol: "POL", Name: "Polygon", Decimals: 18},
+	"avax":         {Symbol: "AVAX", Name: "Avalanche", Decimals: 18},
+	"bnb":          {Symbol: "BNB", Name: "BNB", Decimals: 18},
+	"opbnb":        {Symbol: "BNB", Name: "BNB", Decimals: 18},
+	"fantom":       {Symbol: "FTM", Name: "Fantom", Decimals: 18},
+	"mantle":       {Symbol: "MNT", Name: "Mantle", Decimals: 18},
+	"metis":        {Symbol: "METIS", Name: "Metis", Decimals: 18},
+	"celo":         {Symbol: "CELO", Name: "Celo", Decimals: 18},
+	"ronin":        {Symbol: "RON", Name: "Ronin", Decimals: 18},
+	"rootstock":    {Symbol: "RBTC", Name: "Rootstock Smart Bitcoin", Decimals: 18},
+	"sonic":        {Symbol: "S", Name: "Sonic", Decimals: 18},
+	"sei":          {Symbol: "SEI", Name: "Sei", Decimals: 18},
+	"zetachain":    {Symbol: "ZETA", Name: "ZetaChain", Decimals: 18},
+}
+
+// internalTransferNetworks lists the network families where alchemy_getAssetTransfers supports the internal category
+var internalTransferNetworks = map[string]bool{
+	"eth":     true,
+	"polygon": true,
+}
+
+// NetworkFromURL derives the network name from an Alchemy URL, e.g. eth-mainnet from
+// https://eth-mainnet.g.alchemy.com/v2/
+func NetworkFromURL(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || !strings.HasSuffix(parsed.Hostname(), ".alchemy.com") {
+		return ""
+	}
+	network, _, _ := strings.Cut(parsed.Hostname(), ".")
+	return network
+}
+
+func networkFamily(network string) string {
+	family, _, _ := strings.Cut(network, "-")
+	return family
+}
+
+// NativeTokenFor returns the gas token of network, unknown networks are assumed to use ETH
+func NativeTokenFor(network string) NativeToken {
+	if native, ok := nativeTokens[networkFamily(network)]; ok {
+		return native
+	}
+	return ether
+}
+
+// TransferCategories returns the alchemy_getAssetTransfers categories network supports
+func TransferCategories(network string) []string {
+	categories := []string{"external", "erc20", "erc721", "erc1155"}
+	if internalTransferNetworks[networkFamily(network)] {
+		categories = append(categories, "internal")
+	}
+	return categories
+}
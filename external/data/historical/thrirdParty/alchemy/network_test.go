@@ -0,0 +1,36 @@
+package alchemy
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestNetworkMetadata(t *testing.T) {
+	for _, test := range []struct {
+		url      string
+		network  string
+		symbol   string
+		internal bool
+	}{
+		{"https://eth-mainnet.g.alchemy.com/v2/", "eth-mainnet", "ETH", true},
+		{"https://polygon-mainnet.g.alchemy.com/v2/", "polygon-mainnet", "POL", true},
+		{"https://base-mainnet.g.alchemy.com/v2/", "base-mainnet", "ETH", false},
+		{"https://avax-mainnet.g.alchemy.com/v2/", "avax-mainnet", "AVAX", false},
+		{"https://mantle-mainnet.g.alchemy.com/v2/", "mantle-mainnet", "MNT", false},
+	} {
+		network := NetworkFromURL(test.url)
+		if network != test.network {
+			t.Errorf("Expected network %s for %s, got %s", test.network, test.url, network)
+		}
+		if native := NativeTokenFor(network); native.Symbol != test.symbol || native.Decimals != 18 {
+			t.Errorf("Unexpected native token %+v for %s", native, network)
+		}
+		if internal := slices.Contains(TransferCategories(network), "internal"); internal != test.internal {
+			t.Errorf("Expected internal transfers %v for %s", test.internal, network)
+		}
+	}
+
+	if network := NetworkFromURL("https://example.com/rpc"); network != "" {
+		t.Errorf("Expected non-Alchemy URLs to have no network, got %s", network)
+	}
+}
@@ -0,0 +1,72 @@
+package alchemy
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/tashunc/nugenesis-wallet-backend/external/data/historical"
+	"github.com/tashunc/nugenesis-wallet-backend/external/data/historical/thrirdParty/alchemy/alchemy_models"
+)
+
+// maxTransferPageSize is the largest maxCount alchemy_getAssetTransfers accepts
+const maxTransferPageSize = 1000
+
+// Streams of the merged transfer feed, they are the keys of its historical.MergeCursor
+const (
+	streamSent     = "sent"
+	streamReceived = "received"
+)
+
+// pageFetcher fetches one page of a stream, an empty pageKey is the first page
+type pageFetcher func(pageKey string, pageSize int) (*alchemy_models.AssetTransfersResponse, error)
+
+// transferStream adapts a direction of alchemy_getAssetTransfers to the shared merge paginator
+func transferStream(name string, fetch pageFetcher, skip func(alchemy_models.AssetTransfer) bool) *historical.Stream[alchemy_models.AssetTransfer] {
+	return &historical.Stream[alchemy_models.AssetTransfer]{
+		Name: name,
+		Fetch: func(pageKey string, pageSize int) (historical.StreamPage[alchemy_models.AssetTransfer], error) {
+			response, err := fetch(pageKey, pageSize)
+			if err != nil {
+				return historical.StreamPage[alchemy_models.AssetTransfer]{}, err
+			}
+			page := historical.StreamPage[alchemy_models.AssetTransfer]{Items: response.Transfers}
+			if response.PageKey != nil {
+				page.Next = *response.PageKey
+			}
+			return page, nil
+		},
+		Skip: skip,
+	}
+}
+
+// transferBefore orders transfers newest first by block number, then log index
+func transferBefore(a, b alchemy_models.AssetTransfer) bool {
+	blockA, blockB := parseHexUint(a.BlockNum), parseHexUint(b.BlockNum)
+	if blockA != blockB {
+		return blockA > blockB
+	}
+	logA, logB := logIndex(a.UniqueId), logIndex(b.UniqueId)
+	if logA != logB {
+		return logA > logB
+	}
+	return a.UniqueId > b.UniqueId
+}
+
+func parseHexUint(value string) uint64 {
+	parsed, _ := strconv.ParseUint(strings.TrimPrefix(value, "0x"), 16, 64)
+	return parsed
+}
+
+// logIndex reads the log index of a uniqueId such as 0xhash:log:5, transfers that are not logs get -1
+// and sort after the logs of their block
+func logIndex(uniqueID string) int64 {
+	_, index, found := strings.Cut(uniqueID, ":log:")
+	if !found {
+		return -1
+	}
+	parsed, err := strconv.ParseInt(index, 10, 64)
+	if err != nil {
+		return -1
+	}
+	return parsed
+}
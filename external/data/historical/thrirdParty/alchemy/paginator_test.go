@@ -0,0 +1,85 @@
+package alchemy
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/tashunc/nugenesis-wallet-backend/external/data/historical"
+	"github.com/tashunc/nugenesis-wallet-backend/external/data/historical/thrirdParty/alchemy/alchemy_models"
+)
+
+const testAddress = "0x00000000000000000000000000000000000000aa"
+
+func transfer(block int, log int, from string) alchemy_models.AssetTransfer {
+	return alchemy_models.AssetTransfer{
+		BlockNum: fmt.Sprintf("0x%x", block),
+		UniqueId: fmt.Sprintf("0x%d:log:%d", block, log),
+		From:     from,
+	}
+}
+
+// pagedFetcher serves transfers newest first, using the offset as pageKey like an opaque Alchemy key
+func pagedFetcher(transfers []alchemy_models.AssetTransfer) pageFetcher {
+	return func(pageKey string, pageSize int) (*alchemy_models.AssetTransfersResponse, error) {
+		start, _ := strconv.Atoi(pageKey)
+		end := min(start+pageSize, len(transfers))
+		response := &alchemy_models.AssetTransfersResponse{Transfers: transfers[start:end]}
+		if end < len(transfers) {
+			next := strconv.Itoa(end)
+			response.PageKey = &next
+		}
+		return response, nil
+	}
+}
+
+func TestTransferBefore(t *testing.T) {
+	transfers := []alchemy_models.AssetTransfer{
+		transfer(70, 2, ""), {BlockNum: "0x5a", UniqueId: "0xabc:external"}, transfer(90, 1, ""), transfer(70, 4, ""),
+	}
+	sort.Slice(transfers, func(i, j int) bool { return transferBefore(transfers[i], transfers[j]) })
+
+	var order []string
+	for _, transfer := range transfers {
+		order = append(order, transfer.UniqueId)
+	}
+	// 0x5a is block 90, transfers that are not logs sort after the logs of their block
+	expected := []string{"0x90:log:1", "0xabc:external", "0x70:log:4", "0x70:log:2"}
+	if fmt.Sprint(order) != fmt.Sprint(expected) {
+		t.Errorf("Expected %v, got %v", expected, order)
+	}
+}
+
+func TestTransferStreamsMergeSentAndReceived(t *testing.T) {
+	sent := []alchemy_models.AssetTransfer{transfer(90, 1, testAddress), transfer(70, 2, testAddress), transfer(10, 3, testAddress)}
+	received := []alchemy_models.AssetTransfer{transfer(95, 0, "0xbb"), transfer(70, 2, testAddress), transfer(50, 7, "0xcc")}
+
+	var seen []string
+	cursor := historical.MergeCursor{PageSize: 2}
+	for page := 0; page < 10; page++ {
+		streams := []*historical.Stream[alchemy_models.AssetTransfer]{
+			transferStream(streamSent, pagedFetcher(sent), nil),
+			transferStream(streamReceived, pagedFetcher(received),
+				func(transfer alchemy_models.AssetTransfer) bool { return transfer.From == testAddress }),
+		}
+		transfers, next, err := historical.MergeStreams(streams, cursor, 2, transferBefore)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, transfer := range transfers {
+			seen = append(seen, transfer.UniqueId)
+		}
+		if next == "" {
+			break
+		}
+		if cursor, err = historical.DecodeMergeCursor(next, maxTransferPageSize); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected := []string{"0x95:log:0", "0x90:log:1", "0x70:log:2", "0x50:log:7", "0x10:log:3"}
+	if fmt.Sprint(seen) != fmt.Sprint(expected) {
+		t.Errorf("Expected %v, got %v", expected, seen)
+	}
+}
@@ -0,0 +1,40 @@
+package alchemy
+
+import (
+	"context"
+
+	"github.com/tashunc/nugenesis-wallet-backend/external/data/historical"
+)
+
+const ProviderName = "alchemy"
+
+// HistoryProvider serves EVM address history from alchemy_getAssetTransfers through historical.HistoryProvider
+type HistoryProvider struct {
+	service *Service
+}
+
+// HistoryProvider returns a history provider sharing the controller's service
+func (c *Controller) HistoryProvider() *HistoryProvider {
+	return &HistoryProvider{service: c.service}
+}
+
+func (p *HistoryProvider) Name() string {
+	return ProviderName
+}
+
+// GetHistory merges the sent and received transfers, the cursor keeps the position of both
+func (p *HistoryProvider) GetHistory(ctx context.Context, request historical.HistoryRequest) (*historical.HistoryPage, error) {
+	if !ValidateEthereumAddress(request.Address) {
+		return nil, ErrInvalidAddress
+	}
+
+	transactions, cursor, err := p.service.GetAddressTransfers(ctx, request.Address, request.Limit, request.Cursor, request.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	return &historical.HistoryPage{
+		Transactions: transactions,
+		Cursor:       cursor,
+	}, nil
+}
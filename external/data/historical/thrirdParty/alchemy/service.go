@@ -2,11 +2,16 @@ package alchemy
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"github.com/tashunc/nugenesis-wallet-backend/external/data/historical"
 	"github.com/tashunc/nugenesis-wallet-backend/external/data/historical/thrirdParty/alchemy/alchemy_models"
+	"github.com/tashunc/nugenesis-wallet-backend/external/models"
+	"github.com/tashunc/nugenesis-wallet-backend/pkg/httpclient"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 )
 
@@ -18,6 +23,8 @@ const (
 type Service struct {
 	apiKey  *string
 	baseURL *string
+	// network is the Alchemy network of baseURL, e.g. base-mainnet, empty for the data API
+	network string
 	client  *http.Client
 }
 
@@ -25,11 +32,17 @@ func NewService(apiKey *string, baseURL *string) *Service {
 	return &Service{
 		apiKey:  apiKey,
 		baseURL: baseURL,
-		client:  &http.Client{},
+		network: NetworkFromURL(*baseURL),
+		client: httpclient.New(httpclient.Config{
+			Name:          "alchemy-history",
+			Timeout:       Timeout,
+			RatePerSecond: 25,
+			Burst:         25,
+		}),
 	}
 }
 
-func (s *Service) GetTokensByAddress(addresses []alchemy_models.AddressRequest, limit *int) (*alchemy_models.TokensByAddressResponse, error) {
+func (s *Service) GetTokensByAddress(ctx context.Context, addresses []alchemy_models.AddressRequest, limit *int) (*alchemy_models.TokensByAddressResponse, error) {
 	url := fmt.Sprintf("%s/%s/assets/tokens/by-address", *s.baseURL, *s.apiKey)
 
 	requestBody := alchemy_models.TokensByAddressRequest{
@@ -42,12 +55,13 @@ func (s *Service) GetTokensByAddress(addresses []alchemy_models.AddressRequest,
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
+	httpclient.MarkIdempotent(req)
 
 	resp, err := s.client.Do(req)
 	if err != nil {
@@ -79,7 +93,7 @@ func (s *Service) GetTokensByAddress(addresses []alchemy_models.AddressRequest,
 	return &response, nil
 }
 
-func (s *Service) GetTransactionHistory(addresses []alchemy_models.AddressRequest, before *string, after *string, limit *int) (*alchemy_models.TransactionHistoryResponse, error) {
+func (s *Service) GetTransactionHistory(ctx context.Context, addresses []alchemy_models.AddressRequest, before *string, after *string, limit *int) (*alchemy_models.TransactionHistoryResponse, error) {
 	url := fmt.Sprintf("https://api.g.alchemy.com/data/v1/%s/transactions/history/by-address", *s.apiKey)
 
 	requestBody := alchemy_models.TransactionHistoryRequest{
@@ -94,12 +108,13 @@ func (s *Service) GetTransactionHistory(addresses []alchemy_models.AddressReques
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
+	httpclient.MarkIdempotent(req)
 
 	resp, err := s.client.Do(req)
 	if err != nil {
@@ -132,9 +147,9 @@ func (s *Service) GetTransactionHistory(addresses []alchemy_models.AddressReques
 }
 
 // GetAssetTransfers implements the alchemy_getAssetTransfers RPC to get historical asset transfer data
-func (s *Service) GetAssetTransfers(request *alchemy_models.AssetTransfersRequest) (*alchemy_models.AssetTransfersResponse, error) {
+func (s *Service) GetAssetTransfers(ctx context.Context, request *alchemy_models.AssetTransfersRequest) (*alchemy_models.AssetTransfersResponse, error) {
 	// Alchemy RPC endpoint format: https://[network].g.alchemy.com/v2/[api-key]
-	url := fmt.Sprintf("https://eth-mainnet.g.alchemy.com/v2/%s", *s.apiKey)
+	url := fmt.Sprintf("%s%s", *s.baseURL, *s.apiKey)
 
 	// Create JSON-RPC request
 	rpcRequest := map[string]interface{}{
@@ -149,12 +164,13 @@ func (s *Service) GetAssetTransfers(request *alchemy_models.AssetTransfersReques
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
+	httpclient.MarkIdempotent(req)
 
 	resp, err := s.client.Do(req)
 	if err != nil {
@@ -199,4 +215,57 @@ func (s *Service) GetAssetTransfers(request *alchemy_models.AssetTransfersReques
 	return &rpcResponse.Result, nil
 }
 
+// GetAddressTransfers returns up to limit transfers sent from and to the address, newest first, mapped to the
+// standard transaction model and valued in currency at block time. The returned composite cursor resumes both
+// directions exactly where the page ended, it is empty once the history is exhausted.
+func (s *Service) GetAddressTransfers(ctx context.Context, address string, limit int, cursor string, currency string) ([]models.Transaction, string, error) {
+	position := historical.MergeCursor{PageSize: min(limit, maxTransferPageSize)}
+	if cursor != "" {
+		decoded, err := historical.DecodeMergeCursor(cursor, maxTransferPageSize)
+		if err != nil {
+			return nil, "", err
+		}
+		position = decoded
+	}
+
+	categories := TransferCategories(s.network)
+	fetch := func(direction func(*alchemy_models.AssetTransfersRequest)) pageFetcher {
+		return func(pageKey string, pageSize int) (*alchemy_models.AssetTransfersResponse, error) {
+			maxCount := fmt.Sprintf("0x%x", pageSize)
+			order := "desc"
+			withMetadata := true
+			request := &alchemy_models.AssetTransfersRequest{
+				Category:     categories,
+				MaxCount:     &maxCount,
+				Order:        &order,
+				WithMetadata: &withMetadata,
+			}
+			if pageKey != "" {
+				request.PageKey = &pageKey
+			}
+			direction(request)
+			return s.GetAssetTransfers(ctx, request)
+		}
+	}
+
+	streams := []*historical.Stream[alchemy_models.AssetTransfer]{
+		transferStream(streamSent, fetch(func(request *alchemy_models.AssetTransfersRequest) { request.FromAddress = &address }), nil),
+		// Transfers to self are already part of the sent stream
+		transferStream(streamReceived, fetch(func(request *alchemy_models.AssetTransfersRequest) { request.ToAddress = &address }),
+			func(transfer alchemy_models.AssetTransfer) bool { return strings.EqualFold(transfer.From, address) }),
+	}
+
+	transfers, next, err := historical.MergeStreams(streams, position, limit, transferBefore)
+	if err != nil {
+		return nil, "", err
+	}
+
+	native := NativeTokenFor(s.network)
+	mappedTxs := make([]models.Transaction, 0, len(transfers))
+	for _, transfer := range transfers {
+		mappedTxs = append(mappedTxs, MapAssetTransferToTransaction(transfer, address, currency, native))
+	}
+	return mappedTxs, next, nil
+}
+
 //func (s *Service) GetSolanaHistory	(addresses []alchemy_models.AddressRequest, limit *int) (*alchemy_models.TokensByAddressResponse, error) {
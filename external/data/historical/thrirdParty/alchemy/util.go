@@ -1,9 +1,12 @@
 package alchemy
 
 import (
+	"errors"
 	"fmt"
+	"github.com/tashunc/nugenesis-wallet-backend/external/data/fmv/pricing"
 	"github.com/tashunc/nugenesis-wallet-backend/external/data/historical/thrirdParty/alchemy/alchemy_models"
 	"github.com/tashunc/nugenesis-wallet-backend/external/models"
+	"math/big"
 	"strconv"
 	"strings"
 	"time"
@@ -65,6 +68,8 @@ func truncateAddress(addr string) string {
 	return addr[:10] + "..." + addr[len(addr)-6:]
 }
 
+var ErrInvalidAddress = errors.New("invalid EVM address format")
+
 func ValidateEthereumAddress(address string) bool {
 	if !strings.HasPrefix(address, "0x") {
 		return false
@@ -119,15 +124,9 @@ func truncateHash(hash string) string {
 	return hash[:8] + "..." + hash[len(hash)-4:]
 }
 
-func pow10(n int) int64 {
-	result := int64(1)
-	for i := 0; i < n; i++ {
-		result *= 10
-	}
-	return result
-}
-
-func MapAssetTransferToTransaction(transfer alchemy_models.AssetTransfer, userAddress string) models.Transaction {
+// MapAssetTransferToTransaction maps an Alchemy asset transfer of a chain whose gas token is native,
+// quoting fungible transfers at block time
+func MapAssetTransferToTransaction(transfer alchemy_models.AssetTransfer, userAddress string, currency string, native NativeToken) models.Transaction {
 	// Parse block number and metadata
 	var txTime time.Time
 	if transfer.Metadata != nil && transfer.Metadata.BlockTimestamp != "" {
@@ -146,68 +145,61 @@ func MapAssetTransferToTransaction(transfer alchemy_models.AssetTransfer, userAd
 		}
 	}
 
-	// Determine token and amount
-	token := "ETH"
+	// Determine token and amount, native transfers are priced as the chain's gas token
+	token := native.Symbol
 	var amount float64
 	category := transfer.Category
 
-	if transfer.Asset != nil && *transfer.Asset != "" {
+	if transfer.Asset != nil && *transfer.Asset != "" && category != "external" && category != "internal" {
 		token = *transfer.Asset
 	}
 
 	if transfer.Value != nil {
 		amount = *transfer.Value
 	} else if transfer.RawContract.Value != nil {
-		// Parse hex value
-		if valueStr := *transfer.RawContract.Value; strings.HasPrefix(valueStr, "0x") {
-			if val, err := strconv.ParseInt(valueStr[2:], 16, 64); err == nil {
-				// Get decimals
-				decimals := 18
-				if transfer.RawContract.Decimal != nil {
-					if d, err := strconv.Atoi(*transfer.RawContract.Decimal); err == nil {
-						decimals = d
-					} else if strings.HasPrefix(*transfer.RawContract.Decimal, "0x") {
-						if d, err := strconv.ParseInt((*transfer.RawContract.Decimal)[2:], 16, 64); err == nil {
-							decimals = int(d)
-						}
-					}
+		if raw, ok := new(big.Int).SetString(strings.TrimPrefix(*transfer.RawContract.Value, "0x"), 16); ok {
+			decimals := native.Decimals
+			if transfer.RawContract.Decimal != nil {
+				if d, err := strconv.ParseInt(*transfer.RawContract.Decimal, 0, 64); err == nil {
+					decimals = int(d)
 				}
-				amount = float64(val) / float64(pow10(decimals))
 			}
+			amount, _ = new(big.Float).Quo(new(big.Float).SetInt(raw), new(big.Float).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil))).Float64()
 		}
 	}
 
-	// Handle NFTs
+	// Handle NFTs, which have no fungible price
+	var valueQuote *models.Quote
 	if transfer.Category == "erc721" || transfer.Category == "erc1155" {
 		if transfer.Erc721TokenId != nil {
 			token = fmt.Sprintf("%s #%s", token, *transfer.Erc721TokenId)
 		}
 		amount = 1
+	} else {
+		valueQuote = pricing.Quote(token, amount, txTime)
 	}
 
-	// Estimate USD value (simplified, in production you'd use real-time prices)
-	usdValue := amount * 3500 // Example conversion rate
-
 	// Status is always completed for confirmed transfers
 	status := "completed"
 
 	// Fee information is not provided in asset transfers
-	fee := "$0.00"
+	fee := pricing.FormatFiat(0, currency)
 
 	return models.Transaction{
-		ID:        transfer.Hash,
-		Type:      txType,
-		Category:  category,
-		Status:    status,
-		Token:     token,
-		Amount:    fmt.Sprintf("%.6f", amount),
-		Value:     fmt.Sprintf("$%.2f", usdValue),
-		Address:   truncateAddress(relevantAddress),
-		ToAddress: truncateAddress(getToAddress(transfer)),
-		Date:      txTime.Format("2006-01-02"),
-		Time:      txTime.Format("15:04"),
-		Fee:       fee,
-		Hash:      truncateHash(transfer.Hash),
+		ID:         transfer.Hash,
+		Type:       txType,
+		Category:   category,
+		Status:     status,
+		Token:      token,
+		Amount:     fmt.Sprintf("%.6f", amount),
+		Address:    truncateAddress(relevantAddress),
+		ToAddress:  truncateAddress(getToAddress(transfer)),
+		Date:       txTime.Format("2006-01-02"),
+		Time:       txTime.Format("15:04"),
+		Fee:        fee,
+		Hash:       truncateHash(transfer.Hash),
+		Leg:        transfer.UniqueId,
+		ValueQuote: valueQuote,
 	}
 }
 
@@ -1,13 +1,5 @@
 package blockchaininfo
 
-import (
-	"github.com/gin-gonic/gin"
-	"github.com/tashunc/nugenesis-wallet-backend/external/models"
-	"log"
-	"net/http"
-	"strconv"
-)
-
 type Controller struct {
 	service *Service
 }
@@ -18,53 +10,6 @@ func NewController() *Controller {
 	}
 }
 
-func (c *Controller) GetAddressInfo(ctx *gin.Context) {
-	address := ctx.Param("address")
-	if address == "" {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "address parameter is required"})
-		return
-	}
-
-	limitStr := ctx.DefaultQuery("limit", "50")
-	offsetStr := ctx.DefaultQuery("offset", "0")
-
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit parameter"})
-		return
-	}
-
-	offset, err := strconv.Atoi(offsetStr)
-	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid offset parameter"})
-		return
-	}
-	log.Printf("[START] %s %s?%s", address, limit, limit)
-
-	addressInfo, err := c.service.GetAddressInfo(address, limit, offset)
-
-	var mappedTransactions []models.Transaction
-	log.Println(addressInfo)
-	if addressInfo != nil {
-		log.Printf("[START] %d ", len(addressInfo.Txs))
-		for _, tx := range addressInfo.Txs {
-			mappedTx := MapTxToTransaction(tx, address, addressInfo.Hash160)
-			mappedTransactions = append(mappedTransactions, mappedTx)
-		}
-	} else {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	//reponse := utils.MapTxToTransaction(addressInfo, address, addressInfo.Hash160)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-	//return mappedTransactions, nil
-	ctx.JSON(http.StatusOK, mappedTransactions)
-}
-
 //func (c *Controller) GetMultiAddress(ctx *gin.Context) {
 //	addressesParam := ctx.Query("addresses")
 //	if addressesParam == "" {
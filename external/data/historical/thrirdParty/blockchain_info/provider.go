@@ -0,0 +1,58 @@
+package blockchaininfo
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/tashunc/nugenesis-wallet-backend/external/data/historical"
+	"github.com/tashunc/nugenesis-wallet-backend/external/models"
+)
+
+const ProviderName = "blockchain_info"
+
+// HistoryProvider serves Bitcoin address history from blockchain.info through historical.HistoryProvider
+type HistoryProvider struct {
+	service *Service
+}
+
+// HistoryProvider returns a history provider sharing the controller's service
+func (c *Controller) HistoryProvider() *HistoryProvider {
+	return &HistoryProvider{service: c.service}
+}
+
+func (p *HistoryProvider) Name() string {
+	return ProviderName
+}
+
+// GetHistory uses the rawaddr offset as cursor
+func (p *HistoryProvider) GetHistory(ctx context.Context, request historical.HistoryRequest) (*historical.HistoryPage, error) {
+	offset := 0
+	if request.Cursor != "" {
+		parsed, err := strconv.Atoi(request.Cursor)
+		if err != nil || parsed < 0 {
+			return nil, fmt.Errorf("invalid blockchain.info cursor %q", request.Cursor)
+		}
+		offset = parsed
+	}
+
+	addressInfo, err := p.service.GetAddressInfo(ctx, request.Address, request.Limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	transactions := make([]models.Transaction, 0, len(addressInfo.Txs))
+	for _, tx := range addressInfo.Txs {
+		transactions = append(transactions, MapTxToTransaction(tx, request.Address, tx.Hash))
+	}
+
+	cursor := ""
+	if next := offset + len(addressInfo.Txs); len(addressInfo.Txs) > 0 && next < addressInfo.NTx {
+		cursor = strconv.Itoa(next)
+	}
+
+	return &historical.HistoryPage{
+		Transactions: transactions,
+		Cursor:       cursor,
+	}, nil
+}
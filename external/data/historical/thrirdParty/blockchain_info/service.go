@@ -1,9 +1,11 @@
 package blockchaininfo
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/tashunc/nugenesis-wallet-backend/external/data/historical/thrirdParty/blockchain_info/blockchain_info_models"
+	"github.com/tashunc/nugenesis-wallet-backend/pkg/httpclient"
 	"io"
 	"log"
 	"net/http"
@@ -24,19 +26,28 @@ type Service struct {
 
 func NewService() *Service {
 	return &Service{
-		client: &http.Client{
-			Timeout: Timeout,
-		},
+		// blockchain.info throttles aggressively, stay around one request per second
+		client: httpclient.New(httpclient.Config{
+			Name:          "blockchain_info",
+			Timeout:       Timeout,
+			RatePerSecond: 1,
+			Burst:         5,
+		}),
 		baseURL: BaseURL,
 	}
 }
 
 // https://www.blockchain.com/explorer/api/blockchain_api
-func (s *Service) GetAddressInfo(address string, limit int, offset int) (*blockchain_info_models.AddressInfo, error) {
+func (s *Service) GetAddressInfo(ctx context.Context, address string, limit int, offset int) (*blockchain_info_models.AddressInfo, error) {
 	url := fmt.Sprintf("%s/rawaddr/%s?limit=%d&offset=%d", s.baseURL, address, limit, offset)
 
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
 	for retries := 0; retries < 1; retries++ {
-		resp, err := s.client.Get(url)
+		resp, err := s.client.Do(req)
 		log.Println("Here1")
 		log.Println(resp)
 		log.Println(err)
@@ -154,15 +165,19 @@ func (s *Service) GetUnspentOutputs(address string) (*blockchain_info_models.Uns
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
-	}
-
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
 
+	if resp.StatusCode != http.StatusOK {
+		// blockchain.info answers an address without UTXOs with an error status
+		if strings.Contains(string(body), "No free outputs to spend") {
+			return &blockchain_info_models.UnspentOutputs{}, nil
+		}
+		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
+	}
+
 	var unspentOutputs blockchain_info_models.UnspentOutputs
 	if err := json.Unmarshal(body, &unspentOutputs); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
@@ -1,6 +1,7 @@
 package blockchaininfo
 
 import (
+	"context"
 	"encoding/json"
 	"github.com/tashunc/nugenesis-wallet-backend/external/data/historical/thrirdParty/blockchain_info/blockchain_info_models"
 	"net/http"
@@ -41,7 +42,7 @@ func TestService_GetAddressInfo(t *testing.T) {
 		baseURL: server.URL,
 	}
 
-	result, err := service.GetAddressInfo("1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", 50, 0)
+	result, err := service.GetAddressInfo(context.Background(), "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", 50, 0)
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
@@ -67,12 +68,12 @@ func TestService_GetAddressInfo_APIError(t *testing.T) {
 		baseURL: server.URL,
 	}
 
-	_, err := service.GetAddressInfo("invalid-address", 50, 0)
+	_, err := service.GetAddressInfo(context.Background(), "invalid-address", 50, 0)
 	if err == nil {
 		t.Fatal("Expected an error, got nil")
 	}
 
-	expectedError := "API returned status 400"
+	expectedError := "API returned status 400: Bad Request"
 	if err.Error() != expectedError {
 		t.Errorf("Expected error %s, got %s", expectedError, err.Error())
 	}
@@ -2,6 +2,7 @@ package blockchaininfo
 
 import (
 	"fmt"
+	"github.com/tashunc/nugenesis-wallet-backend/external/data/fmv/pricing"
 	"github.com/tashunc/nugenesis-wallet-backend/external/data/historical/thrirdParty/blockchain_info/blockchain_info_models"
 	"time"
 )
@@ -52,12 +53,8 @@ func MapTxToTransaction(tx blockchain_info_models.Tx, userAddress string, txID s
 	// Convert satoshis to BTC (assuming Bitcoin)
 	btcAmount := float64(amount) / 100000000.0
 
-	// Calculate approximate USD value (you'd want to use real exchange rates)
-	usdValue := btcAmount * 50000.0 // Example rate
-
-	// Convert fee from satoshis to USD
+	// Quote amount and fee at the block time, they are valued once the page is fetched
 	feeInBTC := float64(tx.Fee) / 100000000.0
-	feeInUSD := feeInBTC * 50000.0
 
 	// Determine status based on block confirmation
 	status := "pending"
@@ -69,17 +66,17 @@ func MapTxToTransaction(tx blockchain_info_models.Tx, userAddress string, txID s
 	}
 
 	return models.Transaction{
-		ID:      txID,
-		Type:    txType,
-		Status:  status,
-		Token:   "BTC", // You might want to make this configurable
-		Amount:  fmt.Sprintf("%.8f", btcAmount),
-		Value:   fmt.Sprintf("$%.2f", usdValue),
-		Address: truncateAddress(relevantAddress),
-		Date:    txTime.Format("2006-01-02"),
-		Time:    txTime.Format("15:04"),
-		Fee:     fmt.Sprintf("$%.2f", feeInUSD),
-		Hash:    truncateHash(tx.Hash),
+		ID:         txID,
+		Type:       txType,
+		Status:     status,
+		Token:      "BTC", // You might want to make this configurable
+		Amount:     fmt.Sprintf("%.8f", btcAmount),
+		Address:    truncateAddress(relevantAddress),
+		Date:       txTime.Format("2006-01-02"),
+		Time:       txTime.Format("15:04"),
+		Hash:       truncateHash(tx.Hash),
+		ValueQuote: pricing.Quote("BTC", btcAmount, txTime),
+		FeeQuote:   pricing.Quote("BTC", feeInBTC, txTime),
 	}
 }
 
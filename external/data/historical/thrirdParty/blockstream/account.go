@@ -0,0 +1,251 @@
+package blockstream
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+)
+
+// Script types of the single-key accounts, named after the BIP defining their derivation
+const (
+	ScriptP2PKH      = "p2pkh"       // BIP44
+	ScriptP2SHP2WPKH = "p2sh-p2wpkh" // BIP49
+	ScriptP2WPKH     = "p2wpkh"      // BIP84
+	ScriptP2TR       = "p2tr"        // BIP86
+)
+
+// Chains of an account, the last unhardened step before the address index
+const (
+	ReceiveChain = 0
+	ChangeChain  = 1
+)
+
+var ErrInvalidAccountKey = errors.New("invalid account key or descriptor")
+
+// Mainnet SLIP-132 versions of the extended public keys and the script each of them implies
+var extendedKeyScripts = map[[4]byte]string{
+	{0x04, 0x88, 0xb2, 0x1e}: ScriptP2PKH,      // xpub
+	{0x04, 0x9d, 0x7c, 0xb2}: ScriptP2SHP2WPKH, // ypub
+	{0x04, 0xb2, 0x47, 0x46}: ScriptP2WPKH,     // zpub
+}
+
+// Descriptor wrappers of the supported single-key scripts, outermost first
+var descriptorScripts = []struct {
+	prefix string
+	script string
+}{
+	{"sh(wpkh(", ScriptP2SHP2WPKH},
+	{"wpkh(", ScriptP2WPKH},
+	{"pkh(", ScriptP2PKH},
+	{"tr(", ScriptP2TR},
+}
+
+// Account is the public side of an HD account, deriving the addresses of its receive and change chains
+type Account struct {
+	ScriptType string
+	chains     map[uint32]*hdkeychain.ExtendedKey
+}
+
+// ParseAccount accepts an xpub, ypub or zpub at the account level, or a single-key output descriptor such as
+// wpkh([fingerprint/84'/0'/0']xpub.../<0;1>/*). A descriptor ending in /0/* or /1/* scans the usual 0 and 1 chains.
+func ParseAccount(input string) (*Account, error) {
+	input = strings.TrimSpace(input)
+	if strings.Contains(input, "(") {
+		return parseDescriptor(input)
+	}
+
+	key, err := parseExtendedPublicKey(input)
+	if err != nil {
+		return nil, err
+	}
+	return newAccount(extendedKeyScripts[[4]byte(key.Version())], key, []uint32{ReceiveChain, ChangeChain})
+}
+
+func parseDescriptor(descriptor string) (*Account, error) {
+	body, checksum, hasChecksum := strings.Cut(descriptor, "#")
+	if hasChecksum && descriptorChecksum(body) != checksum {
+		return nil, fmt.Errorf("%w: descriptor checksum mismatch", ErrInvalidAccountKey)
+	}
+
+	script := ""
+	inner := ""
+	for _, wrapper := range descriptorScripts {
+		if strings.HasPrefix(body, wrapper.prefix) {
+			script = wrapper.script
+			closing := strings.Count(wrapper.prefix, "(")
+			if !strings.HasSuffix(body, strings.Repeat(")", closing)) {
+				return nil, fmt.Errorf("%w: unbalanced descriptor", ErrInvalidAccountKey)
+			}
+			inner = body[len(wrapper.prefix) : len(body)-closing]
+			break
+		}
+	}
+	if script == "" {
+		return nil, fmt.Errorf("%w: only pkh, sh(wpkh), wpkh and tr single-key descriptors are supported", ErrInvalidAccountKey)
+	}
+
+	// Drop the key origin, the scripts only depend on the key itself
+	if strings.HasPrefix(inner, "[") {
+		end := strings.Index(inner, "]")
+		if end < 0 {
+			return nil, fmt.Errorf("%w: unterminated key origin", ErrInvalidAccountKey)
+		}
+		inner = inner[end+1:]
+	}
+
+	encodedKey, path, _ := strings.Cut(inner, "/")
+	key, err := parseExtendedPublicKey(encodedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	chainStep, ok := strings.CutSuffix(path, "/*")
+	if !ok {
+		return nil, fmt.Errorf("%w: descriptor must end in a ranged /<chain>/* path", ErrInvalidAccountKey)
+	}
+	chains := []uint32{ReceiveChain, ChangeChain}
+	if multipath, ok := strings.CutPrefix(chainStep, "<"); ok {
+		multipath, ok = strings.CutSuffix(multipath, ">")
+		if !ok {
+			return nil, fmt.Errorf("%w: unterminated multipath step", ErrInvalidAccountKey)
+		}
+		chains = chains[:0]
+		for _, step := range strings.Split(multipath, ";") {
+			chain, err := strconv.ParseUint(step, 10, 31)
+			if err != nil {
+				return nil, fmt.Errorf("%w: invalid multipath step %q", ErrInvalidAccountKey, step)
+			}
+			chains = append(chains, uint32(chain))
+		}
+		if len(chains) != 2 {
+			return nil, fmt.Errorf("%w: multipath step must list a receive and a change chain", ErrInvalidAccountKey)
+		}
+	} else if chainStep != "0" && chainStep != "1" {
+		return nil, fmt.Errorf("%w: unsupported derivation path %q", ErrInvalidAccountKey, path)
+	}
+
+	return newAccount(script, key, chains)
+}
+
+// parseExtendedPublicKey rejects private and non-mainnet keys, this endpoint never needs spending keys
+func parseExtendedPublicKey(encoded string) (*hdkeychain.ExtendedKey, error) {
+	key, err := hdkeychain.NewKeyFromString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidAccountKey, err)
+	}
+	if key.IsPrivate() {
+		return nil, fmt.Errorf("%w: private keys are not accepted", ErrInvalidAccountKey)
+	}
+	if _, ok := extendedKeyScripts[[4]byte(key.Version())]; !ok {
+		return nil, fmt.Errorf("%w: only mainnet xpub, ypub and zpub keys are supported", ErrInvalidAccountKey)
+	}
+	return key, nil
+}
+
+func newAccount(script string, key *hdkeychain.ExtendedKey, chains []uint32) (*Account, error) {
+	account := &Account{ScriptType: script, chains: make(map[uint32]*hdkeychain.ExtendedKey, len(chains))}
+	for i, chain := range chains {
+		chainKey, err := key.Derive(chain)
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive chain %d: %w", chain, err)
+		}
+		// Index the chains by role so that a custom multipath like <2;3> still maps to receive and change
+		account.chains[uint32(i)] = chainKey
+	}
+	return account, nil
+}
+
+// Address derives the address at index of the receive or change chain
+func (a *Account) Address(chain uint32, index uint32) (string, error) {
+	chainKey, ok := a.chains[chain]
+	if !ok {
+		return "", fmt.Errorf("account has no chain %d", chain)
+	}
+	child, err := chainKey.Derive(index)
+	if err != nil {
+		return "", fmt.Errorf("failed to derive index %d: %w", index, err)
+	}
+	publicKey, err := child.ECPubKey()
+	if err != nil {
+		return "", err
+	}
+
+	params := &chaincfg.MainNetParams
+	pubKeyHash := btcutil.Hash160(publicKey.SerializeCompressed())
+	var address btcutil.Address
+	switch a.ScriptType {
+	case ScriptP2PKH:
+		address, err = btcutil.NewAddressPubKeyHash(pubKeyHash, params)
+	case ScriptP2SHP2WPKH:
+		witnessProgram := append([]byte{txscript.OP_0, txscript.OP_DATA_20}, pubKeyHash...)
+		address, err = btcutil.NewAddressScriptHash(witnessProgram, params)
+	case ScriptP2WPKH:
+		address, err = btcutil.NewAddressWitnessPubKeyHash(pubKeyHash, params)
+	case ScriptP2TR:
+		outputKey := txscript.ComputeTaprootKeyNoScript(publicKey)
+		address, err = btcutil.NewAddressTaproot(schnorr.SerializePubKey(outputKey), params)
+	default:
+		return "", fmt.Errorf("unsupported script type %s", a.ScriptType)
+	}
+	if err != nil {
+		return "", err
+	}
+	return address.EncodeAddress(), nil
+}
+
+const (
+	descriptorInputCharset = "0123456789()[],'/*abcdefgh@:$%{}" +
+		"IJKLMNOPQRSTUVWXYZ&+-.;<=>?!^_|~" +
+		"ijklmnopqrstuvwxyzABCDEFGH`#\"\\ "
+	descriptorChecksumCharset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+)
+
+// descriptorChecksum computes the BIP380 checksum of a descriptor, empty when it has characters outside the charset
+func descriptorChecksum(descriptor string) string {
+	polymod := func(c uint64, value uint64) uint64 {
+		top := c >> 35
+		c = (c&0x7ffffffff)<<5 ^ value
+		for i, generator := range []uint64{0xf5dee51989, 0xa9fdca3312, 0x1bab10e32d, 0x3706b1677a, 0x644d626ffd} {
+			if top>>i&1 == 1 {
+				c ^= generator
+			}
+		}
+		return c
+	}
+
+	c := uint64(1)
+	class, classCount := uint64(0), 0
+	for _, ch := range []byte(descriptor) {
+		position := bytes.IndexByte([]byte(descriptorInputCharset), ch)
+		if position < 0 {
+			return ""
+		}
+		c = polymod(c, uint64(position&31))
+		class = class*3 + uint64(position>>5)
+		if classCount++; classCount == 3 {
+			c = polymod(c, class)
+			class, classCount = 0, 0
+		}
+	}
+	if classCount > 0 {
+		c = polymod(c, class)
+	}
+	for i := 0; i < 8; i++ {
+		c = polymod(c, 0)
+	}
+	c ^= 1
+
+	checksum := make([]byte, 8)
+	for i := range checksum {
+		checksum[i] = descriptorChecksumCharset[c>>(5*(7-i))&31]
+	}
+	return string(checksum)
+}
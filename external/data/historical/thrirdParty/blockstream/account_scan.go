@@ -0,0 +1,369 @@
+package blockstream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/tashunc/nugenesis-wallet-backend/external/data/fmv/pricing"
+	"github.com/tashunc/nugenesis-wallet-backend/external/data/historical/thrirdParty/blockstream/blockstream_models"
+)
+
+const (
+	DefaultGapLimit = 20
+	MaxGapLimit     = 200
+	// maxChainAddresses bounds the discovery of a single chain, far beyond any wallet respecting its gap limit
+	maxChainAddresses = 5000
+	// maxAddressHistoryPages bounds the confirmed history fetched per address to 1000 transactions
+	maxAddressHistoryPages = 40
+	// maxScanCalls bounds the upstream requests of a single scan
+	maxScanCalls = 2000
+	// scanWorkers is how many upstream requests a scan runs at once
+	scanWorkers = 8
+)
+
+var (
+	ErrAccountTooLarge = errors.New("account exceeds the scanning limits")
+	// ErrPartialScan means the scan ran out of its request budget before covering the whole account
+	ErrPartialScan = errors.New("account scan stopped before covering the whole account")
+)
+
+// scanBudget counts the upstream requests of a scan against maxScanCalls
+type scanBudget struct {
+	calls     atomic.Int64
+	addresses atomic.Int64
+}
+
+func (b *scanBudget) spend() error {
+	if b.calls.Add(1) > maxScanCalls {
+		return fmt.Errorf("%w: looked up %d addresses within the budget of %d requests", ErrPartialScan, b.addresses.Load(), maxScanCalls)
+	}
+	return nil
+}
+
+type accountAddress struct {
+	address string
+	chain   uint32
+	index   uint32
+	info    *blockstream_models.AddressInfo
+}
+
+// ScanAccount discovers the used addresses of both chains of account, stopping a chain after gapLimit consecutive
+// unused addresses, and merges their history, balance and unspent outputs. It fails with ErrPartialScan once the
+// scan used up its request budget, and stops when ctx is cancelled.
+func (s *Service) ScanAccount(ctx context.Context, account *Account, gapLimit int, currency string) (*blockstream_models.AccountResponse, error) {
+	budget := &scanBudget{}
+	receive, nextReceiveIndex, err := s.discoverChain(ctx, budget, account, ReceiveChain, gapLimit)
+	if err != nil {
+		return nil, err
+	}
+	change, _, err := s.discoverChain(ctx, budget, account, ChangeChain, gapLimit)
+	if err != nil {
+		return nil, err
+	}
+	used := append(receive, change...)
+
+	owned := make(map[string]bool, len(used))
+	var balance blockstream_models.AccountBalance
+	for _, addr := range used {
+		owned[addr.address] = true
+		balance.Confirmed += addr.info.ChainStats.FundedTxoSum - addr.info.ChainStats.SpentTxoSum
+		balance.Unconfirmed += addr.info.MempoolStats.FundedTxoSum - addr.info.MempoolStats.SpentTxoSum
+	}
+	balance.Total = balance.Confirmed + balance.Unconfirmed
+
+	histories := make([][]blockstream_models.TransactionResponse, len(used))
+	utxos := make([][]blockstream_models.AccountUTXO, len(used))
+	err = forEachConcurrently(ctx, len(used), func(ctx context.Context, i int) error {
+		history, err := s.getFullAddressHistory(ctx, budget, used[i].address)
+		if err != nil {
+			return err
+		}
+		histories[i] = history
+		utxos[i], err = s.getAccountUTXOs(ctx, budget, used[i])
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	transactions := mergeAccountHistory(histories)
+	standardized := make([]blockstream_models.StandardizedTransaction, 0, len(transactions))
+	for _, tx := range transactions {
+		standardized = append(standardized, mapAccountTransaction(tx, owned, s.network))
+	}
+	var valuations []pricing.Valuation
+	if quote := s.network.quote(balance.Total, time.Time{}); quote != nil {
+		valuations = append(valuations, pricing.Valuation{Target: &balance.Value, Quote: quote})
+	}
+	fillValues(ctx, standardized, currency, valuations...)
+
+	nextReceiveAddress, err := account.Address(ReceiveChain, nextReceiveIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	accountUTXOs := make([]blockstream_models.AccountUTXO, 0)
+	for _, addressUTXOs := range utxos {
+		accountUTXOs = append(accountUTXOs, addressUTXOs...)
+	}
+
+	return &blockstream_models.AccountResponse{
+		ScriptType:         account.ScriptType,
+		GapLimit:           gapLimit,
+		Transactions:       standardized,
+		Balance:            balance,
+		UTXOs:              accountUTXOs,
+		NextReceiveAddress: nextReceiveAddress,
+		NextReceiveIndex:   nextReceiveIndex,
+		UsedAddressCount:   len(used),
+	}, nil
+}
+
+// discoverChain looks up the addresses of a chain in windows of gapLimit and returns the used ones with the index
+// following the last used address
+func (s *Service) discoverChain(ctx context.Context, budget *scanBudget, account *Account, chain uint32, gapLimit int) ([]accountAddress, uint32, error) {
+	var used []accountAddress
+	next := uint32(0)
+	for start := uint32(0); start-next < uint32(gapLimit); start += uint32(gapLimit) {
+		if start >= maxChainAddresses {
+			return nil, 0, fmt.Errorf("%w: more than %d addresses on chain %d", ErrAccountTooLarge, maxChainAddresses, chain)
+		}
+
+		window := make([]accountAddress, gapLimit)
+		err := forEachConcurrently(ctx, gapLimit, func(ctx context.Context, i int) error {
+			index := start + uint32(i)
+			address, err := account.Address(chain, index)
+			if err != nil {
+				return err
+			}
+			if err := budget.spend(); err != nil {
+				return err
+			}
+			budget.addresses.Add(1)
+			info, err := s.GetAddressInfo(ctx, address)
+			if err != nil {
+				return fmt.Errorf("failed to look up %s: %w", address, err)
+			}
+			window[i] = accountAddress{address: address, chain: chain, index: index, info: info}
+			return nil
+		})
+		if err != nil {
+			return nil, 0, err
+		}
+
+		for _, addr := range window {
+			if addr.info.ChainStats.TxCount+addr.info.MempoolStats.TxCount > 0 {
+				used = append(used, addr)
+				next = addr.index + 1
+			}
+		}
+	}
+	return used, next, nil
+}
+
+// getFullAddressHistory follows the confirmed pages of an address until a short page ends its history
+func (s *Service) getFullAddressHistory(ctx context.Context, budget *scanBudget, address string) ([]blockstream_models.TransactionResponse, error) {
+	var history []blockstream_models.TransactionResponse
+	lastSeenTxid := ""
+	for page := 0; page < maxAddressHistoryPages; page++ {
+		if err := budget.spend(); err != nil {
+			return nil, err
+		}
+		response, err := s.GetAddressTransactionsPage(ctx, address, lastSeenTxid)
+		if err != nil {
+			return nil, err
+		}
+
+		confirmed := 0
+		for _, tx := range *response {
+			history = append(history, tx)
+			if tx.Status.Confirmed {
+				confirmed++
+				lastSeenTxid = tx.Txid
+			}
+		}
+		if confirmed < ChainPageSize {
+			return history, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s has more than %d transactions", ErrAccountTooLarge, address, maxAddressHistoryPages*ChainPageSize)
+}
+
+func (s *Service) getAccountUTXOs(ctx context.Context, budget *scanBudget, addr accountAddress) ([]blockstream_models.AccountUTXO, error) {
+	funded := addr.info.ChainStats.FundedTxoCount + addr.info.MempoolStats.FundedTxoCount
+	spent := addr.info.ChainStats.SpentTxoCount + addr.info.MempoolStats.SpentTxoCount
+	if funded <= spent {
+		return nil, nil
+	}
+
+	if err := budget.spend(); err != nil {
+		return nil, err
+	}
+	utxos, err := s.GetAddressUTXOs(ctx, addr.address)
+	if err != nil {
+		return nil, err
+	}
+	accountUTXOs := make([]blockstream_models.AccountUTXO, 0, len(utxos))
+	for _, utxo := range utxos {
+		accountUTXOs = append(accountUTXOs, blockstream_models.AccountUTXO{
+			Txid:        utxo.Txid,
+			Vout:        utxo.Vout,
+			Value:       utxo.Value,
+			Address:     addr.address,
+			Chain:       addr.chain,
+			Index:       addr.index,
+			Confirmed:   utxo.Status.Confirmed,
+			BlockHeight: utxo.Status.BlockHeight,
+		})
+	}
+	return accountUTXOs, nil
+}
+
+// mergeAccountHistory drops the transactions seen from several addresses and orders them mempool first, then newest block first
+func mergeAccountHistory(histories [][]blockstream_models.TransactionResponse) []blockstream_models.TransactionResponse {
+	seen := make(map[string]bool)
+	var merged []blockstream_models.TransactionResponse
+	for _, history := range histories {
+		for _, tx := range history {
+			if !seen[tx.Txid] {
+				seen[tx.Txid] = true
+				merged = append(merged, tx)
+			}
+		}
+	}
+
+	height := func(tx blockstream_models.TransactionResponse) int {
+		if !tx.Status.Confirmed || tx.Status.BlockHeight == nil {
+			return int(^uint(0) >> 1)
+		}
+		return *tx.Status.BlockHeight
+	}
+	sort.SliceStable(merged, func(i, j int) bool {
+		if hi, hj := height(merged[i]), height(merged[j]); hi != hj {
+			return hi > hj
+		}
+		return merged[i].Txid < merged[j].Txid
+	})
+	return merged
+}
+
+// mapAccountTransaction classifies a transaction from the account's point of view. Moving coins between addresses of the
+// account is a self transfer, and the fee is only reported when the account funded every input.
+func mapAccountTransaction(tx blockstream_models.TransactionResponse, owned map[string]bool, network Network) blockstream_models.StandardizedTransaction {
+	var ownedIn, ownedOut, externalOut int64
+	allInputsOwned := len(tx.Vin) > 0
+	sender := ""
+	for _, input := range tx.Vin {
+		if owned[input.Prevout.ScriptpubkeyAddress] {
+			ownedIn += input.Prevout.Value
+			continue
+		}
+		allInputsOwned = false
+		if sender == "" {
+			sender = input.Prevout.ScriptpubkeyAddress
+		}
+	}
+
+	recipient, ownAddress := "", ""
+	for _, output := range tx.Vout {
+		if owned[output.ScriptpubkeyAddress] {
+			ownedOut += output.Value
+			if ownAddress == "" {
+				ownAddress = output.ScriptpubkeyAddress
+			}
+			continue
+		}
+		externalOut += output.Value
+		if recipient == "" {
+			recipient = output.ScriptpubkeyAddress
+		}
+	}
+
+	txType, address, fee := "send", recipient, int64(0)
+	var amount int64
+	switch net := ownedOut - ownedIn; {
+	case ownedIn == 0:
+		txType, amount, address = "receive", ownedOut, sender
+	case recipient == "":
+		txType, amount, address, fee = "self", ownedOut, ownAddress, int64(tx.Fee)
+	case net >= 0:
+		// A collaborative transaction paying the account more than it contributed
+		txType, amount, address = "receive", net, sender
+	case allInputsOwned:
+		amount, fee = externalOut, int64(tx.Fee)
+	default:
+		// The account's share of the fee is unknown, so it is included in the amount
+		amount = -net
+	}
+
+	status := "completed"
+	if !tx.Status.Confirmed {
+		status = "pending"
+	}
+	date, timeStr := formatDateTime(tx.Status.BlockTime)
+	var valuedAt time.Time
+	if tx.Status.BlockTime != nil {
+		valuedAt = time.Unix(*tx.Status.BlockTime, 0)
+	}
+
+	return blockstream_models.StandardizedTransaction{
+		ID:         tx.Txid,
+		Type:       txType,
+		Status:     status,
+		Token:      network.Symbol,
+		Amount:     network.FormatAmount(amount),
+		Address:    TruncateAddress(address),
+		Date:       date,
+		Time:       timeStr,
+		Hash:       TruncateAddress(tx.Txid),
+		ValueQuote: network.quote(amount, valuedAt),
+		FeeQuote:   network.quote(fee, valuedAt),
+	}
+}
+
+// forEachConcurrently runs fn for 0..n-1 on scanWorkers goroutines and returns the first error. The ctx passed to fn
+// is cancelled on the first error, and no further items are started once it is done.
+func forEachConcurrently(ctx context.Context, n int, fn func(ctx context.Context, i int) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+	items := make(chan int)
+	for w := 0; w < min(scanWorkers, n); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range items {
+				if err := fn(ctx, i); err != nil {
+					once.Do(func() {
+						firstErr = err
+						cancel()
+					})
+				}
+			}
+		}()
+	}
+
+feed:
+	for i := 0; i < n && ctx.Err() == nil; i++ {
+		select {
+		case items <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(items)
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,136 @@
+package blockstream
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/tashunc/nugenesis-wallet-backend/external/data/historical/thrirdParty/blockstream/blockstream_models"
+)
+
+// Test vectors of BIP84 and BIP86 for the "abandon ... about" mnemonic
+const (
+	bip84Zpub = "zpub6rFR7y4Q2AijBEqTUquhVz398htDFrtymD9xYYfG1m4wAcvPhXNfE3EfH1r1ADqtfSdVCToUG868RvUUkgDKf31mGDtKsAYz2oz2AGutZYs"
+	bip86Xpub = "xpub6BgBgsespWvERF3LHQu6CnqdvfEvtMcQjYrcRzx53QJjSxarj2afYWcLteoGVky7D3UKDP9QyrLprQ3VCECoY49yfdDEHGCtMMj92pReUsQ"
+)
+
+func TestParseAccountDerivesAddresses(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		chain   uint32
+		address string
+	}{
+		{"zpub receive", bip84Zpub, ReceiveChain, "bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu"},
+		{"zpub change", bip84Zpub, ChangeChain, "bc1q8c6fshw2dlwun7ekn9qwf37cu2rn755upcp6el"},
+		{"tr descriptor", "tr([73c5da0a/86'/0'/0']" + bip86Xpub + "/<0;1>/*)", ReceiveChain, "bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			account, err := ParseAccount(tt.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			address, err := account.Address(tt.chain, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if address != tt.address {
+				t.Errorf("Expected %s, got %s", tt.address, address)
+			}
+		})
+	}
+}
+
+func TestParseAccountRejectsInvalidDescriptors(t *testing.T) {
+	for _, input := range []string{
+		"wpkh(" + bip86Xpub + "/0/*)#aaaaaaaa",
+		"wsh(" + bip86Xpub + "/0/*)",
+		"wpkh(" + bip86Xpub + "/0/5)",
+		"not a key",
+	} {
+		if _, err := ParseAccount(input); !errors.Is(err, ErrInvalidAccountKey) {
+			t.Errorf("Expected %q to be rejected, got %v", input, err)
+		}
+	}
+}
+
+func TestDescriptorChecksum(t *testing.T) {
+	if checksum := descriptorChecksum("raw(deadbeef)"); checksum != "89f8spxm" {
+		t.Errorf("Expected the BIP380 checksum 89f8spxm, got %s", checksum)
+	}
+}
+
+func TestMapAccountTransactionClassifiesSelfTransfers(t *testing.T) {
+	owned := map[string]bool{"receive0": true, "change0": true}
+	tx := blockstream_models.TransactionResponse{
+		Txid: "self",
+		Fee:  200,
+		Vin:  []blockstream_models.Input{{Prevout: blockstream_models.Prevout{ScriptpubkeyAddress: "receive0", Value: 10000}}},
+		Vout: []blockstream_models.Output{{ScriptpubkeyAddress: "change0", Value: 9800}},
+	}
+	if mapped := mapAccountTransaction(tx, owned, BitcoinNetwork()); mapped.Type != "self" || mapped.Amount != "0.00009800" {
+		t.Errorf("Expected a self transfer of 9800 sats, got %+v", mapped)
+	}
+
+	tx.Vout = []blockstream_models.Output{{ScriptpubkeyAddress: "external", Value: 6000}, {ScriptpubkeyAddress: "change0", Value: 3800}}
+	if mapped := mapAccountTransaction(tx, owned, BitcoinNetwork()); mapped.Type != "send" || mapped.Amount != "0.00006000" {
+		t.Errorf("Expected a send of 6000 sats, got %+v", mapped)
+	}
+}
+
+func TestForEachConcurrentlyBoundsWorkersAndStopsOnError(t *testing.T) {
+	failure := errors.New("upstream down")
+	var running, peak, started atomic.Int64
+	err := forEachConcurrently(context.Background(), 100, func(ctx context.Context, i int) error {
+		started.Add(1)
+		current := running.Add(1)
+		defer running.Add(-1)
+		for {
+			observed := peak.Load()
+			if current <= observed || peak.CompareAndSwap(observed, current) {
+				break
+			}
+		}
+		if i == 3 {
+			return failure
+		}
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if !errors.Is(err, failure) {
+		t.Errorf("Expected the first error, got %v", err)
+	}
+	if peak.Load() > scanWorkers {
+		t.Errorf("Expected at most %d concurrent calls, got %d", scanWorkers, peak.Load())
+	}
+	if started.Load() == 100 {
+		t.Error("Expected no further items to start after the error")
+	}
+}
+
+func TestForEachConcurrentlyStopsWhenTheRequestIsCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var started atomic.Int64
+	err := forEachConcurrently(ctx, 100, func(ctx context.Context, i int) error {
+		started.Add(1)
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) || started.Load() != 0 {
+		t.Errorf("Expected the scan to stop, got %v after %d calls", err, started.Load())
+	}
+}
+
+func TestScanBudgetReportsAPartialScan(t *testing.T) {
+	budget := &scanBudget{}
+	for i := 0; i < maxScanCalls; i++ {
+		if err := budget.spend(); err != nil {
+			t.Fatalf("Expected call %d to be within the budget, got %v", i, err)
+		}
+	}
+	if err := budget.spend(); !errors.Is(err, ErrPartialScan) {
+		t.Errorf("Expected ErrPartialScan, got %v", err)
+	}
+}
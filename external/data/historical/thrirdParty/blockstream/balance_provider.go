@@ -0,0 +1,65 @@
+package blockstream
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/tashunc/nugenesis-wallet-backend/external/data/fmv/pricing"
+	"github.com/tashunc/nugenesis-wallet-backend/external/models"
+)
+
+// BalanceProvider serves the native balance of an address on an Esplora network through portfolio.BalanceProvider
+type BalanceProvider struct {
+	service *Service
+}
+
+// BalanceProvider returns a balance provider sharing the controller's service
+func (c *Controller) BalanceProvider() *BalanceProvider {
+	return &BalanceProvider{service: c.service}
+}
+
+func (p *BalanceProvider) Name() string {
+	return ProviderName
+}
+
+// GetBalances returns a single native coin row including unconfirmed mempool activity
+func (p *BalanceProvider) GetBalances(ctx context.Context, address string) ([]models.WalletTokenBalance, error) {
+	network := p.service.network
+	if !network.ValidAddress(address) {
+		return nil, ErrInvalidAddress
+	}
+
+	info, err := p.service.GetAddressInfo(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := info.ChainStats.FundedTxoSum - info.ChainStats.SpentTxoSum +
+		info.MempoolStats.FundedTxoSum - info.MempoolStats.SpentTxoSum
+	amount := network.ToUnits(raw)
+
+	balance := models.WalletTokenBalance{
+		Name:        network.DisplayName,
+		Symbol:      network.Symbol,
+		Decimals:    strconv.Itoa(network.Decimals),
+		Balance:     network.FormatAmount(raw),
+		BalanceRaw:  strconv.FormatInt(raw, 10),
+		NativeToken: true,
+		Chain:       network.Name,
+	}
+
+	// A missing price leaves the balance unvalued rather than failing the lookup, test coins are never priced
+	if network.PriceID == "" {
+		return []models.WalletTokenBalance{balance}, nil
+	}
+	if price, err := pricing.GetPriceService().PriceAt(network.PriceID, time.Now(), "usd"); err == nil {
+		balance.UsdPrice = price
+		balance.UsdValue = amount * price
+	} else {
+		log.Printf("failed to price %s balance: %v", network.Symbol, err)
+	}
+
+	return []models.WalletTokenBalance{balance}, nil
+}
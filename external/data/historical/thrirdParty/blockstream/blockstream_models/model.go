@@ -1,5 +1,7 @@
 package blockstream_models
 
+import "github.com/tashunc/nugenesis-wallet-backend/external/models"
+
 type TransactionResponse struct {
 	Txid     string   `json:"txid"`
 	Version  int      `json:"version"`
@@ -19,9 +21,10 @@ type Input struct {
 }
 
 type Prevout struct {
-	Scriptpubkey     string `json:"scriptpubkey"`
-	ScriptpubkeyType string `json:"scriptpubkey_type"`
-	Value            int64  `json:"value"`
+	Scriptpubkey        string `json:"scriptpubkey"`
+	ScriptpubkeyType    string `json:"scriptpubkey_type"`
+	ScriptpubkeyAddress string `json:"scriptpubkey_address,omitempty"`
+	Value               int64  `json:"value"`
 }
 
 type Output struct {
@@ -51,9 +54,86 @@ type StandardizedTransaction struct {
 	Time    string `json:"time"`
 	Fee     string `json:"fee"`
 	Hash    string `json:"hash"`
+	// ValueQuote and FeeQuote are priced into Value and Fee once the whole history is mapped
+	ValueQuote *models.Quote `json:"-"`
+	FeeQuote   *models.Quote `json:"-"`
+}
+
+// BroadcastResponse carries the txid accepted by POST /tx, or the node rejection message
+type BroadcastResponse struct {
+	Txid  string `json:"txid,omitempty"`
+	Error string `json:"error,omitempty"`
 }
 
-type StandardizedTransactionsResponse struct {
-	Transactions []StandardizedTransaction `json:"transactions"`
-	TotalCount   int                       `json:"totalCount"`
+// AddressStats sums the outputs funding and spent by an address, in satoshis
+type AddressStats struct {
+	FundedTxoCount int   `json:"funded_txo_count"`
+	FundedTxoSum   int64 `json:"funded_txo_sum"`
+	SpentTxoCount  int   `json:"spent_txo_count"`
+	SpentTxoSum    int64 `json:"spent_txo_sum"`
+	TxCount        int   `json:"tx_count"`
+}
+
+type AddressInfo struct {
+	Address      string       `json:"address"`
+	ChainStats   AddressStats `json:"chain_stats"`
+	MempoolStats AddressStats `json:"mempool_stats"`
+}
+
+// UTXO is an unspent output of GET /address/:address/utxo
+type UTXO struct {
+	Txid   string `json:"txid"`
+	Vout   uint32 `json:"vout"`
+	Value  int64  `json:"value"`
+	Status Status `json:"status"`
+}
+
+type AccountRequest struct {
+	// Key is an xpub, ypub or zpub, or a single-key output descriptor
+	Key string `json:"key" binding:"required"`
+	// GapLimit is the number of consecutive unused addresses ending the scan of a chain
+	GapLimit int    `json:"gapLimit"`
+	Currency string `json:"currency"`
+}
+
+// AccountUTXO is an unspent output of the account with the derivation of the address holding it
+type AccountUTXO struct {
+	Txid        string `json:"txid"`
+	Vout        uint32 `json:"vout"`
+	Value       int64  `json:"value"`
+	Address     string `json:"address"`
+	Chain       uint32 `json:"chain"`
+	Index       uint32 `json:"index"`
+	Confirmed   bool   `json:"confirmed"`
+	BlockHeight *int   `json:"blockHeight,omitempty"`
+}
+
+// AccountBalance sums the account outputs in satoshis
+type AccountBalance struct {
+	Confirmed   int64  `json:"confirmed"`
+	Unconfirmed int64  `json:"unconfirmed"`
+	Total       int64  `json:"total"`
+	Value       string `json:"value"`
+}
+
+type AccountResponse struct {
+	ScriptType         string                    `json:"scriptType"`
+	GapLimit           int                       `json:"gapLimit"`
+	Transactions       []StandardizedTransaction `json:"transactions"`
+	Balance            AccountBalance            `json:"balance"`
+	UTXOs              []AccountUTXO             `json:"utxos"`
+	NextReceiveAddress string                    `json:"nextReceiveAddress"`
+	NextReceiveIndex   uint32                    `json:"nextReceiveIndex"`
+	UsedAddressCount   int                       `json:"usedAddressCount"`
+}
+
+// FeeEstimates maps a confirmation target in blocks, e.g. "1" or "144", to a fee rate in sat/vB
+type FeeEstimates map[string]float64
+
+// MempoolStats is the backlog summary of GET /mempool. FeeHistogram lists [fee rate, vsize] buckets, highest rate first.
+type MempoolStats struct {
+	Count        int          `json:"count"`
+	VSize        int64        `json:"vsize"`
+	TotalFee     int64        `json:"total_fee"`
+	FeeHistogram [][2]float64 `json:"fee_histogram"`
 }
@@ -1,7 +1,10 @@
 package blockstream
 
 import (
+	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
+	"github.com/tashunc/nugenesis-wallet-backend/external/data/historical/thrirdParty/blockstream/blockstream_models"
 	"net/http"
 )
 
@@ -9,25 +12,45 @@ type Controller struct {
 	service *Service
 }
 
-func NewController() *Controller {
+func NewController(network Network) *Controller {
 	return &Controller{
-		service: NewService(),
+		service: NewService(network),
 	}
 }
 
-func (c *Controller) GetAddressTransactions(ctx *gin.Context) {
-	address := ctx.Param("address")
-	if address == "" {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "address parameter is required"})
+// ScanAccount returns the merged history, balance and UTXOs of an xpub or descriptor account
+func (c *Controller) ScanAccount(ctx *gin.Context) {
+	var request blockstream_models.AccountRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	if !ValidateBitcoinAddress(address) {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid Bitcoin address format"})
+	account, err := ParseAccount(request.Key)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	gapLimit := request.GapLimit
+	if gapLimit == 0 {
+		gapLimit = DefaultGapLimit
+	}
+	if gapLimit < 1 || gapLimit > MaxGapLimit {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("gapLimit must be between 1 and %d", MaxGapLimit)})
 		return
 	}
 
-	response, err := c.service.GetAddressTransactionsStandardized(address)
+	currency := request.Currency
+	if currency == "" {
+		currency = "usd"
+	}
+
+	response, err := c.service.ScanAccount(ctx.Request.Context(), account, gapLimit, currency)
+	if errors.Is(err, ErrAccountTooLarge) || errors.Is(err, ErrPartialScan) {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -0,0 +1,209 @@
+package blockstream
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/bech32"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/tashunc/nugenesis-wallet-backend/external/models"
+	"github.com/tashunc/nugenesis-wallet-backend/external/models/general"
+)
+
+// Net tells the networks of a coin apart, test networks share the SLIP-44 coin type of their mainnet
+type Net string
+
+const (
+	Mainnet Net = "mainnet"
+	Testnet Net = "testnet"
+	Signet  Net = "signet"
+)
+
+// NetworkID identifies a network by its coin type and the net it runs on
+type NetworkID struct {
+	CoinType general.CoinType
+	Net      Net
+}
+
+// Network is an Esplora-compatible UTXO chain with the address rules and units of its coin
+type Network struct {
+	CoinType general.CoinType
+	Net      Net
+	// Name identifies the network in logs and balance rows, e.g. bitcoin or litecoin
+	Name        string
+	DisplayName string
+	BaseURL     string
+	Symbol      string
+	Decimals    int
+	// PriceID is the CoinGecko id of the coin, empty for test networks whose coins have no price
+	PriceID string
+	// Params holds the address version bytes and bech32 prefix of the network
+	Params *chaincfg.Params
+}
+
+// ID returns the coin type and net of the network
+func (n Network) ID() NetworkID {
+	return NetworkID{CoinType: n.CoinType, Net: n.Net}
+}
+
+// esploraNetwork describes the default API of a network, urlEnv overrides it or enables a network without one
+type esploraNetwork struct {
+	network Network
+	urlEnv  string
+}
+
+// LitecoinParams carries the Litecoin address formats. Only the fields used to encode and decode addresses are set.
+var LitecoinParams = chaincfg.Params{
+	Name:             "litecoin",
+	Net:              wire.BitcoinNet(0xdbb6c0fb),
+	PubKeyHashAddrID: 0x30,
+	ScriptHashAddrID: 0x32,
+	Bech32HRPSegwit:  "ltc",
+	HDPublicKeyID:    [4]byte{0x01, 0x9d, 0xa4, 0x62},
+	HDPrivateKeyID:   [4]byte{0x01, 0x9d, 0x9c, 0xfe},
+}
+
+// DogecoinParams carries the Dogecoin address formats, Dogecoin has no segwit addresses
+var DogecoinParams = chaincfg.Params{
+	Name:             "dogecoin",
+	Net:              wire.BitcoinNet(0xc0c0c0c0),
+	PubKeyHashAddrID: 0x1e,
+	ScriptHashAddrID: 0x16,
+	HDPublicKeyID:    [4]byte{0x02, 0xfa, 0xca, 0xfd},
+	HDPrivateKeyID:   [4]byte{0x02, 0xfa, 0xc3, 0x98},
+}
+
+var esploraNetworks = []esploraNetwork{
+	{urlEnv: "ESPLORA_BITCOIN_URL", network: Network{
+		CoinType: general.Bitcoin, Net: Mainnet,
+		Name: "bitcoin", DisplayName: "Bitcoin", BaseURL: BaseURL, Symbol: "BTC", Decimals: 8, PriceID: "bitcoin", Params: &chaincfg.MainNetParams,
+	}},
+	{urlEnv: "ESPLORA_BITCOIN_TESTNET_URL", network: Network{
+		CoinType: general.Bitcoin, Net: Testnet,
+		Name: "bitcoin-testnet", DisplayName: "Bitcoin Testnet", BaseURL: "https://blockstream.info/testnet/api", Symbol: "tBTC", Decimals: 8, Params: &chaincfg.TestNet3Params,
+	}},
+	{urlEnv: "ESPLORA_BITCOIN_SIGNET_URL", network: Network{
+		CoinType: general.Bitcoin, Net: Signet,
+		Name: "bitcoin-signet", DisplayName: "Bitcoin Signet", BaseURL: "https://mempool.space/signet/api", Symbol: "sBTC", Decimals: 8, Params: &chaincfg.SigNetParams,
+	}},
+	{urlEnv: "ESPLORA_LITECOIN_URL", network: Network{
+		CoinType: general.Litecoin, Net: Mainnet,
+		Name: "litecoin", DisplayName: "Litecoin", BaseURL: "https://litecoinspace.org/api", Symbol: "LTC", Decimals: 8, PriceID: "litecoin", Params: &LitecoinParams,
+	}},
+	{urlEnv: "ESPLORA_DOGECOIN_URL", network: Network{
+		CoinType: general.Dogecoin, Net: Mainnet,
+		Name: "dogecoin", DisplayName: "Dogecoin", Symbol: "DOGE", Decimals: 8, PriceID: "dogecoin", Params: &DogecoinParams,
+	}},
+}
+
+// NetworkFor resolves the Esplora API of a network. ok is false for networks without a default API unless their
+// ESPLORA_*_URL variable is set.
+func NetworkFor(id NetworkID) (Network, bool) {
+	for _, entry := range esploraNetworks {
+		if entry.network.ID() != id {
+			continue
+		}
+		network := entry.network
+		if url := os.Getenv(entry.urlEnv); url != "" {
+			network.BaseURL = url
+		}
+		return network, network.BaseURL != ""
+	}
+	return Network{}, false
+}
+
+// BitcoinNetwork returns Bitcoin mainnet, which always has an API
+func BitcoinNetwork() Network {
+	network, _ := NetworkFor(NetworkID{CoinType: general.Bitcoin, Net: Mainnet})
+	return network
+}
+
+// ConfiguredNetworks returns every network an Esplora API is available for
+func ConfiguredNetworks() []Network {
+	var networks []Network
+	for _, entry := range esploraNetworks {
+		if network, ok := NetworkFor(entry.network.ID()); ok {
+			networks = append(networks, network)
+		}
+	}
+	return networks
+}
+
+// ValidAddress accepts the P2PKH, P2SH, segwit and taproot addresses of the network
+func (n Network) ValidAddress(address string) bool {
+	decoded, err := decodeAddress(address, n.Params)
+	if err != nil || !decoded.IsForNet(n.Params) {
+		return false
+	}
+	// DecodeAddress also accepts raw hex public keys, which are not addresses
+	_, isPubKey := decoded.(*btcutil.AddressPubKey)
+	return !isPubKey
+}
+
+var errUnknownWitnessProgram = errors.New("unknown witness program")
+
+// decodeAddress decodes an address against params. btcutil.DecodeAddress only recognizes the bech32 prefixes of
+// networks registered with chaincfg, so segwit addresses are decoded here with the prefix of params.
+func decodeAddress(address string, params *chaincfg.Params) (btcutil.Address, error) {
+	if params.Bech32HRPSegwit == "" || !strings.HasPrefix(strings.ToLower(address), params.Bech32HRPSegwit+"1") {
+		return btcutil.DecodeAddress(address, params)
+	}
+
+	_, data, encoding, err := bech32.DecodeGeneric(address)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 1 {
+		return nil, errUnknownWitnessProgram
+	}
+	// Witness version 0 uses bech32, later versions bech32m
+	version := data[0]
+	if (version == 0) != (encoding == bech32.Version0) {
+		return nil, errUnknownWitnessProgram
+	}
+	program, err := bech32.ConvertBits(data[1:], 5, 8, false)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case version == 0 && len(program) == 20:
+		return btcutil.NewAddressWitnessPubKeyHash(program, params)
+	case version == 0 && len(program) == 32:
+		return btcutil.NewAddressWitnessScriptHash(program, params)
+	case version == 1 && len(program) == 32:
+		return btcutil.NewAddressTaproot(program, params)
+	}
+	return nil, errUnknownWitnessProgram
+}
+
+// ToUnits converts an amount in the smallest unit, e.g. satoshis, to coins
+func (n Network) ToUnits(amount int64) float64 {
+	return float64(amount) / pow10(n.Decimals)
+}
+
+// FormatAmount formats an amount in the smallest unit with the coin's decimals
+func (n Network) FormatAmount(amount int64) string {
+	return fmt.Sprintf("%.*f", n.Decimals, n.ToUnits(amount))
+}
+
+// quote prepares an amount in the smallest unit for valuation, coins without a price are left unvalued
+func (n Network) quote(amount int64, at time.Time) *models.Quote {
+	if n.PriceID == "" {
+		return nil
+	}
+	return &models.Quote{CoinID: n.PriceID, Amount: n.ToUnits(amount), At: at}
+}
+
+func pow10(n int) float64 {
+	result := 1.0
+	for i := 0; i < n; i++ {
+		result *= 10
+	}
+	return result
+}
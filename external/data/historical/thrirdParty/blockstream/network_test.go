@@ -0,0 +1,115 @@
+package blockstream
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/tashunc/nugenesis-wallet-backend/external/data/historical"
+	"github.com/tashunc/nugenesis-wallet-backend/external/models/general"
+)
+
+func encode(address btcutil.Address, err error) string {
+	if err != nil {
+		panic(err)
+	}
+	return address.EncodeAddress()
+}
+
+func TestValidAddressFollowsNetworkRules(t *testing.T) {
+	hash := make([]byte, 20)
+	litecoinLegacy := encode(btcutil.NewAddressPubKeyHash(hash, &LitecoinParams))
+	litecoinSegwit := encode(btcutil.NewAddressWitnessPubKeyHash(hash, &LitecoinParams))
+	dogecoin := encode(btcutil.NewAddressPubKeyHash(hash, &DogecoinParams))
+	testnetSegwit := encode(btcutil.NewAddressWitnessPubKeyHash(hash, &chaincfg.TestNet3Params))
+
+	bitcoin, _ := NetworkFor(NetworkID{CoinType: general.Bitcoin, Net: Mainnet})
+	testnet, _ := NetworkFor(NetworkID{CoinType: general.Bitcoin, Net: Testnet})
+	litecoin, _ := NetworkFor(NetworkID{CoinType: general.Litecoin, Net: Mainnet})
+	litecoinTaproot := encode(btcutil.NewAddressTaproot(make([]byte, 32), &LitecoinParams))
+	tests := []struct {
+		network Network
+		address string
+		valid   bool
+	}{
+		{bitcoin, "bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu", true},
+		{bitcoin, testnetSegwit, false},
+		{testnet, testnetSegwit, true},
+		{litecoin, litecoinLegacy, true},
+		{litecoin, litecoinSegwit, true},
+		{litecoin, litecoinTaproot, true},
+		{litecoin, "ltc1qqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqq", false},
+		{testnet, litecoinSegwit, false},
+		{litecoin, "bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu", false},
+		{litecoin, dogecoin, false},
+		{bitcoin, "0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798", false},
+	}
+	for _, tt := range tests {
+		if valid := tt.network.ValidAddress(tt.address); valid != tt.valid {
+			t.Errorf("%s: expected %s valid=%v", tt.network.Name, tt.address, tt.valid)
+		}
+	}
+}
+
+func TestHistoryProviderAgainstEsplora(t *testing.T) {
+	address := encode(btcutil.NewAddressWitnessPubKeyHash(make([]byte, 20), &LitecoinParams))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/address/"+address+"/txs" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`[{"txid":"abc","fee":1000,"status":{"confirmed":true,"block_height":10,"block_time":1700000000},
+			"vin":[{"prevout":{"scriptpubkey_address":"other","value":260000000}}],
+			"vout":[{"scriptpubkey_address":"` + address + `","value":250000000}]}]`))
+	}))
+	defer server.Close()
+
+	litecoin, _ := NetworkFor(NetworkID{CoinType: general.Litecoin, Net: Mainnet})
+	litecoin.BaseURL = server.URL
+	litecoin.PriceID = ""
+	provider := NewController(litecoin).HistoryProvider()
+
+	page, err := provider.GetHistory(context.Background(), historical.HistoryRequest{Address: address, Currency: "usd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Transactions) != 1 || page.Cursor != "" {
+		t.Fatalf("Expected a single page with one transaction, got %+v", page)
+	}
+	if tx := page.Transactions[0]; tx.Type != "receive" || tx.Token != "LTC" || tx.Amount != "2.50000000" {
+		t.Errorf("Unexpected transaction %+v", tx)
+	}
+
+	if _, err := provider.GetHistory(context.Background(), historical.HistoryRequest{Address: "bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu"}); err != ErrInvalidAddress {
+		t.Errorf("Expected a Bitcoin address to be rejected on Litecoin, got %v", err)
+	}
+}
+
+func TestNetworkForUsesConfiguredURL(t *testing.T) {
+	t.Setenv("ESPLORA_DOGECOIN_URL", "")
+	if _, ok := NetworkFor(NetworkID{CoinType: general.Dogecoin, Net: Mainnet}); ok {
+		t.Error("Expected Dogecoin to need a configured API")
+	}
+
+	t.Setenv("ESPLORA_DOGECOIN_URL", "https://esplora.example/doge/api")
+	if network, ok := NetworkFor(NetworkID{CoinType: general.Dogecoin, Net: Mainnet}); !ok || network.BaseURL != "https://esplora.example/doge/api" {
+		t.Errorf("Expected the configured Dogecoin API, got %+v", network)
+	}
+}
+
+func TestNetworksKeepTheCoinTypeOfTheirMainnet(t *testing.T) {
+	if chaincfg.IsBech32SegwitPrefix(LitecoinParams.Bech32HRPSegwit + "1") {
+		t.Error("Expected the Litecoin prefix to stay out of the global chaincfg registry")
+	}
+
+	signet, ok := NetworkFor(NetworkID{CoinType: general.Bitcoin, Net: Signet})
+	if !ok || signet.CoinType != general.Bitcoin || signet.Params != &chaincfg.SigNetParams {
+		t.Errorf("Expected signet under the Bitcoin coin type, got %+v", signet)
+	}
+	if _, ok := NetworkFor(NetworkID{CoinType: general.BitcoinCash, Net: Mainnet}); ok {
+		t.Error("Expected no Esplora network for Bitcoin Cash")
+	}
+}
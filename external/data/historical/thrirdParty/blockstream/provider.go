@@ -0,0 +1,81 @@
+package blockstream
+
+import (
+	"context"
+
+	"github.com/tashunc/nugenesis-wallet-backend/external/data/historical"
+	"github.com/tashunc/nugenesis-wallet-backend/external/data/historical/thrirdParty/blockstream/blockstream_models"
+	"github.com/tashunc/nugenesis-wallet-backend/external/models"
+)
+
+const (
+	ProviderName = "blockstream"
+	// ChainPageSize is the fixed number of confirmed transactions Esplora returns per page
+	ChainPageSize = 25
+)
+
+// HistoryProvider serves the address history of an Esplora network through historical.HistoryProvider
+type HistoryProvider struct {
+	service *Service
+}
+
+// HistoryProvider returns a history provider sharing the controller's service
+func (c *Controller) HistoryProvider() *HistoryProvider {
+	return &HistoryProvider{service: c.service}
+}
+
+func (p *HistoryProvider) Name() string {
+	return ProviderName
+}
+
+// GetHistory uses the last seen confirmed txid as cursor. Esplora has a fixed page size, so request.Limit is ignored.
+func (p *HistoryProvider) GetHistory(ctx context.Context, request historical.HistoryRequest) (*historical.HistoryPage, error) {
+	if !p.service.network.ValidAddress(request.Address) {
+		return nil, ErrInvalidAddress
+	}
+
+	rawTransactions, err := p.service.GetAddressTransactionsPage(ctx, request.Address, request.Cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	transactions := make([]models.Transaction, 0, len(*rawTransactions))
+	confirmed := 0
+	lastConfirmedTxid := ""
+	for _, tx := range *rawTransactions {
+		transactions = append(transactions, toTransaction(mapSingleTransaction(tx, request.Address, p.service.network)))
+		if tx.Status.Confirmed {
+			confirmed++
+			lastConfirmedTxid = tx.Txid
+		}
+	}
+
+	// A short page of confirmed transactions means the end of the address history
+	cursor := ""
+	if confirmed >= ChainPageSize {
+		cursor = lastConfirmedTxid
+	}
+
+	return &historical.HistoryPage{
+		Transactions: transactions,
+		Cursor:       cursor,
+	}, nil
+}
+
+func toTransaction(tx blockstream_models.StandardizedTransaction) models.Transaction {
+	return models.Transaction{
+		ID:         tx.ID,
+		Type:       tx.Type,
+		Status:     tx.Status,
+		Token:      tx.Token,
+		Amount:     tx.Amount,
+		Value:      tx.Value,
+		Address:    tx.Address,
+		Date:       tx.Date,
+		Time:       tx.Time,
+		Fee:        tx.Fee,
+		Hash:       tx.Hash,
+		ValueQuote: tx.ValueQuote,
+		FeeQuote:   tx.FeeQuote,
+	}
+}
@@ -1,37 +1,57 @@
 package blockstream
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/tashunc/nugenesis-wallet-backend/external/data/historical/thrirdParty/blockstream/blockstream_models"
+	"github.com/tashunc/nugenesis-wallet-backend/pkg/httpclient"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 )
 
 const (
+	// BaseURL is the Bitcoin mainnet Esplora API of Blockstream
 	BaseURL = "https://blockstream.info/api"
 	Timeout = 30 * time.Second
 )
 
+// Service is a client of an Esplora API, Blockstream's for Bitcoin and any compatible server for the other networks
 type Service struct {
+	network Network
 	baseURL string
 	client  *http.Client
 }
 
-func NewService() *Service {
+func NewService(network Network) *Service {
 	return &Service{
-		baseURL: BaseURL,
-		client: &http.Client{
-			Timeout: Timeout,
-		},
+		network: network,
+		baseURL: strings.TrimSuffix(network.BaseURL, "/"),
+		client: httpclient.New(httpclient.Config{
+			Name:          "esplora-" + network.Name,
+			Timeout:       Timeout,
+			RatePerSecond: 10,
+			Burst:         10,
+		}),
 	}
 }
 
-func (s *Service) GetAddressTransactions(address string) (*blockstream_models.AddressTransactionsResponse, error) {
+// Network returns the chain the service reads
+func (s *Service) Network() Network {
+	return s.network
+}
+
+// GetAddressTransactionsPage returns mempool and the first confirmed transactions when lastSeenTxid is empty,
+// otherwise the next page of confirmed transactions after lastSeenTxid
+func (s *Service) GetAddressTransactionsPage(ctx context.Context, address string, lastSeenTxid string) (*blockstream_models.AddressTransactionsResponse, error) {
 	url := fmt.Sprintf("%s/address/%s/txs", s.baseURL, address)
+	if lastSeenTxid != "" {
+		url = fmt.Sprintf("%s/address/%s/txs/chain/%s", s.baseURL, address, lastSeenTxid)
+	}
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -65,12 +85,175 @@ func (s *Service) GetAddressTransactions(address string) (*blockstream_models.Ad
 	return &response, nil
 }
 
-func (s *Service) GetAddressTransactionsStandardized(address string) (*blockstream_models.StandardizedTransactionsResponse, error) {
-	rawTransactions, err := s.GetAddressTransactions(address)
+// BroadcastTransaction submits a raw transaction in hex. A rejection by the node is returned in the response, not as an error.
+func (s *Service) BroadcastTransaction(rawTx string) (*blockstream_models.BroadcastResponse, error) {
+	url := fmt.Sprintf("%s/tx", s.baseURL)
+
+	resp, err := s.client.Post(url, "text/plain", strings.NewReader(rawTx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to broadcast transaction: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var broadcastResp blockstream_models.BroadcastResponse
+	if resp.StatusCode == http.StatusOK {
+		broadcastResp.Txid = strings.TrimSpace(string(body))
+	} else {
+		broadcastResp.Error = strings.TrimSpace(string(body))
+	}
+	return &broadcastResp, nil
+}
+
+// GetAddressInfo returns the confirmed and mempool funding totals of an address
+func (s *Service) GetAddressInfo(ctx context.Context, address string) (*blockstream_models.AddressInfo, error) {
+	url := fmt.Sprintf("%s/address/%s", s.baseURL, address)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer func(Body io.ReadCloser) {
+		err := Body.Close()
+		if err != nil {
+			fmt.Printf("failed to close response body: %v\n", err)
+		}
+	}(resp.Body)
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var info blockstream_models.AddressInfo
+	if err := json.Unmarshal(body, &info); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return &info, nil
+}
+
+// GetAddressUTXOs returns the confirmed and mempool unspent outputs of an address
+func (s *Service) GetAddressUTXOs(ctx context.Context, address string) ([]blockstream_models.UTXO, error) {
+	url := fmt.Sprintf("%s/address/%s/utxo", s.baseURL, address)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer func(Body io.ReadCloser) {
+		err := Body.Close()
+		if err != nil {
+			fmt.Printf("failed to close response body: %v\n", err)
+		}
+	}(resp.Body)
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var utxos []blockstream_models.UTXO
+	if err := json.Unmarshal(body, &utxos); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return utxos, nil
+}
+
+// GetFeeEstimates returns the fee rates expected to confirm within each target number of blocks
+func (s *Service) GetFeeEstimates() (blockstream_models.FeeEstimates, error) {
+	var estimates blockstream_models.FeeEstimates
+	if err := s.getJSON(fmt.Sprintf("%s/fee-estimates", s.baseURL), &estimates); err != nil {
+		return nil, err
+	}
+	return estimates, nil
+}
+
+// GetMempoolStats returns the size of the mempool backlog and its fee rate histogram
+func (s *Service) GetMempoolStats() (*blockstream_models.MempoolStats, error) {
+	var stats blockstream_models.MempoolStats
+	if err := s.getJSON(fmt.Sprintf("%s/mempool", s.baseURL), &stats); err != nil {
 		return nil, err
 	}
+	return &stats, nil
+}
+
+func (s *Service) getJSON(url string, target interface{}) error {
+	resp, err := s.client.Get(url)
+	if err != nil {
+		return fmt.Errorf("failed to make request: %w", err)
+	}
+	defer func(Body io.ReadCloser) {
+		err := Body.Close()
+		if err != nil {
+			fmt.Printf("failed to close response body: %v\n", err)
+		}
+	}(resp.Body)
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
 
-	standardizedResponse := MapToStandardizedTransactions(rawTransactions, address)
-	return standardizedResponse, nil
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	if err := json.Unmarshal(body, target); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return nil
+}
+
+// GetTransactionHex returns a confirmed or mempool transaction in raw hex
+func (s *Service) GetTransactionHex(txid string) (string, error) {
+	url := fmt.Sprintf("%s/tx/%s/hex", s.baseURL, txid)
+
+	resp, err := s.client.Get(url)
+	if err != nil {
+		return "", fmt.Errorf("failed to make request: %w", err)
+	}
+	defer func(Body io.ReadCloser) {
+		err := Body.Close()
+		if err != nil {
+			fmt.Printf("failed to close response body: %v\n", err)
+		}
+	}(resp.Body)
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
+	}
+	return strings.TrimSpace(string(body)), nil
 }
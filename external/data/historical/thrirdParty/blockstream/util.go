@@ -1,27 +1,14 @@
 package blockstream
 
 import (
-	"fmt"
+	"context"
+	"errors"
+	"github.com/tashunc/nugenesis-wallet-backend/external/data/fmv/pricing"
 	"github.com/tashunc/nugenesis-wallet-backend/external/data/historical/thrirdParty/blockstream/blockstream_models"
-	"regexp"
-	"strings"
 	"time"
 )
 
-func ValidateBitcoinAddress(address string) bool {
-	if address == "" {
-		return false
-	}
-
-	p2pkhPattern := regexp.MustCompile(`^[13][a-km-zA-HJ-NP-Z1-9]{25,34}$`)
-	bech32Pattern := regexp.MustCompile(`^bc1[a-z0-9]{39,59}$`)
-
-	return p2pkhPattern.MatchString(address) || bech32Pattern.MatchString(address)
-}
-
-func FormatSatoshisToBTC(satoshis int64) float64 {
-	return float64(satoshis) / 100000000
-}
+var ErrInvalidAddress = errors.New("invalid address format for the network")
 
 func TruncateAddress(addr string) string {
 	if len(addr) <= 20 {
@@ -30,32 +17,7 @@ func TruncateAddress(addr string) string {
 	return addr[:10] + "..." + addr[len(addr)-6:]
 }
 
-func IsSegwitAddress(address string) bool {
-	return strings.HasPrefix(address, "bc1") || strings.HasPrefix(address, "tb1")
-}
-
-func MapToStandardizedTransactions(rawTransactions *blockstream_models.AddressTransactionsResponse, targetAddress string) *blockstream_models.StandardizedTransactionsResponse {
-	if rawTransactions == nil {
-		return &blockstream_models.StandardizedTransactionsResponse{
-			Transactions: []blockstream_models.StandardizedTransaction{},
-			TotalCount:   0,
-		}
-	}
-
-	var standardizedTxs []blockstream_models.StandardizedTransaction
-
-	for _, tx := range *rawTransactions {
-		standardizedTx := mapSingleTransaction(tx, targetAddress)
-		standardizedTxs = append(standardizedTxs, standardizedTx)
-	}
-
-	return &blockstream_models.StandardizedTransactionsResponse{
-		Transactions: standardizedTxs,
-		TotalCount:   len(standardizedTxs),
-	}
-}
-
-func mapSingleTransaction(tx blockstream_models.TransactionResponse, targetAddress string) blockstream_models.StandardizedTransaction {
+func mapSingleTransaction(tx blockstream_models.TransactionResponse, targetAddress string, network Network) blockstream_models.StandardizedTransaction {
 	txType := determineTxType(tx, targetAddress)
 	status := "completed"
 	if !tx.Status.Confirmed {
@@ -66,22 +28,23 @@ func mapSingleTransaction(tx blockstream_models.TransactionResponse, targetAddre
 
 	date, timeStr := formatDateTime(tx.Status.BlockTime)
 
-	fee := fmt.Sprintf("$%.2f", calculateFeeInUSD(tx.Fee))
-
-	value := fmt.Sprintf("$%.2f", amount*getBTCPriceUSD())
-
+	// Value at block time, unconfirmed transactions are valued at the spot price
+	var valuedAt time.Time
+	if tx.Status.BlockTime != nil {
+		valuedAt = time.Unix(*tx.Status.BlockTime, 0)
+	}
 	return blockstream_models.StandardizedTransaction{
-		ID:      tx.Txid,
-		Type:    txType,
-		Status:  status,
-		Token:   "BTC",
-		Amount:  fmt.Sprintf("%.8f", amount),
-		Value:   value,
-		Address: TruncateAddress(recipientAddr),
-		Date:    date,
-		Time:    timeStr,
-		Fee:     fee,
-		Hash:    TruncateAddress(tx.Txid),
+		ID:         tx.Txid,
+		Type:       txType,
+		Status:     status,
+		Token:      network.Symbol,
+		Amount:     network.FormatAmount(amount),
+		Address:    TruncateAddress(recipientAddr),
+		Date:       date,
+		Time:       timeStr,
+		Hash:       TruncateAddress(tx.Txid),
+		ValueQuote: network.quote(amount, valuedAt),
+		FeeQuote:   network.quote(int64(tx.Fee), valuedAt),
 	}
 }
 
@@ -101,7 +64,8 @@ func determineTxType(tx blockstream_models.TransactionResponse, targetAddress st
 	return "send"
 }
 
-func calculateAmountAndAddress(tx blockstream_models.TransactionResponse, targetAddress string, txType string) (float64, string) {
+// calculateAmountAndAddress returns the amount in the smallest unit and the counterparty address
+func calculateAmountAndAddress(tx blockstream_models.TransactionResponse, targetAddress string, txType string) (int64, string) {
 	var amount int64 = 0
 	var address string = ""
 
@@ -122,7 +86,7 @@ func calculateAmountAndAddress(tx blockstream_models.TransactionResponse, target
 		}
 	}
 
-	return FormatSatoshisToBTC(amount), address
+	return amount, address
 }
 
 func formatDateTime(blockTime *int64) (string, string) {
@@ -135,11 +99,19 @@ func formatDateTime(blockTime *int64) (string, string) {
 	return t.Format("2006-01-02"), t.Format("15:04")
 }
 
-func calculateFeeInUSD(feeInSatoshis int) float64 {
-	feeBTC := FormatSatoshisToBTC(int64(feeInSatoshis))
-	return feeBTC * getBTCPriceUSD()
-}
+// fillValues prices the quoted values and fees of standardized transactions, plus any extra valuations
+func fillValues(ctx context.Context, transactions []blockstream_models.StandardizedTransaction, currency string, extra ...pricing.Valuation) {
+	valuations := extra
+	for i := range transactions {
+		if quote := transactions[i].ValueQuote; quote != nil {
+			valuations = append(valuations, pricing.Valuation{Target: &transactions[i].Value, Quote: quote})
+		}
+		if quote := transactions[i].FeeQuote; quote != nil {
+			valuations = append(valuations, pricing.Valuation{Target: &transactions[i].Fee, Quote: quote})
+		}
+	}
 
-func getBTCPriceUSD() float64 {
-	return 45000.0
+	ctx, cancel := context.WithTimeout(ctx, pricing.ValuationTimeout)
+	defer cancel()
+	pricing.GetPriceService().Fill(ctx, valuations, currency)
 }
@@ -1,11 +1,16 @@
 package etherscan_models
 
+import "encoding/json"
+
+// AddressResponse wraps every account action, Result is a message string instead of a list on errors
 type AddressResponse struct {
-	Status  string    `json:"status"`
-	Message string    `json:"message"`
-	Result  []TxEntry `json:"result"`
+	Status  string          `json:"status"`
+	Message string          `json:"message"`
+	Result  json.RawMessage `json:"result"`
 }
 
+// TxEntry is one row of txlist, txlistinternal, tokentx, tokennfttx or token1155tx, the token fields
+// are only set by the token actions
 type TxEntry struct {
 	BlockNumber       string `json:"blockNumber"`
 	TimeStamp         string `json:"timeStamp"`
@@ -21,4 +26,14 @@ type TxEntry struct {
 	CumulativeGasUsed string `json:"cumulativeGasUsed"`
 	TxReceiptStatus   string `json:"txreceipt_status"`
 	Input             string `json:"input"`
+	TransactionIndex  string `json:"transactionIndex"`
+	LogIndex          string `json:"logIndex"`
+	TraceID           string `json:"traceId"`
+	TokenName         string `json:"tokenName"`
+	TokenSymbol       string `json:"tokenSymbol"`
+	TokenDecimal      string `json:"tokenDecimal"`
+	TokenID           string `json:"tokenID"`
+	TokenValue        string `json:"tokenValue"`
+	// Category is the transfer kind of the action the entry came from, e.g. external or erc20
+	Category string `json:"-"`
 }
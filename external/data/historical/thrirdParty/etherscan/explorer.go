@@ -0,0 +1,90 @@
+package etherscan
+
+import (
+	"os"
+
+	"github.com/tashunc/nugenesis-wallet-backend/external/models/general"
+)
+
+// V2BaseURL is the Etherscan multichain API, one ETHERSCAN_API_KEY serves every chain selected with chainid
+const V2BaseURL = "https://api.etherscan.io/v2/api"
+
+// NativeToken is the gas token of an explorer's chain
+type NativeToken struct {
+	Symbol   string
+	Decimals int
+}
+
+// Explorer is a resolved Etherscan-family API for one chain
+type Explorer struct {
+	// Name identifies the explorer in logs, e.g. bscscan or etherscan-v2
+	Name    string
+	BaseURL string
+	APIKey  string
+	// ChainID is sent as chainid to the V2 API, it is zero for chain specific explorers
+	ChainID int64
+	Native  NativeToken
+}
+
+// explorerChain describes where the history of a chain can be read
+type explorerChain struct {
+	name      string
+	chainID   int64
+	legacyURL string
+	// keyEnv holds the API key of the chain's own explorer, which takes precedence over the V2 API
+	keyEnv string
+	native NativeToken
+}
+
+var ether = NativeToken{Symbol: "ETH", Decimals: 18}
+
+var explorerChains = map[general.CoinType]explorerChain{
+	general.Ethereum:        {name: "etherscan", chainID: 1, legacyURL: "https://api.etherscan.io/api", native: ether},
+	general.Binance:         {name: "bscscan", chainID: 56, legacyURL: "https://api.bscscan.com/api", keyEnv: "BSCSCAN_API_KEY", native: NativeToken{Symbol: "BNB", Decimals: 18}},
+	general.SmartChain:      {name: "bscscan", chainID: 56, legacyURL: "https://api.bscscan.com/api", keyEnv: "BSCSCAN_API_KEY", native: NativeToken{Symbol: "BNB", Decimals: 18}},
+	general.OpBNB:           {name: "opbnbscan", chainID: 204, legacyURL: "https://api-opbnb.bscscan.com/api", keyEnv: "OPBNBSCAN_API_KEY", native: NativeToken{Symbol: "BNB", Decimals: 18}},
+	general.Polygon:         {name: "polygonscan", chainID: 137, legacyURL: "https://api.polygonscan.com/api", keyEnv: "POLYGONSCAN_API_KEY", native: NativeToken{Symbol: "POL", Decimals: 18}},
+	general.PolygonzkEVM:    {name: "zkevm-polygonscan", chainID: 1101, legacyURL: "https://api-zkevm.polygonscan.com/api", keyEnv: "POLYGONZKEVMSCAN_API_KEY", native: ether},
+	general.Arbitrum:        {name: "arbiscan", chainID: 42161, legacyURL: "https://api.arbiscan.io/api", keyEnv: "ARBISCAN_API_KEY", native: ether},
+	general.ArbitrumNova:    {name: "nova-arbiscan", chainID: 42170, legacyURL: "https://api-nova.arbiscan.io/api", keyEnv: "NOVA_ARBISCAN_API_KEY", native: ether},
+	general.Optimism:        {name: "optimistic-etherscan", chainID: 10, legacyURL: "https://api-optimistic.etherscan.io/api", keyEnv: "OPTIMISTIC_ETHERSCAN_API_KEY", native: ether},
+	general.Base:            {name: "basescan", chainID: 8453, legacyURL: "https://api.basescan.org/api", keyEnv: "BASESCAN_API_KEY", native: ether},
+	general.Linea:           {name: "lineascan", chainID: 59144, legacyURL: "https://api.lineascan.build/api", keyEnv: "LINEASCAN_API_KEY", native: ether},
+	general.Scroll:          {name: "scrollscan", chainID: 534352, legacyURL: "https://api.scrollscan.com/api", keyEnv: "SCROLLSCAN_API_KEY", native: ether},
+	general.Blast:           {name: "blastscan", chainID: 81457, legacyURL: "https://api.blastscan.io/api", keyEnv: "BLASTSCAN_API_KEY", native: ether},
+	general.AvalancheCChain: {name: "snowscan", chainID: 43114, legacyURL: "https://api.snowscan.xyz/api", keyEnv: "SNOWSCAN_API_KEY", native: NativeToken{Symbol: "AVAX", Decimals: 18}},
+	general.Fantom:          {name: "ftmscan", chainID: 250, legacyURL: "https://api.ftmscan.com/api", keyEnv: "FTMSCAN_API_KEY", native: NativeToken{Symbol: "FTM", Decimals: 18}},
+	general.Celo:            {name: "celoscan", chainID: 42220, legacyURL: "https://api.celoscan.io/api", keyEnv: "CELOSCAN_API_KEY", native: NativeToken{Symbol: "CELO", Decimals: 18}},
+	general.Mantle:          {name: "mantlescan", chainID: 5000, legacyURL: "https://api.mantlescan.xyz/api", keyEnv: "MANTLESCAN_API_KEY", native: NativeToken{Symbol: "MNT", Decimals: 18}},
+	general.Sonic:           {name: "sonicscan", chainID: 146, legacyURL: "https://api.sonicscan.org/api", keyEnv: "SONICSCAN_API_KEY", native: NativeToken{Symbol: "S", Decimals: 18}},
+}
+
+// ExplorerFor resolves the explorer of a chain. A key for the chain's own explorer selects it, otherwise
+// ETHERSCAN_API_KEY selects the V2 API. ok is false when neither key is set or the chain has no explorer.
+func ExplorerFor(coinType general.CoinType) (Explorer, bool) {
+	chain, exists := explorerChains[coinType]
+	if !exists {
+		return Explorer{}, false
+	}
+
+	if chain.keyEnv != "" {
+		if apiKey := os.Getenv(chain.keyEnv); apiKey != "" {
+			return Explorer{Name: chain.name, BaseURL: chain.legacyURL, APIKey: apiKey, Native: chain.native}, true
+		}
+	}
+	if apiKey := os.Getenv("ETHERSCAN_API_KEY"); apiKey != "" {
+		return Explorer{Name: "etherscan-v2", BaseURL: V2BaseURL, APIKey: apiKey, ChainID: chain.chainID, Native: chain.native}, true
+	}
+	return Explorer{}, false
+}
+
+// ConfiguredExplorers returns the explorer of every chain an API key is available for
+func ConfiguredExplorers() map[general.CoinType]Explorer {
+	explorers := make(map[general.CoinType]Explorer)
+	for coinType := range explorerChains {
+		if explorer, ok := ExplorerFor(coinType); ok {
+			explorers[coinType] = explorer
+		}
+	}
+	return explorers
+}
@@ -0,0 +1,142 @@
+package etherscan
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/tashunc/nugenesis-wallet-backend/external/data/historical"
+	"github.com/tashunc/nugenesis-wallet-backend/external/data/historical/thrirdParty/etherscan/etherscan_models"
+)
+
+// feedActions are merged into one feed, entries of the same position keep this order
+var feedActions = []string{ActionTxList, ActionTxListInternal, ActionTokenTx, ActionTokenNFTTx, ActionToken1155Tx}
+
+const (
+	maxPageSize = 1000
+	// resultWindow is how deep the explorers page, page * offset may not exceed it
+	resultWindow = 10000
+	// noEndBlock is the EndBlock of a listing that is not restricted to a block range
+	noEndBlock = -1
+)
+
+// ErrResultWindowExceeded means a single block holds more entries than the explorer can page through
+var ErrResultWindowExceeded = errors.New("history is deeper than the explorer can page through")
+
+// actionPage is the page token of an action stream. Listings are paged by page number until the result window,
+// then restarted at the last block read with EndBlock, dropping the entries of that block already served.
+type actionPage struct {
+	Page     int
+	EndBlock int64
+	// Drop is how many entries at the start of Page were served before the listing was restarted
+	Drop int
+	// RunBlock and RunCount are the last block of the listing so far and how many of its entries were read
+	RunBlock int64
+	RunCount int
+}
+
+func firstActionPage() actionPage {
+	return actionPage{Page: 1, EndBlock: noEndBlock, RunBlock: noEndBlock}
+}
+
+func (p actionPage) String() string {
+	return fmt.Sprintf("%d:%d:%d:%d:%d", p.Page, p.EndBlock, p.Drop, p.RunBlock, p.RunCount)
+}
+
+func parseActionPage(token string) (actionPage, error) {
+	if token == "" {
+		return firstActionPage(), nil
+	}
+	parts := strings.Split(token, ":")
+	if len(parts) != 5 {
+		return actionPage{}, fmt.Errorf("%w: malformed explorer page", historical.ErrInvalidCursor)
+	}
+	var numbers [5]int64
+	for i, part := range parts {
+		n, err := strconv.ParseInt(part, 10, 64)
+		if err != nil {
+			return actionPage{}, fmt.Errorf("%w: malformed explorer page", historical.ErrInvalidCursor)
+		}
+		numbers[i] = n
+	}
+	page := actionPage{Page: int(numbers[0]), EndBlock: numbers[1], Drop: int(numbers[2]), RunBlock: numbers[3], RunCount: int(numbers[4])}
+	if page.Page < 1 || page.Drop < 0 || page.RunCount < 0 {
+		return actionPage{}, fmt.Errorf("%w: malformed explorer page", historical.ErrInvalidCursor)
+	}
+	return page, nil
+}
+
+// actionFetcher returns one page of an action newest first, restricted to blocks up to endBlock unless it is noEndBlock
+type actionFetcher func(page int, pageSize int, endBlock int64) ([]etherscan_models.TxEntry, error)
+
+// actionStream adapts an account action to the shared merge paginator
+func actionStream(action string, fetch actionFetcher) *historical.Stream[etherscan_models.TxEntry] {
+	return &historical.Stream[etherscan_models.TxEntry]{
+		Name: action,
+		Fetch: func(token string, pageSize int) (historical.StreamPage[etherscan_models.TxEntry], error) {
+			position, err := parseActionPage(token)
+			if err != nil {
+				return historical.StreamPage[etherscan_models.TxEntry]{}, err
+			}
+			return fetchActionPage(fetch, position, pageSize)
+		},
+	}
+}
+
+func fetchActionPage(fetch actionFetcher, position actionPage, pageSize int) (historical.StreamPage[etherscan_models.TxEntry], error) {
+	var page historical.StreamPage[etherscan_models.TxEntry]
+	if position.Page*pageSize > resultWindow {
+		return page, ErrResultWindowExceeded
+	}
+	entries, err := fetch(position.Page, pageSize, position.EndBlock)
+	if err != nil {
+		return page, err
+	}
+
+	runBlock, runCount := position.RunBlock, position.RunCount
+	for _, entry := range entries {
+		block, _ := strconv.ParseInt(entry.BlockNumber, 10, 64)
+		if block == runBlock {
+			runCount++
+		} else {
+			runBlock, runCount = block, 1
+		}
+	}
+	page.Items = entries[min(position.Drop, len(entries)):]
+
+	// A short page is the last one
+	if len(entries) < pageSize {
+		return page, nil
+	}
+	next := actionPage{Page: position.Page + 1, EndBlock: position.EndBlock, RunBlock: runBlock, RunCount: runCount}
+	if next.Page*pageSize > resultWindow {
+		// Restart the listing at the last block read. Its entries come first, so the ones already read are
+		// skipped by starting at their page and dropping the rest of them from it.
+		next = actionPage{
+			Page:     runCount/pageSize + 1,
+			EndBlock: runBlock,
+			Drop:     runCount % pageSize,
+			RunBlock: runBlock,
+			RunCount: runCount / pageSize * pageSize,
+		}
+	}
+	page.Next = next.String()
+	return page, nil
+}
+
+// entryBefore orders entries newest first by block, transaction index and log index
+func entryBefore(a, b etherscan_models.TxEntry) bool {
+	for _, field := range [][2]string{
+		{a.BlockNumber, b.BlockNumber},
+		{a.TransactionIndex, b.TransactionIndex},
+		{a.LogIndex, b.LogIndex},
+	} {
+		x, _ := strconv.ParseInt(field[0], 10, 64)
+		y, _ := strconv.ParseInt(field[1], 10, 64)
+		if x != y {
+			return x > y
+		}
+	}
+	return false
+}
@@ -0,0 +1,138 @@
+package etherscan
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/tashunc/nugenesis-wallet-backend/external/data/historical"
+	"github.com/tashunc/nugenesis-wallet-backend/external/data/historical/thrirdParty/etherscan/etherscan_models"
+	"github.com/tashunc/nugenesis-wallet-backend/external/models/general"
+)
+
+func entry(block int, log int) etherscan_models.TxEntry {
+	return etherscan_models.TxEntry{
+		BlockNumber: fmt.Sprint(block),
+		LogIndex:    fmt.Sprint(log),
+		Hash:        fmt.Sprintf("%d/%d", block, log),
+	}
+}
+
+// pagedFetcher serves entries newest first like the explorers' page, offset and endblock parameters
+func pagedFetcher(entries []etherscan_models.TxEntry) actionFetcher {
+	return func(page int, pageSize int, endBlock int64) ([]etherscan_models.TxEntry, error) {
+		if page*pageSize > resultWindow {
+			return nil, errors.New("result window is too large")
+		}
+		listing := entries
+		if endBlock != noEndBlock {
+			listing = nil
+			for _, entry := range entries {
+				if block, _ := strconv.ParseInt(entry.BlockNumber, 10, 64); block <= endBlock {
+					listing = append(listing, entry)
+				}
+			}
+		}
+		start := min((page-1)*pageSize, len(listing))
+		return listing[start:min(start+pageSize, len(listing))], nil
+	}
+}
+
+// readAction pages through a single action with the merge paginator and returns the hashes it served
+func readAction(t *testing.T, fetch actionFetcher, pageSize int, limit int) ([]string, error) {
+	t.Helper()
+	var seen []string
+	cursor := historical.MergeCursor{PageSize: pageSize}
+	for page := 0; page < 100; page++ {
+		streams := []*historical.Stream[etherscan_models.TxEntry]{actionStream(ActionTokenTx, fetch)}
+		entries, next, err := historical.MergeStreams(streams, cursor, limit, entryBefore)
+		if err != nil {
+			return seen, err
+		}
+		for _, entry := range entries {
+			seen = append(seen, entry.Hash)
+		}
+		if next == "" {
+			return seen, nil
+		}
+		if cursor, err = historical.DecodeMergeCursor(next, maxPageSize); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Fatal("Expected the history to end")
+	return nil, nil
+}
+
+func TestActionStreamPagesPastTheResultWindowByBlockRange(t *testing.T) {
+	// Three entries a block, with one block of more than a page straddling the result window
+	var entries []etherscan_models.TxEntry
+	block := 50000
+	for len(entries) < 9500 {
+		entries = append(entries, entry(block, 2-len(entries)%3))
+		if len(entries)%3 == 0 {
+			block--
+		}
+	}
+	block--
+	for log := 0; log < 1500; log++ {
+		entries = append(entries, entry(block, 2000-log))
+	}
+	for i := 0; i < 1000; i++ {
+		if i%3 == 0 {
+			block--
+		}
+		entries = append(entries, entry(block, 2-i%3))
+	}
+
+	seen, err := readAction(t, pagedFetcher(entries), 1000, 700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(seen) != len(entries) {
+		t.Fatalf("Expected %d entries, got %d", len(entries), len(seen))
+	}
+	for i, entry := range entries {
+		if seen[i] != entry.Hash {
+			t.Fatalf("Expected %s at %d, got %s", entry.Hash, i, seen[i])
+		}
+	}
+}
+
+func TestActionStreamFailsOnABlockDeeperThanTheResultWindow(t *testing.T) {
+	var entries []etherscan_models.TxEntry
+	for log := resultWindow + 10; log > 0; log-- {
+		entries = append(entries, entry(100, log))
+	}
+	if _, err := readAction(t, pagedFetcher(entries), 1000, 1000); !errors.Is(err, ErrResultWindowExceeded) {
+		t.Errorf("Expected ErrResultWindowExceeded, got %v", err)
+	}
+}
+
+func TestParseActionPageRejectsGarbage(t *testing.T) {
+	for _, token := range []string{"1:2", "x:-1:0:-1:0", "0:-1:0:-1:0", "1:-1:-1:-1:0"} {
+		if _, err := parseActionPage(token); !errors.Is(err, historical.ErrInvalidCursor) {
+			t.Errorf("Expected %q to be rejected, got %v", token, err)
+		}
+	}
+}
+
+func TestExplorerFor(t *testing.T) {
+	t.Setenv("ETHERSCAN_API_KEY", "v2-key")
+	t.Setenv("BSCSCAN_API_KEY", "")
+
+	explorer, ok := ExplorerFor(general.Binance)
+	if !ok || explorer.BaseURL != V2BaseURL || explorer.ChainID != 56 || explorer.Native.Symbol != "BNB" {
+		t.Errorf("Expected the V2 API with chainid 56, got %+v", explorer)
+	}
+
+	t.Setenv("BSCSCAN_API_KEY", "bsc-key")
+	explorer, ok = ExplorerFor(general.Binance)
+	if !ok || explorer.BaseURL != "https://api.bscscan.com/api" || explorer.ChainID != 0 || explorer.APIKey != "bsc-key" {
+		t.Errorf("Expected BscScan with its own key, got %+v", explorer)
+	}
+
+	if _, ok := ExplorerFor(general.Solana); ok {
+		t.Error("Expected no explorer for a non EVM chain")
+	}
+}
@@ -0,0 +1,36 @@
+package etherscan
+
+import (
+	"context"
+
+	"github.com/tashunc/nugenesis-wallet-backend/external/data/historical"
+)
+
+const ProviderName = "etherscan"
+
+// HistoryProvider serves EVM address history from an Etherscan-family explorer through historical.HistoryProvider
+type HistoryProvider struct {
+	service *Service
+}
+
+// HistoryProvider returns a history provider sharing the controller's service
+func (c *Controller) HistoryProvider() *HistoryProvider {
+	return &HistoryProvider{service: c.service}
+}
+
+func (p *HistoryProvider) Name() string {
+	return ProviderName
+}
+
+// GetHistory merges normal, internal and token transfers, the cursor keeps the page and offset of each action
+func (p *HistoryProvider) GetHistory(ctx context.Context, request historical.HistoryRequest) (*historical.HistoryPage, error) {
+	transactions, cursor, err := p.service.GetAddressHistory(ctx, request.Address, request.Limit, request.Cursor, request.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	return &historical.HistoryPage{
+		Transactions: transactions,
+		Cursor:       cursor,
+	}, nil
+}
@@ -1,57 +1,154 @@
 package etherscan
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"github.com/tashunc/nugenesis-wallet-backend/external/data/historical"
 	"github.com/tashunc/nugenesis-wallet-backend/external/data/historical/thrirdParty/etherscan/etherscan_models"
+	"github.com/tashunc/nugenesis-wallet-backend/external/models"
+	"github.com/tashunc/nugenesis-wallet-backend/pkg/httpclient"
 	"io"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 )
 
+const Timeout = 30 * time.Second
+
+// Account actions merged into the history feed, with the transfer category of their entries
+const (
+	ActionTxList         = "txlist"
+	ActionTxListInternal = "txlistinternal"
+	ActionTokenTx        = "tokentx"
+	ActionTokenNFTTx     = "tokennfttx"
+	ActionToken1155Tx    = "token1155tx"
+)
+
+var actionCategories = map[string]string{
+	ActionTxList:         "external",
+	ActionTxListInternal: "internal",
+	ActionTokenTx:        "erc20",
+	ActionTokenNFTTx:     "erc721",
+	ActionToken1155Tx:    "erc1155",
+}
+
 type Service struct {
-	apiKey  string
-	baseURL string
-	client  *http.Client
+	explorer Explorer
+	client   *http.Client
 }
 
-func NewService(apiKey string) *Service {
+func NewService(explorer Explorer) *Service {
 	return &Service{
-		apiKey:  apiKey,
-		baseURL: "https://api.etherscan.io/api",
-		client:  &http.Client{},
+		explorer: explorer,
+		// Free tier allows 5 calls per second
+		client: httpclient.New(httpclient.Config{
+			Name:          explorer.Name,
+			Timeout:       Timeout,
+			RatePerSecond: 5,
+			Burst:         5,
+		}),
 	}
 }
 
-func (s *Service) GetAddressInfo(address string, limit, offset int) (*etherscan_models.AddressResponse, error) {
-	url := fmt.Sprintf(
-		"%s?module=account&action=txlist&address=%s&startblock=0&endblock=99999999&page=%d&offset=%d&sort=desc&apikey=%s",
-		s.baseURL, address, (offset/limit)+1, limit, s.apiKey,
-	)
+// Native returns the gas token of the explorer's chain
+func (s *Service) Native() NativeToken {
+	return s.explorer.Native
+}
+
+// GetTransactions returns one page of an account action for address, newest first. page starts at 1,
+// endBlock restricts the listing to blocks up to it unless it is noEndBlock.
+func (s *Service) GetTransactions(ctx context.Context, action string, address string, page int, pageSize int, endBlock int64) ([]etherscan_models.TxEntry, error) {
+	query := url.Values{}
+	if s.explorer.ChainID != 0 {
+		query.Set("chainid", strconv.FormatInt(s.explorer.ChainID, 10))
+	}
+	query.Set("module", "account")
+	query.Set("action", action)
+	query.Set("address", address)
+	query.Set("page", strconv.Itoa(page))
+	query.Set("offset", strconv.Itoa(pageSize))
+	if endBlock != noEndBlock {
+		query.Set("startblock", "0")
+		query.Set("endblock", strconv.FormatInt(endBlock, 10))
+	}
+	query.Set("sort", "desc")
+	query.Set("apikey", s.explorer.APIKey)
 
-	resp, err := s.client.Get(url)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.explorer.BaseURL+"?"+query.Encode(), nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to request Etherscan: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request %s: %w", s.explorer.Name, err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("Etherscan API returned status %d", resp.StatusCode)
+		return nil, fmt.Errorf("%s API returned status %d", s.explorer.Name, resp.StatusCode)
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read Etherscan response: %w", err)
+		return nil, fmt.Errorf("failed to read %s response: %w", s.explorer.Name, err)
 	}
-	fmt.Printf("Etherscan response body: %s\n", string(body))
 
 	var apiResp etherscan_models.AddressResponse
 	if err := json.Unmarshal(body, &apiResp); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal Etherscan response: %w", err)
+		return nil, fmt.Errorf("failed to unmarshal %s response: %w", s.explorer.Name, err)
 	}
 
+	// An address without entries is reported as status 0, it is not an error for history
 	if apiResp.Status != "1" {
-		return nil, fmt.Errorf("Etherscan API error: %s", apiResp.Message)
+		if strings.HasPrefix(apiResp.Message, "No transactions found") || strings.HasPrefix(apiResp.Message, "No token transfers found") {
+			return nil, nil
+		}
+		var detail string
+		_ = json.Unmarshal(apiResp.Result, &detail)
+		return nil, fmt.Errorf("%s API error: %s %s", s.explorer.Name, apiResp.Message, detail)
 	}
 
-	return &apiResp, nil
+	var entries []etherscan_models.TxEntry
+	if err := json.Unmarshal(apiResp.Result, &entries); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %s %s result: %w", s.explorer.Name, action, err)
+	}
+	for i := range entries {
+		entries[i].Category = actionCategories[action]
+	}
+	return entries, nil
+}
+
+// GetAddressHistory merges every action of the address into one feed of up to limit transactions, newest first,
+// quoted at block time. The returned composite cursor is empty once the history is exhausted.
+func (s *Service) GetAddressHistory(ctx context.Context, address string, limit int, cursor string, currency string) ([]models.Transaction, string, error) {
+	position := historical.MergeCursor{PageSize: min(limit, maxPageSize)}
+	if cursor != "" {
+		decoded, err := historical.DecodeMergeCursor(cursor, maxPageSize)
+		if err != nil {
+			return nil, "", err
+		}
+		position = decoded
+	}
+
+	streams := make([]*historical.Stream[etherscan_models.TxEntry], 0, len(feedActions))
+	for _, action := range feedActions {
+		action := action
+		streams = append(streams, actionStream(action, func(page int, pageSize int, endBlock int64) ([]etherscan_models.TxEntry, error) {
+			return s.GetTransactions(ctx, action, address, page, pageSize, endBlock)
+		}))
+	}
+
+	entries, next, err := historical.MergeStreams(streams, position, limit, entryBefore)
+	if err != nil {
+		return nil, "", err
+	}
+
+	transactions := make([]models.Transaction, 0, len(entries))
+	for _, entry := range entries {
+		transactions = append(transactions, MapTxToTransaction(entry, address, currency, s.explorer.Native))
+	}
+	return transactions, next, nil
 }
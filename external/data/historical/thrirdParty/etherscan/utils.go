@@ -2,28 +2,24 @@ package etherscan
 
 import (
 	"fmt"
+	"github.com/tashunc/nugenesis-wallet-backend/external/data/fmv/pricing"
 	"github.com/tashunc/nugenesis-wallet-backend/external/data/historical/thrirdParty/etherscan/etherscan_models"
 	"github.com/tashunc/nugenesis-wallet-backend/external/models"
+	"math/big"
 	"strconv"
+	"strings"
 	"time"
 )
 
-func MapTxToTransaction(tx etherscan_models.TxEntry, userAddress string) models.Transaction {
+// MapTxToTransaction maps an explorer entry of a chain whose gas token is native, quoting amount and fee at
+// block time. The fee is only reported on the transaction itself when the address paid it.
+func MapTxToTransaction(tx etherscan_models.TxEntry, userAddress string, currency string, native NativeToken) models.Transaction {
 	timestamp, _ := strconv.ParseInt(tx.TimeStamp, 10, 64)
 	txTime := time.Unix(timestamp, 0)
 
-	valueWei, _ := strconv.ParseFloat(tx.Value, 64)
-	ethAmount := valueWei / 1e18
-	usdValue := ethAmount * 3500 // Example ETH to USD rate
-
-	feeWei, _ := strconv.ParseFloat(tx.GasPrice, 64)
-	gasUsed, _ := strconv.ParseFloat(tx.GasUsed, 64)
-	feeETH := (feeWei * gasUsed) / 1e18
-	feeUSD := feeETH * 3500
-
 	txType := "receive"
 	relevantAddress := tx.From
-	if tx.From == userAddress {
+	if strings.EqualFold(tx.From, userAddress) {
 		txType = "send"
 		relevantAddress = tx.To
 	}
@@ -33,19 +29,81 @@ func MapTxToTransaction(tx etherscan_models.TxEntry, userAddress string) models.
 		status = "failed"
 	}
 
+	token := native.Symbol
+	decimals := native.Decimals
+	if tx.TokenSymbol != "" {
+		token = tx.TokenSymbol
+	}
+	if tx.TokenDecimal != "" {
+		decimals, _ = strconv.Atoi(tx.TokenDecimal)
+	}
+
+	var amount float64
+	var valueQuote *models.Quote
+	switch tx.Category {
+	case "erc721", "erc1155":
+		// NFTs have no fungible price
+		amount = 1
+		if tx.TokenValue != "" {
+			amount, _ = strconv.ParseFloat(tx.TokenValue, 64)
+		}
+		token = fmt.Sprintf("%s #%s", token, tx.TokenID)
+	default:
+		amount = scaleAmount(tx.Value, decimals)
+		valueQuote = pricing.Quote(token, amount, txTime)
+	}
+
+	fee := pricing.FormatFiat(0, currency)
+	var feeQuote *models.Quote
+	if tx.Category == "external" && txType == "send" {
+		gasPrice, _ := new(big.Int).SetString(tx.GasPrice, 10)
+		gasUsed, _ := new(big.Int).SetString(tx.GasUsed, 10)
+		if gasPrice != nil && gasUsed != nil {
+			feeAmount := scaleAmount(new(big.Int).Mul(gasPrice, gasUsed).String(), native.Decimals)
+			feeQuote = pricing.Quote(native.Symbol, feeAmount, txTime)
+		}
+	}
+
 	return models.Transaction{
-		ID:      tx.Hash,
-		Type:    txType,
-		Status:  status,
-		Token:   "ETH",
-		Amount:  fmt.Sprintf("%.6f", ethAmount),
-		Value:   fmt.Sprintf("$%.2f", usdValue),
-		Address: truncateAddress(relevantAddress),
-		Date:    txTime.Format("2006-01-02"),
-		Time:    txTime.Format("15:04"),
-		Fee:     fmt.Sprintf("$%.2f", feeUSD),
-		Hash:    truncateHash(tx.Hash),
+		ID:         tx.Hash,
+		Type:       txType,
+		Category:   tx.Category,
+		Status:     status,
+		Token:      token,
+		Amount:     fmt.Sprintf("%.6f", amount),
+		Address:    truncateAddress(relevantAddress),
+		ToAddress:  truncateAddress(tx.To),
+		Date:       txTime.Format("2006-01-02"),
+		Time:       txTime.Format("15:04"),
+		Fee:        fee,
+		Hash:       truncateHash(tx.Hash),
+		Leg:        entryLeg(tx),
+		ValueQuote: valueQuote,
+		FeeQuote:   feeQuote,
+	}
+}
+
+// entryLeg identifies an entry within its transaction. Token transfers are told apart by log index and, for ERC-1155
+// batches sharing a log, token id; internal transfers by trace id.
+func entryLeg(tx etherscan_models.TxEntry) string {
+	switch tx.Category {
+	case "external":
+		return tx.Category
+	case "internal":
+		return tx.Category + ":" + tx.TraceID
+	default:
+		return tx.Category + ":" + tx.LogIndex + ":" + tx.TokenID
+	}
+}
+
+// scaleAmount converts an integer amount in base units to token units
+func scaleAmount(raw string, decimals int) float64 {
+	base, ok := new(big.Int).SetString(raw, 10)
+	if !ok {
+		return 0
 	}
+	amount, _ := new(big.Float).Quo(new(big.Float).SetInt(base), new(big.Float).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil))).Float64()
+	return amount
 }
 
 func truncateAddress(address string) string {
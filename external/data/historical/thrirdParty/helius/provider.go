@@ -0,0 +1,51 @@
+package helius
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tashunc/nugenesis-wallet-backend/external/data/historical"
+	"github.com/tashunc/nugenesis-wallet-backend/external/models"
+)
+
+const ProviderName = "helius"
+
+// HistoryProvider serves Solana address history from Helius through historical.HistoryProvider
+type HistoryProvider struct {
+	service *Service
+}
+
+// HistoryProvider returns a history provider sharing the controller's service
+func (c *Controller) HistoryProvider() *HistoryProvider {
+	return &HistoryProvider{service: c.service}
+}
+
+func (p *HistoryProvider) Name() string {
+	return ProviderName
+}
+
+// GetHistory uses the signature of the oldest transaction of the previous page as the before cursor,
+// a request Until signature stops the history before that transaction
+func (p *HistoryProvider) GetHistory(ctx context.Context, request historical.HistoryRequest) (*historical.HistoryPage, error) {
+	if request.Cursor != "" && !isSignature(request.Cursor) {
+		return nil, fmt.Errorf("%w: invalid Helius cursor %q", historical.ErrInvalidCursor, request.Cursor)
+	}
+	if request.Until != "" && !isSignature(request.Until) {
+		return nil, fmt.Errorf("%w: until must be a transaction signature", historical.ErrInvalidCursor)
+	}
+
+	txInfo, err := p.service.GetAddressInfo(ctx, request.Address, request.Limit, request.Cursor, request.Until)
+	if err != nil {
+		return nil, err
+	}
+
+	transactions := make([]models.Transaction, 0, len(txInfo))
+	for _, tx := range txInfo {
+		transactions = append(transactions, MapTxToTransaction(tx, request.Address)...)
+	}
+
+	return &historical.HistoryPage{
+		Transactions: transactions,
+		Cursor:       nextCursor(txInfo, min(request.Limit, MaxPageSize)),
+	}, nil
+}
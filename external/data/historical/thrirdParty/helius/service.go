@@ -1,14 +1,21 @@
 package helius
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/tashunc/nugenesis-wallet-backend/external/data/historical/thrirdParty/helius/helius_models"
+	"github.com/tashunc/nugenesis-wallet-backend/pkg/httpclient"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
+	"strconv"
+	"time"
 )
 
+const Timeout = 30 * time.Second
+
 type Service struct {
 	apiKey  string
 	baseURL string
@@ -19,24 +26,52 @@ func NewService() *Service {
 	return &Service{
 		apiKey:  os.Getenv("HELIUS_API_KEY"),
 		baseURL: "https://api.helius.xyz/v0",
-		client:  &http.Client{},
+		client: httpclient.New(httpclient.Config{
+			Name:          "helius",
+			Timeout:       Timeout,
+			RatePerSecond: 10,
+			Burst:         10,
+		}),
 	}
 }
 
-// GetAddressInfo retrieves information about a specific address
-func (s *Service) GetAddressInfo(address string, limit, offset int) ([]helius_models.Transaction, error) {
-	url := fmt.Sprintf("%s/addresses/%s/transactions?api-key=%s&limit=%d&offset=%d", s.baseURL, address, s.apiKey, limit, offset)
+// MaxPageSize is the largest limit the Helius transactions endpoint accepts
+const MaxPageSize = 100
 
-	req, err := http.NewRequest("GET", url, nil)
+// GetAddressInfo retrieves the parsed transactions of an address, newest first.
+// before returns transactions older than that signature and until stops at that signature, both are optional.
+func (s *Service) GetAddressInfo(ctx context.Context, address string, limit int, before string, until string) ([]helius_models.Transaction, error) {
+	query := url.Values{}
+	query.Set("api-key", s.apiKey)
+	query.Set("limit", strconv.Itoa(min(limit, MaxPageSize)))
+	if before != "" {
+		query.Set("before", before)
+	}
+	if until != "" {
+		query.Set("until", until)
+	}
+	requestURL := fmt.Sprintf("%s/addresses/%s/transactions?%s", s.baseURL, url.PathEscape(address), query.Encode())
+
+	req, err := http.NewRequestWithContext(ctx, "GET", requestURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request Helius: %w", err)
+	}
+	defer resp.Body.Close()
+
 	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
 
-	fmt.Println("Helius response:", string(body))
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Helius API returned status %d: %s", resp.StatusCode, string(body))
+	}
 
 	var result []helius_models.Transaction
 	if err := json.Unmarshal(body, &result); err != nil {
@@ -1,15 +1,24 @@
 package helius
 
 import (
-	"fmt"
+	"math"
+	"math/big"
+	"strconv"
 	"sync"
 	"time"
 
+	"github.com/btcsuite/btcd/btcutil/base58"
+	"github.com/tashunc/nugenesis-wallet-backend/external/data/fmv/pricing"
 	"github.com/tashunc/nugenesis-wallet-backend/external/data/historical/thrirdParty/helius/helius_models"
 	"github.com/tashunc/nugenesis-wallet-backend/external/models"
 	"github.com/tashunc/nugenesis-wallet-backend/static/staticServices"
 )
 
+const (
+	lamportsPerSOL = 1e9
+	solDecimals    = 9
+)
+
 var programMapping = map[string]string{
 	"11111111111111111111111111111111":            "native_transfer", // System Program
 	"TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA": "token_transfer",  // SPL Token Program
@@ -19,6 +28,12 @@ var programMapping = map[string]string{
 	"MemoSq4gqABAXKb96qnH8TysNcWxMyWCqXgDLGmfcHr": "memo",            // Memo Program
 }
 
+// auxiliaryPrograms only tune or annotate a transaction, they do not say what it does
+var auxiliaryPrograms = map[string]bool{
+	"ComputeBudget111111111111111111111111111111": true,
+	"MemoSq4gqABAXKb96qnH8TysNcWxMyWCqXgDLGmfcHr": true,
+}
+
 var (
 	// AssetService instance for accessing Solana token symbols
 	assetService     *staticServices.AssetService
@@ -30,162 +45,209 @@ func initAssetService() {
 	assetService = staticServices.NewAssetService()
 }
 
-// getTokenSymbol returns the token symbol for a given mint address
+// getToken returns the symbol and decimals of a mint, found is false for mints missing from the asset files
 // Uses the static AssetService cache from the static folder
-func getTokenSymbol(mint string) string {
+func getToken(mint string) (symbol string, decimals int, found bool) {
 	// Ensure AssetService is initialized (happens only once)
 	assetServiceOnce.Do(initAssetService)
 
-	// Use AssetService to get the token symbol
 	return assetService.GetTokenSymbolByMint(mint)
 }
 
+// transferLeg is one movement of SOL or an SPL token into or out of the wallet
+type transferLeg struct {
+	direction string
+	category  string
+	token     string
+	decimals  int
+	amount    float64
+	from      string
+	to        string
+}
+
+// MapTxToTransaction maps a Helius enhanced transaction to one transaction per transfer leg touching the address,
+// quoting amounts at block time. The fee is attached to the legs when the address paid it.
 func MapTxToTransaction(tx helius_models.Transaction, address string) []models.Transaction {
-	// Extract the necessary fields from the transaction data
-	signature := tx.Signature
-	fee := float64(tx.Fee) / 1e9 // Convert lamports to SOL (1 SOL = 1e9 lamports)
-	feePayer := tx.FeePayer
-	timestamp := tx.Timestamp
-
-	// Convert timestamp to human-readable date and time
-	date, timeFormatted := formatTimestamp(timestamp)
-
-	// Default values
-	transactionType := "unknown"
-	token := "SOL"
-	amount := "0" // Since no native transfer or token transfer
-	value := "0"  // Same as amount
-	//address := feePayer
-
-	// Check if there are instructions and map the programId to type
-	for _, instruction := range tx.Instructions {
-		programID := instruction.ProgramID
-
-		// Set the transaction type based on programID
-		switch programID {
-		case "11111111111111111111111111111111":
-			transactionType = "native_transfer" // System Program
-		case "TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA":
-			transactionType = "token_transfer" // SPL Token Program
-		case "Stake11111111111111111111111111111111111111":
-			transactionType = "stake" // Stake Program
-		case "Vote111111111111111111111111111111111111111":
-			transactionType = "vote" // Vote Program
-		case "ComputeBudget111111111111111111111111111111":
-			transactionType = "contract_call" // Compute Budget Program
-		default:
-			transactionType = "program_interaction" // Custom Program
+	date, timeFormatted := formatTimestamp(tx.Timestamp)
+	blockTime := time.Unix(tx.Timestamp, 0)
+
+	fee := 0.0
+	if tx.FeePayer == address {
+		fee = float64(tx.Fee) / lamportsPerSOL
+	}
+	feeQuote := pricing.Quote("SOL", fee, blockTime)
+
+	status := "success"
+	if tx.TransactionError != nil {
+		status = "failed"
+	}
+
+	legs := transferLegs(tx, address)
+	if len(legs) == 0 {
+		legs = balanceChangeLegs(tx, address)
+	}
+
+	// Transactions without any value movement, e.g. votes or failed calls, are still listed with their fee
+	if len(legs) == 0 {
+		direction := "receive"
+		if tx.FeePayer == address {
+			direction = "send"
 		}
-		//}
-		//
-		//// Convert timestamp to human-readable date and time
-		//t := time.Unix(timestamp, 0)
-		//date := t.Format("2006-01-02")
-		//timeFormatted := t.Format("15:04:05")
-		//
-		//// Default values
-		//transactionType := "unknown"
-		//token := "SOL"
-		//amount := "0" // Since no native transfer or token transfer
-		//value := "0"  // Same as amount
-		//address := feePayer
-
-		// Check if there are instructions and map the programId to type
-		//instructions := tx["instructions"].([]interface{})
-		//for _, instruction := range instructions {
-		//	programID := instruction.(map[string]interface{})["programId"].(string)
-		//
-		//	// Set the transaction type based on programID
-		//	if txType, exists := programMapping[programID]; exists {
-		//		transactionType = txType
-		//	}
+		legs = append(legs, transferLeg{
+			direction: direction,
+			category:  classifyInstructions(tx.Instructions),
+			token:     "SOL",
+			decimals:  solDecimals,
+			from:      tx.FeePayer,
+		})
 	}
 
-	// Return the mapped transaction
-	var mappedTransactions []models.Transaction
-	if len(tx.NativeTransfers) == 0 {
+	mappedTransactions := make([]models.Transaction, 0, len(legs))
+	for i, leg := range legs {
 		mappedTransactions = append(mappedTransactions, models.Transaction{
-			ID:       signature,
-			Type:     "send",
-			Category: transactionType,
-			Status:   "success", // assuming no error in the transaction
-			Token:    token,
-			Amount:   amount,
-			Value:    value,
-			Address:  feePayer,
-			Date:     date,
-			Time:     timeFormatted,
-			Fee:      fmt.Sprintf("%.9f", fee),
-			Hash:     signature,
+			ID:         tx.Signature,
+			Type:       leg.direction,
+			Category:   leg.category,
+			Status:     status,
+			Token:      leg.token,
+			Amount:     strconv.FormatFloat(leg.amount, 'f', leg.decimals, 64),
+			Address:    leg.from,
+			ToAddress:  leg.to,
+			Date:       date,
+			Time:       timeFormatted,
+			Hash:       tx.Signature,
+			Leg:        strconv.Itoa(i),
+			ValueQuote: pricing.Quote(leg.token, leg.amount, blockTime),
+			FeeQuote:   feeQuote,
 		})
-	} else {
-
-		for _, transfer := range tx.NativeTransfers {
-			if address == transfer.FromUserAccount {
-				transactionType = "send"
-			} else if address == transfer.ToUserAccount {
-				transactionType = "receive"
-			} else {
-				continue
-			}
-			solAmount := float64(transfer.Amount) / 1e9
-			mappedTransactions = append(mappedTransactions, models.Transaction{
-				ID:        signature,
-				Type:      transactionType,
-				Category:  "native_transfer",
-				Status:    "success", // assuming no error in the transaction
-				Token:     token,
-				Amount:    fmt.Sprintf("%.9f", solAmount),
-				Value:     fmt.Sprintf("%.9f", solAmount),
-				Address:   transfer.FromUserAccount, // Fixed: use actual sender, not feePayer
-				ToAddress: transfer.ToUserAccount,
-				Date:      date,
-				Time:      timeFormatted,
-				Fee:       fmt.Sprintf("%.9f", fee),
-				Hash:      signature,
-			})
+	}
+	return mappedTransactions
+}
+
+// transferLegs reads the native and token transfers sent or received by the address, transfers to self are skipped
+func transferLegs(tx helius_models.Transaction, address string) []transferLeg {
+	var legs []transferLeg
+	for _, transfer := range tx.NativeTransfers {
+		direction, ok := legDirection(address, transfer.FromUserAccount, transfer.ToUserAccount)
+		if !ok || transfer.Amount == 0 {
+			continue
+		}
+		legs = append(legs, transferLeg{
+			direction: direction,
+			category:  "native_transfer",
+			token:     "SOL",
+			decimals:  solDecimals,
+			amount:    float64(transfer.Amount) / lamportsPerSOL,
+			from:      transfer.FromUserAccount,
+			to:        transfer.ToUserAccount,
+		})
+	}
+
+	for _, transfer := range tx.TokenTransfers {
+		direction, ok := legDirection(address, transfer.FromUserAccount, transfer.ToUserAccount)
+		if !ok {
+			continue
 		}
+		symbol, decimals, found := getToken(transfer.Mint)
+		if !found {
+			decimals = -1
+		}
+		legs = append(legs, transferLeg{
+			direction: direction,
+			category:  "token_transfer",
+			token:     symbol,
+			decimals:  decimals,
+			amount:    transfer.TokenAmount,
+			from:      transfer.FromUserAccount,
+			to:        transfer.ToUserAccount,
+		})
+	}
+	return legs
+}
 
+// balanceChangeLegs derives legs from the account balance changes of the address, for transactions such as
+// program interactions where Helius reports no transfers touching the wallet
+func balanceChangeLegs(tx helius_models.Transaction, address string) []transferLeg {
+	var legs []transferLeg
+	for _, account := range tx.AccountData {
+		if account.Account != address {
+			continue
+		}
+		// The fee is reported separately, it is not a transfer
+		change := account.NativeBalanceChange
+		if tx.FeePayer == address {
+			change += tx.Fee
+		}
+		if change != 0 {
+			legs = append(legs, balanceLeg(address, "native_transfer", "SOL", solDecimals, float64(change)/lamportsPerSOL))
+		}
 	}
 
-	if len(tx.TokenTransfers) > 0 {
-		for _, transfer := range tx.TokenTransfers {
-			// Skip if address is not involved
-			if address != transfer.FromUserAccount && address != transfer.ToUserAccount {
+	for _, account := range tx.AccountData {
+		for _, change := range account.TokenBalanceChanges {
+			if change.UserAccount != address {
 				continue
 			}
-
-			var transactionType string
-			if address == transfer.FromUserAccount {
-				transactionType = "send"
-			} else {
-				transactionType = "receive"
+			raw, ok := new(big.Int).SetString(change.TokenAmount.TokenAmount, 10)
+			if !ok || raw.Sign() == 0 {
+				continue
 			}
+			symbol, decimals, found := getToken(change.Mint)
+			if !found {
+				decimals = int(change.TokenAmount.Decimals)
+			}
+			amount, _ := new(big.Float).Quo(new(big.Float).SetInt(raw), new(big.Float).SetFloat64(math.Pow10(int(change.TokenAmount.Decimals)))).Float64()
+			legs = append(legs, balanceLeg(address, "token_transfer", symbol, decimals, amount))
+		}
+	}
+	return legs
+}
+
+func balanceLeg(address string, category string, token string, decimals int, change float64) transferLeg {
+	if change < 0 {
+		return transferLeg{direction: "send", category: category, token: token, decimals: decimals, amount: -change, from: address}
+	}
+	return transferLeg{direction: "receive", category: category, token: token, decimals: decimals, amount: change, to: address}
+}
+
+func legDirection(address string, from string, to string) (string, bool) {
+	switch {
+	case from == address && to == address:
+		return "", false
+	case from == address:
+		return "send", true
+	case to == address:
+		return "receive", true
+	default:
+		return "", false
+	}
+}
 
-			tokenMint := transfer.Mint
-			tokenSymbol := getTokenSymbol(tokenMint)
-			tokenAmount := transfer.TokenAmount
-
-			mappedTransactions = append(mappedTransactions, models.Transaction{
-				ID:        signature,
-				Type:      transactionType,
-				Category:  "token_transfer",
-				Status:    "success",
-				Token:     tokenSymbol, // Now shows "USDC" instead of mint
-				Amount:    fmt.Sprintf("%.9f", tokenAmount),
-				Value:     fmt.Sprintf("%.9f", tokenAmount),
-				Address:   transfer.FromUserAccount,
-				ToAddress: transfer.ToUserAccount,
-				Date:      date,
-				Time:      timeFormatted,
-				Fee:       fmt.Sprintf("%.9f", fee),
-				Hash:      signature,
-			})
+// classifyInstructions names a transaction after its first instruction that is not a compute budget or memo
+func classifyInstructions(instructions []helius_models.Instruction) string {
+	for _, instruction := range instructions {
+		if auxiliaryPrograms[instruction.ProgramID] {
+			continue
+		}
+		if category, exists := programMapping[instruction.ProgramID]; exists {
+			return category
 		}
+		return "program_interaction" // Custom Program
 	}
+	return "unknown"
+}
 
-	return mappedTransactions
+// isSignature reports whether value is a base58 transaction signature, as used by the before and until cursors
+func isSignature(value string) bool {
+	return len(base58.Decode(value)) == 64
+}
 
+// nextCursor returns the signature to pass as before for the next page, empty once a short page ends the history
+func nextCursor(transactions []helius_models.Transaction, limit int) string {
+	if limit <= 0 || len(transactions) < limit {
+		return ""
+	}
+	return transactions[len(transactions)-1].Signature
 }
 
 func formatTimestamp(timestamp int64) (string, string) {
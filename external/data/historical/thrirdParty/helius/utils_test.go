@@ -0,0 +1,80 @@
+package helius
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/tashunc/nugenesis-wallet-backend/external/data/historical"
+	"github.com/tashunc/nugenesis-wallet-backend/external/data/historical/thrirdParty/helius/helius_models"
+)
+
+const wallet = "4Nd1mBQtrMJVYVfKf2PJy9NZUZdTAsp7D4xWLs4gDB4T"
+
+func TestTransferLegs(t *testing.T) {
+	tx := helius_models.Transaction{
+		FeePayer: wallet,
+		NativeTransfers: []helius_models.NativeTransfer{
+			{Amount: 1500000000, FromUserAccount: wallet, ToUserAccount: "other"},
+			{Amount: 2000, FromUserAccount: "other", ToUserAccount: "third"},
+		},
+		TokenTransfers: []helius_models.TokenTransfer{
+			{FromUserAccount: "other", ToUserAccount: wallet, TokenAmount: 12.5, Mint: "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v"},
+		},
+	}
+
+	legs := transferLegs(tx, wallet)
+	if len(legs) != 2 {
+		t.Fatalf("Expected the two legs touching the wallet, got %+v", legs)
+	}
+	if legs[0].direction != "send" || legs[0].token != "SOL" || legs[0].amount != 1.5 {
+		t.Errorf("Unexpected native leg %+v", legs[0])
+	}
+	if legs[1].direction != "receive" || legs[1].category != "token_transfer" || legs[1].amount != 12.5 {
+		t.Errorf("Unexpected token leg %+v", legs[1])
+	}
+}
+
+func TestBalanceChangeLegsExcludeFee(t *testing.T) {
+	tx := helius_models.Transaction{
+		FeePayer: wallet,
+		Fee:      5000,
+		AccountData: []helius_models.AccountData{
+			{Account: wallet, NativeBalanceChange: -250005000},
+		},
+	}
+
+	legs := balanceChangeLegs(tx, wallet)
+	if len(legs) != 1 || legs[0].direction != "send" || legs[0].amount != 0.25 {
+		t.Errorf("Expected a 0.25 SOL send without the fee, got %+v", legs)
+	}
+}
+
+func TestClassifyInstructionsSkipsAuxiliaryPrograms(t *testing.T) {
+	instructions := []helius_models.Instruction{
+		{ProgramID: "ComputeBudget111111111111111111111111111111"},
+		{ProgramID: "Stake11111111111111111111111111111111111111"},
+		{ProgramID: "JUP6LkbZbjS1jKKwapdHNy74zcZ3tLUZoi5QNyVTaV4"},
+	}
+	if category := classifyInstructions(instructions); category != "stake" {
+		t.Errorf("Expected stake, got %s", category)
+	}
+}
+
+func TestNextCursor(t *testing.T) {
+	page := []helius_models.Transaction{{Signature: "a"}, {Signature: "b"}}
+	if cursor := nextCursor(page, 2); cursor != "b" {
+		t.Errorf("Expected the oldest signature of a full page, got %q", cursor)
+	}
+	if cursor := nextCursor(page, 3); cursor != "" {
+		t.Errorf("Expected a short page to end the history, got %q", cursor)
+	}
+}
+
+func TestHistoryProviderRejectsMalformedUntil(t *testing.T) {
+	provider := &HistoryProvider{}
+	_, err := provider.GetHistory(context.Background(), historical.HistoryRequest{Address: wallet, Limit: 10, Until: "not-a-signature"})
+	if !errors.Is(err, historical.ErrInvalidCursor) {
+		t.Errorf("Expected ErrInvalidCursor for a malformed until, got %v", err)
+	}
+}
@@ -0,0 +1,52 @@
+package moralis
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tashunc/nugenesis-wallet-backend/external/data/historical/thrirdParty/moralis/moralis_models"
+	"github.com/tashunc/nugenesis-wallet-backend/external/models"
+)
+
+const (
+	// balancePageSize is the largest page Moralis returns for wallet token balances
+	balancePageSize = 100
+	// maxBalancePages bounds the non-spam balances of one wallet to 2000 tokens
+	maxBalancePages = 20
+)
+
+// BalanceProvider serves wallet token balances for a single Moralis chain through portfolio.BalanceProvider
+type BalanceProvider struct {
+	controller *Controller
+}
+
+// BalanceProvider returns a balance provider for the controller's chain
+func (c *Controller) BalanceProvider() *BalanceProvider {
+	return &BalanceProvider{controller: c}
+}
+
+func (p *BalanceProvider) Name() string {
+	return ProviderName
+}
+
+// GetBalances follows the balance pages of the wallet and returns every non-spam balance, the Solana gateway is not
+// paginated. A wallet over maxBalancePages pages fails rather than reporting a partial balance.
+func (p *BalanceProvider) GetBalances(ctx context.Context, address string) ([]models.WalletTokenBalance, error) {
+	if p.controller.chain == "solana" {
+		return p.controller.solanaWalletTokenBalances(ctx, address, "mainnet")
+	}
+
+	var balances []moralis_models.TokenBalance
+	cursor := ""
+	for page := 0; page < maxBalancePages; page++ {
+		response, err := p.controller.service.GetWalletTokenBalances(ctx, address, p.controller.chain, cursor, balancePageSize, true)
+		if err != nil {
+			return nil, err
+		}
+		balances = append(balances, response.Result...)
+		if cursor = response.Cursor; cursor == "" {
+			return p.controller.mapTokenBalances(balances, p.controller.chain), nil
+		}
+	}
+	return nil, fmt.Errorf("wallet holds more than %d token balances", maxBalancePages*balancePageSize)
+}
@@ -1,6 +1,7 @@
 package moralis
 
 import (
+	"context"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
@@ -62,73 +63,95 @@ func enrichBalancesWithCoinGeckoPrices(balances []models.WalletTokenBalance) []m
 	return service.EnrichBalancesWithPrices(balances)
 }
 
-func (c *Controller) GetWalletHistory(ctx *gin.Context) {
+// GetWalletTokenBalances retrieves all token balances (including native tokens) for a wallet address
+// Supports multi-chain and multi-token with fiat values
+func (c *Controller) GetWalletTokenBalances(ctx *gin.Context) {
 	address := ctx.Param("address")
 	if address == "" {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "address parameter is required"})
 		return
 	}
 
+	// Get optional query parameters
 	cursor := ctx.Query("cursor")
 	limitStr := ctx.DefaultQuery("limit", "100")
+	excludeSpamStr := ctx.DefaultQuery("exclude_spam", "true")
+	chain := ctx.DefaultQuery("chain", c.chain) // Allow override of default chain
 
+	// Parse limit parameter
 	limit, err := strconv.Atoi(limitStr)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit parameter"})
 		return
 	}
 
-	history, err := c.service.GetWalletHistory(address, c.chain, cursor, limit)
+	// Parse exclude_spam parameter
+	excludeSpam, err := strconv.ParseBool(excludeSpamStr)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+		excludeSpam = true // Default to excluding spam
 	}
 
-	// Map Moralis transactions to standard transaction format
-	var mappedTransactions []models.Transaction
-	for _, tx := range history.Result {
-		mappedTx := MapHistoryToTransaction(tx, address)
-		mappedTransactions = append(mappedTransactions, mappedTx)
+	response, err := c.walletTokenBalances(ctx.Request.Context(), address, chain, cursor, limit, excludeSpam)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
 	}
 
-	ctx.JSON(http.StatusOK, mappedTransactions)
+	ctx.JSON(http.StatusOK, response)
 }
 
-// GetWalletTokenBalances retrieves all token balances (including native tokens) for a wallet address
-// Supports multi-chain and multi-token with fiat values
-func (c *Controller) GetWalletTokenBalances(ctx *gin.Context) {
+// GetSolanaWalletTokenBalances retrieves all token balances (including native SOL) for a Solana wallet address
+// Uses Moralis Solana Gateway API which has a different endpoint structure
+func (c *Controller) GetSolanaWalletTokenBalances(ctx *gin.Context) {
 	address := ctx.Param("address")
 	if address == "" {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "address parameter is required"})
 		return
 	}
 
-	// Get optional query parameters
-	cursor := ctx.Query("cursor")
-	limitStr := ctx.DefaultQuery("limit", "100")
-	excludeSpamStr := ctx.DefaultQuery("exclude_spam", "true")
-	chain := ctx.DefaultQuery("chain", c.chain) // Allow override of default chain
+	// Get optional network parameter (default: mainnet)
+	network := ctx.DefaultQuery("network", "mainnet")
 
-	// Parse limit parameter
-	limit, err := strconv.Atoi(limitStr)
+	mappedBalances, err := c.solanaWalletTokenBalances(ctx.Request.Context(), address, network)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit parameter"})
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Parse exclude_spam parameter
-	excludeSpam, err := strconv.ParseBool(excludeSpamStr)
-	if err != nil {
-		excludeSpam = true // Default to excluding spam
+	// Prepare response
+	response := models.WalletTokenBalancesResponse{
+		Success:  true,
+		Address:  address,
+		Chain:    "solana",
+		Balances: mappedBalances,
+		Cursor:   "",    // Solana API doesn't use cursor pagination
+		HasMore:  false, // No pagination for Solana API
 	}
 
+	ctx.JSON(http.StatusOK, response)
+}
+
+// walletTokenBalances fetches one page of EVM token balances mapped to the standard format and enriched with prices
+func (c *Controller) walletTokenBalances(ctx context.Context, address string, chain string, cursor string, limit int, excludeSpam bool) (*models.WalletTokenBalancesResponse, error) {
 	// Call Moralis API to get token balances
-	balances, err := c.service.GetWalletTokenBalances(address, chain, cursor, limit, excludeSpam)
+	balances, err := c.service.GetWalletTokenBalances(ctx, address, chain, cursor, limit, excludeSpam)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+		return nil, err
 	}
 
+	// Prepare response with pagination info
+	return &models.WalletTokenBalancesResponse{
+		Success:  true,
+		Address:  address,
+		Chain:    chain,
+		Balances: c.mapTokenBalances(balances.Result, chain),
+		Cursor:   balances.Cursor,
+		HasMore:  balances.HasMore,
+	}, nil
+}
+
+// mapTokenBalances maps Moralis EVM token balances to the standard format, filtered by token_id and enriched with prices
+func (c *Controller) mapTokenBalances(balances []moralis_models.TokenBalance, chain string) []models.WalletTokenBalance {
 	// Get token ID service
 	var tokenIDService TokenIDServiceInterface
 	if c.tokenIDServiceGetter != nil {
@@ -137,7 +160,7 @@ func (c *Controller) GetWalletTokenBalances(ctx *gin.Context) {
 
 	// Map Moralis token balances to standard format
 	var mappedBalances []models.WalletTokenBalance
-	for _, token := range balances.Result {
+	for _, token := range balances {
 		mappedBalance := MapTokenBalanceToStandard(token, chain, tokenIDService)
 		mappedBalances = append(mappedBalances, mappedBalance)
 	}
@@ -146,45 +169,21 @@ func (c *Controller) GetWalletTokenBalances(ctx *gin.Context) {
 	mappedBalances = filterBalancesByTokenID(mappedBalances)
 
 	// Enrich balances with CoinGecko prices if UsdPrice/UsdValue are missing
-	mappedBalances = enrichBalancesWithCoinGeckoPrices(mappedBalances)
-
-	// Prepare response with pagination info
-	response := models.WalletTokenBalancesResponse{
-		Success:  true,
-		Address:  address,
-		Chain:    chain,
-		Balances: mappedBalances,
-		Cursor:   balances.Cursor,
-		HasMore:  balances.HasMore,
-	}
-
-	ctx.JSON(http.StatusOK, response)
+	return enrichBalancesWithCoinGeckoPrices(mappedBalances)
 }
 
-// GetSolanaWalletTokenBalances retrieves all token balances (including native SOL) for a Solana wallet address
-// Uses Moralis Solana Gateway API which has a different endpoint structure
-func (c *Controller) GetSolanaWalletTokenBalances(ctx *gin.Context) {
-	address := ctx.Param("address")
-	if address == "" {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "address parameter is required"})
-		return
-	}
-
-	// Get optional network parameter (default: mainnet)
-	network := ctx.DefaultQuery("network", "mainnet")
-
+// solanaWalletTokenBalances fetches native SOL and SPL token balances mapped to the standard format
+func (c *Controller) solanaWalletTokenBalances(ctx context.Context, address string, network string) ([]models.WalletTokenBalance, error) {
 	// Get native SOL balance
-	nativeBalance, err := c.service.GetSolanaBalance(address, network)
+	nativeBalance, err := c.service.GetSolanaBalance(ctx, address, network)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+		return nil, err
 	}
 
 	// Get SPL token balances
-	tokenBalances, err := c.service.GetSolanaTokenBalances(address, network)
+	tokenBalances, err := c.service.GetSolanaTokenBalances(ctx, address, network)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+		return nil, err
 	}
 
 	// Get token ID service
@@ -218,17 +217,5 @@ func (c *Controller) GetSolanaWalletTokenBalances(ctx *gin.Context) {
 	mappedBalances = filterBalancesByTokenID(mappedBalances)
 
 	// Enrich balances with CoinGecko prices if UsdPrice/UsdValue are missing
-	mappedBalances = enrichBalancesWithCoinGeckoPrices(mappedBalances)
-
-	// Prepare response
-	response := models.WalletTokenBalancesResponse{
-		Success:  true,
-		Address:  address,
-		Chain:    "solana",
-		Balances: mappedBalances,
-		Cursor:   "",    // Solana API doesn't use cursor pagination
-		HasMore:  false, // No pagination for Solana API
-	}
-
-	ctx.JSON(http.StatusOK, response)
+	return enrichBalancesWithCoinGeckoPrices(mappedBalances), nil
 }
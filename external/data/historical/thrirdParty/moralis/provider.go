@@ -0,0 +1,48 @@
+package moralis
+
+import (
+	"context"
+
+	"github.com/tashunc/nugenesis-wallet-backend/external/data/historical"
+	"github.com/tashunc/nugenesis-wallet-backend/external/models"
+)
+
+const ProviderName = "moralis"
+
+// HistoryProvider serves wallet history for a single Moralis chain through historical.HistoryProvider
+type HistoryProvider struct {
+	service *Service
+	chain   string
+}
+
+// HistoryProvider returns a history provider for the controller's chain sharing its service
+func (c *Controller) HistoryProvider() *HistoryProvider {
+	return &HistoryProvider{service: c.service, chain: c.chain}
+}
+
+func (p *HistoryProvider) Name() string {
+	return ProviderName
+}
+
+// GetHistory passes the Moralis cursor through unchanged
+func (p *HistoryProvider) GetHistory(ctx context.Context, request historical.HistoryRequest) (*historical.HistoryPage, error) {
+	history, err := p.service.GetWalletHistory(ctx, request.Address, p.chain, request.Cursor, request.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	transactions := make([]models.Transaction, 0, len(history.Result))
+	for _, tx := range history.Result {
+		transactions = append(transactions, MapHistoryToTransaction(tx, request.Address))
+	}
+
+	cursor := ""
+	if history.HasMore || (history.Cursor != "" && len(history.Result) > 0) {
+		cursor = history.Cursor
+	}
+
+	return &historical.HistoryPage{
+		Transactions: transactions,
+		Cursor:       cursor,
+	}, nil
+}
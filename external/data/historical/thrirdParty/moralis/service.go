@@ -1,14 +1,19 @@
 package moralis
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/tashunc/nugenesis-wallet-backend/external/data/historical/thrirdParty/moralis/moralis_models"
+	"github.com/tashunc/nugenesis-wallet-backend/pkg/httpclient"
 	"io"
 	"net/http"
 	"os"
+	"time"
 )
 
+const Timeout = 30 * time.Second
+
 type Service struct {
 	apiKey  string
 	baseURL string
@@ -19,11 +24,16 @@ func NewService() *Service {
 	return &Service{
 		apiKey:  os.Getenv("MORALIS_API_KEY"),
 		baseURL: "https://deep-index.moralis.io/api/v2.2",
-		client:  &http.Client{},
+		client: httpclient.New(httpclient.Config{
+			Name:          "moralis",
+			Timeout:       Timeout,
+			RatePerSecond: 25,
+			Burst:         25,
+		}),
 	}
 }
 
-func (s *Service) GetWalletHistory(address string, chain string, cursor string, limit int) (*moralis_models.WalletHistoryResponse, error) {
+func (s *Service) GetWalletHistory(ctx context.Context, address string, chain string, cursor string, limit int) (*moralis_models.WalletHistoryResponse, error) {
 	url := fmt.Sprintf(
 		"%s/wallets/%s/history?chain=%s&order=DESC",
 		s.baseURL, address, chain,
@@ -37,7 +47,7 @@ func (s *Service) GetWalletHistory(address string, chain string, cursor string,
 		url += fmt.Sprintf("&limit=%d", limit)
 	}
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -78,7 +88,7 @@ func (s *Service) GetWalletHistory(address string, chain string, cursor string,
 
 // GetWalletTokenBalances retrieves token balances (including native tokens) for a wallet address
 // Supports multiple chains and includes USD values for tokens
-func (s *Service) GetWalletTokenBalances(address string, chain string, cursor string, limit int, excludeSpam bool) (*moralis_models.WalletTokenBalancesResponse, error) {
+func (s *Service) GetWalletTokenBalances(ctx context.Context, address string, chain string, cursor string, limit int, excludeSpam bool) (*moralis_models.WalletTokenBalancesResponse, error) {
 	// Build the URL with required parameters
 	url := fmt.Sprintf(
 		"%s/wallets/%s/tokens?chain=%s",
@@ -99,7 +109,7 @@ func (s *Service) GetWalletTokenBalances(address string, chain string, cursor st
 	}
 
 	// Create HTTP request
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -143,7 +153,7 @@ func (s *Service) GetWalletTokenBalances(address string, chain string, cursor st
 
 // GetSolanaTokenBalances retrieves SPL token balances for a Solana wallet address
 // Uses the Solana-specific Moralis Gateway API
-func (s *Service) GetSolanaTokenBalances(address string, network string) (*moralis_models.SolanaTokenBalancesResponse, error) {
+func (s *Service) GetSolanaTokenBalances(ctx context.Context, address string, network string) (*moralis_models.SolanaTokenBalancesResponse, error) {
 	// Default to mainnet if network is not specified
 	if network == "" {
 		network = "mainnet"
@@ -156,7 +166,7 @@ func (s *Service) GetSolanaTokenBalances(address string, network string) (*moral
 	)
 
 	// Create HTTP request
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -199,7 +209,7 @@ func (s *Service) GetSolanaTokenBalances(address string, network string) (*moral
 }
 
 // GetSolanaBalance retrieves native SOL balance for a Solana wallet address
-func (s *Service) GetSolanaBalance(address string, network string) (*moralis_models.SolanaBalanceResponse, error) {
+func (s *Service) GetSolanaBalance(ctx context.Context, address string, network string) (*moralis_models.SolanaBalanceResponse, error) {
 	// Default to mainnet if network is not specified
 	if network == "" {
 		network = "mainnet"
@@ -212,7 +222,7 @@ func (s *Service) GetSolanaBalance(address string, network string) (*moralis_mod
 	)
 
 	// Create HTTP request
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
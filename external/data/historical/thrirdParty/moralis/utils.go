@@ -3,6 +3,7 @@ package moralis
 import (
 	"encoding/hex"
 	"fmt"
+	"github.com/tashunc/nugenesis-wallet-backend/external/data/fmv/pricing"
 	"github.com/tashunc/nugenesis-wallet-backend/external/data/historical/thrirdParty/moralis/moralis_models"
 	"github.com/tashunc/nugenesis-wallet-backend/external/models"
 	"math/big"
@@ -17,7 +18,8 @@ type TokenIDServiceInterface interface {
 	GetTokenIDForNative(chain, symbol string) string
 }
 
-// MapHistoryToTransaction converts Moralis history transaction to standard transaction format
+// MapHistoryToTransaction converts Moralis history transaction to standard transaction format,
+// quoting the transferred token at block time
 func MapHistoryToTransaction(tx moralis_models.HistoryTransaction, walletAddress string) models.Transaction {
 	timestamp, _ := time.Parse(time.RFC3339, tx.BlockTimestamp)
 
@@ -147,19 +149,19 @@ func MapHistoryToTransaction(tx moralis_models.HistoryTransaction, walletAddress
 	}
 
 	return models.Transaction{
-		ID:        tx.Hash,
-		Type:      txType,
-		Category:  category,
-		Status:    status,
-		Token:     tokenSymbol,
-		Amount:    fmt.Sprintf("%.6f", ethAmount),
-		Value:     fmt.Sprintf("$%.2f", ethAmount*2.0), // Example price, should use real rates
-		Address:   relevantAddress,
-		ToAddress: truncateAddress(tx.ToAddress),
-		Date:      timestamp.Format("2006-01-02"),
-		Time:      timestamp.Format("15:04"),
-		Fee:       fmt.Sprintf("%.8f", feeAmount),
-		Hash:      truncateHash(tx.Hash),
+		ID:         tx.Hash,
+		Type:       txType,
+		Category:   category,
+		Status:     status,
+		Token:      tokenSymbol,
+		Amount:     fmt.Sprintf("%.6f", ethAmount),
+		Address:    relevantAddress,
+		ToAddress:  truncateAddress(tx.ToAddress),
+		Date:       timestamp.Format("2006-01-02"),
+		Time:       timestamp.Format("15:04"),
+		Fee:        fmt.Sprintf("%.8f", feeAmount),
+		Hash:       truncateHash(tx.Hash),
+		ValueQuote: pricing.Quote(tokenSymbol, ethAmount, timestamp),
 	}
 }
 
@@ -0,0 +1,97 @@
+package portfolio
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/tashunc/nugenesis-wallet-backend/external/models"
+	"github.com/tashunc/nugenesis-wallet-backend/external/models/general"
+	"github.com/tashunc/nugenesis-wallet-backend/pkg/deadline"
+)
+
+// DefaultChainTimeout bounds the balance lookup of a single wallet
+const DefaultChainTimeout = 10 * time.Second
+
+var ErrUnsupportedChain = errors.New("balances not supported for this blockchain")
+
+// Aggregator fans out balance lookups to the provider of each chain and merges them into one portfolio
+type Aggregator struct {
+	providers map[general.CoinType]BalanceProvider
+	timeout   time.Duration
+}
+
+func NewAggregator(timeout time.Duration, providers map[general.CoinType]BalanceProvider) *Aggregator {
+	if timeout <= 0 {
+		timeout = DefaultChainTimeout
+	}
+	return &Aggregator{
+		providers: providers,
+		timeout:   timeout,
+	}
+}
+
+// Aggregate looks up all wallets concurrently. A failing wallet is reported in its chain result and left out of the
+// totals, it never fails the whole portfolio. PortfolioPercentage is recalculated against the grand total.
+func (a *Aggregator) Aggregate(ctx context.Context, wallets []models.PortfolioWallet) *models.PortfolioControllerResponse {
+	chains := make([]models.PortfolioChainResult, len(wallets))
+	balances := make([][]models.WalletTokenBalance, len(wallets))
+
+	var wg sync.WaitGroup
+	for i, wallet := range wallets {
+		wg.Add(1)
+		go func(i int, wallet models.PortfolioWallet) {
+			defer wg.Done()
+			chains[i] = models.PortfolioChainResult{
+				CoinType: wallet.CoinType,
+				Address:  wallet.Address,
+			}
+
+			provider, ok := a.providers[general.CoinType(wallet.CoinType)]
+			if !ok {
+				chains[i].Error = &models.SendRawTransactionError{Code: 400, Message: ErrUnsupportedChain.Error()}
+				return
+			}
+			chains[i].Provider = provider.Name()
+
+			rows, err := a.call(ctx, provider, wallet.Address)
+			if err != nil {
+				log.Printf("balance provider %s failed for %s: %v", provider.Name(), wallet.Address, err)
+				chains[i].Error = &models.SendRawTransactionError{Code: 502, Message: err.Error()}
+				return
+			}
+
+			for _, row := range rows {
+				chains[i].UsdValue += row.UsdValue
+			}
+			chains[i].TokenCount = len(rows)
+			balances[i] = rows
+		}(i, wallet)
+	}
+	wg.Wait()
+
+	response := &models.PortfolioControllerResponse{
+		Chains:   chains,
+		Balances: []models.WalletTokenBalance{},
+	}
+	for i := range chains {
+		response.TotalUsdValue += chains[i].UsdValue
+		response.Balances = append(response.Balances, balances[i]...)
+	}
+	for i := range response.Balances {
+		response.Balances[i].PortfolioPercentage = 0
+		if response.TotalUsdValue > 0 {
+			response.Balances[i].PortfolioPercentage = response.Balances[i].UsdValue / response.TotalUsdValue * 100
+		}
+	}
+	return response
+}
+
+// call runs a single provider with the chain timeout applied
+func (a *Aggregator) call(ctx context.Context, provider BalanceProvider, address string) ([]models.WalletTokenBalance, error) {
+	return deadline.Call(ctx, a.timeout, func(ctx context.Context) ([]models.WalletTokenBalance, error) {
+		return provider.GetBalances(ctx, address)
+	})
+}
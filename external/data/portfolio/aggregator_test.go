@@ -0,0 +1,75 @@
+package portfolio
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/tashunc/nugenesis-wallet-backend/external/models"
+	"github.com/tashunc/nugenesis-wallet-backend/external/models/general"
+)
+
+type fakeProvider struct {
+	name     string
+	balances []models.WalletTokenBalance
+	err      error
+	delay    time.Duration
+}
+
+func (f *fakeProvider) Name() string {
+	return f.name
+}
+
+func (f *fakeProvider) GetBalances(ctx context.Context, address string) ([]models.WalletTokenBalance, error) {
+	if f.delay > 0 {
+		time.Sleep(f.delay)
+	}
+	return f.balances, f.err
+}
+
+func TestAggregate(t *testing.T) {
+	aggregator := NewAggregator(50*time.Millisecond, map[general.CoinType]BalanceProvider{
+		general.Ethereum: &fakeProvider{name: "moralis", balances: []models.WalletTokenBalance{
+			{Symbol: "ETH", UsdValue: 300, PortfolioPercentage: 100},
+			{Symbol: "USDC", UsdValue: 100, PortfolioPercentage: 0},
+		}},
+		general.Bitcoin: &fakeProvider{name: "blockstream", balances: []models.WalletTokenBalance{
+			{Symbol: "BTC", UsdValue: 600},
+		}},
+		general.Solana:  &fakeProvider{name: "moralis", err: errors.New("upstream down")},
+		general.Polygon: &fakeProvider{name: "moralis", delay: time.Second},
+	})
+
+	response := aggregator.Aggregate(context.Background(), []models.PortfolioWallet{
+		{CoinType: string(general.Ethereum), Address: "0xabc"},
+		{CoinType: string(general.Bitcoin), Address: "bc1q"},
+		{CoinType: string(general.Solana), Address: "sol"},
+		{CoinType: string(general.Polygon), Address: "0xdef"},
+		{CoinType: string(general.Tron), Address: "T"},
+	})
+
+	if response.TotalUsdValue != 1000 {
+		t.Errorf("Expected total 1000, got %f", response.TotalUsdValue)
+	}
+	if len(response.Balances) != 3 {
+		t.Fatalf("Expected 3 balances, got %d", len(response.Balances))
+	}
+
+	expected := map[string]float64{"ETH": 30, "USDC": 10, "BTC": 60}
+	for _, balance := range response.Balances {
+		if math.Abs(balance.PortfolioPercentage-expected[balance.Symbol]) > 1e-9 {
+			t.Errorf("%s: expected %f%%, got %f%%", balance.Symbol, expected[balance.Symbol], balance.PortfolioPercentage)
+		}
+	}
+
+	if response.Chains[0].UsdValue != 400 || response.Chains[0].TokenCount != 2 || response.Chains[0].Error != nil {
+		t.Errorf("Unexpected Ethereum result %+v", response.Chains[0])
+	}
+	for i, code := range map[int]int{2: 502, 3: 502, 4: 400} {
+		if response.Chains[i].Error == nil || response.Chains[i].Error.Code != code {
+			t.Errorf("Expected chain %d to fail with %d, got %+v", i, code, response.Chains[i].Error)
+		}
+	}
+}
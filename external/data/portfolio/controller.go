@@ -0,0 +1,79 @@
+package portfolio
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tashunc/nugenesis-wallet-backend/external/models"
+)
+
+// MaxWallets bounds the fan-out of a single portfolio request
+const MaxWallets = 20
+
+// Controller serves the multi-chain portfolio endpoint
+type Controller struct {
+	aggregator *Aggregator
+}
+
+func NewController(aggregator *Aggregator) *Controller {
+	return &Controller{
+		aggregator: aggregator,
+	}
+}
+
+// GetPortfolio merges the balances of all requested wallets. It fails only when every wallet failed.
+func (c *Controller) GetPortfolio(ctx *gin.Context) {
+	var request models.PortfolioControllerRequest
+
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.PortfolioControllerResponse{
+			Success: false,
+			Message: "Invalid request format",
+			Error: &models.SendRawTransactionError{
+				Code:    400,
+				Message: err.Error(),
+			},
+		})
+		return
+	}
+
+	if len(request.Wallets) > MaxWallets {
+		ctx.JSON(http.StatusBadRequest, models.PortfolioControllerResponse{
+			Success: false,
+			Message: "Invalid request format",
+			Error: &models.SendRawTransactionError{
+				Code:    400,
+				Message: fmt.Sprintf("at most %d wallets are allowed", MaxWallets),
+			},
+		})
+		return
+	}
+
+	response := c.aggregator.Aggregate(ctx.Request.Context(), request.Wallets)
+
+	failed := 0
+	for _, chain := range response.Chains {
+		if chain.Error != nil {
+			failed++
+		}
+	}
+
+	switch {
+	case failed == len(response.Chains):
+		response.Message = "Failed to get balances for all wallets"
+		response.Error = &models.SendRawTransactionError{
+			Code:    502,
+			Message: "all balance lookups failed",
+		}
+		ctx.JSON(http.StatusBadGateway, response)
+		return
+	case failed > 0:
+		response.Message = "Portfolio retrieved, some wallets failed"
+	default:
+		response.Message = "Portfolio retrieved successfully"
+	}
+
+	response.Success = true
+	ctx.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,15 @@
+package portfolio
+
+import (
+	"context"
+
+	"github.com/tashunc/nugenesis-wallet-backend/external/models"
+)
+
+// BalanceProvider is implemented by every third-party backend that can list the token balances of an address
+type BalanceProvider interface {
+	// Name identifies the provider in responses and logs
+	Name() string
+	// GetBalances returns native and token balances with UsdValue set where a price is known
+	GetBalances(ctx context.Context, address string) ([]models.WalletTokenBalance, error)
+}
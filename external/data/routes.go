@@ -1,31 +1,51 @@
 package data
 
 import (
+	"context"
+	"errors"
 	"github.com/gin-gonic/gin"
+	"github.com/tashunc/nugenesis-wallet-backend/external/apikey"
+	"github.com/tashunc/nugenesis-wallet-backend/external/auth"
+	"github.com/tashunc/nugenesis-wallet-backend/external/data/historical"
+	"github.com/tashunc/nugenesis-wallet-backend/external/data/historical/index"
 	"github.com/tashunc/nugenesis-wallet-backend/external/data/historical/thrirdParty/alchemy"
 	blockchaininfo "github.com/tashunc/nugenesis-wallet-backend/external/data/historical/thrirdParty/blockchain_info"
 	"github.com/tashunc/nugenesis-wallet-backend/external/data/historical/thrirdParty/blockstream"
 	"github.com/tashunc/nugenesis-wallet-backend/external/data/historical/thrirdParty/etherscan"
 	"github.com/tashunc/nugenesis-wallet-backend/external/data/historical/thrirdParty/helius"
 	"github.com/tashunc/nugenesis-wallet-backend/external/data/historical/thrirdParty/moralis"
+	"github.com/tashunc/nugenesis-wallet-backend/external/data/portfolio"
 	"github.com/tashunc/nugenesis-wallet-backend/external/data/rpc/alchemy/alchemy_general"
+	"github.com/tashunc/nugenesis-wallet-backend/external/data/rpc/alchemy/alchemy_solana"
+	"github.com/tashunc/nugenesis-wallet-backend/external/data/rpc/bitcoin"
 	"github.com/tashunc/nugenesis-wallet-backend/external/models/general"
+	"github.com/tashunc/nugenesis-wallet-backend/pkg/database"
+	"github.com/tashunc/nugenesis-wallet-backend/static/staticServices"
+	"log"
 	"os"
+	"strconv"
 	"sync"
+	"time"
 )
 
 // Controller pool
 type ControllerPool struct {
 	bitcoinController          *blockchaininfo.Controller
-	blockstreamController      *blockstream.Controller
-	ethereumController         *etherscan.Controller
 	solanaController           *helius.Controller
 	polygonController          *moralis.Controller
 	ethereumMoralisController  *moralis.Controller
 	solanaMoralisController    *moralis.Controller
 	alchemyTokenController     *alchemy.Controller
+	bitcoinRPCController       *bitcoin.Controller
+	solanaRPCController        *alchemy_solana.Controller
 	alchemyHistoricControllers map[general.CoinType]*alchemy.Controller
 	alchemyRPCControllers      map[general.CoinType]*alchemy_general.Controller
+	explorerControllers        map[general.CoinType]*etherscan.Controller
+	esploraControllers         map[blockstream.NetworkID]*blockstream.Controller
+	historyControllers         map[general.CoinType]*historical.Controller
+	testHistoryControllers     map[blockstream.NetworkID]*historical.Controller
+	indexController            *index.Controller
+	portfolioController        *portfolio.Controller
 	once                       sync.Once
 }
 
@@ -38,12 +58,40 @@ func (cp *ControllerPool) GetAlchemyHistoricController(coinType general.CoinType
 	return cp.alchemyHistoricControllers[coinType]
 }
 
+func (cp *ControllerPool) GetHistoryController(coinType general.CoinType) *historical.Controller {
+	return cp.historyControllers[coinType]
+}
+
+// GetNetworkHistoryController returns the history of coinType on net, mainnet being the regular history controller
+func (cp *ControllerPool) GetNetworkHistoryController(coinType general.CoinType, net blockstream.Net) *historical.Controller {
+	if net == blockstream.Mainnet {
+		return cp.historyControllers[coinType]
+	}
+	return cp.testHistoryControllers[blockstream.NetworkID{CoinType: coinType, Net: net}]
+}
+
+func (cp *ControllerPool) GetPortfolioController() *portfolio.Controller {
+	return cp.portfolioController
+}
+
+func (cp *ControllerPool) GetSolanaRPCController() *alchemy_solana.Controller {
+	return cp.solanaRPCController
+}
+
+func (cp *ControllerPool) GetBitcoinRPCController() *bitcoin.Controller {
+	return cp.bitcoinRPCController
+}
+
 func (cp *ControllerPool) GetBitcoinController() *blockchaininfo.Controller {
 	return cp.bitcoinController
 }
 
 func (cp *ControllerPool) GetBlockstreamController() *blockstream.Controller {
-	return cp.blockstreamController
+	return cp.esploraControllers[blockstream.NetworkID{CoinType: general.Bitcoin, Net: blockstream.Mainnet}]
+}
+
+func (cp *ControllerPool) GetEsploraController(id blockstream.NetworkID) *blockstream.Controller {
+	return cp.esploraControllers[id]
 }
 
 func (cp *ControllerPool) GetEthereumController() *alchemy.Controller {
@@ -75,6 +123,10 @@ func initControllers() {
 		controllerPool = &ControllerPool{
 			alchemyRPCControllers:      make(map[general.CoinType]*alchemy_general.Controller),
 			alchemyHistoricControllers: make(map[general.CoinType]*alchemy.Controller),
+			explorerControllers:        make(map[general.CoinType]*etherscan.Controller),
+			esploraControllers:         make(map[blockstream.NetworkID]*blockstream.Controller),
+			historyControllers:         make(map[general.CoinType]*historical.Controller),
+			testHistoryControllers:     make(map[blockstream.NetworkID]*historical.Controller),
 		}
 	}
 
@@ -88,8 +140,15 @@ func initControllers() {
 		}
 
 		controllerPool.bitcoinController = blockchaininfo.NewController()
-		controllerPool.blockstreamController = blockstream.NewController()
-		controllerPool.ethereumController = etherscan.NewController()
+		// Bitcoin always reads Blockstream, the other UTXO chains any Esplora API configured for them
+		for _, network := range blockstream.ConfiguredNetworks() {
+			controllerPool.esploraControllers[network.ID()] = blockstream.NewController(network)
+		}
+		controllerPool.bitcoinRPCController = bitcoin.NewController()
+		// Etherscan-family explorers are a second history source for every EVM chain with an API key
+		for coinType, explorer := range etherscan.ConfiguredExplorers() {
+			controllerPool.explorerControllers[coinType] = etherscan.NewController(explorer)
+		}
 		controllerPool.solanaController = helius.NewController()
 
 		// Create Moralis controllers
@@ -104,7 +163,7 @@ func initControllers() {
 		controllerPool.polygonController.SetTokenIDServiceGetter(tokenIDServiceGetter)
 		controllerPool.ethereumMoralisController.SetTokenIDServiceGetter(tokenIDServiceGetter)
 		controllerPool.solanaMoralisController.SetTokenIDServiceGetter(tokenIDServiceGetter)
-		//controllerPool.alchemyTokenController = alchemy.NewController()
+		controllerPool.alchemyTokenController = alchemy.NewController(alchemy.BaseURL)
 
 		envMap := map[general.CoinType]string{
 			general.Bitcoin:         "ALCHEMY_BITCOIN_RPC_BASE_URL",
@@ -133,21 +192,146 @@ func initControllers() {
 			general.OpBNB:           "ALCHEMY_OPBNB_RPC_BASE_URL",
 		}
 
+		alchemyURLs := make(map[general.CoinType]string)
 		for coinType, envVar := range envMap {
-			if url := os.Getenv(envVar); url != "" {
+			url := os.Getenv(envVar)
+			if url == "" {
+				continue
+			}
+			alchemyURLs[coinType] = url
+			// The eth_* and alchemy_getAssetTransfers controllers only serve EVM chains
+			switch coinType {
+			case general.Solana:
+				controllerPool.solanaRPCController = alchemy_solana.NewController(url)
+			case general.Bitcoin:
+				// Bitcoin RPC is served by the bitcoin package, its Alchemy URL only feeds the portfolio
+			default:
 				controllerPool.alchemyRPCControllers[coinType] = alchemy_general.NewController(url)
 				controllerPool.alchemyHistoricControllers[coinType] = alchemy.NewController(url)
 			}
 		}
+
+		initHistoryControllers()
+		initPortfolioController(alchemyURLs)
 	})
 }
 
+// initPortfolioController wires the balance provider of every chain the portfolio endpoint can value.
+// Ethereum, Polygon and Solana use Moralis like /balances, the UTXO chains their Esplora API and the remaining Alchemy EVM
+// chains use the Alchemy Portfolio API.
+func initPortfolioController(alchemyURLs map[general.CoinType]string) {
+	timeout := portfolio.DefaultChainTimeout
+	if seconds, err := strconv.Atoi(os.Getenv("PORTFOLIO_CHAIN_TIMEOUT_SECONDS")); err == nil && seconds > 0 {
+		timeout = time.Duration(seconds) * time.Second
+	}
+
+	providers := map[general.CoinType]portfolio.BalanceProvider{
+		general.Ethereum: controllerPool.ethereumMoralisController.BalanceProvider(),
+		general.Polygon:  controllerPool.polygonController.BalanceProvider(),
+		general.Solana:   controllerPool.solanaMoralisController.BalanceProvider(),
+	}
+	// Test coins have no value, so only mainnets take part in the portfolio
+	for id, controller := range controllerPool.esploraControllers {
+		if id.Net == blockstream.Mainnet {
+			providers[id.CoinType] = controller.BalanceProvider()
+		}
+	}
+
+	for coinType, rpcURL := range alchemyURLs {
+		if _, exists := providers[coinType]; exists {
+			continue
+		}
+		if network := alchemy.NetworkFromURL(rpcURL); network != "" {
+			providers[coinType] = controllerPool.alchemyTokenController.BalanceProvider(network)
+		}
+	}
+
+	controllerPool.portfolioController = portfolio.NewController(portfolio.NewAggregator(timeout, providers))
+}
+
+// initHistoryControllers wires the priority-ordered history providers of every supported chain
+func initHistoryControllers() {
+	timeout := historical.DefaultProviderTimeout
+	if seconds, err := strconv.Atoi(os.Getenv("HISTORY_PROVIDER_TIMEOUT_SECONDS")); err == nil && seconds > 0 {
+		timeout = time.Duration(seconds) * time.Second
+	}
+
+	providers := map[general.CoinType][]historical.HistoryProvider{
+		general.Solana: {
+			controllerPool.solanaController.HistoryProvider(),
+		},
+	}
+
+	// Test networks are served live from their Esplora API and never indexed
+	for id, controller := range controllerPool.esploraControllers {
+		if id.Net != blockstream.Mainnet {
+			live := historical.NewFailoverProvider(timeout, controller.HistoryProvider())
+			controllerPool.testHistoryControllers[id] = historical.NewController(live, historical.LegacyObject)
+			continue
+		}
+		providers[id.CoinType] = append(providers[id.CoinType], controller.HistoryProvider())
+	}
+	providers[general.Bitcoin] = append(providers[general.Bitcoin], controllerPool.bitcoinController.HistoryProvider())
+
+	// Every Alchemy-backed EVM chain serves history from alchemy_getAssetTransfers first
+	for coinType, controller := range controllerPool.alchemyHistoricControllers {
+		providers[coinType] = append(providers[coinType], controller.HistoryProvider())
+	}
+
+	providers[general.Ethereum] = append(providers[general.Ethereum], controllerPool.ethereumMoralisController.HistoryProvider())
+	providers[general.Polygon] = append(providers[general.Polygon], controllerPool.polygonController.HistoryProvider())
+
+	for coinType, controller := range controllerPool.explorerControllers {
+		providers[coinType] = append(providers[coinType], controller.HistoryProvider())
+	}
+
+	// With Postgres configured, history is read from the index first and the live providers feed the sync worker
+	var store index.Store
+	if db, err := database.GetDB(); err == nil {
+		store = index.NewPostgresStore(db)
+		controllerPool.indexController = index.NewController(store)
+	} else if !errors.Is(err, database.ErrNotConfigured) {
+		log.Printf("history index disabled: %v", err)
+	}
+
+	sources := make(map[string]historical.HistoryProvider)
+	for coinType, chainProviders := range providers {
+		live := historical.NewFailoverProvider(timeout, chainProviders...)
+		sources[string(coinType)] = live
+
+		failover := live
+		if store != nil {
+			indexed := append([]historical.HistoryProvider{index.NewHistoryProvider(store, string(coinType))}, chainProviders...)
+			failover = historical.NewFailoverProvider(timeout, indexed...)
+		}
+		legacy := historical.LegacyList
+		if _, ok := controllerPool.esploraControllers[blockstream.NetworkID{CoinType: coinType, Net: blockstream.Mainnet}]; ok {
+			legacy = historical.LegacyObject
+		}
+		controllerPool.historyControllers[coinType] = historical.NewController(failover, legacy)
+	}
+
+	if store != nil {
+		interval := index.DefaultSyncInterval
+		if seconds, err := strconv.Atoi(os.Getenv("HISTORY_SYNC_INTERVAL_SECONDS")); err == nil && seconds > 0 {
+			interval = time.Duration(seconds) * time.Second
+		}
+		index.NewWorker(store, sources, interval).Start(context.Background())
+	}
+}
+
 func RegisterRoutes(rg *gin.RouterGroup) {
 	// Initialize controllers once
 	initControllers()
 
+	// Every route below is registered on a group carrying its API key scope
+	portfolioGroup := rg.Group("/portfolio", apikey.RequireScope(apikey.ScopeBalancesRead))
+	portfolioGroup.POST("", func(ctx *gin.Context) {
+		controllerPool.GetPortfolioController().GetPortfolio(ctx)
+	})
+
 	productGroup := rg.Group("/data")
-	productGroup.GET("/", GetProducts)
+	productGroup.Group("", apikey.RequireScope(apikey.ScopeChainRead)).GET("/", GetProducts)
 
 	blockchainGroup := productGroup.Group("/:id")
 	{
@@ -157,44 +341,55 @@ func RegisterRoutes(rg *gin.RouterGroup) {
 }
 
 func registerHistoricalRoutes(rg *gin.RouterGroup) {
-	rg.GET("/address/:address", func(ctx *gin.Context) {
-		blockchainID := ctx.Param("id")
-		//coinType := general.CoinType(blockchainID)
-		//
-		//if controller := controllerPool.GetAlchemyHistoricController(coinType); controller != nil {
-		//	controller.GetTransactionHistoryByAddress(ctx)
-		//} else {
-		//	ctx.JSON(400, gin.H{"error": "Unsupported blockchain"})
-		//}
-		//
-		switch general.CoinType(blockchainID) {
-		case general.Bitcoin:
-			controllerPool.GetBlockstreamController().GetAddressTransactions(ctx)
-		case general.Ethereum:
-			controllerPool.GetEthereumController().GetAssetTransfers(ctx)
-		case general.Solana:
-			controllerPool.GetSolanaController().GetAddressInfo(ctx)
-		case general.Polygon:
-			controllerPool.GetPolygonController().GetWalletHistory(ctx)
-		default:
+	history := rg.Group("", apikey.RequireScope(apikey.ScopeHistoryRead))
+	balances := rg.Group("", apikey.RequireScope(apikey.ScopeBalancesRead))
+
+	history.GET("/address/:address", func(ctx *gin.Context) {
+		coinType := general.CoinType(ctx.Param("id"))
+		net := blockstream.Net(ctx.DefaultQuery("network", string(blockstream.Mainnet)))
+
+		if controller := controllerPool.GetNetworkHistoryController(coinType, net); controller != nil {
+			controller.GetAddressHistory(ctx)
+		} else {
 			ctx.JSON(400, gin.H{"error": "Unsupported blockchain"})
 		}
 	})
 
-	rg.GET("/tokens/:address", func(ctx *gin.Context) {
+	// Subscribing an address to the history index is limited to signed in users
+	history.POST("/address/:address/watch", auth.RequireAuth(), func(ctx *gin.Context) {
+		if controllerPool.GetHistoryController(general.CoinType(ctx.Param("id"))) == nil {
+			ctx.JSON(400, gin.H{"error": "Unsupported blockchain"})
+			return
+		}
+		if controllerPool.indexController == nil {
+			ctx.JSON(503, gin.H{"error": "History index is not configured"})
+			return
+		}
+		controllerPool.indexController.Watch(ctx)
+	})
+
+	history.POST("/account", func(ctx *gin.Context) {
+		if general.CoinType(ctx.Param("id")) != general.Bitcoin {
+			ctx.JSON(400, gin.H{"error": "Account scanning is only supported for Bitcoin"})
+			return
+		}
+		controllerPool.GetBlockstreamController().ScanAccount(ctx)
+	})
+
+	balances.GET("/tokens/:address", func(ctx *gin.Context) {
 		controllerPool.GetAlchemyTokenController().GetTokensByAddress(ctx)
 	})
 
-	rg.POST("/tokens", func(ctx *gin.Context) {
+	balances.POST("/tokens", func(ctx *gin.Context) {
 		controllerPool.GetAlchemyTokenController().GetTokensByMultipleAddresses(ctx)
 	})
 
-	rg.GET("/tokens", func(ctx *gin.Context) {
+	balances.GET("/tokens", func(ctx *gin.Context) {
 		controllerPool.GetAlchemyTokenController().GetTokensByAddressQuery(ctx)
 	})
 
 	// Wallet token balances endpoint with multi-chain support
-	rg.GET("/balances/:address", func(ctx *gin.Context) {
+	balances.GET("/balances/:address", func(ctx *gin.Context) {
 		blockchainID := ctx.Param("id")
 
 		// Support multi-chain token balances
@@ -213,41 +408,140 @@ func registerHistoricalRoutes(rg *gin.RouterGroup) {
 }
 
 func RegisterRPCRoutes(rg *gin.RouterGroup) {
+	chain := rg.Group("", apikey.RequireScope(apikey.ScopeChainRead))
+	balances := rg.Group("", apikey.RequireScope(apikey.ScopeBalancesRead))
+	build := rg.Group("", apikey.RequireScope(apikey.ScopeTxBuild))
+	// Broadcasting requires the tx:send scope and a registered single-use nonce, see ClientNonceAuthMiddleware
+	send := rg.Group("", apikey.RequireScope(apikey.ScopeTxSend), staticServices.ClientNonceAuthMiddleware(staticServices.GetNonceStore()))
 
-	rg.POST("/send", func(ctx *gin.Context) {
+	send.POST("/send", func(ctx *gin.Context) {
 		blockchainID := ctx.Param("id")
 		coinType := general.CoinType(blockchainID)
 
-		if controller := controllerPool.GetAlchemyRPCController(coinType); controller != nil {
+		if coinType == general.Bitcoin {
+			controllerPool.GetBitcoinRPCController().SendRawTransaction(ctx)
+		} else if solanaController := controllerPool.GetSolanaRPCController(); coinType == general.Solana && solanaController != nil {
+			solanaController.SendRawTransaction(ctx)
+		} else if controller := controllerPool.GetAlchemyRPCController(coinType); controller != nil {
 			controller.SendRawTransaction(ctx)
 		} else {
 			ctx.JSON(400, gin.H{"error": "Unsupported blockchain"})
 		}
 	})
 
-	rg.POST("/feeEstimate", func(ctx *gin.Context) {
+	chain.POST("/feeEstimate", func(ctx *gin.Context) {
 		blockchainID := ctx.Param("id")
 		coinType := general.CoinType(blockchainID)
 
-		if controller := controllerPool.GetAlchemyRPCController(coinType); controller != nil {
+		if coinType == general.Bitcoin {
+			controllerPool.GetBitcoinRPCController().GetFeeEstimates(ctx)
+		} else if solanaController := controllerPool.GetSolanaRPCController(); coinType == general.Solana && solanaController != nil {
+			solanaController.GetEstimateFee(ctx)
+		} else if controller := controllerPool.GetAlchemyRPCController(coinType); controller != nil {
 			controller.GetEstimateGas(ctx)
 		} else {
 			ctx.JSON(400, gin.H{"error": "Unsupported blockchain"})
 		}
 	})
 
-	rg.GET("/getGasPrice", func(ctx *gin.Context) {
+	balances.GET("/utxos/:address", func(ctx *gin.Context) {
+		if general.CoinType(ctx.Param("id")) != general.Bitcoin {
+			ctx.JSON(400, gin.H{"error": "UTXOs are only supported for Bitcoin"})
+			return
+		}
+		controllerPool.GetBitcoinRPCController().GetUTXOs(ctx)
+	})
+
+	build.POST("/coinselect", func(ctx *gin.Context) {
+		if general.CoinType(ctx.Param("id")) != general.Bitcoin {
+			ctx.JSON(400, gin.H{"error": "Coin selection is only supported for Bitcoin"})
+			return
+		}
+		controllerPool.GetBitcoinRPCController().CoinSelect(ctx)
+	})
+
+	build.POST("/psbt", func(ctx *gin.Context) {
+		if general.CoinType(ctx.Param("id")) != general.Bitcoin {
+			ctx.JSON(400, gin.H{"error": "PSBTs are only supported for Bitcoin"})
+			return
+		}
+		controllerPool.GetBitcoinRPCController().CreatePSBT(ctx)
+	})
+
+	build.POST("/psbt/decode", func(ctx *gin.Context) {
+		if general.CoinType(ctx.Param("id")) != general.Bitcoin {
+			ctx.JSON(400, gin.H{"error": "PSBTs are only supported for Bitcoin"})
+			return
+		}
+		controllerPool.GetBitcoinRPCController().DecodePSBT(ctx)
+	})
+
+	build.POST("/psbt/finalize", func(ctx *gin.Context) {
+		if general.CoinType(ctx.Param("id")) != general.Bitcoin {
+			ctx.JSON(400, gin.H{"error": "PSBTs are only supported for Bitcoin"})
+			return
+		}
+		controllerPool.GetBitcoinRPCController().FinalizePSBT(ctx)
+	})
+
+	chain.GET("/getGasPrice", func(ctx *gin.Context) {
 		blockchainID := ctx.Param("id")
 		coinType := general.CoinType(blockchainID)
 
-		if controller := controllerPool.GetAlchemyRPCController(coinType); controller != nil {
+		if coinType == general.Bitcoin {
+			controllerPool.GetBitcoinRPCController().GetFeeEstimates(ctx)
+		} else if solanaController := controllerPool.GetSolanaRPCController(); coinType == general.Solana && solanaController != nil {
+			solanaController.GetGasPrice(ctx)
+		} else if controller := controllerPool.GetAlchemyRPCController(coinType); controller != nil {
 			controller.GetGasPrice(ctx)
 		} else {
 			ctx.JSON(400, gin.H{"error": "Unsupported blockchain"})
 		}
 	})
 
-	rg.POST("/getCount", func(ctx *gin.Context) {
+	balances.POST("/balance", func(ctx *gin.Context) {
+		coinType := general.CoinType(ctx.Param("id"))
+
+		if controller := controllerPool.GetAlchemyRPCController(coinType); controller != nil {
+			controller.GetBalance(ctx)
+		} else {
+			ctx.JSON(400, gin.H{"error": "Unsupported blockchain"})
+		}
+	})
+
+	balances.POST("/tokenBalances", func(ctx *gin.Context) {
+		coinType := general.CoinType(ctx.Param("id"))
+
+		if controller := controllerPool.GetAlchemyRPCController(coinType); controller != nil {
+			controller.GetTokenBalances(ctx)
+		} else {
+			ctx.JSON(400, gin.H{"error": "Unsupported blockchain"})
+		}
+	})
+
+	chain.GET("/fees", func(ctx *gin.Context) {
+		coinType := general.CoinType(ctx.Param("id"))
+
+		if coinType == general.Bitcoin {
+			controllerPool.GetBitcoinRPCController().GetFeeEstimates(ctx)
+		} else if controller := controllerPool.GetAlchemyRPCController(coinType); controller != nil {
+			controller.GetFeeSuggestions(ctx)
+		} else {
+			ctx.JSON(400, gin.H{"error": "Unsupported blockchain"})
+		}
+	})
+
+	chain.GET("/recentBlockhash", func(ctx *gin.Context) {
+		coinType := general.CoinType(ctx.Param("id"))
+
+		if solanaController := controllerPool.GetSolanaRPCController(); coinType == general.Solana && solanaController != nil {
+			solanaController.GetRecentBlockhash(ctx)
+		} else {
+			ctx.JSON(400, gin.H{"error": "Unsupported blockchain"})
+		}
+	})
+
+	chain.POST("/getCount", func(ctx *gin.Context) {
 		blockchainID := ctx.Param("id")
 		coinType := general.CoinType(blockchainID)
 
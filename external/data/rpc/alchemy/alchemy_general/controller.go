@@ -1,10 +1,15 @@
 package alchemy_general
 
 import (
+	"fmt"
+	"log"
+	"math/big"
 	"net/http"
 	"os"
 	_ "os"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/tashunc/nugenesis-wallet-backend/external/data/rpc/alchemy/alchemy_models"
@@ -13,6 +18,14 @@ import (
 
 type Controller struct {
 	service *Service
+
+	// blockTime is the measured average block time, refreshed after blockTimeTTL. A failed sample is retried after
+	// blockTimeRetryAfter, and only one request samples at a time while the others use the cached value.
+	blockTimeMu         sync.Mutex
+	blockTime           time.Duration
+	blockTimeSampledAt  time.Time
+	blockTimeFailedAt   time.Time
+	blockTimeRefreshing bool
 }
 
 func NewController(baseUrl string) *Controller {
@@ -216,3 +229,258 @@ func (c *Controller) GetGasPrice(ctx *gin.Context) {
 		Message:  "Gas price retrieved successfully",
 	})
 }
+
+// GetFeeSuggestions returns slow, standard and fast fee tiers, using eth_feeHistory on EIP-1559 chains and falling
+// back to eth_gasPrice elsewhere
+func (c *Controller) GetFeeSuggestions(ctx *gin.Context) {
+	blockTime := c.averageBlockTime()
+
+	history, err := c.service.GetFeeHistory(feeHistoryBlocks, feePercentiles)
+	if err != nil {
+		log.Printf("failed to get fee history, falling back to the gas price: %v", err)
+	} else if history.Error == nil {
+		var nodeTip *big.Int
+		if tipResponse, err := c.service.GetMaxPriorityFeePerGas(); err == nil && tipResponse.Error == nil {
+			nodeTip, _ = parseQuantity(tipResponse.Result)
+		}
+
+		if baseFee, tips, ok := SuggestEIP1559Fees(history.Result, nodeTip); ok {
+			tiers := eip1559Tiers(baseFee, tips, blockTime)
+			ctx.JSON(http.StatusOK, models.FeeSuggestionsControllerResponse{
+				Success:       true,
+				Mode:          FeeModeEIP1559,
+				BaseFeePerGas: formatQuantity(baseFee),
+				Slow:          tiers[0],
+				Standard:      tiers[1],
+				Fast:          tiers[2],
+				Message:       "Fee suggestions retrieved successfully",
+			})
+			return
+		}
+	}
+
+	response, err := c.service.GetGasPrice()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, models.FeeSuggestionsControllerResponse{
+			Success: false,
+			Message: "Failed to get gas price",
+			Error: &models.SendRawTransactionError{
+				Code:    500,
+				Message: err.Error(),
+			},
+		})
+		return
+	}
+
+	if response.Error != nil {
+		ctx.JSON(http.StatusBadRequest, models.FeeSuggestionsControllerResponse{
+			Success: false,
+			Message: "Gas price retrieval failed",
+			Error:   response.Error,
+		})
+		return
+	}
+
+	gasPrice, ok := parseQuantity(response.Result)
+	if !ok {
+		ctx.JSON(http.StatusInternalServerError, models.FeeSuggestionsControllerResponse{
+			Success: false,
+			Message: "Gas price retrieval failed",
+			Error: &models.SendRawTransactionError{
+				Code:    500,
+				Message: "invalid gas price " + response.Result,
+			},
+		})
+		return
+	}
+
+	tiers := legacyTiers(gasPrice, blockTime)
+	ctx.JSON(http.StatusOK, models.FeeSuggestionsControllerResponse{
+		Success:  true,
+		Mode:     FeeModeLegacy,
+		Slow:     tiers[0],
+		Standard: tiers[1],
+		Fast:     tiers[2],
+		Message:  "Fee suggestions retrieved successfully",
+	})
+}
+
+// averageBlockTime measures the block time over the last blockTimeSampleBlocks blocks. It returns 0 when the chain
+// cannot be sampled, which leaves inclusion estimates unset rather than failing the request.
+func (c *Controller) averageBlockTime() time.Duration {
+	c.blockTimeMu.Lock()
+	cached := c.blockTime
+	fresh := !c.blockTimeSampledAt.IsZero() && time.Since(c.blockTimeSampledAt) < blockTimeTTL
+	failed := !c.blockTimeFailedAt.IsZero() && time.Since(c.blockTimeFailedAt) < blockTimeRetryAfter
+	if fresh || failed || c.blockTimeRefreshing {
+		c.blockTimeMu.Unlock()
+		return cached
+	}
+	c.blockTimeRefreshing = true
+	c.blockTimeMu.Unlock()
+
+	blockTime, err := c.sampleBlockTime()
+
+	c.blockTimeMu.Lock()
+	defer c.blockTimeMu.Unlock()
+	c.blockTimeRefreshing = false
+	if err != nil {
+		log.Printf("failed to sample block time: %v", err)
+		c.blockTimeFailedAt = time.Now()
+		return cached
+	}
+	c.blockTime = blockTime
+	c.blockTimeSampledAt = time.Now()
+	c.blockTimeFailedAt = time.Time{}
+	return blockTime
+}
+
+func (c *Controller) sampleBlockTime() (time.Duration, error) {
+	latest, err := c.service.GetBlockByNumber("latest")
+	if err != nil {
+		return 0, err
+	}
+	if latest.Error != nil || latest.Result == nil {
+		return 0, fmt.Errorf("latest block unavailable")
+	}
+
+	number, ok := parseQuantity(latest.Result.Number)
+	if !ok || number.Int64() < blockTimeSampleBlocks {
+		return 0, fmt.Errorf("invalid latest block number %q", latest.Result.Number)
+	}
+
+	earlier, err := c.service.GetBlockByNumber(formatQuantity(big.NewInt(number.Int64() - blockTimeSampleBlocks)))
+	if err != nil {
+		return 0, err
+	}
+	if earlier.Error != nil || earlier.Result == nil {
+		return 0, fmt.Errorf("block %d unavailable", number.Int64()-blockTimeSampleBlocks)
+	}
+
+	latestTime, ok := parseQuantity(latest.Result.Timestamp)
+	if !ok {
+		return 0, fmt.Errorf("invalid timestamp %q", latest.Result.Timestamp)
+	}
+	earlierTime, ok := parseQuantity(earlier.Result.Timestamp)
+	if !ok {
+		return 0, fmt.Errorf("invalid timestamp %q", earlier.Result.Timestamp)
+	}
+
+	elapsed := time.Duration(latestTime.Int64()-earlierTime.Int64()) * time.Second
+	return elapsed / blockTimeSampleBlocks, nil
+}
+
+func (c *Controller) GetBalance(ctx *gin.Context) {
+	var request models.GetBalanceControllerRequest
+
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.GetBalanceControllerResponse{
+			Success: false,
+			Message: "Invalid request format",
+			Error: &models.SendRawTransactionError{
+				Code:    400,
+				Message: err.Error(),
+			},
+		})
+		return
+	}
+
+	blockParameter := request.BlockParameter
+	if blockParameter == "" {
+		blockParameter = "latest"
+	}
+
+	response, err := c.service.GetBalance(request.Address, blockParameter)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, models.GetBalanceControllerResponse{
+			Success: false,
+			Message: "Failed to get balance",
+			Error: &models.SendRawTransactionError{
+				Code:    500,
+				Message: err.Error(),
+			},
+		})
+		return
+	}
+
+	if response.Error != nil {
+		ctx.JSON(http.StatusBadRequest, models.GetBalanceControllerResponse{
+			Success: false,
+			Message: "Balance retrieval failed",
+			Error:   response.Error,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.GetBalanceControllerResponse{
+		Success: true,
+		Balance: response.Result,
+		Message: "Balance retrieved successfully",
+	})
+}
+
+// maxTokenBalancePages bounds the erc20 pages fetched for one request, Alchemy returns 100 balances per page
+const maxTokenBalancePages = 10
+
+// GetTokenBalances returns the balances of the requested contracts, or of every erc20 token held when none are given.
+// A listing longer than maxTokenBalancePages pages returns a cursor to continue from.
+func (c *Controller) GetTokenBalances(ctx *gin.Context) {
+	var request models.GetTokenBalancesControllerRequest
+
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.GetTokenBalancesControllerResponse{
+			Success: false,
+			Message: "Invalid request format",
+			Error: &models.SendRawTransactionError{
+				Code:    400,
+				Message: err.Error(),
+			},
+		})
+		return
+	}
+
+	var balances []models.TokenBalanceData
+	pageKey := request.Cursor
+	for page := 0; page < maxTokenBalancePages; page++ {
+		response, err := c.service.GetTokenBalances(request.Address, request.ContractAddresses, pageKey)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, models.GetTokenBalancesControllerResponse{
+				Success: false,
+				Message: "Failed to get token balances",
+				Error: &models.SendRawTransactionError{
+					Code:    500,
+					Message: err.Error(),
+				},
+			})
+			return
+		}
+
+		if response.Error != nil {
+			ctx.JSON(http.StatusBadRequest, models.GetTokenBalancesControllerResponse{
+				Success: false,
+				Message: "Token balances retrieval failed",
+				Error:   response.Error,
+			})
+			return
+		}
+
+		if response.Result == nil {
+			pageKey = ""
+			break
+		}
+		balances = append(balances, response.Result.TokenBalances...)
+		pageKey = response.Result.PageKey
+		if pageKey == "" || len(request.ContractAddresses) > 0 {
+			pageKey = ""
+			break
+		}
+	}
+
+	ctx.JSON(http.StatusOK, models.GetTokenBalancesControllerResponse{
+		Success:       true,
+		Address:       request.Address,
+		TokenBalances: balances,
+		Cursor:        pageKey,
+		Message:       "Token balances retrieved successfully",
+	})
+}
@@ -0,0 +1,171 @@
+package alchemy_general
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tashunc/nugenesis-wallet-backend/external/models"
+)
+
+// rpcNode is a JSON-RPC server answering each method with a canned result, or HTTP 400 for methods without one
+type rpcNode struct {
+	mu      sync.Mutex
+	results map[string]func(params []json.RawMessage) interface{}
+	calls   map[string]int
+}
+
+func newTestController(t *testing.T, results map[string]func(params []json.RawMessage) interface{}) (*Controller, *rpcNode) {
+	t.Helper()
+	node := &rpcNode{results: results, calls: make(map[string]int)}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&request)
+
+		node.mu.Lock()
+		node.calls[request.Method]++
+		result, ok := node.results[request.Method]
+		node.mu.Unlock()
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": 1, "result": result(request.Params)})
+	}))
+	t.Cleanup(server.Close)
+
+	apiKey, baseURL := "", server.URL
+	return &Controller{service: NewService(&apiKey, &baseURL)}, node
+}
+
+func (n *rpcNode) callCount(method string) int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.calls[method]
+}
+
+func constant(result interface{}) func([]json.RawMessage) interface{} {
+	return func([]json.RawMessage) interface{} { return result }
+}
+
+func serve(handler gin.HandlerFunc, target string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(recorder)
+	ctx.Request = httptest.NewRequest(http.MethodGet, target, nil)
+	handler(ctx)
+	return recorder
+}
+
+func TestGetFeeSuggestionsFallsBackToGasPrice(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	controller, node := newTestController(t, map[string]func([]json.RawMessage) interface{}{
+		"eth_gasPrice": constant("0x3b9aca00"),
+	})
+
+	recorder := serve(controller.GetFeeSuggestions, "/fees")
+	var response models.FeeSuggestionsControllerResponse
+	_ = json.Unmarshal(recorder.Body.Bytes(), &response)
+	if recorder.Code != http.StatusOK || response.Mode != FeeModeLegacy || response.Standard == nil {
+		t.Fatalf("Expected legacy fees when eth_feeHistory fails, got %d %s", recorder.Code, recorder.Body.String())
+	}
+
+	// The failed block time sample is not retried by the next request
+	serve(controller.GetFeeSuggestions, "/fees")
+	if calls := node.callCount("eth_getBlockByNumber"); calls != 1 {
+		t.Errorf("Expected the failed block time sample to be cached, got %d block requests", calls)
+	}
+}
+
+func post(handler gin.HandlerFunc, body string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(recorder)
+	ctx.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	ctx.Request.Header.Set("Content-Type", "application/json")
+	handler(ctx)
+	return recorder
+}
+
+func TestGetBalance(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var blockParameter string
+	controller, _ := newTestController(t, map[string]func([]json.RawMessage) interface{}{
+		"eth_getBalance": func(params []json.RawMessage) interface{} {
+			_ = json.Unmarshal(params[1], &blockParameter)
+			return "0xde0b6b3a7640000"
+		},
+	})
+
+	recorder := post(controller.GetBalance, `{"address":"0xd8dA6BF26964aF9D7eEd9e03E53415D37aA96045"}`)
+	var response models.GetBalanceControllerResponse
+	_ = json.Unmarshal(recorder.Body.Bytes(), &response)
+	if recorder.Code != http.StatusOK || response.Balance != "0xde0b6b3a7640000" || blockParameter != "latest" {
+		t.Errorf("Unexpected balance at %q: %d %s", blockParameter, recorder.Code, recorder.Body.String())
+	}
+
+	if recorder := post(controller.GetBalance, `{}`); recorder.Code != http.StatusBadRequest {
+		t.Errorf("Expected a request without address to be rejected, got %d", recorder.Code)
+	}
+}
+
+func TestGetTokenBalancesReturnsCursorWhenTruncated(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	// Every page points to another one, so the listing stops after maxTokenBalancePages
+	controller, node := newTestController(t, map[string]func([]json.RawMessage) interface{}{
+		"alchemy_getTokenBalances": func(params []json.RawMessage) interface{} {
+			page := 0
+			if len(params) > 2 {
+				var options struct {
+					PageKey string `json:"pageKey"`
+				}
+				_ = json.Unmarshal(params[2], &options)
+				page, _ = strconv.Atoi(options.PageKey)
+			}
+			return map[string]interface{}{
+				"tokenBalances": []map[string]string{{"contractAddress": fmt.Sprintf("0x%d", page), "tokenBalance": "0x1"}},
+				"pageKey":       strconv.Itoa(page + 1),
+			}
+		},
+	})
+
+	recorder := post(controller.GetTokenBalances, `{"address":"0xd8dA6BF26964aF9D7eEd9e03E53415D37aA96045"}`)
+	var response models.GetTokenBalancesControllerResponse
+	_ = json.Unmarshal(recorder.Body.Bytes(), &response)
+	if len(response.TokenBalances) != maxTokenBalancePages || response.Cursor != strconv.Itoa(maxTokenBalancePages) {
+		t.Fatalf("Expected %d balances and a cursor, got %s", maxTokenBalancePages, recorder.Body.String())
+	}
+
+	recorder = post(controller.GetTokenBalances, `{"address":"0xd8dA6BF26964aF9D7eEd9e03E53415D37aA96045","cursor":"`+response.Cursor+`"}`)
+	_ = json.Unmarshal(recorder.Body.Bytes(), &response)
+	if response.TokenBalances[0].ContractAddress != fmt.Sprintf("0x%d", maxTokenBalancePages) {
+		t.Errorf("Expected the listing to continue from the cursor, got %s", recorder.Body.String())
+	}
+	if calls := node.callCount("alchemy_getTokenBalances"); calls != 2*maxTokenBalancePages {
+		t.Errorf("Expected %d page requests, got %d", 2*maxTokenBalancePages, calls)
+	}
+}
+
+func TestGetTokenBalancesOfContracts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	controller, _ := newTestController(t, map[string]func([]json.RawMessage) interface{}{
+		"alchemy_getTokenBalances": constant(map[string]interface{}{
+			"tokenBalances": []map[string]string{{"contractAddress": "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48", "tokenBalance": "0x5"}},
+			"pageKey":       "next",
+		}),
+	})
+
+	recorder := post(controller.GetTokenBalances, `{"address":"0xd8dA6BF26964aF9D7eEd9e03E53415D37aA96045","contractAddresses":["0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"]}`)
+	var response models.GetTokenBalancesControllerResponse
+	_ = json.Unmarshal(recorder.Body.Bytes(), &response)
+	if recorder.Code != http.StatusOK || len(response.TokenBalances) != 1 || response.Cursor != "" {
+		t.Errorf("Expected the single page of the requested contracts, got %s", recorder.Body.String())
+	}
+}
@@ -0,0 +1,131 @@
+package alchemy_general
+
+import (
+	"math"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tashunc/nugenesis-wallet-backend/external/data/rpc/alchemy/alchemy_models"
+	"github.com/tashunc/nugenesis-wallet-backend/external/models"
+)
+
+const (
+	FeeModeEIP1559 = "eip1559"
+	FeeModeLegacy  = "legacy"
+
+	// feeHistoryBlocks is the number of recent blocks the priority fee percentiles are taken from
+	feeHistoryBlocks = 20
+	// blockTimeSampleBlocks is the distance between the two blocks used to measure the average block time
+	blockTimeSampleBlocks = 100
+	blockTimeTTL          = time.Hour
+	// blockTimeRetryAfter spaces out the samples of a chain whose blocks cannot be fetched
+	blockTimeRetryAfter = time.Minute
+)
+
+// feePercentiles are the priority fee percentiles requested for the slow, standard and fast tiers
+var feePercentiles = []float64{10, 50, 90}
+
+// tierBlocks is the expected number of blocks until inclusion for the slow, standard and fast tiers
+var tierBlocks = [3]int64{6, 3, 1}
+
+// legacyMultipliers scale eth_gasPrice, in percent, for the slow, standard and fast tiers
+var legacyMultipliers = [3]int64{90, 100, 120}
+
+// parseQuantity parses a hex quantity such as 0x1a
+func parseQuantity(value string) (*big.Int, bool) {
+	value = strings.TrimPrefix(strings.TrimPrefix(value, "0x"), "0X")
+	if value == "" {
+		return nil, false
+	}
+	return new(big.Int).SetString(value, 16)
+}
+
+func formatQuantity(value *big.Int) string {
+	return "0x" + value.Text(16)
+}
+
+// SuggestEIP1559Fees derives base fee and per tier priority fees from a fee history. Each tier tip is the median of
+// its percentile over non-empty blocks, the standard tip is raised to the node suggestion when that is higher.
+// The returned bool is false when the chain reports no base fee, i.e. it does not support EIP-1559.
+func SuggestEIP1559Fees(history *alchemy_models.FeeHistory, nodeTip *big.Int) (*big.Int, [3]*big.Int, bool) {
+	var tips [3]*big.Int
+	if history == nil || len(history.BaseFeePerGas) == 0 {
+		return nil, tips, false
+	}
+
+	baseFee, ok := parseQuantity(history.BaseFeePerGas[len(history.BaseFeePerGas)-1])
+	if !ok || baseFee.Sign() == 0 {
+		return nil, tips, false
+	}
+
+	for tier := range tips {
+		var rewards []*big.Int
+		for block, reward := range history.Reward {
+			if block < len(history.GasUsedRatio) && history.GasUsedRatio[block] == 0 {
+				continue
+			}
+			if tier >= len(reward) {
+				continue
+			}
+			if value, ok := parseQuantity(reward[tier]); ok {
+				rewards = append(rewards, value)
+			}
+		}
+		tips[tier] = medianBig(rewards)
+	}
+
+	if nodeTip != nil && nodeTip.Cmp(tips[1]) > 0 {
+		tips[1] = nodeTip
+	}
+	if tips[0].Cmp(tips[1]) > 0 {
+		tips[0] = tips[1]
+	}
+	if tips[2].Cmp(tips[1]) < 0 {
+		tips[2] = tips[1]
+	}
+	return baseFee, tips, true
+}
+
+// eip1559Tiers allows the base fee to double before the transaction is priced out, which covers six full blocks
+func eip1559Tiers(baseFee *big.Int, tips [3]*big.Int, blockTime time.Duration) [3]*models.FeeTier {
+	var tiers [3]*models.FeeTier
+	for i, tip := range tips {
+		maxFee := new(big.Int).Add(new(big.Int).Mul(baseFee, big.NewInt(2)), tip)
+		tiers[i] = &models.FeeTier{
+			MaxFeePerGas:         formatQuantity(maxFee),
+			MaxPriorityFeePerGas: formatQuantity(tip),
+			EstimatedSeconds:     estimatedSeconds(tierBlocks[i], blockTime),
+		}
+	}
+	return tiers
+}
+
+func legacyTiers(gasPrice *big.Int, blockTime time.Duration) [3]*models.FeeTier {
+	var tiers [3]*models.FeeTier
+	for i, multiplier := range legacyMultipliers {
+		price := new(big.Int).Mul(gasPrice, big.NewInt(multiplier))
+		price.Div(price, big.NewInt(100))
+		tiers[i] = &models.FeeTier{
+			GasPrice:         formatQuantity(price),
+			EstimatedSeconds: estimatedSeconds(tierBlocks[i], blockTime),
+		}
+	}
+	return tiers
+}
+
+func estimatedSeconds(blocks int64, blockTime time.Duration) int64 {
+	if blockTime <= 0 {
+		return 0
+	}
+	return int64(math.Ceil((time.Duration(blocks) * blockTime).Seconds()))
+}
+
+func medianBig(values []*big.Int) *big.Int {
+	if len(values) == 0 {
+		return big.NewInt(0)
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i].Cmp(values[j]) < 0 })
+	return values[len(values)/2]
+}
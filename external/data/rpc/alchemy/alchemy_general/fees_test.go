@@ -0,0 +1,62 @@
+package alchemy_general
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/tashunc/nugenesis-wallet-backend/external/data/rpc/alchemy/alchemy_models"
+)
+
+func TestSuggestEIP1559Fees(t *testing.T) {
+	history := &alchemy_models.FeeHistory{
+		BaseFeePerGas: []string{"0x3b9aca00", "0x3b9aca00", "0x3b9aca00", "0x77359400"},
+		GasUsedRatio:  []float64{0.5, 0, 0.9},
+		Reward: [][]string{
+			{"0x1", "0x64", "0x3e8"},
+			{"0x0", "0x0", "0x0"},
+			{"0x3", "0xc8", "0x7d0"},
+		},
+	}
+
+	baseFee, tips, ok := SuggestEIP1559Fees(history, nil)
+	if !ok {
+		t.Fatal("Expected EIP-1559 fees")
+	}
+	if baseFee.Int64() != 2000000000 {
+		t.Errorf("Expected base fee of the next block, got %s", baseFee)
+	}
+	// the empty block is ignored, so each median is the higher of the two remaining blocks
+	if tips[0].Int64() != 3 || tips[1].Int64() != 200 || tips[2].Int64() != 2000 {
+		t.Errorf("Unexpected tips %v", tips)
+	}
+
+	_, tips, _ = SuggestEIP1559Fees(history, big.NewInt(5000))
+	if tips[1].Int64() != 5000 || tips[2].Int64() != 5000 {
+		t.Errorf("Expected node tip to raise standard and fast tiers, got %v", tips)
+	}
+
+	tiers := eip1559Tiers(baseFee, tips, 12*time.Second)
+	if tiers[1].MaxFeePerGas != formatQuantity(big.NewInt(4000005000)) {
+		t.Errorf("Expected max fee of twice the base fee plus tip, got %s", tiers[1].MaxFeePerGas)
+	}
+	if tiers[2].EstimatedSeconds != 12 || tiers[0].EstimatedSeconds != 72 {
+		t.Errorf("Unexpected inclusion estimates %d, %d", tiers[2].EstimatedSeconds, tiers[0].EstimatedSeconds)
+	}
+}
+
+func TestSuggestEIP1559FeesWithoutBaseFee(t *testing.T) {
+	history := &alchemy_models.FeeHistory{
+		BaseFeePerGas: []string{"0x0", "0x0"},
+		GasUsedRatio:  []float64{0.5},
+	}
+
+	if _, _, ok := SuggestEIP1559Fees(history, nil); ok {
+		t.Error("Expected a chain without base fee to fall back to legacy")
+	}
+
+	tiers := legacyTiers(big.NewInt(1000), 0)
+	if tiers[0].GasPrice != "0x384" || tiers[2].GasPrice != "0x4b0" || tiers[1].EstimatedSeconds != 0 {
+		t.Errorf("Unexpected legacy tiers %+v %+v %+v", tiers[0], tiers[1], tiers[2])
+	}
+}
@@ -5,11 +5,16 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/tashunc/nugenesis-wallet-backend/external/data/rpc/alchemy/alchemy_models"
+	"github.com/tashunc/nugenesis-wallet-backend/pkg/httpclient"
 	"io"
 	"log"
 	"net/http"
+	"time"
 )
 
+// Timeout bounds a JSON-RPC call including retries
+const Timeout = 30 * time.Second
+
 type Service struct {
 	apiKey  *string
 	baseURL *string
@@ -20,7 +25,12 @@ func NewService(apiKey *string, baseURL *string) *Service {
 	return &Service{
 		apiKey:  apiKey,
 		baseURL: baseURL,
-		client:  &http.Client{},
+		client: httpclient.New(httpclient.Config{
+			Name:          "alchemy-evm",
+			Timeout:       Timeout,
+			RatePerSecond: 25,
+			Burst:         25,
+		}),
 	}
 }
 
@@ -40,7 +50,7 @@ func (s *Service) PostSendRawTransaction(signedTxs []string) (*alchemy_models.Se
 		return nil, fmt.Errorf("failed to marshal payload: %w", err)
 	}
 
-	resp, err := http.Post(url, "application/json", bytes.NewBuffer(body))
+	resp, err := s.client.Post(url, "application/json", bytes.NewBuffer(body))
 	if err != nil {
 		return nil, fmt.Errorf("failed to send POST request: %w", err)
 	}
@@ -85,7 +95,7 @@ func (s *Service) GetEstimateGas(transactionObject alchemy_models.TransactionObj
 		return nil, fmt.Errorf("failed to marshal payload: %w", err)
 	}
 
-	resp, err := http.Post(url, "application/json", bytes.NewBuffer(body))
+	resp, err := httpclient.PostIdempotent(s.client, url, "application/json", bytes.NewBuffer(body))
 	if err != nil {
 		return nil, fmt.Errorf("failed to send POST request: %w", err)
 	}
@@ -130,7 +140,7 @@ func (s *Service) GetTransactionCount(address string, blockParameter string) (*a
 		return nil, fmt.Errorf("failed to marshal payload: %w", err)
 	}
 
-	resp, err := http.Post(url, "application/json", bytes.NewBuffer(body))
+	resp, err := httpclient.PostIdempotent(s.client, url, "application/json", bytes.NewBuffer(body))
 	if err != nil {
 		return nil, fmt.Errorf("failed to send POST request: %w", err)
 	}
@@ -175,7 +185,7 @@ func (s *Service) GetGasPrice() (*alchemy_models.GetGasPriceResponse, error) {
 		return nil, fmt.Errorf("failed to marshal payload: %w", err)
 	}
 
-	resp, err := http.Post(url, "application/json", bytes.NewBuffer(body))
+	resp, err := httpclient.PostIdempotent(s.client, url, "application/json", bytes.NewBuffer(body))
 	if err != nil {
 		return nil, fmt.Errorf("failed to send POST request: %w", err)
 	}
@@ -204,3 +214,125 @@ func (s *Service) GetGasPrice() (*alchemy_models.GetGasPriceResponse, error) {
 
 	return &response, nil
 }
+
+// GetFeeHistory returns base fees and the given priority fee percentiles of the last blockCount blocks
+func (s *Service) GetFeeHistory(blockCount int, percentiles []float64) (*alchemy_models.FeeHistoryResponse, error) {
+	request := &alchemy_models.RPCRequest{
+		Jsonrpc: "2.0",
+		Method:  "eth_feeHistory",
+		Params:  []interface{}{fmt.Sprintf("0x%x", blockCount), "latest", percentiles},
+		Id:      1,
+	}
+
+	var response alchemy_models.FeeHistoryResponse
+	if err := s.post(request, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+func (s *Service) GetMaxPriorityFeePerGas() (*alchemy_models.MaxPriorityFeePerGasResponse, error) {
+	request := &alchemy_models.RPCRequest{
+		Jsonrpc: "2.0",
+		Method:  "eth_maxPriorityFeePerGas",
+		Params:  []interface{}{},
+		Id:      1,
+	}
+
+	var response alchemy_models.MaxPriorityFeePerGasResponse
+	if err := s.post(request, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// GetBlockByNumber returns the header of a block, blockParameter is a hex number or a tag such as latest
+func (s *Service) GetBlockByNumber(blockParameter string) (*alchemy_models.GetBlockByNumberResponse, error) {
+	request := &alchemy_models.RPCRequest{
+		Jsonrpc: "2.0",
+		Method:  "eth_getBlockByNumber",
+		Params:  []interface{}{blockParameter, false},
+		Id:      1,
+	}
+
+	var response alchemy_models.GetBlockByNumberResponse
+	if err := s.post(request, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// post sends a JSON-RPC request and decodes the response envelope, node errors are left in the response
+func (s *Service) post(request interface{}, response interface{}) error {
+	url := fmt.Sprintf("%s%s", *s.baseURL, *s.apiKey)
+
+	body, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	resp, err := httpclient.PostIdempotent(s.client, url, "application/json", bytes.NewBuffer(body))
+	if err != nil {
+		return fmt.Errorf("failed to send POST request: %w", err)
+	}
+	defer func(Body io.ReadCloser) {
+		err := Body.Close()
+		if err != nil {
+			log.Printf("failed to close response body: %v", err)
+		}
+	}(resp.Body)
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("alchemy API returned status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	if err := json.Unmarshal(respBody, response); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return nil
+}
+
+// GetBalance returns the native balance in wei of an address at blockParameter
+func (s *Service) GetBalance(address string, blockParameter string) (*alchemy_models.GetBalanceResponse, error) {
+	request := &alchemy_models.RPCRequest{
+		Jsonrpc: "2.0",
+		Method:  "eth_getBalance",
+		Params:  []interface{}{address, blockParameter},
+		Id:      1,
+	}
+
+	var response alchemy_models.GetBalanceResponse
+	if err := s.post(request, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// GetTokenBalances returns the balances of the given contracts, or one page of all erc20 balances held by the address
+// when contractAddresses is empty
+func (s *Service) GetTokenBalances(address string, contractAddresses []string, pageKey string) (*alchemy_models.GetTokenBalancesResponse, error) {
+	params := []interface{}{address, "erc20"}
+	if len(contractAddresses) > 0 {
+		params = []interface{}{address, contractAddresses}
+	} else if pageKey != "" {
+		params = append(params, map[string]string{"pageKey": pageKey})
+	}
+
+	request := &alchemy_models.RPCRequest{
+		Jsonrpc: "2.0",
+		Method:  "alchemy_getTokenBalances",
+		Params:  params,
+		Id:      1,
+	}
+
+	var response alchemy_models.GetTokenBalancesResponse
+	if err := s.post(request, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
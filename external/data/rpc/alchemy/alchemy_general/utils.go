@@ -2,24 +2,24 @@ package alchemy_general
 
 import (
 	"fmt"
+	"github.com/tashunc/nugenesis-wallet-backend/external/data/fmv/pricing"
 	"github.com/tashunc/nugenesis-wallet-backend/external/data/historical/thrirdParty/etherscan/etherscan_models"
 	"github.com/tashunc/nugenesis-wallet-backend/external/models"
 	"strconv"
 	"time"
 )
 
-func MapTxToTransaction(tx etherscan_models.TxEntry, userAddress string) models.Transaction {
+// MapTxToTransaction maps an explorer transaction, valuing amount and fee in currency at block time
+func MapTxToTransaction(tx etherscan_models.TxEntry, userAddress string, currency string) models.Transaction {
 	timestamp, _ := strconv.ParseInt(tx.TimeStamp, 10, 64)
 	txTime := time.Unix(timestamp, 0)
 
 	valueWei, _ := strconv.ParseFloat(tx.Value, 64)
 	ethAmount := valueWei / 1e18
-	usdValue := ethAmount * 3500 // Example ETH to USD rate
 
 	feeWei, _ := strconv.ParseFloat(tx.GasPrice, 64)
 	gasUsed, _ := strconv.ParseFloat(tx.GasUsed, 64)
 	feeETH := (feeWei * gasUsed) / 1e18
-	feeUSD := feeETH * 3500
 
 	txType := "receive"
 	relevantAddress := tx.From
@@ -28,6 +28,8 @@ func MapTxToTransaction(tx etherscan_models.TxEntry, userAddress string) models.
 		relevantAddress = tx.To
 	}
 
+	priceService := pricing.GetPriceService()
+
 	status := "completed"
 	if tx.IsError == "1" {
 		status = "failed"
@@ -39,11 +41,11 @@ func MapTxToTransaction(tx etherscan_models.TxEntry, userAddress string) models.
 		Status:  status,
 		Token:   "ETH",
 		Amount:  fmt.Sprintf("%.6f", ethAmount),
-		Value:   fmt.Sprintf("$%.2f", usdValue),
+		Value:   priceService.FormatValue("ETH", ethAmount, txTime, currency),
 		Address: truncateAddress(relevantAddress),
 		Date:    txTime.Format("2006-01-02"),
 		Time:    txTime.Format("15:04"),
-		Fee:     fmt.Sprintf("$%.2f", feeUSD),
+		Fee:     priceService.FormatValue("ETH", feeETH, txTime, currency),
 		Hash:    truncateHash(tx.Hash),
 	}
 }
@@ -88,3 +88,67 @@ type GetGasPriceResponse struct {
 	Result  string                          `json:"result,omitempty"`
 	Error   *models.SendRawTransactionError `json:"error,omitempty"`
 }
+
+// RPCRequest is a JSON-RPC request with mixed-type params, e.g. eth_feeHistory
+type RPCRequest struct {
+	Jsonrpc string        `json:"jsonrpc"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+	Id      int           `json:"id"`
+}
+
+// FeeHistory is the eth_feeHistory result. BaseFeePerGas has one more entry than the requested block count, the last
+// being the base fee of the next block. Reward holds the requested priority fee percentiles for each block.
+type FeeHistory struct {
+	OldestBlock   string     `json:"oldestBlock"`
+	BaseFeePerGas []string   `json:"baseFeePerGas"`
+	GasUsedRatio  []float64  `json:"gasUsedRatio"`
+	Reward        [][]string `json:"reward,omitempty"`
+}
+
+type FeeHistoryResponse struct {
+	Jsonrpc string                          `json:"jsonrpc"`
+	Id      int                             `json:"id"`
+	Result  *FeeHistory                     `json:"result,omitempty"`
+	Error   *models.SendRawTransactionError `json:"error,omitempty"`
+}
+
+type MaxPriorityFeePerGasResponse struct {
+	Jsonrpc string                          `json:"jsonrpc"`
+	Id      int                             `json:"id"`
+	Result  string                          `json:"result,omitempty"`
+	Error   *models.SendRawTransactionError `json:"error,omitempty"`
+}
+
+type BlockHeader struct {
+	Number    string `json:"number"`
+	Timestamp string `json:"timestamp"`
+}
+
+type GetBlockByNumberResponse struct {
+	Jsonrpc string                          `json:"jsonrpc"`
+	Id      int                             `json:"id"`
+	Result  *BlockHeader                    `json:"result,omitempty"`
+	Error   *models.SendRawTransactionError `json:"error,omitempty"`
+}
+
+type GetBalanceResponse struct {
+	Jsonrpc string                          `json:"jsonrpc"`
+	Id      int                             `json:"id"`
+	Result  string                          `json:"result,omitempty"`
+	Error   *models.SendRawTransactionError `json:"error,omitempty"`
+}
+
+// TokenBalances is the alchemy_getTokenBalances result, PageKey is set when more erc20 balances are available
+type TokenBalances struct {
+	Address       string                    `json:"address"`
+	TokenBalances []models.TokenBalanceData `json:"tokenBalances"`
+	PageKey       string                    `json:"pageKey,omitempty"`
+}
+
+type GetTokenBalancesResponse struct {
+	Jsonrpc string                          `json:"jsonrpc"`
+	Id      int                             `json:"id"`
+	Result  *TokenBalances                  `json:"result,omitempty"`
+	Error   *models.SendRawTransactionError `json:"error,omitempty"`
+}
@@ -0,0 +1,46 @@
+package alchemy_models
+
+import (
+	"encoding/json"
+
+	"github.com/tashunc/nugenesis-wallet-backend/external/models"
+)
+
+type SolanaRPCRequest struct {
+	Jsonrpc string        `json:"jsonrpc"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+	Id      int           `json:"id"`
+}
+
+// SolanaRPCResponse carries the raw result so each method decodes its own shape
+type SolanaRPCResponse struct {
+	Jsonrpc string                          `json:"jsonrpc"`
+	Id      int                             `json:"id"`
+	Result  json.RawMessage                 `json:"result,omitempty"`
+	Error   *models.SendRawTransactionError `json:"error,omitempty"`
+}
+
+type SolanaContext struct {
+	Slot uint64 `json:"slot"`
+}
+
+type LatestBlockhash struct {
+	Context SolanaContext `json:"context"`
+	Value   struct {
+		Blockhash            string `json:"blockhash"`
+		LastValidBlockHeight uint64 `json:"lastValidBlockHeight"`
+	} `json:"value"`
+}
+
+// FeeForMessage value is null when the blockhash of the message has expired
+type FeeForMessage struct {
+	Context SolanaContext `json:"context"`
+	Value   *uint64       `json:"value"`
+}
+
+type PrioritizationFee struct {
+	Slot uint64 `json:"slot"`
+	// PrioritizationFee is in micro-lamports per compute unit
+	PrioritizationFee uint64 `json:"prioritizationFee"`
+}
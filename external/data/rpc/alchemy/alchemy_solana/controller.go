@@ -0,0 +1,182 @@
+package alchemy_solana
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tashunc/nugenesis-wallet-backend/external/data/rpc/alchemy/alchemy_models"
+	"github.com/tashunc/nugenesis-wallet-backend/external/models"
+)
+
+type Controller struct {
+	service *Service
+}
+
+func NewController(baseUrl string) *Controller {
+	AlchemyApiKey := os.Getenv("ALCHEMY_API_KEY")
+	controllerBaseURL := baseUrl
+	return &Controller{
+		service: NewService(&AlchemyApiKey, &controllerBaseURL),
+	}
+}
+
+// rpcErrorStatus maps node errors to 400 and transport failures to 500
+func rpcErrorStatus(err error) (int, *models.SendRawTransactionError) {
+	var rpcErr *RPCError
+	if errors.As(err, &rpcErr) {
+		return http.StatusBadRequest, &rpcErr.SendRawTransactionError
+	}
+	return http.StatusInternalServerError, &models.SendRawTransactionError{
+		Code:    500,
+		Message: err.Error(),
+	}
+}
+
+// SendRawTransaction broadcasts one signed transaction and returns its signature as the transaction hash
+func (c *Controller) SendRawTransaction(ctx *gin.Context) {
+	var request models.SendRawTransactionControllerRequest
+
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.SendRawTransactionControllerResponse{
+			Success: false,
+			Message: "Invalid request format",
+			Error: &models.SendRawTransactionError{
+				Code:    400,
+				Message: err.Error(),
+			},
+		})
+		return
+	}
+
+	if len(request.SignedTransactions) != 1 {
+		ctx.JSON(http.StatusBadRequest, models.SendRawTransactionControllerResponse{
+			Success: false,
+			Message: "Invalid request format",
+			Error: &models.SendRawTransactionError{
+				Code:    400,
+				Message: "params must contain exactly one signed transaction",
+			},
+		})
+		return
+	}
+
+	signature, err := c.service.SendTransaction(request.SignedTransactions[0])
+	if err != nil {
+		status, rpcErr := rpcErrorStatus(err)
+		ctx.JSON(status, models.SendRawTransactionControllerResponse{
+			Success: false,
+			Message: "Failed to send transaction",
+			Error:   rpcErr,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.SendRawTransactionControllerResponse{
+		Success:         true,
+		TransactionHash: signature,
+		Message:         "Transaction sent successfully",
+	})
+}
+
+// GetEstimateFee returns the base fee of a compiled message in lamports
+func (c *Controller) GetEstimateFee(ctx *gin.Context) {
+	var request models.SolanaFeeEstimateControllerRequest
+
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.EstimateGasControllerResponse{
+			Success: false,
+			Message: "Invalid request format",
+			Error: &models.SendRawTransactionError{
+				Code:    400,
+				Message: err.Error(),
+			},
+		})
+		return
+	}
+
+	fee, err := c.service.GetFeeForMessage(request.Message, DefaultCommitment)
+	if err != nil {
+		status, rpcErr := rpcErrorStatus(err)
+		ctx.JSON(status, models.EstimateGasControllerResponse{
+			Success: false,
+			Message: "Failed to estimate fee",
+			Error:   rpcErr,
+		})
+		return
+	}
+
+	if fee.Value == nil {
+		ctx.JSON(http.StatusBadRequest, models.EstimateGasControllerResponse{
+			Success: false,
+			Message: "Fee estimation failed",
+			Error: &models.SendRawTransactionError{
+				Code:    400,
+				Message: "blockhash of the message has expired",
+			},
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.EstimateGasControllerResponse{
+		Success:      true,
+		EstimatedGas: strconv.FormatUint(*fee.Value, 10),
+		Message:      "Fee estimated successfully in lamports",
+	})
+}
+
+// GetGasPrice returns the median priority fee of recent slots in micro-lamports per compute unit
+func (c *Controller) GetGasPrice(ctx *gin.Context) {
+	fees, err := c.service.GetRecentPrioritizationFees(ctx.QueryArray("account"))
+	if err != nil {
+		status, rpcErr := rpcErrorStatus(err)
+		ctx.JSON(status, models.GetGasPriceControllerResponse{
+			Success: false,
+			Message: "Failed to get prioritization fees",
+			Error:   rpcErr,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.GetGasPriceControllerResponse{
+		Success:  true,
+		GasPrice: strconv.FormatUint(medianPrioritizationFee(fees), 10),
+		Message:  "Priority fee retrieved successfully in micro-lamports per compute unit",
+	})
+}
+
+func (c *Controller) GetRecentBlockhash(ctx *gin.Context) {
+	blockhash, err := c.service.GetLatestBlockhash(DefaultCommitment)
+	if err != nil {
+		status, rpcErr := rpcErrorStatus(err)
+		ctx.JSON(status, models.RecentBlockhashControllerResponse{
+			Success: false,
+			Message: "Failed to get recent blockhash",
+			Error:   rpcErr,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.RecentBlockhashControllerResponse{
+		Success:              true,
+		Blockhash:            blockhash.Value.Blockhash,
+		LastValidBlockHeight: blockhash.Value.LastValidBlockHeight,
+		Slot:                 blockhash.Context.Slot,
+		Message:              "Recent blockhash retrieved successfully",
+	})
+}
+
+func medianPrioritizationFee(fees []alchemy_models.PrioritizationFee) uint64 {
+	if len(fees) == 0 {
+		return 0
+	}
+	values := make([]uint64, 0, len(fees))
+	for _, fee := range fees {
+		values = append(values, fee.PrioritizationFee)
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+	return values[len(values)/2]
+}
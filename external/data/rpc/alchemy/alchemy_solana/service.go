@@ -0,0 +1,172 @@
+package alchemy_solana
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/tashunc/nugenesis-wallet-backend/external/data/rpc/alchemy/alchemy_models"
+	"github.com/tashunc/nugenesis-wallet-backend/external/models"
+	"github.com/tashunc/nugenesis-wallet-backend/pkg/httpclient"
+)
+
+const (
+	Timeout = 30 * time.Second
+	// DefaultCommitment is used for blockhash and fee queries, matching the preflight commitment of sendTransaction
+	DefaultCommitment = "confirmed"
+)
+
+// RPCError is an error object returned by the Solana node, e.g. a failed preflight simulation
+type RPCError struct {
+	models.SendRawTransactionError
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("solana RPC error %d: %s", e.Code, e.Message)
+}
+
+type Service struct {
+	apiKey  *string
+	baseURL *string
+	client  *http.Client
+}
+
+func NewService(apiKey *string, baseURL *string) *Service {
+	return &Service{
+		apiKey:  apiKey,
+		baseURL: baseURL,
+		client: httpclient.New(httpclient.Config{
+			Name:          "alchemy-solana",
+			Timeout:       Timeout,
+			RatePerSecond: 25,
+			Burst:         25,
+		}),
+	}
+}
+
+// base58Alphabet excludes 0, O, I, l and the base64 symbols, so a base64 transaction practically always contains
+// a character outside it
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// SendTransaction submits a signed transaction and returns its signature.
+// The transaction may be base64 or base58 encoded, base64 is preferred by the node.
+func (s *Service) SendTransaction(signedTx string) (string, error) {
+	encoding := "base64"
+	if strings.Trim(signedTx, base58Alphabet) == "" {
+		encoding = "base58"
+	}
+
+	var signature string
+	err := s.call("sendTransaction", []interface{}{
+		signedTx,
+		map[string]interface{}{
+			"encoding":            encoding,
+			"preflightCommitment": DefaultCommitment,
+		},
+	}, &signature)
+	if err != nil {
+		return "", err
+	}
+	return signature, nil
+}
+
+func (s *Service) GetLatestBlockhash(commitment string) (*alchemy_models.LatestBlockhash, error) {
+	var result alchemy_models.LatestBlockhash
+	err := s.call("getLatestBlockhash", []interface{}{
+		map[string]string{"commitment": commitment},
+	}, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetFeeForMessage returns the base fee in lamports for a base64 encoded message
+func (s *Service) GetFeeForMessage(message string, commitment string) (*alchemy_models.FeeForMessage, error) {
+	var result alchemy_models.FeeForMessage
+	err := s.call("getFeeForMessage", []interface{}{
+		message,
+		map[string]string{"commitment": commitment},
+	}, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetRecentPrioritizationFees returns the priority fees paid in recent slots by transactions locking the accounts,
+// or by any transaction when no accounts are given
+func (s *Service) GetRecentPrioritizationFees(accounts []string) ([]alchemy_models.PrioritizationFee, error) {
+	params := []interface{}{}
+	if len(accounts) > 0 {
+		params = append(params, accounts)
+	}
+
+	var result []alchemy_models.PrioritizationFee
+	if err := s.call("getRecentPrioritizationFees", params, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// call performs one JSON-RPC request, node errors are returned as *RPCError
+func (s *Service) call(method string, params []interface{}, result interface{}) error {
+	url := fmt.Sprintf("%s%s", *s.baseURL, *s.apiKey)
+
+	request := &alchemy_models.SolanaRPCRequest{
+		Jsonrpc: "2.0",
+		Method:  method,
+		Params:  params,
+		Id:      1,
+	}
+
+	body, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	// Only reads are retried, sendTransaction is left to the caller
+	var resp *http.Response
+	if method == "sendTransaction" {
+		resp, err = s.client.Post(url, "application/json", bytes.NewBuffer(body))
+	} else {
+		resp, err = httpclient.PostIdempotent(s.client, url, "application/json", bytes.NewBuffer(body))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to send POST request: %w", err)
+	}
+	defer func(Body io.ReadCloser) {
+		err := Body.Close()
+		if err != nil {
+			log.Printf("failed to close response body: %v", err)
+		}
+	}(resp.Body)
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("alchemy API returned status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	var response alchemy_models.SolanaRPCResponse
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	if response.Error != nil {
+		return &RPCError{SendRawTransactionError: *response.Error}
+	}
+
+	if err := json.Unmarshal(response.Result, result); err != nil {
+		return fmt.Errorf("failed to unmarshal %s result: %w", method, err)
+	}
+	return nil
+}
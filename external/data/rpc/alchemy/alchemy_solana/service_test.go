@@ -0,0 +1,79 @@
+package alchemy_solana
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/tashunc/nugenesis-wallet-backend/external/data/rpc/alchemy/alchemy_models"
+)
+
+func newTestService(handler http.HandlerFunc) (*Service, func()) {
+	server := httptest.NewServer(handler)
+	apiKey := ""
+	baseURL := server.URL
+	return &Service{apiKey: &apiKey, baseURL: &baseURL, client: server.Client()}, server.Close
+}
+
+func TestSendTransactionEncoding(t *testing.T) {
+	var encoding string
+	service, closeServer := newTestService(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			Params []json.RawMessage `json:"params"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		var config struct {
+			Encoding string `json:"encoding"`
+		}
+		_ = json.Unmarshal(request.Params[1], &config)
+		encoding = config.Encoding
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"5VERv8NMvzbJMEkV8xnrLkEaWRtSz9CosKDYjCJjBRnbJLgp8uirBgmQpjKhoR4tjF3ZpRzrFmBV6UjKdiSZkQUW"}`))
+	})
+	defer closeServer()
+
+	if _, err := service.SendTransaction("AQABAg+/=="); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if encoding != "base64" {
+		t.Errorf("Expected base64 encoding, got %s", encoding)
+	}
+
+	if _, err := service.SendTransaction("4hXTCkRzt9WyecNzV1XPgCDfGAZzQKNxLXgynz5QDuWWPSAZBZSHptvWRL3BjCvzUXRdKvHL2b7yGrRQcWyaqsaBCncVG7BFggS8w9snUts67BSh3EqKpXLUm5UMHfD7ZBe9GhARjbNQMLJ1QD3Spr6oMTBU6EhdB4RD8CP2xUxr2u3d6fos36PD98XS6oX8TQjLpsMwncs5DAMiD4nNnR8NBfyghGCWvCVifVwvA8B8TJxE1aiyiv2L429BCWfyzAme5sZW8rDb14NeCQHhZbtNqfXhcp2tAnaAT"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if encoding != "base58" {
+		t.Errorf("Expected base58 encoding, got %s", encoding)
+	}
+}
+
+func TestCallReturnsRPCError(t *testing.T) {
+	service, closeServer := newTestService(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32002,"message":"Transaction simulation failed: Blockhash not found"}}`))
+	})
+	defer closeServer()
+
+	_, err := service.SendTransaction("AQABAg+/==")
+	var rpcErr *RPCError
+	if !errors.As(err, &rpcErr) {
+		t.Fatalf("Expected *RPCError, got %v", err)
+	}
+	if rpcErr.Code != -32002 {
+		t.Errorf("Expected code -32002, got %d", rpcErr.Code)
+	}
+}
+
+func TestMedianPrioritizationFee(t *testing.T) {
+	fees := []alchemy_models.PrioritizationFee{
+		{Slot: 1, PrioritizationFee: 500},
+		{Slot: 2, PrioritizationFee: 0},
+		{Slot: 3, PrioritizationFee: 1000},
+	}
+	if median := medianPrioritizationFee(fees); median != 500 {
+		t.Errorf("Expected median 500, got %d", median)
+	}
+	if median := medianPrioritizationFee(nil); median != 0 {
+		t.Errorf("Expected median 0 for no fees, got %d", median)
+	}
+}
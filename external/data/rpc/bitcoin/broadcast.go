@@ -0,0 +1,157 @@
+package bitcoin
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/btcsuite/btcd/wire"
+)
+
+// Rejection reasons returned to clients in SendRawTransactionError.Reason
+const (
+	RejectInvalidTransaction = "invalid_transaction"
+	RejectInsufficientFee    = "insufficient_fee"
+	RejectFeeTooHigh         = "fee_too_high"
+	RejectInputsSpent        = "inputs_missing_or_spent"
+	RejectMempoolConflict    = "mempool_conflict"
+	RejectNonStandard        = "non_standard"
+	RejectScriptVerifyFailed = "script_verify_failed"
+	RejectDust               = "dust"
+	RejectNonFinal           = "non_final"
+	RejectAlreadyKnown       = "already_known"
+	RejectUnknown            = "unknown"
+)
+
+// rejectPatterns maps bitcoind reject strings, as relayed by blockchain.info and Esplora, to reasons.
+// Order matters: the first match wins.
+var rejectPatterns = []struct {
+	pattern string
+	reason  string
+}{
+	{"already in block chain", RejectAlreadyKnown},
+	{"txn-already-known", RejectAlreadyKnown},
+	{"txn-already-in-mempool", RejectAlreadyKnown},
+	{"min relay fee not met", RejectInsufficientFee},
+	{"mempool min fee not met", RejectInsufficientFee},
+	{"insufficient fee", RejectInsufficientFee},
+	{"absurdly-high-fee", RejectFeeTooHigh},
+	{"max-fee-exceeded", RejectFeeTooHigh},
+	{"fee exceeds maximum", RejectFeeTooHigh},
+	{"txn-mempool-conflict", RejectMempoolConflict},
+	{"bad-txns-inputs-missingorspent", RejectInputsSpent},
+	{"missing inputs", RejectInputsSpent},
+	{"missing-inputs", RejectInputsSpent},
+	{"dust", RejectDust},
+	{"non-final", RejectNonFinal},
+	{"non-bip68-final", RejectNonFinal},
+	{"decode failed", RejectInvalidTransaction},
+	{"parse error", RejectInvalidTransaction},
+	{"bad-txns", RejectInvalidTransaction},
+	{"script-verify-flag", RejectScriptVerifyFailed},
+	{"scriptsig", RejectNonStandard},
+	{"scriptpubkey", RejectNonStandard},
+	{"tx-size", RejectNonStandard},
+	// A non-standard transaction version, as the RPC error JSON and the legacy "code: reason" and "reason (code)" forms
+	{`"message":"version"`, RejectNonStandard},
+	{"64: version", RejectNonStandard},
+	{"version (code 64)", RejectNonStandard},
+}
+
+// BroadcastError is a transaction rejected by the network, Reason is one of the Reject constants
+type BroadcastError struct {
+	Reason   string
+	Message  string
+	Provider string
+}
+
+func (e *BroadcastError) Error() string {
+	return fmt.Sprintf("%s rejected transaction (%s): %s", e.Provider, e.Reason, e.Message)
+}
+
+// ClassifyRejection maps a provider rejection message to a reason
+func ClassifyRejection(message string) string {
+	lower := strings.ToLower(message)
+	for _, reject := range rejectPatterns {
+		if strings.Contains(lower, reject.pattern) {
+			return reject.reason
+		}
+	}
+	return RejectUnknown
+}
+
+// DecodeTxid parses a raw transaction in hex and returns its txid
+func DecodeTxid(rawTx string) (string, error) {
+	raw, err := hex.DecodeString(strings.TrimSpace(rawTx))
+	if err != nil {
+		return "", fmt.Errorf("transaction is not valid hex: %w", err)
+	}
+
+	var tx wire.MsgTx
+	if err := tx.Deserialize(bytes.NewReader(raw)); err != nil {
+		return "", fmt.Errorf("failed to decode transaction: %w", err)
+	}
+	return tx.TxHash().String(), nil
+}
+
+// Broadcast submits a signed transaction through blockchain.info and falls back to Blockstream.
+// Definite rejections are returned as *BroadcastError without trying the fallback, since every node would agree.
+func (s *Service) Broadcast(rawTx string) (string, error) {
+	rawTx = strings.TrimSpace(rawTx)
+	txid, err := DecodeTxid(rawTx)
+	if err != nil {
+		return "", &BroadcastError{Reason: RejectInvalidTransaction, Message: err.Error(), Provider: "local"}
+	}
+
+	pushResp, err := s.blockchainInfo.PushTransaction(rawTx)
+	switch {
+	case err != nil:
+		log.Printf("blockchain.info broadcast of %s failed, falling back to Blockstream: %v", txid, err)
+	case pushResp.Error == "":
+		return txid, nil
+	default:
+		if done, err := settle("blockchain_info", txid, pushResp.Error); done {
+			return settled(txid, err)
+		}
+		log.Printf("blockchain.info rejected %s with an unknown reason, falling back to Blockstream: %s", txid, pushResp.Error)
+	}
+
+	broadcastResp, err := s.blockstream.BroadcastTransaction(rawTx)
+	if err != nil {
+		return "", fmt.Errorf("all broadcast providers failed: %w", err)
+	}
+	if broadcastResp.Error == "" {
+		if broadcastResp.Txid != "" && broadcastResp.Txid != txid {
+			log.Printf("Blockstream returned txid %s for locally computed %s", broadcastResp.Txid, txid)
+		}
+		return txid, nil
+	}
+
+	if done, err := settle("blockstream", txid, broadcastResp.Error); done {
+		return settled(txid, err)
+	}
+	return "", &BroadcastError{Reason: RejectUnknown, Message: broadcastResp.Error, Provider: "blockstream"}
+}
+
+// settle reports whether a rejection is final; a transaction the network already has counts as broadcast
+func settle(provider string, txid string, message string) (bool, error) {
+	reason := ClassifyRejection(message)
+	switch reason {
+	case RejectUnknown:
+		return false, nil
+	case RejectAlreadyKnown:
+		log.Printf("%s already has transaction %s: %s", provider, txid, message)
+		return true, nil
+	default:
+		return true, &BroadcastError{Reason: reason, Message: message, Provider: provider}
+	}
+}
+
+func settled(txid string, err error) (string, error) {
+	if err != nil {
+		return "", err
+	}
+	return txid, nil
+}
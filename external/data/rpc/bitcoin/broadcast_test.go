@@ -0,0 +1,44 @@
+package bitcoin
+
+import "testing"
+
+// genesisCoinbase is the coinbase transaction of the genesis block
+const genesisCoinbase = "01000000010000000000000000000000000000000000000000000000000000000000000000ffffffff4d04ffff001d0104455468652054696d65732030332f4a616e2f32303039204368616e63656c6c6f72206f6e206272696e6b206f66207365636f6e64206261696c6f757420666f722062616e6b73ffffffff0100f2052a01000000434104678afdb0fe5548271967f1a67130b7105cd6a828e03909a67962e0ea1f61deb649f6bc3f4cef38c4f35504e51ec112de5c384df7ba0b8d578a4c702b6bf11d5fac00000000"
+
+func TestDecodeTxid(t *testing.T) {
+	txid, err := DecodeTxid(genesisCoinbase)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b"
+	if txid != expected {
+		t.Errorf("Expected txid %s, got %s", expected, txid)
+	}
+
+	if _, err := DecodeTxid("0100zz"); err == nil {
+		t.Error("Expected an error for invalid hex")
+	}
+}
+
+func TestClassifyRejection(t *testing.T) {
+	cases := map[string]string{
+		`sendrawtransaction RPC error: {"code":-26,"message":"min relay fee not met, 110 < 141"}`:   RejectInsufficientFee,
+		`sendrawtransaction RPC error: {"code":-25,"message":"bad-txns-inputs-missingorspent"}`:     RejectInputsSpent,
+		`sendrawtransaction RPC error: {"code":-26,"message":"txn-mempool-conflict"}`:               RejectMempoolConflict,
+		`sendrawtransaction RPC error: {"code":-27,"message":"Transaction already in block chain"}`: RejectAlreadyKnown,
+		`Transaction decode failed`: RejectInvalidTransaction,
+		`mandatory-script-verify-flag-failed (Signature must be zero for failed CHECK(MULTI)SIG operation)`: RejectScriptVerifyFailed,
+		`sendrawtransaction RPC error: {"code":-25,"message":"max-fee-exceeded"}`:                           RejectFeeTooHigh,
+		`absurdly-high-fee, 1000000 > 100000 (code 256)`:                                                    RejectFeeTooHigh,
+		`sendrawtransaction RPC error: {"code":-26,"message":"version"}`:                                    RejectNonStandard,
+		`Service temporarily unavailable`:                                                                   RejectUnknown,
+		`Unsupported API version`:                                                                           RejectUnknown,
+	}
+
+	for message, expected := range cases {
+		if reason := ClassifyRejection(message); reason != expected {
+			t.Errorf("%q: expected %s, got %s", message, expected, reason)
+		}
+	}
+}
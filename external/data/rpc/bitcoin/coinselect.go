@@ -0,0 +1,224 @@
+package bitcoin
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/tashunc/nugenesis-wallet-backend/external/models"
+)
+
+const (
+	StrategyBranchAndBound = "bnb"
+	StrategyLargestFirst   = "largest_first"
+
+	// bnbMaxTries bounds the branch-and-bound search like Bitcoin Core does
+	bnbMaxTries = 100000
+)
+
+var (
+	ErrInsufficientFunds = errors.New("insufficient funds")
+	ErrUnknownStrategy   = errors.New("unknown coin selection strategy")
+	// errNoChangelessMatch means branch-and-bound found no input set that avoids a change output
+	errNoChangelessMatch = errors.New("no changeless input set found")
+)
+
+// SelectionParams describes the payment the inputs must fund
+type SelectionParams struct {
+	// Target is the amount paid to the recipient in satoshis
+	Target int64
+	// FeeRate is in sat/vB
+	FeeRate       float64
+	RecipientType ScriptType
+	ChangeType    ScriptType
+}
+
+// Selection is the chosen input set; Change is zero when the transaction has no change output
+type Selection struct {
+	Strategy   string
+	Inputs     []models.UTXO
+	InputTotal int64
+	Change     int64
+	Fee        int64
+	VSize      int64
+}
+
+// coin is a spendable UTXO with its size and value net of the fee to spend it
+type coin struct {
+	utxo           models.UTXO
+	scriptType     ScriptType
+	weight         int64
+	effectiveValue int64
+}
+
+// SelectCoins picks inputs for the payment. An empty strategy means branch-and-bound with a largest-first fallback.
+func SelectCoins(strategy string, utxos []models.UTXO, params SelectionParams) (*Selection, error) {
+	coins, err := toCoins(utxos, params.FeeRate)
+	if err != nil {
+		return nil, err
+	}
+
+	switch strategy {
+	case StrategyLargestFirst:
+		return selectLargestFirst(coins, params)
+	case StrategyBranchAndBound, "":
+		selection, err := selectBranchAndBound(coins, params)
+		if errors.Is(err, errNoChangelessMatch) {
+			return selectLargestFirst(coins, params)
+		}
+		return selection, err
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownStrategy, strategy)
+	}
+}
+
+func toCoins(utxos []models.UTXO, feeRate float64) ([]coin, error) {
+	coins := make([]coin, 0, len(utxos))
+	for _, utxo := range utxos {
+		scriptType, err := ScriptTypeFromScript(utxo.Script)
+		if err != nil {
+			return nil, fmt.Errorf("utxo %s:%d: %w", utxo.Txid, utxo.Vout, err)
+		}
+		weight, exists := inputWeights[scriptType]
+		if !exists {
+			return nil, fmt.Errorf("utxo %s:%d: cannot size %s inputs", utxo.Txid, utxo.Vout, scriptType)
+		}
+
+		effectiveValue := utxo.Value - feeForWeight(weight, feeRate)
+		// Inputs that cost more to spend than they are worth never help
+		if effectiveValue <= 0 {
+			continue
+		}
+		coins = append(coins, coin{utxo: utxo, scriptType: scriptType, weight: weight, effectiveValue: effectiveValue})
+	}
+
+	sort.SliceStable(coins, func(i, j int) bool {
+		return coins[i].effectiveValue > coins[j].effectiveValue
+	})
+	return coins, nil
+}
+
+// selectBranchAndBound searches for the input set whose effective value lands between the target and the
+// target plus the cost of a change output, so no change is created. Among matches the smallest excess wins.
+func selectBranchAndBound(coins []coin, params SelectionParams) (*Selection, error) {
+	// The segwit marker is counted when any candidate is segwit, a slight overestimate for legacy-only picks
+	baseWeight := txOverheadWeight + outputWeights[params.RecipientType] + markerWeight(coins)
+	target := params.Target + feeForWeight(baseWeight, params.FeeRate)
+	costOfChange := feeForWeight(outputWeights[params.ChangeType], params.FeeRate) +
+		feeForWeight(inputWeights[params.ChangeType], params.FeeRate)
+
+	var available int64
+	for _, c := range coins {
+		available += c.effectiveValue
+	}
+	if available < target {
+		return nil, ErrInsufficientFunds
+	}
+
+	var (
+		tries     int
+		selected  = make([]bool, len(coins))
+		best      []bool
+		bestValue int64 = math.MaxInt64
+	)
+
+	var search func(index int, value int64, remaining int64)
+	search = func(index int, value int64, remaining int64) {
+		tries++
+		if tries > bnbMaxTries || value > target+costOfChange || value+remaining < target {
+			return
+		}
+		if value >= target {
+			if value < bestValue {
+				bestValue = value
+				best = append(best[:0], selected...)
+			}
+			return
+		}
+		if index >= len(coins) {
+			return
+		}
+
+		remaining -= coins[index].effectiveValue
+
+		// Including a coin equal to the one just left out explores the same sums again
+		duplicate := index > 0 && !selected[index-1] && coins[index].effectiveValue == coins[index-1].effectiveValue
+		if !duplicate {
+			selected[index] = true
+			search(index+1, value+coins[index].effectiveValue, remaining)
+			selected[index] = false
+		}
+		search(index+1, value, remaining)
+	}
+	search(0, 0, available)
+
+	if best == nil {
+		return nil, errNoChangelessMatch
+	}
+
+	selection := &Selection{Strategy: StrategyBranchAndBound}
+	weight := baseWeight
+	for i, chosen := range best {
+		if chosen {
+			selection.Inputs = append(selection.Inputs, coins[i].utxo)
+			selection.InputTotal += coins[i].utxo.Value
+			weight += coins[i].weight
+		}
+	}
+	// The excess over the target is small enough to give to the miner
+	selection.Fee = selection.InputTotal - params.Target
+	selection.VSize = vsize(weight)
+	return selection, nil
+}
+
+// selectLargestFirst adds the biggest coins until the payment and fee are covered, creating change above dust
+func selectLargestFirst(coins []coin, params SelectionParams) (*Selection, error) {
+	baseWeight := txOverheadWeight + outputWeights[params.RecipientType] + markerWeight(coins)
+	changeWeight := outputWeights[params.ChangeType]
+
+	selection := &Selection{Strategy: StrategyLargestFirst}
+	weight := baseWeight
+	for _, c := range coins {
+		selection.Inputs = append(selection.Inputs, c.utxo)
+		selection.InputTotal += c.utxo.Value
+		weight += c.weight
+
+		feeWithoutChange := feeForWeight(weight, params.FeeRate)
+		if selection.InputTotal < params.Target+feeWithoutChange {
+			continue
+		}
+
+		feeWithChange := feeForWeight(weight+changeWeight, params.FeeRate)
+		if change := selection.InputTotal - params.Target - feeWithChange; change >= dustLimits[params.ChangeType] {
+			selection.Change = change
+			selection.Fee = feeWithChange
+			selection.VSize = vsize(weight + changeWeight)
+			return selection, nil
+		}
+
+		// Change would be dust, it goes to the fee instead
+		selection.Fee = selection.InputTotal - params.Target
+		selection.VSize = vsize(weight)
+		return selection, nil
+	}
+
+	return nil, ErrInsufficientFunds
+}
+
+func markerWeight(coins []coin) int64 {
+	for _, c := range coins {
+		if c.scriptType.isSegwit() {
+			return segwitMarkerWeight
+		}
+	}
+	return 0
+}
+
+func vsize(weight int64) int64 {
+	return (weight + 3) / 4
+}
+
+func feeForWeight(weight int64, feeRate float64) int64 {
+	return int64(math.Ceil(float64(weight) * feeRate / 4))
+}
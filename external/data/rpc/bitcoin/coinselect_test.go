@@ -0,0 +1,101 @@
+package bitcoin
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/tashunc/nugenesis-wallet-backend/external/models"
+)
+
+const p2wpkhScript = "0014751e76e8199196d454941c45d1b3a323f1433bd6"
+
+func testUTXOs(values ...int64) []models.UTXO {
+	utxos := make([]models.UTXO, 0, len(values))
+	for i, value := range values {
+		utxos = append(utxos, models.UTXO{
+			Txid:   "tx",
+			Vout:   uint32(i),
+			Value:  value,
+			Script: p2wpkhScript,
+		})
+	}
+	return utxos
+}
+
+func TestSelectCoins_BranchAndBoundFindsChangelessMatch(t *testing.T) {
+	params := SelectionParams{Target: 100000, FeeRate: 1, RecipientType: P2WPKH, ChangeType: P2WPKH}
+
+	// 10.5 vB overhead + 31 vB output + 68 vB per input, the 60000+40200 pair covers 100000 plus ~178 sat of fees
+	selection, err := SelectCoins(StrategyBranchAndBound, testUTXOs(500000, 60000, 40200, 1000), params)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if selection.Strategy != StrategyBranchAndBound {
+		t.Errorf("Expected strategy bnb, got %s", selection.Strategy)
+	}
+	if len(selection.Inputs) != 2 || selection.InputTotal != 100200 {
+		t.Fatalf("Expected the 60000 and 40200 inputs, got %+v", selection.Inputs)
+	}
+	if selection.Change != 0 {
+		t.Errorf("Expected no change, got %d", selection.Change)
+	}
+	if selection.Fee != 200 {
+		t.Errorf("Expected fee 200, got %d", selection.Fee)
+	}
+	if selection.VSize != 178 {
+		t.Errorf("Expected vsize 178, got %d", selection.VSize)
+	}
+}
+
+func TestSelectCoins_FallsBackToLargestFirst(t *testing.T) {
+	params := SelectionParams{Target: 100000, FeeRate: 2, RecipientType: P2WPKH, ChangeType: P2WPKH}
+
+	selection, err := SelectCoins("", testUTXOs(30000, 500000), params)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if selection.Strategy != StrategyLargestFirst {
+		t.Errorf("Expected strategy largest_first, got %s", selection.Strategy)
+	}
+	if len(selection.Inputs) != 1 || selection.Inputs[0].Value != 500000 {
+		t.Fatalf("Expected the 500000 input, got %+v", selection.Inputs)
+	}
+	// 42 + 124 + 272 + 124 weight units = 141 vB at 2 sat/vB
+	if selection.VSize != 141 || selection.Fee != 281 {
+		t.Errorf("Expected vsize 141 and fee 281, got %d and %d", selection.VSize, selection.Fee)
+	}
+	if selection.InputTotal != params.Target+selection.Fee+selection.Change {
+		t.Errorf("Inputs %d do not balance target, fee and change %d", selection.InputTotal, selection.Change)
+	}
+}
+
+func TestSelectCoins_InsufficientFunds(t *testing.T) {
+	params := SelectionParams{Target: 100000, FeeRate: 5, RecipientType: P2WPKH, ChangeType: P2WPKH}
+
+	_, err := SelectCoins(StrategyLargestFirst, testUTXOs(50000, 49000), params)
+	if !errors.Is(err, ErrInsufficientFunds) {
+		t.Errorf("Expected ErrInsufficientFunds, got %v", err)
+	}
+}
+
+func TestScriptTypeFromAddress(t *testing.T) {
+	cases := map[string]ScriptType{
+		"1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa":                             P2PKH,
+		"3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy":                             P2SHP2WPKH,
+		"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4":                     P2WPKH,
+		"bc1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3qccfmv3": P2WSH,
+		"bc1p5d7rjq7g6rdk2yhzks9smlaqtedr4dekq08ge8ztwac72sfr9rusxg3297": P2TR,
+	}
+	for address, expected := range cases {
+		scriptType, err := ScriptTypeFromAddress(address)
+		if err != nil || scriptType != expected {
+			t.Errorf("%s: expected %s, got %s (%v)", address, expected, scriptType, err)
+		}
+	}
+
+	if _, err := ScriptTypeFromAddress("tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx"); err == nil {
+		t.Error("Expected a testnet address to be rejected")
+	}
+}
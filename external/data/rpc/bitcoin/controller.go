@@ -0,0 +1,387 @@
+package bitcoin
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tashunc/nugenesis-wallet-backend/external/models"
+)
+
+type Controller struct {
+	service *Service
+}
+
+func NewController() *Controller {
+	return &Controller{
+		service: NewService(),
+	}
+}
+
+// SendRawTransaction broadcasts one signed transaction in hex and returns its txid
+func (c *Controller) SendRawTransaction(ctx *gin.Context) {
+	var request models.SendRawTransactionControllerRequest
+
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.SendRawTransactionControllerResponse{
+			Success: false,
+			Message: "Invalid request format",
+			Error: &models.SendRawTransactionError{
+				Code:    400,
+				Message: err.Error(),
+			},
+		})
+		return
+	}
+
+	if len(request.SignedTransactions) != 1 {
+		ctx.JSON(http.StatusBadRequest, models.SendRawTransactionControllerResponse{
+			Success: false,
+			Message: "Invalid request format",
+			Error: &models.SendRawTransactionError{
+				Code:    400,
+				Message: "params must contain exactly one signed transaction",
+			},
+		})
+		return
+	}
+
+	txid, err := c.service.Broadcast(request.SignedTransactions[0])
+	if err != nil {
+		var broadcastErr *BroadcastError
+		if errors.As(err, &broadcastErr) {
+			ctx.JSON(http.StatusBadRequest, models.SendRawTransactionControllerResponse{
+				Success: false,
+				Message: "Transaction failed",
+				Error: &models.SendRawTransactionError{
+					Code:    400,
+					Message: broadcastErr.Message,
+					Reason:  broadcastErr.Reason,
+				},
+			})
+			return
+		}
+
+		ctx.JSON(http.StatusBadGateway, models.SendRawTransactionControllerResponse{
+			Success: false,
+			Message: "Failed to send transaction",
+			Error: &models.SendRawTransactionError{
+				Code:    502,
+				Message: err.Error(),
+			},
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.SendRawTransactionControllerResponse{
+		Success:         true,
+		TransactionHash: txid,
+		Message:         "Transaction sent successfully",
+	})
+}
+
+func (c *Controller) GetUTXOs(ctx *gin.Context) {
+	address := ctx.Param("address")
+	if _, err := ScriptTypeFromAddress(address); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.GetUTXOsControllerResponse{
+			Success: false,
+			Message: "Invalid Bitcoin address",
+			Error: &models.SendRawTransactionError{
+				Code:    400,
+				Message: err.Error(),
+			},
+		})
+		return
+	}
+
+	utxos, err := c.service.GetUTXOs(address)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, models.GetUTXOsControllerResponse{
+			Success: false,
+			Message: "Failed to get unspent outputs",
+			Error: &models.SendRawTransactionError{
+				Code:    500,
+				Message: err.Error(),
+			},
+		})
+		return
+	}
+
+	var total int64
+	for _, utxo := range utxos {
+		total += utxo.Value
+	}
+
+	ctx.JSON(http.StatusOK, models.GetUTXOsControllerResponse{
+		Success: true,
+		Address: address,
+		UTXOs:   utxos,
+		Total:   total,
+	})
+}
+
+// CoinSelect picks the inputs of a payment from the address UTXOs and returns the unsigned transaction layout
+func (c *Controller) CoinSelect(ctx *gin.Context) {
+	var request models.CoinSelectControllerRequest
+
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		coinSelectError(ctx, http.StatusBadRequest, "Invalid request format", err)
+		return
+	}
+
+	if _, err := ScriptTypeFromAddress(request.Address); err != nil {
+		coinSelectError(ctx, http.StatusBadRequest, "Invalid address", err)
+		return
+	}
+
+	changeType, err := ScriptTypeFromAddress(request.ChangeAddress)
+	if err != nil {
+		coinSelectError(ctx, http.StatusBadRequest, "Invalid change address", err)
+		return
+	}
+
+	recipientType := P2WPKH
+	if request.RecipientAddress != "" {
+		if recipientType, err = ScriptTypeFromAddress(request.RecipientAddress); err != nil {
+			coinSelectError(ctx, http.StatusBadRequest, "Invalid recipient address", err)
+			return
+		}
+	}
+
+	utxos, err := c.service.GetUTXOs(request.Address)
+	if err != nil {
+		coinSelectError(ctx, http.StatusInternalServerError, "Failed to get unspent outputs", err)
+		return
+	}
+
+	spendable := make([]models.UTXO, 0, len(utxos))
+	for _, utxo := range utxos {
+		if utxo.Confirmations >= request.MinConfirmations {
+			spendable = append(spendable, utxo)
+		}
+	}
+
+	selection, err := SelectCoins(request.Strategy, spendable, SelectionParams{
+		Target:        request.TargetAmount,
+		FeeRate:       request.FeeRate,
+		RecipientType: recipientType,
+		ChangeType:    changeType,
+	})
+	if err != nil {
+		status := http.StatusUnprocessableEntity
+		if errors.Is(err, ErrUnknownStrategy) {
+			status = http.StatusBadRequest
+		}
+		coinSelectError(ctx, status, "Coin selection failed", err)
+		return
+	}
+
+	outputs := []models.CoinSelectOutput{{
+		Address: request.RecipientAddress,
+		Value:   request.TargetAmount,
+	}}
+	if selection.Change > 0 {
+		outputs = append(outputs, models.CoinSelectOutput{
+			Address:  request.ChangeAddress,
+			Value:    selection.Change,
+			IsChange: true,
+		})
+	}
+
+	ctx.JSON(http.StatusOK, models.CoinSelectControllerResponse{
+		Success:    true,
+		Strategy:   selection.Strategy,
+		Inputs:     selection.Inputs,
+		Outputs:    outputs,
+		InputTotal: selection.InputTotal,
+		Fee:        selection.Fee,
+		FeeRate:    request.FeeRate,
+		VSize:      selection.VSize,
+		Message:    "Coins selected successfully",
+	})
+}
+
+func coinSelectError(ctx *gin.Context, status int, message string, err error) {
+	ctx.JSON(status, models.CoinSelectControllerResponse{
+		Success: false,
+		Message: message,
+		Error: &models.SendRawTransactionError{
+			Code:    status,
+			Message: err.Error(),
+		},
+	})
+}
+
+// GetFeeEstimates returns the sat/vB fee tiers and mempool backlog, and the projected fee when the request sizes a
+// transaction. Inputs and outputs are read from the query string or a JSON body.
+func (c *Controller) GetFeeEstimates(ctx *gin.Context) {
+	var request models.BitcoinFeeEstimateRequest
+	if err := ctx.ShouldBind(&request); err != nil {
+		feeEstimateError(ctx, http.StatusBadRequest, "Invalid request format", err)
+		return
+	}
+
+	market, err := c.service.GetFeeRates()
+	if err != nil {
+		feeEstimateError(ctx, http.StatusBadGateway, "Failed to get fee estimates", err)
+		return
+	}
+
+	response := models.BitcoinFeeEstimateControllerResponse{
+		Success:    true,
+		FeeRates:   &market.Rates,
+		Mempool:    &market.Mempool,
+		AgeSeconds: int64(market.Age().Seconds()),
+		Stale:      market.Stale,
+		Message:    "Fee estimates retrieved successfully",
+	}
+
+	if request.Inputs > 0 || request.Outputs > 0 {
+		inputType := P2WPKH
+		if request.ScriptType != "" {
+			inputType = ScriptType(request.ScriptType)
+		}
+		outputType := inputType
+		if request.OutputType != "" {
+			outputType = ScriptType(request.OutputType)
+		}
+
+		if response.Projection, err = ProjectFees(market.Rates, request.Inputs, request.Outputs, inputType, outputType); err != nil {
+			feeEstimateError(ctx, http.StatusBadRequest, "Invalid transaction size", err)
+			return
+		}
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+func feeEstimateError(ctx *gin.Context, status int, message string, err error) {
+	ctx.JSON(status, models.BitcoinFeeEstimateControllerResponse{
+		Success: false,
+		Message: message,
+		Error: &models.SendRawTransactionError{
+			Code:    status,
+			Message: err.Error(),
+		},
+	})
+}
+
+// CreatePSBT builds an unsigned PSBT from chosen UTXOs and outputs for an external signer
+func (c *Controller) CreatePSBT(ctx *gin.Context) {
+	var request models.CreatePSBTControllerRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		psbtError(ctx, http.StatusBadRequest, "Invalid request format", err)
+		return
+	}
+
+	packet, err := c.service.CreatePSBT(ctx.Request.Context(), request)
+	if err != nil {
+		psbtError(ctx, psbtErrorStatus(err), "Failed to create PSBT", err)
+		return
+	}
+	encoded, err := packet.B64Encode()
+	if err != nil {
+		psbtError(ctx, http.StatusInternalServerError, "Failed to encode PSBT", err)
+		return
+	}
+
+	addresses := make([]string, 0, len(request.Inputs))
+	for _, input := range request.Inputs {
+		addresses = append(addresses, input.Address)
+	}
+	summary, err := SummarizePSBT(packet, addresses)
+	if err != nil {
+		psbtError(ctx, psbtErrorStatus(err), "Failed to summarize PSBT", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.PSBTControllerResponse{
+		Success: true,
+		PSBT:    encoded,
+		Summary: summary,
+		Message: "PSBT created successfully",
+	})
+}
+
+// DecodePSBT summarizes a PSBT for the user to confirm before signing
+func (c *Controller) DecodePSBT(ctx *gin.Context) {
+	var request models.DecodePSBTControllerRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		psbtError(ctx, http.StatusBadRequest, "Invalid request format", err)
+		return
+	}
+
+	packet, err := DecodePSBT(request.PSBT)
+	if err != nil {
+		psbtError(ctx, http.StatusBadRequest, "Failed to decode PSBT", err)
+		return
+	}
+	summary, err := SummarizePSBT(packet, request.Addresses)
+	if err != nil {
+		psbtError(ctx, http.StatusBadRequest, "Failed to summarize PSBT", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.PSBTControllerResponse{
+		Success: true,
+		Summary: summary,
+		Message: "PSBT decoded successfully",
+	})
+}
+
+// FinalizePSBT turns a fully signed PSBT into the raw transaction hex accepted by sendRawTransaction
+func (c *Controller) FinalizePSBT(ctx *gin.Context) {
+	var request models.FinalizePSBTControllerRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		psbtError(ctx, http.StatusBadRequest, "Invalid request format", err)
+		return
+	}
+
+	packet, err := DecodePSBT(request.PSBT)
+	if err != nil {
+		psbtError(ctx, http.StatusBadRequest, "Failed to decode PSBT", err)
+		return
+	}
+	tx, err := FinalizePSBT(packet)
+	if err != nil {
+		psbtError(ctx, psbtErrorStatus(err), "Failed to finalize PSBT", err)
+		return
+	}
+
+	var raw bytes.Buffer
+	if err := tx.Serialize(&raw); err != nil {
+		psbtError(ctx, http.StatusInternalServerError, "Failed to serialize transaction", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.PSBTControllerResponse{
+		Success: true,
+		Hex:     hex.EncodeToString(raw.Bytes()),
+		Txid:    tx.TxHash().String(),
+		Message: "PSBT finalized successfully",
+	})
+}
+
+func psbtErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrInvalidPSBTRequest), errors.Is(err, ErrInvalidPSBT):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrUnknownUTXO), errors.Is(err, ErrIncompletePSBT):
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusBadGateway
+	}
+}
+
+func psbtError(ctx *gin.Context, status int, message string, err error) {
+	ctx.JSON(status, models.PSBTControllerResponse{
+		Success: false,
+		Message: message,
+		Error: &models.SendRawTransactionError{
+			Code:    status,
+			Message: err.Error(),
+		},
+	})
+}
@@ -0,0 +1,206 @@
+package bitcoin
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/tashunc/nugenesis-wallet-backend/external/data/historical/thrirdParty/blockstream/blockstream_models"
+	"github.com/tashunc/nugenesis-wallet-backend/external/models"
+)
+
+// Confirmation targets in blocks of the fee tiers, at ten minutes per block
+const (
+	TargetNextBlock = 1
+	TargetHalfHour  = 3
+	TargetHour      = 6
+	TargetEconomy   = 144
+)
+
+const (
+	// minRelayFeeRate and incrementalRelayFeeRate are Bitcoin Core's defaults in sat/vB
+	minRelayFeeRate         = 1.0
+	incrementalRelayFeeRate = 1.0
+	// maxBlockVSize is the virtual size of a full block
+	maxBlockVSize = 1_000_000
+	// feeCacheTTL keeps the estimates for a fraction of a block interval
+	feeCacheTTL = 30 * time.Second
+	// maxFeeStaleness is how long an expired market is served while Esplora cannot be read
+	maxFeeStaleness = 30 * time.Minute
+)
+
+// FeeMarket is the last computed fee market, shared by every request until it expires
+type FeeMarket struct {
+	Rates     models.BitcoinFeeRates
+	Mempool   models.BitcoinMempoolStats
+	FetchedAt time.Time
+	// Stale is set when Esplora could not be read and an expired market is served instead
+	Stale bool
+}
+
+// Age is how long ago the market was read from Esplora
+func (m FeeMarket) Age() time.Duration {
+	return time.Since(m.FetchedAt)
+}
+
+type feeCache struct {
+	mutex  sync.Mutex
+	market *FeeMarket
+	// refresh is the refresh in flight, nil when none is running
+	refresh *feeRefresh
+}
+
+// feeRefresh is one fetch shared by every caller that finds the market expired while it runs
+type feeRefresh struct {
+	done   chan struct{}
+	market *FeeMarket
+	err    error
+}
+
+// get returns the cached market while it is fresh and refreshes it with fetch otherwise. Concurrent callers share a
+// single refresh and wait for its result, the lock is only held to read and store the market. When fetch fails, an
+// expired market up to maxFeeStaleness old is served as stale.
+func (c *feeCache) get(fetch func() (*FeeMarket, error)) (FeeMarket, error) {
+	c.mutex.Lock()
+	cached := c.market
+	if cached != nil && cached.Age() < feeCacheTTL {
+		c.mutex.Unlock()
+		return *cached, nil
+	}
+	refresh := c.refresh
+	if refresh == nil {
+		refresh = &feeRefresh{done: make(chan struct{})}
+		c.refresh = refresh
+		c.mutex.Unlock()
+
+		refresh.market, refresh.err = fetch()
+
+		c.mutex.Lock()
+		if refresh.err == nil {
+			c.market = refresh.market
+		}
+		c.refresh = nil
+		c.mutex.Unlock()
+		close(refresh.done)
+	} else {
+		c.mutex.Unlock()
+		<-refresh.done
+	}
+
+	if refresh.err != nil {
+		if cached != nil && cached.Age() < maxFeeStaleness {
+			stale := *cached
+			stale.Stale = true
+			return stale, nil
+		}
+		return FeeMarket{}, refresh.err
+	}
+	return *refresh.market, nil
+}
+
+// GetFeeRates returns the fee tiers and the mempool backlog, read from Esplora at most once per feeCacheTTL
+func (s *Service) GetFeeRates() (FeeMarket, error) {
+	return s.fees.get(s.fetchFeeMarket)
+}
+
+func (s *Service) fetchFeeMarket() (*FeeMarket, error) {
+	estimates, err := s.blockstream.GetFeeEstimates()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fee estimates: %w", err)
+	}
+	stats, err := s.blockstream.GetMempoolStats()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mempool stats: %w", err)
+	}
+
+	mempool := models.BitcoinMempoolStats{
+		Count:                   stats.Count,
+		VSize:                   stats.VSize,
+		TotalFee:                stats.TotalFee,
+		NextBlockFeeRate:        nextBlockFeeRate(stats.FeeHistogram),
+		IncrementalRelayFeeRate: incrementalRelayFeeRate,
+	}
+	return &FeeMarket{
+		Rates:     feeRatesFromEstimates(estimates, mempool.NextBlockFeeRate),
+		Mempool:   mempool,
+		FetchedAt: time.Now(),
+	}, nil
+}
+
+// feeRatesFromEstimates picks the tiers from the Esplora estimates. The next block tier is raised to what the current
+// backlog requires, since estimates lag behind sudden fee spikes, and slower tiers never exceed faster ones.
+func feeRatesFromEstimates(estimates blockstream_models.FeeEstimates, nextBlockFloor float64) models.BitcoinFeeRates {
+	nextBlock := roundFeeRate(math.Max(estimateFor(estimates, TargetNextBlock), nextBlockFloor))
+	halfHour := roundFeeRate(math.Min(estimateFor(estimates, TargetHalfHour), nextBlock))
+	hour := roundFeeRate(math.Min(estimateFor(estimates, TargetHour), halfHour))
+	economy := roundFeeRate(math.Min(estimateFor(estimates, TargetEconomy), hour))
+
+	return models.BitcoinFeeRates{NextBlock: nextBlock, HalfHour: halfHour, Hour: hour, Economy: economy}
+}
+
+// estimateFor returns the estimate of the target, or of the closest shorter target when Esplora has none for it
+func estimateFor(estimates blockstream_models.FeeEstimates, target int) float64 {
+	bestTarget, rate := 0, minRelayFeeRate
+	for key, estimate := range estimates {
+		blocks, err := strconv.Atoi(key)
+		if err != nil || blocks > target || blocks <= bestTarget {
+			continue
+		}
+		bestTarget, rate = blocks, estimate
+	}
+	return rate
+}
+
+// nextBlockFeeRate walks the fee histogram from the highest rate until a block is full
+func nextBlockFeeRate(histogram [][2]float64) float64 {
+	buckets := append([][2]float64(nil), histogram...)
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i][0] > buckets[j][0] })
+
+	var vsize float64
+	for _, bucket := range buckets {
+		if vsize += bucket[1]; vsize >= maxBlockVSize {
+			return bucket[0]
+		}
+	}
+	return minRelayFeeRate
+}
+
+// roundFeeRate rounds up to 0.1 sat/vB and never goes below the minimum relay fee rate
+func roundFeeRate(rate float64) float64 {
+	return math.Max(math.Ceil(rate*10)/10, minRelayFeeRate)
+}
+
+// ProjectFees sizes a transaction of inputs spending inputType and outputs of outputType and prices it at each tier
+func ProjectFees(rates models.BitcoinFeeRates, inputs int, outputs int, inputType ScriptType, outputType ScriptType) (*models.BitcoinFeeProjection, error) {
+	inputWeight, ok := inputWeights[inputType]
+	if !ok {
+		return nil, fmt.Errorf("unsupported input script type %q", inputType)
+	}
+	outputWeight, ok := outputWeights[outputType]
+	if !ok {
+		return nil, fmt.Errorf("unsupported output script type %q", outputType)
+	}
+	if inputs < 1 || outputs < 1 {
+		return nil, fmt.Errorf("a transaction needs at least one input and one output")
+	}
+
+	weight := txOverheadWeight + int64(inputs)*inputWeight + int64(outputs)*outputWeight
+	if inputType.isSegwit() {
+		weight += segwitMarkerWeight
+	}
+
+	return &models.BitcoinFeeProjection{
+		Inputs:     inputs,
+		Outputs:    outputs,
+		ScriptType: string(inputType),
+		OutputType: string(outputType),
+		VSize:      vsize(weight),
+		NextBlock:  feeForWeight(weight, rates.NextBlock),
+		HalfHour:   feeForWeight(weight, rates.HalfHour),
+		Hour:       feeForWeight(weight, rates.Hour),
+		Economy:    feeForWeight(weight, rates.Economy),
+	}, nil
+}
@@ -0,0 +1,128 @@
+package bitcoin
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/tashunc/nugenesis-wallet-backend/external/data/historical/thrirdParty/blockstream/blockstream_models"
+	"github.com/tashunc/nugenesis-wallet-backend/external/models"
+)
+
+func TestFeeRatesFromEstimates(t *testing.T) {
+	// Esplora has no 3 and 6 block targets here, and the 1 block estimate lags behind the backlog
+	estimates := blockstream_models.FeeEstimates{"1": 20.04, "2": 15, "5": 8, "144": 9.5}
+
+	rates := feeRatesFromEstimates(estimates, 25)
+	expected := models.BitcoinFeeRates{NextBlock: 25, HalfHour: 15, Hour: 8, Economy: 8}
+	if rates != expected {
+		t.Errorf("Expected %+v, got %+v", expected, rates)
+	}
+
+	if rates := feeRatesFromEstimates(blockstream_models.FeeEstimates{}, 0); rates.Economy != minRelayFeeRate || rates.NextBlock != minRelayFeeRate {
+		t.Errorf("Expected the minimum relay fee without estimates, got %+v", rates)
+	}
+}
+
+func TestNextBlockFeeRate(t *testing.T) {
+	histogram := [][2]float64{{5, 400000}, {30, 300000}, {12, 700000}}
+	if rate := nextBlockFeeRate(histogram); rate != 12 {
+		t.Errorf("Expected the block to fill at 12 sat/vB, got %v", rate)
+	}
+	if rate := nextBlockFeeRate(histogram[:1]); rate != minRelayFeeRate {
+		t.Errorf("Expected the minimum relay fee for a backlog under a block, got %v", rate)
+	}
+}
+
+func TestProjectFees(t *testing.T) {
+	rates := models.BitcoinFeeRates{NextBlock: 10, HalfHour: 5, Hour: 2, Economy: 1}
+
+	// 10 vB overhead, 0.5 vB marker, 68 vB input and two 31 vB outputs
+	projection, err := ProjectFees(rates, 1, 2, P2WPKH, P2WPKH)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if projection.VSize != 141 || projection.NextBlock != 1405 || projection.Economy != 141 {
+		t.Errorf("Unexpected projection %+v", projection)
+	}
+
+	// 10 vB overhead, 0.5 vB marker, 104.5 vB 2-of-3 multisig input and one 43 vB output
+	projection, err = ProjectFees(rates, 1, 1, P2WSH, P2WSH)
+	if err != nil || projection.VSize != 158 {
+		t.Errorf("Unexpected P2WSH projection %+v (%v)", projection, err)
+	}
+
+	if _, err := ProjectFees(rates, 1, 1, ScriptType("p2pk"), P2WPKH); err == nil {
+		t.Error("Expected an unknown input type to be rejected")
+	}
+}
+
+func TestFeeCacheServesStaleMarketWhenRefreshFails(t *testing.T) {
+	cache := &feeCache{}
+	failure := errors.New("esplora down")
+	fetched := &FeeMarket{Rates: models.BitcoinFeeRates{NextBlock: 12}, FetchedAt: time.Now().Add(-time.Minute)}
+
+	market, err := cache.get(func() (*FeeMarket, error) { return fetched, nil })
+	if err != nil || market.Stale || market.Rates.NextBlock != 12 {
+		t.Fatalf("Expected the fetched market, got %+v, %v", market, err)
+	}
+
+	market, err = cache.get(func() (*FeeMarket, error) { return nil, failure })
+	if err != nil || !market.Stale || market.Age() < time.Minute {
+		t.Errorf("Expected the expired market to be served as stale with its age, got %+v, %v", market, err)
+	}
+
+	cache.market.FetchedAt = time.Now().Add(-maxFeeStaleness)
+	if _, err := cache.get(func() (*FeeMarket, error) { return nil, failure }); !errors.Is(err, failure) {
+		t.Errorf("Expected the upstream error past the staleness limit, got %v", err)
+	}
+}
+
+func TestFeeCacheCoalescesConcurrentRefreshes(t *testing.T) {
+	cache := &feeCache{market: &FeeMarket{FetchedAt: time.Now().Add(-time.Minute)}}
+	release := make(chan struct{})
+	started := make(chan struct{})
+	var fetches atomic.Int32
+	fetch := func() (*FeeMarket, error) {
+		if fetches.Add(1) == 1 {
+			close(started)
+			<-release
+		}
+		return &FeeMarket{Rates: models.BitcoinFeeRates{NextBlock: 30}, FetchedAt: time.Now()}, nil
+	}
+
+	markets := make([]FeeMarket, 3)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		markets[0], _ = cache.get(fetch)
+	}()
+	<-started
+
+	// Requests arriving during the refresh wait for it instead of fetching again
+	for i := 1; i < len(markets); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			markets[i], _ = cache.get(fetch)
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := fetches.Load(); n != 1 {
+		t.Errorf("Expected a single refresh, got %d", n)
+	}
+	for i, market := range markets {
+		if market.Rates.NextBlock != 30 || market.Stale {
+			t.Errorf("Expected caller %d to get the refreshed market, got %+v", i, market)
+		}
+	}
+	if cache.market.Age() > time.Second || cache.refresh != nil {
+		t.Error("Expected the refreshed market to be cached and the refresh to be cleared")
+	}
+}
@@ -0,0 +1,490 @@
+package bitcoin
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/tashunc/nugenesis-wallet-backend/external/models"
+)
+
+const (
+	// sequenceRBF signals BIP125 replaceability, sequenceLockTime opts out of it while keeping nLockTime enforced
+	sequenceRBF      = wire.MaxTxInSequenceNum - 2
+	sequenceLockTime = wire.MaxTxInSequenceNum - 1
+	psbtTxVersion    = 2
+)
+
+var (
+	// ErrInvalidPSBTRequest is a malformed address, script, derivation or output in a create request
+	ErrInvalidPSBTRequest = errors.New("invalid psbt request")
+	ErrInvalidPSBT        = errors.New("invalid psbt")
+	ErrUnknownUTXO        = errors.New("input is not an unspent output of its address")
+	ErrIncompletePSBT     = errors.New("psbt is not fully signed")
+)
+
+// CreatePSBT builds an unsigned PSBT spending the given outputs of the user's addresses. Every input carries the
+// UTXO data signers need: the spent output for segwit, and the full previous transaction except for taproot, which
+// hardware wallets require to verify input amounts.
+func (s *Service) CreatePSBT(ctx context.Context, request models.CreatePSBTControllerRequest) (*psbt.Packet, error) {
+	spendable := make(map[string]map[string]models.UTXO)
+	outpoints := make([]*wire.OutPoint, 0, len(request.Inputs))
+	sequences := make([]uint32, 0, len(request.Inputs))
+	spent := make([]models.UTXO, 0, len(request.Inputs))
+	for _, input := range request.Inputs {
+		hash, err := chainhash.NewHashFromStr(input.Txid)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid txid %s", ErrInvalidPSBTRequest, input.Txid)
+		}
+
+		if _, exists := spendable[input.Address]; !exists {
+			if spendable[input.Address], err = s.getSpendableOutputs(ctx, input.Address); err != nil {
+				return nil, err
+			}
+		}
+		utxo, ok := spendable[input.Address][outpointKey(input.Txid, input.Vout)]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s:%d of %s", ErrUnknownUTXO, input.Txid, input.Vout, input.Address)
+		}
+
+		outpoints = append(outpoints, wire.NewOutPoint(hash, input.Vout))
+		spent = append(spent, utxo)
+		switch {
+		case !request.DisableRBF:
+			sequences = append(sequences, sequenceRBF)
+		case request.LockTime > 0:
+			sequences = append(sequences, sequenceLockTime)
+		default:
+			sequences = append(sequences, wire.MaxTxInSequenceNum)
+		}
+	}
+
+	txOuts := make([]*wire.TxOut, 0, len(request.Outputs))
+	for _, output := range request.Outputs {
+		pkScript, err := addressScript(output.Address)
+		if err != nil {
+			return nil, err
+		}
+		if scriptType, err := ScriptTypeFromScript(hex.EncodeToString(pkScript)); err == nil && output.Value < dustLimits[scriptType] {
+			return nil, fmt.Errorf("%w: output to %s is below the dust limit of %d sats", ErrInvalidPSBTRequest, output.Address, dustLimits[scriptType])
+		}
+		txOuts = append(txOuts, wire.NewTxOut(output.Value, pkScript))
+	}
+	if err := checkOutputsCovered(spent, txOuts); err != nil {
+		return nil, err
+	}
+
+	packet, err := psbt.New(outpoints, txOuts, psbtTxVersion, request.LockTime, sequences)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPSBTRequest, err)
+	}
+
+	for i, input := range request.Inputs {
+		if err := s.fillInput(&packet.Inputs[i], input, spent[i]); err != nil {
+			return nil, err
+		}
+	}
+	for i, output := range request.Outputs {
+		if err := fillOutput(&packet.Outputs[i], output, txOuts[i].PkScript); err != nil {
+			return nil, err
+		}
+	}
+	return packet, nil
+}
+
+// getSpendableOutputs reads the UTXOs of an address from blockchain.info and falls back to Esplora
+func (s *Service) getSpendableOutputs(ctx context.Context, address string) (map[string]models.UTXO, error) {
+	pkScript, err := addressScript(address)
+	if err != nil {
+		return nil, err
+	}
+
+	utxos, err := s.GetUTXOs(address)
+	if err != nil {
+		log.Printf("blockchain.info UTXO lookup of %s failed, falling back to Esplora: %v", address, err)
+		outputs, err := s.blockstream.GetAddressUTXOs(ctx, address)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get unspent outputs of %s: %w", address, err)
+		}
+		utxos = make([]models.UTXO, 0, len(outputs))
+		for _, output := range outputs {
+			utxos = append(utxos, models.UTXO{Txid: output.Txid, Vout: output.Vout, Value: output.Value})
+		}
+	}
+
+	byOutpoint := make(map[string]models.UTXO, len(utxos))
+	for _, utxo := range utxos {
+		// Esplora does not return scripts, and every output of an address has the same one
+		utxo.Script = hex.EncodeToString(pkScript)
+		byOutpoint[outpointKey(utxo.Txid, utxo.Vout)] = utxo
+	}
+	return byOutpoint, nil
+}
+
+func (s *Service) fillInput(pInput *psbt.PInput, input models.PSBTInputRequest, utxo models.UTXO) error {
+	pkScript, err := hex.DecodeString(utxo.Script)
+	if err != nil {
+		return fmt.Errorf("invalid script of %s:%d: %w", utxo.Txid, utxo.Vout, err)
+	}
+	if pInput.RedeemScript, err = decodeOptionalHex(input.RedeemScript, "redeem_script"); err != nil {
+		return err
+	}
+	if pInput.WitnessScript, err = decodeOptionalHex(input.WitnessScript, "witness_script"); err != nil {
+		return err
+	}
+
+	class := txscript.GetScriptClass(pkScript)
+	taproot := class == txscript.WitnessV1TaprootTy
+	// P2SH is assumed to wrap a witness program unless a legacy redeem script says otherwise
+	segwit := taproot || class == txscript.WitnessV0PubKeyHashTy || class == txscript.WitnessV0ScriptHashTy ||
+		(class == txscript.ScriptHashTy && (pInput.RedeemScript == nil || txscript.IsWitnessProgram(pInput.RedeemScript)))
+
+	if segwit {
+		pInput.WitnessUtxo = wire.NewTxOut(utxo.Value, pkScript)
+	}
+	if !taproot {
+		if pInput.NonWitnessUtxo, err = s.getPreviousTransaction(utxo.Txid, utxo.Vout); err != nil {
+			return err
+		}
+	}
+
+	for _, derivation := range input.Bip32Derivations {
+		pubKey, fingerprint, path, err := parseDerivation(derivation)
+		if err != nil {
+			return err
+		}
+		if taproot {
+			xOnly := xOnlyKey(pubKey)
+			pInput.TaprootInternalKey = xOnly
+			pInput.TaprootBip32Derivation = append(pInput.TaprootBip32Derivation, &psbt.TaprootBip32Derivation{
+				XOnlyPubKey: xOnly, MasterKeyFingerprint: fingerprint, Bip32Path: path,
+			})
+			continue
+		}
+		pInput.Bip32Derivation = append(pInput.Bip32Derivation, &psbt.Bip32Derivation{
+			PubKey: pubKey, MasterKeyFingerprint: fingerprint, Bip32Path: path,
+		})
+	}
+	return nil
+}
+
+// checkOutputsCovered rejects a spend whose outputs are worth more than the resolved inputs, it could never confirm
+func checkOutputsCovered(spent []models.UTXO, txOuts []*wire.TxOut) error {
+	var inputTotal, outputTotal int64
+	for _, utxo := range spent {
+		inputTotal += utxo.Value
+	}
+	for _, txOut := range txOuts {
+		outputTotal += txOut.Value
+	}
+	if outputTotal > inputTotal {
+		return fmt.Errorf("%w: outputs exceed inputs, %d sats spent from %d sats", ErrInvalidPSBTRequest, outputTotal, inputTotal)
+	}
+	return nil
+}
+
+func fillOutput(pOutput *psbt.POutput, output models.PSBTOutputRequest, pkScript []byte) error {
+	var err error
+	if pOutput.RedeemScript, err = decodeOptionalHex(output.RedeemScript, "redeem_script"); err != nil {
+		return err
+	}
+	if pOutput.WitnessScript, err = decodeOptionalHex(output.WitnessScript, "witness_script"); err != nil {
+		return err
+	}
+
+	taproot := txscript.GetScriptClass(pkScript) == txscript.WitnessV1TaprootTy
+	for _, derivation := range output.Bip32Derivations {
+		pubKey, fingerprint, path, err := parseDerivation(derivation)
+		if err != nil {
+			return err
+		}
+		if taproot {
+			xOnly := xOnlyKey(pubKey)
+			pOutput.TaprootInternalKey = xOnly
+			pOutput.TaprootBip32Derivation = append(pOutput.TaprootBip32Derivation, &psbt.TaprootBip32Derivation{
+				XOnlyPubKey: xOnly, MasterKeyFingerprint: fingerprint, Bip32Path: path,
+			})
+			continue
+		}
+		pOutput.Bip32Derivation = append(pOutput.Bip32Derivation, &psbt.Bip32Derivation{
+			PubKey: pubKey, MasterKeyFingerprint: fingerprint, Bip32Path: path,
+		})
+	}
+	return nil
+}
+
+func (s *Service) getPreviousTransaction(txid string, vout uint32) (*wire.MsgTx, error) {
+	rawTx, err := s.blockstream.GetTransactionHex(txid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get previous transaction %s: %w", txid, err)
+	}
+	raw, err := hex.DecodeString(rawTx)
+	if err != nil {
+		return nil, fmt.Errorf("previous transaction %s is not valid hex: %w", txid, err)
+	}
+
+	var tx wire.MsgTx
+	if err := tx.Deserialize(bytes.NewReader(raw)); err != nil {
+		return nil, fmt.Errorf("failed to decode previous transaction %s: %w", txid, err)
+	}
+	if tx.TxHash().String() != txid || int(vout) >= len(tx.TxOut) {
+		return nil, fmt.Errorf("previous transaction %s does not match the spent output", txid)
+	}
+	return &tx, nil
+}
+
+// DecodePSBT parses a PSBT in base64, as exchanged by most wallets, or in hex
+func DecodePSBT(encoded string) (*psbt.Packet, error) {
+	encoded = strings.TrimSpace(encoded)
+
+	var packet *psbt.Packet
+	var err error
+	if raw, hexErr := hex.DecodeString(encoded); hexErr == nil {
+		packet, err = psbt.NewFromRawBytes(bytes.NewReader(raw), false)
+	} else {
+		packet, err = psbt.NewFromRawBytes(strings.NewReader(encoded), true)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPSBT, err)
+	}
+	return packet, nil
+}
+
+// SummarizePSBT lists the inputs, outputs and fee of a PSBT. Inputs and outputs only count as the wallet's when they
+// pay one of addresses, derivations are not trusted since anyone handing over the PSBT can add them. Every input must
+// carry its UTXO, so the fee is never reported on partial data, and a PSBT paying out more than it spends is invalid.
+func SummarizePSBT(packet *psbt.Packet, addresses []string) (*models.PSBTSummary, error) {
+	owned := make(map[string]bool, len(addresses))
+	for _, address := range addresses {
+		owned[address] = true
+	}
+
+	spent := make(map[wire.OutPoint]bool, len(packet.UnsignedTx.TxIn))
+	for _, txIn := range packet.UnsignedTx.TxIn {
+		if spent[txIn.PreviousOutPoint] {
+			return nil, fmt.Errorf("%w: %s is spent twice", ErrInvalidPSBT, txIn.PreviousOutPoint)
+		}
+		spent[txIn.PreviousOutPoint] = true
+	}
+
+	summary := &models.PSBTSummary{
+		Txid:     packet.UnsignedTx.TxHash().String(),
+		Inputs:   make([]models.PSBTInputSummary, 0, len(packet.Inputs)),
+		Outputs:  make([]models.PSBTOutputSummary, 0, len(packet.Outputs)),
+		LockTime: packet.UnsignedTx.LockTime,
+		Complete: packet.IsComplete(),
+	}
+
+	for i, txIn := range packet.UnsignedTx.TxIn {
+		pInput := packet.Inputs[i]
+		input := models.PSBTInputSummary{
+			Txid:       txIn.PreviousOutPoint.Hash.String(),
+			Vout:       txIn.PreviousOutPoint.Index,
+			Signatures: len(pInput.PartialSigs) + len(pInput.TaprootScriptSpendSig),
+			Finalized:  pInput.FinalScriptSig != nil || pInput.FinalScriptWitness != nil,
+		}
+		if pInput.TaprootKeySpendSig != nil {
+			input.Signatures++
+		}
+		if txIn.Sequence < sequenceLockTime {
+			summary.RBF = true
+		}
+
+		prevOut, err := inputUTXO(packet, i)
+		if err != nil {
+			return nil, err
+		}
+		input.Value = prevOut.Value
+		input.Address, input.ScriptType = describeScript(prevOut.PkScript)
+		input.IsMine = owned[input.Address]
+		summary.InputTotal += prevOut.Value
+		summary.Inputs = append(summary.Inputs, input)
+	}
+
+	for _, txOut := range packet.UnsignedTx.TxOut {
+		output := models.PSBTOutputSummary{Value: txOut.Value}
+		output.Address, output.ScriptType = describeScript(txOut.PkScript)
+		output.IsChange = owned[output.Address]
+
+		summary.OutputTotal += txOut.Value
+		if output.IsChange {
+			summary.Change += txOut.Value
+		} else {
+			summary.Sent += txOut.Value
+		}
+		summary.Outputs = append(summary.Outputs, output)
+	}
+
+	if summary.OutputTotal > summary.InputTotal {
+		return nil, fmt.Errorf("%w: outputs exceed inputs, %d sats spent from %d sats", ErrInvalidPSBT, summary.OutputTotal, summary.InputTotal)
+	}
+	fee := summary.InputTotal - summary.OutputTotal
+	summary.Fee = &fee
+	if summary.VSize = estimateVSize(packet); summary.VSize > 0 {
+		feeRate := math.Round(float64(fee)/float64(summary.VSize)*100) / 100
+		summary.FeeRate = &feeRate
+	}
+	return summary, nil
+}
+
+// FinalizePSBT builds the final scripts of every signed input and extracts the network transaction
+func FinalizePSBT(packet *psbt.Packet) (*wire.MsgTx, error) {
+	var pending []string
+	for i := range packet.UnsignedTx.TxIn {
+		if _, err := psbt.MaybeFinalize(packet, i); err != nil {
+			pending = append(pending, fmt.Sprintf("%d (%v)", i, err))
+		}
+	}
+	if len(pending) > 0 {
+		return nil, fmt.Errorf("%w: inputs %s cannot be finalized", ErrIncompletePSBT, strings.Join(pending, ", "))
+	}
+
+	tx, err := psbt.Extract(packet)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrIncompletePSBT, err)
+	}
+	return tx, nil
+}
+
+// inputUTXO returns the output spent by an input. A full previous transaction must hash to the outpoint and its amount
+// wins over the witness UTXO, which a signer cannot verify on its own.
+func inputUTXO(packet *psbt.Packet, index int) (*wire.TxOut, error) {
+	pInput := packet.Inputs[index]
+	outpoint := packet.UnsignedTx.TxIn[index].PreviousOutPoint
+	if pInput.NonWitnessUtxo != nil {
+		if pInput.NonWitnessUtxo.TxHash() != outpoint.Hash {
+			return nil, fmt.Errorf("%w: previous transaction of input %d does not match its outpoint", ErrInvalidPSBT, index)
+		}
+		if int(outpoint.Index) >= len(pInput.NonWitnessUtxo.TxOut) {
+			return nil, fmt.Errorf("%w: previous transaction of input %d has no output %d", ErrInvalidPSBT, index, outpoint.Index)
+		}
+		prevOut := pInput.NonWitnessUtxo.TxOut[outpoint.Index]
+		if utxo := pInput.WitnessUtxo; utxo != nil && (utxo.Value != prevOut.Value || !bytes.Equal(utxo.PkScript, prevOut.PkScript)) {
+			return nil, fmt.Errorf("%w: witness UTXO of input %d contradicts its previous transaction", ErrInvalidPSBT, index)
+		}
+		return prevOut, nil
+	}
+	if pInput.WitnessUtxo != nil {
+		return pInput.WitnessUtxo, nil
+	}
+	return nil, fmt.Errorf("%w: input %d carries no UTXO", ErrInvalidPSBT, index)
+}
+
+// estimateVSize returns the exact size of a complete PSBT, otherwise the size once signed by single-key inputs.
+// It is zero when an input's witness size cannot be known, e.g. for multisig.
+func estimateVSize(packet *psbt.Packet) int64 {
+	if packet.IsComplete() {
+		if tx, err := psbt.Extract(packet); err == nil {
+			return vsize(int64(tx.SerializeSizeStripped()*3 + tx.SerializeSize()))
+		}
+	}
+
+	weight := int64(txOverheadWeight)
+	hasWitness := false
+	for i, pInput := range packet.Inputs {
+		prevOut, err := inputUTXO(packet, i)
+		if err != nil || pInput.WitnessScript != nil || (pInput.RedeemScript != nil && len(pInput.RedeemScript) != 22) {
+			return 0
+		}
+		scriptType, err := ScriptTypeFromScript(hex.EncodeToString(prevOut.PkScript))
+		inputWeight, ok := inputWeights[scriptType]
+		if err != nil || !ok {
+			return 0
+		}
+		weight += inputWeight
+		hasWitness = hasWitness || scriptType.isSegwit()
+	}
+	for _, txOut := range packet.UnsignedTx.TxOut {
+		weight += int64(8+wire.VarIntSerializeSize(uint64(len(txOut.PkScript)))+len(txOut.PkScript)) * 4
+	}
+	if hasWitness {
+		weight += segwitMarkerWeight
+	}
+	return vsize(weight)
+}
+
+func describeScript(pkScript []byte) (string, string) {
+	address := ""
+	if _, addresses, _, err := txscript.ExtractPkScriptAddrs(pkScript, bitcoinParams); err == nil && len(addresses) == 1 {
+		address = addresses[0].EncodeAddress()
+	}
+	scriptType, _ := ScriptTypeFromScript(hex.EncodeToString(pkScript))
+	return address, string(scriptType)
+}
+
+func addressScript(address string) ([]byte, error) {
+	decoded, err := btcutil.DecodeAddress(address, bitcoinParams)
+	if err != nil || !decoded.IsForNet(bitcoinParams) {
+		return nil, fmt.Errorf("%w: invalid Bitcoin address %s", ErrInvalidPSBTRequest, address)
+	}
+	pkScript, err := txscript.PayToAddrScript(decoded)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unsupported address %s", ErrInvalidPSBTRequest, address)
+	}
+	return pkScript, nil
+}
+
+// parseDerivation decodes the public key, the fingerprint in the little endian form the psbt package serializes,
+// and a path such as m/84'/0'/0'/1/3 where ' or h marks hardened steps
+func parseDerivation(derivation models.PSBTBip32Derivation) ([]byte, uint32, []uint32, error) {
+	pubKey, err := hex.DecodeString(derivation.PubKey)
+	if err != nil || (len(pubKey) != 33 && len(pubKey) != 32) {
+		return nil, 0, nil, fmt.Errorf("%w: invalid derivation pubkey %s", ErrInvalidPSBTRequest, derivation.PubKey)
+	}
+	fingerprintBytes, err := hex.DecodeString(derivation.MasterFingerprint)
+	if err != nil || len(fingerprintBytes) != 4 {
+		return nil, 0, nil, fmt.Errorf("%w: invalid master fingerprint %s", ErrInvalidPSBTRequest, derivation.MasterFingerprint)
+	}
+
+	steps := strings.Split(strings.TrimPrefix(derivation.Path, "m"), "/")
+	path := make([]uint32, 0, len(steps))
+	for _, step := range steps {
+		if step == "" {
+			continue
+		}
+		hardened := strings.HasSuffix(step, "'") || strings.HasSuffix(step, "h")
+		index, err := strconv.ParseUint(strings.TrimRight(step, "'h"), 10, 31)
+		if err != nil {
+			return nil, 0, nil, fmt.Errorf("%w: invalid derivation path %s", ErrInvalidPSBTRequest, derivation.Path)
+		}
+		if hardened {
+			index += 1 << 31
+		}
+		path = append(path, uint32(index))
+	}
+	return pubKey, binary.LittleEndian.Uint32(fingerprintBytes), path, nil
+}
+
+func xOnlyKey(pubKey []byte) []byte {
+	if len(pubKey) == 33 {
+		return pubKey[1:]
+	}
+	return pubKey
+}
+
+func decodeOptionalHex(value string, field string) ([]byte, error) {
+	if value == "" {
+		return nil, nil
+	}
+	decoded, err := hex.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s is not valid hex", ErrInvalidPSBTRequest, field)
+	}
+	return decoded, nil
+}
+
+func outpointKey(txid string, vout uint32) string {
+	return fmt.Sprintf("%s:%d", txid, vout)
+}
@@ -0,0 +1,196 @@
+package bitcoin
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/tashunc/nugenesis-wallet-backend/external/models"
+)
+
+// testSpend builds a PSBT spending 100000 sats of a P2WPKH key to an external address and its change address
+func testSpend(t *testing.T) (*psbt.Packet, *btcec.PrivateKey, string, string) {
+	t.Helper()
+	key, _ := btcec.NewPrivateKey()
+	address, err := btcutil.NewAddressWitnessPubKeyHash(btcutil.Hash160(key.PubKey().SerializeCompressed()), bitcoinParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changeScript, _ := addressScript(address.EncodeAddress())
+	recipientScript, _ := addressScript("bc1q8c6fshw2dlwun7ekn9qwf37cu2rn755upcp6el")
+
+	packet, err := psbt.New(
+		[]*wire.OutPoint{wire.NewOutPoint(&chainhash.Hash{1}, 0)},
+		[]*wire.TxOut{wire.NewTxOut(60000, recipientScript), wire.NewTxOut(39000, changeScript)},
+		psbtTxVersion, 0, []uint32{sequenceRBF},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	packet.Inputs[0].WitnessUtxo = wire.NewTxOut(100000, changeScript)
+	return packet, key, address.EncodeAddress(), "bc1q8c6fshw2dlwun7ekn9qwf37cu2rn755upcp6el"
+}
+
+func TestSummarizePSBTDetectsChange(t *testing.T) {
+	packet, _, address, recipient := testSpend(t)
+
+	summary, err := SummarizePSBT(packet, []string{address})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Sent != 60000 || summary.Change != 39000 || summary.Fee == nil || *summary.Fee != 1000 {
+		t.Errorf("Unexpected totals %+v", summary)
+	}
+	if !summary.Inputs[0].IsMine || summary.Outputs[0].Address != recipient || !summary.Outputs[1].IsChange {
+		t.Errorf("Unexpected inputs %+v and outputs %+v", summary.Inputs, summary.Outputs)
+	}
+	// 10.5 vB overhead, 68 vB input and two 31 vB outputs
+	if summary.VSize != 141 || !summary.RBF || summary.Complete {
+		t.Errorf("Unexpected size and flags %+v", summary)
+	}
+}
+
+func TestSummarizePSBTIgnoresDerivationsOutsideTheAddresses(t *testing.T) {
+	packet, key, _, _ := testSpend(t)
+	derivation := &psbt.Bip32Derivation{PubKey: key.PubKey().SerializeCompressed(), MasterKeyFingerprint: 0xd34db33f, Bip32Path: []uint32{0}}
+	packet.Inputs[0].Bip32Derivation = []*psbt.Bip32Derivation{derivation}
+	// An attacker labels the payment with the wallet's fingerprint to pass it off as change
+	packet.Outputs[0].Bip32Derivation = []*psbt.Bip32Derivation{derivation}
+
+	summary, err := SummarizePSBT(packet, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Inputs[0].IsMine || summary.Outputs[0].IsChange || summary.Change != 0 {
+		t.Errorf("Expected nothing to be attributed to the wallet, got inputs %+v and outputs %+v", summary.Inputs, summary.Outputs)
+	}
+}
+
+func TestSummarizePSBTChecksTheSpentOutputs(t *testing.T) {
+	packet, _, address, _ := testSpend(t)
+	prevTx := wire.NewMsgTx(2)
+	prevTx.AddTxOut(wire.NewTxOut(5000, packet.Inputs[0].WitnessUtxo.PkScript))
+	prevTx.AddTxOut(wire.NewTxOut(100000, packet.Inputs[0].WitnessUtxo.PkScript))
+
+	// The previous transaction must be the one the input spends
+	packet.Inputs[0].NonWitnessUtxo = prevTx
+	if _, err := SummarizePSBT(packet, []string{address}); !errors.Is(err, ErrInvalidPSBT) {
+		t.Errorf("Expected a previous transaction of another outpoint to be rejected, got %v", err)
+	}
+
+	// and its amount wins over a witness UTXO that claims more
+	packet.UnsignedTx.TxIn[0].PreviousOutPoint = wire.OutPoint{Hash: prevTx.TxHash(), Index: 1}
+	packet.Inputs[0].WitnessUtxo = wire.NewTxOut(200000, packet.Inputs[0].WitnessUtxo.PkScript)
+	if _, err := SummarizePSBT(packet, []string{address}); !errors.Is(err, ErrInvalidPSBT) {
+		t.Errorf("Expected a contradicting witness UTXO to be rejected, got %v", err)
+	}
+	packet.Inputs[0].WitnessUtxo = nil
+	summary, err := SummarizePSBT(packet, []string{address})
+	if err != nil || summary.Inputs[0].Value != 100000 || *summary.Fee != 1000 {
+		t.Errorf("Expected the amount of the previous transaction, got %+v, %v", summary, err)
+	}
+
+	packet.Inputs[0].NonWitnessUtxo = nil
+	if _, err := SummarizePSBT(packet, []string{address}); !errors.Is(err, ErrInvalidPSBT) {
+		t.Errorf("Expected an input without UTXO to be rejected, got %v", err)
+	}
+}
+
+func TestSummarizePSBTRejectsDuplicateInputs(t *testing.T) {
+	packet, _, address, _ := testSpend(t)
+	packet.UnsignedTx.TxIn = append(packet.UnsignedTx.TxIn, wire.NewTxIn(&packet.UnsignedTx.TxIn[0].PreviousOutPoint, nil, nil))
+	packet.Inputs = append(packet.Inputs, packet.Inputs[0])
+
+	if _, err := SummarizePSBT(packet, []string{address}); !errors.Is(err, ErrInvalidPSBT) {
+		t.Errorf("Expected an outpoint spent twice to be rejected, got %v", err)
+	}
+}
+
+func TestSummarizePSBTRejectsOverspending(t *testing.T) {
+	packet, _, address, _ := testSpend(t)
+	packet.UnsignedTx.TxOut[1].Value = 41000
+	var buf bytes.Buffer
+	if err := packet.Serialize(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := DecodePSBT(base64.StdEncoding.EncodeToString(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SummarizePSBT(decoded, []string{address}); !errors.Is(err, ErrInvalidPSBT) {
+		t.Errorf("Expected a PSBT paying out more than its inputs to be rejected, got %v", err)
+	}
+}
+
+func TestCreatePSBTRejectsOutputsExceedingInputs(t *testing.T) {
+	spent := []models.UTXO{{Txid: "01", Vout: 0, Value: 60000}, {Txid: "02", Vout: 1, Value: 40000}}
+	if err := checkOutputsCovered(spent, []*wire.TxOut{wire.NewTxOut(60000, nil), wire.NewTxOut(40000, nil)}); err != nil {
+		t.Errorf("Expected outputs spending every input to be accepted, got %v", err)
+	}
+	if err := checkOutputsCovered(spent, []*wire.TxOut{wire.NewTxOut(60000, nil), wire.NewTxOut(40001, nil)}); !errors.Is(err, ErrInvalidPSBTRequest) {
+		t.Errorf("Expected outputs exceeding the inputs to be rejected, got %v", err)
+	}
+}
+
+func TestFinalizePSBT(t *testing.T) {
+	packet, key, _, _ := testSpend(t)
+	if _, err := FinalizePSBT(packet); !errors.Is(err, ErrIncompletePSBT) {
+		t.Fatalf("Expected an unsigned PSBT to be incomplete, got %v", err)
+	}
+
+	prevOut := packet.Inputs[0].WitnessUtxo
+	fetcher := txscript.NewCannedPrevOutputFetcher(prevOut.PkScript, prevOut.Value)
+	sigHashes := txscript.NewTxSigHashes(packet.UnsignedTx, fetcher)
+	witness, err := txscript.WitnessSignature(packet.UnsignedTx, sigHashes, 0, prevOut.Value, prevOut.PkScript, txscript.SigHashAll, key, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	packet.Inputs[0].PartialSigs = []*psbt.PartialSig{{PubKey: witness[1], Signature: witness[0]}}
+
+	encoded, _ := packet.B64Encode()
+	decoded, err := DecodePSBT(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, err := FinalizePSBT(decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tx.TxHash() != packet.UnsignedTx.TxHash() || len(tx.TxIn[0].Witness) != 2 {
+		t.Errorf("Expected the signed transaction %s, got %s", packet.UnsignedTx.TxHash(), tx.TxHash())
+	}
+}
+
+func TestParseDerivation(t *testing.T) {
+	derivation := models.PSBTBip32Derivation{
+		PubKey:            "0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798",
+		MasterFingerprint: "d34db33f",
+		Path:              "m/84'/0h/0'/1/3",
+	}
+	_, fingerprint, path, err := parseDerivation(derivation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fingerprint != 0x3fb34dd3 {
+		t.Errorf("Expected the fingerprint in serialization order, got %x", fingerprint)
+	}
+	expected := []uint32{84 + 1<<31, 1 << 31, 1 << 31, 1, 3}
+	for i := range expected {
+		if i >= len(path) || path[i] != expected[i] {
+			t.Fatalf("Expected path %v, got %v", expected, path)
+		}
+	}
+
+	derivation.Path = "m/84'/x"
+	if _, _, _, err := parseDerivation(derivation); !errors.Is(err, ErrInvalidPSBTRequest) {
+		t.Errorf("Expected an invalid path to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,100 @@
+package bitcoin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+// bitcoinParams are the only network the Bitcoin endpoints accept addresses for
+var bitcoinParams = &chaincfg.MainNetParams
+
+// ScriptType is the kind of output script, used to size inputs and outputs
+type ScriptType string
+
+const (
+	P2PKH      ScriptType = "p2pkh"
+	P2SHP2WPKH ScriptType = "p2sh-p2wpkh"
+	P2WPKH     ScriptType = "p2wpkh"
+	P2WSH      ScriptType = "p2wsh"
+	P2TR       ScriptType = "p2tr"
+)
+
+// inputWeights are the weight units of a signed input spending each script type.
+// P2SH is assumed to wrap P2WPKH, the only P2SH form our wallet creates, and P2WSH a 2-of-3 multisig.
+var inputWeights = map[ScriptType]int64{
+	P2PKH:      592, // 148 vB
+	P2SHP2WPKH: 364, // 91 vB
+	P2WPKH:     272, // 68 vB
+	P2WSH:      418, // 104.5 vB
+	P2TR:       230, // 57.5 vB, key path
+}
+
+var outputWeights = map[ScriptType]int64{
+	P2PKH:      136,
+	P2SHP2WPKH: 128,
+	P2WPKH:     124,
+	P2WSH:      172,
+	P2TR:       172,
+}
+
+// dustLimits follow Bitcoin Core's default dust relay fee of 3 sat/vB
+var dustLimits = map[ScriptType]int64{
+	P2PKH:      546,
+	P2SHP2WPKH: 540,
+	P2WPKH:     294,
+	P2WSH:      330,
+	P2TR:       330,
+}
+
+const (
+	// txOverheadWeight is version, locktime and the input/output counts
+	txOverheadWeight = 40
+	// segwitMarkerWeight is the marker and flag bytes of a transaction with witness inputs
+	segwitMarkerWeight = 2
+)
+
+func (t ScriptType) isSegwit() bool {
+	return t != P2PKH
+}
+
+// ScriptTypeFromScript detects the type of a scriptPubKey given as hex
+func ScriptTypeFromScript(scriptHex string) (ScriptType, error) {
+	script := strings.ToLower(scriptHex)
+	switch {
+	case len(script) == 50 && strings.HasPrefix(script, "76a914") && strings.HasSuffix(script, "88ac"):
+		return P2PKH, nil
+	case len(script) == 46 && strings.HasPrefix(script, "a914") && strings.HasSuffix(script, "87"):
+		return P2SHP2WPKH, nil
+	case len(script) == 44 && strings.HasPrefix(script, "0014"):
+		return P2WPKH, nil
+	case len(script) == 68 && strings.HasPrefix(script, "0020"):
+		return P2WSH, nil
+	case len(script) == 68 && strings.HasPrefix(script, "5120"):
+		return P2TR, nil
+	}
+	return "", fmt.Errorf("unsupported script %s", scriptHex)
+}
+
+// ScriptTypeFromAddress detects the output type of a mainnet address. Testnet and regtest addresses are rejected.
+func ScriptTypeFromAddress(address string) (ScriptType, error) {
+	decoded, err := btcutil.DecodeAddress(address, bitcoinParams)
+	if err != nil || !decoded.IsForNet(bitcoinParams) {
+		return "", fmt.Errorf("invalid Bitcoin mainnet address %s", address)
+	}
+	switch decoded.(type) {
+	case *btcutil.AddressPubKeyHash:
+		return P2PKH, nil
+	case *btcutil.AddressScriptHash:
+		return P2SHP2WPKH, nil
+	case *btcutil.AddressWitnessPubKeyHash:
+		return P2WPKH, nil
+	case *btcutil.AddressWitnessScriptHash:
+		return P2WSH, nil
+	case *btcutil.AddressTaproot:
+		return P2TR, nil
+	}
+	return "", fmt.Errorf("unsupported address %s", address)
+}
@@ -0,0 +1,40 @@
+package bitcoin
+
+import (
+	blockchaininfo "github.com/tashunc/nugenesis-wallet-backend/external/data/historical/thrirdParty/blockchain_info"
+	"github.com/tashunc/nugenesis-wallet-backend/external/data/historical/thrirdParty/blockstream"
+	"github.com/tashunc/nugenesis-wallet-backend/external/models"
+)
+
+type Service struct {
+	blockchainInfo *blockchaininfo.Service
+	blockstream    *blockstream.Service
+	fees           feeCache
+}
+
+func NewService() *Service {
+	return &Service{
+		blockchainInfo: blockchaininfo.NewService(),
+		blockstream:    blockstream.NewService(blockstream.BitcoinNetwork()),
+	}
+}
+
+// GetUTXOs returns the unspent outputs of an address with txids in the usual big-endian display order
+func (s *Service) GetUTXOs(address string) ([]models.UTXO, error) {
+	unspentOutputs, err := s.blockchainInfo.GetUnspentOutputs(address)
+	if err != nil {
+		return nil, err
+	}
+
+	utxos := make([]models.UTXO, 0, len(unspentOutputs.UnspentOutputs))
+	for _, output := range unspentOutputs.UnspentOutputs {
+		utxos = append(utxos, models.UTXO{
+			Txid:          output.TxHashBigEndian,
+			Vout:          uint32(output.TxOutputN),
+			Value:         output.Value,
+			Script:        output.Script,
+			Confirmations: output.Confirmations,
+		})
+	}
+	return utxos, nil
+}
@@ -5,7 +5,7 @@ import (
 	"github.com/tashunc/nugenesis-wallet-backend/static/staticServices"
 )
 
-func RegisterRoutes(rg *gin.RouterGroup, nonceStore *staticServices.NonceStore) {
+func RegisterRoutes(rg *gin.RouterGroup, nonceStore staticServices.NonceStore) {
 	protected := rg.Group("/middleware")
 	protected.Use(staticServices.ClientNonceAuthMiddleware(nonceStore))
 
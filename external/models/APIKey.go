@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// MintAPIKeyControllerRequest creates a partner key, a DailyQuota of 0 means unlimited
+type MintAPIKeyControllerRequest struct {
+	Name       string   `json:"name" binding:"required"`
+	Scopes     []string `json:"scopes" binding:"required,min=1"`
+	DailyQuota int      `json:"dailyQuota" binding:"min=0"`
+}
+
+type APIKeyInfo struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	DailyQuota int        `json:"dailyQuota"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+}
+
+// APIKeyControllerResponse carries Key only when it was just minted, it cannot be retrieved again
+type APIKeyControllerResponse struct {
+	Success bool                     `json:"success"`
+	Key     string                   `json:"key,omitempty"`
+	APIKey  *APIKeyInfo              `json:"apiKey,omitempty"`
+	APIKeys []APIKeyInfo             `json:"apiKeys,omitempty"`
+	Error   *SendRawTransactionError `json:"error,omitempty"`
+	Message string                   `json:"message,omitempty"`
+}
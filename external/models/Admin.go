@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// AuditRecord is an admin action, BeforeCount and AfterCount are asset ID mapping counts
+type AuditRecord struct {
+	ID          int64     `json:"id"`
+	Actor       string    `json:"actor"`
+	RemoteAddr  string    `json:"remoteAddr,omitempty"`
+	Action      string    `json:"action"`
+	Target      string    `json:"target,omitempty"`
+	BeforeCount *int      `json:"beforeCount,omitempty"`
+	AfterCount  *int      `json:"afterCount,omitempty"`
+	Success     bool      `json:"success"`
+	Error       string    `json:"error,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+type AuditLogControllerResponse struct {
+	Success bool                     `json:"success"`
+	Records []AuditRecord            `json:"records"`
+	Error   *SendRawTransactionError `json:"error,omitempty"`
+	Message string                   `json:"message,omitempty"`
+}
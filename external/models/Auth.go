@@ -0,0 +1,36 @@
+package models
+
+type RefreshTokenControllerRequest struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+}
+
+// SessionControllerResponse carries a newly issued or rotated session, ExpiresIn values are in seconds
+type SessionControllerResponse struct {
+	Success          bool                     `json:"success"`
+	AccessToken      string                   `json:"accessToken,omitempty"`
+	RefreshToken     string                   `json:"refreshToken,omitempty"`
+	TokenType        string                   `json:"tokenType,omitempty"`
+	ExpiresIn        int64                    `json:"expiresIn,omitempty"`
+	RefreshExpiresIn int64                    `json:"refreshExpiresIn,omitempty"`
+	User             map[string]interface{}   `json:"user,omitempty"`
+	Error            *SendRawTransactionError `json:"error,omitempty"`
+	Message          string                   `json:"message,omitempty"`
+}
+
+type SIWENonceControllerResponse struct {
+	Nonce     string `json:"nonce"`
+	ExpiresAt int64  `json:"expiresAt"`
+}
+
+// SIWEVerifyControllerRequest carries the EIP-4361 message and its personal_sign signature
+type SIWEVerifyControllerRequest struct {
+	Message   string `json:"message" binding:"required"`
+	Signature string `json:"signature" binding:"required"`
+}
+
+// WalletSignInControllerRequest carries a sign-in message signed by the wallet of CoinType
+type WalletSignInControllerRequest struct {
+	CoinType  string `json:"coinType" binding:"required"`
+	Message   string `json:"message" binding:"required"`
+	Signature string `json:"signature" binding:"required"`
+}
@@ -17,6 +17,8 @@ type GetTokenBalancesControllerRequest struct {
 	BlockchainID      string   `json:"blockchain_id,omitempty"`
 	Address           string   `json:"address" binding:"required"`
 	ContractAddresses []string `json:"contractAddresses,omitempty"`
+	// Cursor continues a listing of every erc20 balance from the cursor of a previous response
+	Cursor string `json:"cursor,omitempty"`
 }
 
 type TokenBalanceData struct {
@@ -29,6 +31,7 @@ type GetTokenBalancesControllerResponse struct {
 	Success       bool                     `json:"success"`
 	Address       string                   `json:"address,omitempty"`
 	TokenBalances []TokenBalanceData       `json:"tokenBalances,omitempty"`
+	Cursor        string                   `json:"cursor,omitempty"` // set when more balances remain than one request fetches
 	Error         *SendRawTransactionError `json:"error,omitempty"`
 	Message       string                   `json:"message,omitempty"`
 }
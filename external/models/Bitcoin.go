@@ -0,0 +1,201 @@
+package models
+
+// UTXO is an unspent Bitcoin output, amounts are in satoshis
+type UTXO struct {
+	Txid          string `json:"txid"`
+	Vout          uint32 `json:"vout"`
+	Value         int64  `json:"value"`
+	Script        string `json:"script"`
+	Confirmations int    `json:"confirmations"`
+}
+
+type GetUTXOsControllerResponse struct {
+	Success bool                     `json:"success"`
+	Address string                   `json:"address,omitempty"`
+	UTXOs   []UTXO                   `json:"utxos"`
+	Total   int64                    `json:"total"`
+	Error   *SendRawTransactionError `json:"error,omitempty"`
+	Message string                   `json:"message,omitempty"`
+}
+
+type CoinSelectControllerRequest struct {
+	// Address owns the UTXOs to spend
+	Address string `json:"address" binding:"required"`
+	// TargetAmount is the amount paid to the recipient in satoshis
+	TargetAmount int64 `json:"target_amount" binding:"required,gt=0"`
+	// FeeRate is in sat/vB
+	FeeRate       float64 `json:"fee_rate" binding:"required,gt=0"`
+	ChangeAddress string  `json:"change_address" binding:"required"`
+	// RecipientAddress only sizes the payment output, a P2WPKH output is assumed when empty
+	RecipientAddress string `json:"recipient_address,omitempty"`
+	// Strategy is bnb or largest_first, bnb falls back to largest_first when no changeless match exists
+	Strategy         string `json:"strategy,omitempty"`
+	MinConfirmations int    `json:"min_confirmations,omitempty"`
+}
+
+type CoinSelectOutput struct {
+	Address  string `json:"address,omitempty"`
+	Value    int64  `json:"value"`
+	IsChange bool   `json:"is_change"`
+}
+
+type CoinSelectControllerResponse struct {
+	Success    bool                     `json:"success"`
+	Strategy   string                   `json:"strategy,omitempty"`
+	Inputs     []UTXO                   `json:"inputs,omitempty"`
+	Outputs    []CoinSelectOutput       `json:"outputs,omitempty"`
+	InputTotal int64                    `json:"input_total,omitempty"`
+	Fee        int64                    `json:"fee,omitempty"`
+	FeeRate    float64                  `json:"fee_rate,omitempty"`
+	VSize      int64                    `json:"vsize,omitempty"`
+	Error      *SendRawTransactionError `json:"error,omitempty"`
+	Message    string                   `json:"message,omitempty"`
+}
+
+// BitcoinFeeEstimateRequest sizes a transaction to project its fee, the rates alone are returned without inputs and outputs
+type BitcoinFeeEstimateRequest struct {
+	Inputs  int `json:"inputs" form:"inputs" binding:"min=0,max=10000"`
+	Outputs int `json:"outputs" form:"outputs" binding:"min=0,max=10000"`
+	// ScriptType of the spent inputs, p2wpkh when empty
+	ScriptType string `json:"script_type,omitempty" form:"script_type"`
+	// OutputType of the created outputs, the input script type when empty
+	OutputType string `json:"output_type,omitempty" form:"output_type"`
+}
+
+// BitcoinFeeRates are fee rates in sat/vB for each confirmation target
+type BitcoinFeeRates struct {
+	NextBlock float64 `json:"next_block"`
+	HalfHour  float64 `json:"half_hour"`
+	Hour      float64 `json:"hour"`
+	Economy   float64 `json:"economy"`
+}
+
+type BitcoinMempoolStats struct {
+	Count    int   `json:"count"`
+	VSize    int64 `json:"vsize"`
+	TotalFee int64 `json:"total_fee"`
+	// NextBlockFeeRate is the lowest fee rate of the backlog that fits in the next block
+	NextBlockFeeRate float64 `json:"next_block_fee_rate"`
+	// IncrementalRelayFeeRate is the rate a BIP125 replacement must pay on top of the fee it replaces
+	IncrementalRelayFeeRate float64 `json:"incremental_relay_fee_rate"`
+}
+
+// BitcoinFeeProjection is the fee in satoshis of a transaction of VSize at each rate of BitcoinFeeRates
+type BitcoinFeeProjection struct {
+	Inputs     int    `json:"inputs"`
+	Outputs    int    `json:"outputs"`
+	ScriptType string `json:"script_type"`
+	OutputType string `json:"output_type"`
+	VSize      int64  `json:"vsize"`
+	NextBlock  int64  `json:"next_block"`
+	HalfHour   int64  `json:"half_hour"`
+	Hour       int64  `json:"hour"`
+	Economy    int64  `json:"economy"`
+}
+
+type BitcoinFeeEstimateControllerResponse struct {
+	Success    bool                     `json:"success"`
+	FeeRates   *BitcoinFeeRates         `json:"fee_rates,omitempty"`
+	Mempool    *BitcoinMempoolStats     `json:"mempool,omitempty"`
+	Projection *BitcoinFeeProjection    `json:"projection,omitempty"`
+	Error      *SendRawTransactionError `json:"error,omitempty"`
+	Message    string                   `json:"message,omitempty"`
+	// AgeSeconds is how old the fee rates are, Stale is set when they could not be refreshed past their TTL
+	AgeSeconds int64 `json:"age_seconds,omitempty"`
+	Stale      bool  `json:"stale,omitempty"`
+}
+
+// PSBTBip32Derivation tells a hardware wallet which key signs an input or owns a change output
+type PSBTBip32Derivation struct {
+	// PubKey is the compressed public key in hex, or the x-only key of a taproot output
+	PubKey string `json:"pubkey" binding:"required"`
+	// MasterFingerprint is the first four bytes of the master key hash160 in hex
+	MasterFingerprint string `json:"master_fingerprint" binding:"required"`
+	// Path is the derivation path from the master key, e.g. m/84'/0'/0'/1/3
+	Path string `json:"path" binding:"required"`
+}
+
+type PSBTInputRequest struct {
+	Txid string `json:"txid" binding:"required"`
+	Vout uint32 `json:"vout"`
+	// Address holds the output, its unspent outputs provide the value and script
+	Address          string                `json:"address" binding:"required"`
+	RedeemScript     string                `json:"redeem_script,omitempty"`
+	WitnessScript    string                `json:"witness_script,omitempty"`
+	Bip32Derivations []PSBTBip32Derivation `json:"bip32_derivations,omitempty" binding:"dive"`
+}
+
+type PSBTOutputRequest struct {
+	Address          string                `json:"address" binding:"required"`
+	Value            int64                 `json:"value" binding:"required,gt=0"`
+	RedeemScript     string                `json:"redeem_script,omitempty"`
+	WitnessScript    string                `json:"witness_script,omitempty"`
+	Bip32Derivations []PSBTBip32Derivation `json:"bip32_derivations,omitempty" binding:"dive"`
+}
+
+type CreatePSBTControllerRequest struct {
+	Inputs   []PSBTInputRequest  `json:"inputs" binding:"required,min=1,dive"`
+	Outputs  []PSBTOutputRequest `json:"outputs" binding:"required,min=1,dive"`
+	LockTime uint32              `json:"locktime,omitempty"`
+	// DisableRBF makes the inputs final, they signal BIP125 replaceability by default
+	DisableRBF bool `json:"disable_rbf,omitempty"`
+}
+
+type DecodePSBTControllerRequest struct {
+	// PSBT is base64 or hex encoded
+	PSBT string `json:"psbt" binding:"required"`
+	// Addresses belong to the user, inputs spending them are marked as owned and outputs paying them as change
+	Addresses []string `json:"addresses,omitempty"`
+}
+
+type FinalizePSBTControllerRequest struct {
+	PSBT string `json:"psbt" binding:"required"`
+}
+
+type PSBTInputSummary struct {
+	Txid       string `json:"txid"`
+	Vout       uint32 `json:"vout"`
+	Address    string `json:"address,omitempty"`
+	ScriptType string `json:"script_type,omitempty"`
+	// Value is read from the full previous transaction when the PSBT carries it
+	Value      int64 `json:"value"`
+	IsMine     bool  `json:"is_mine"`
+	Signatures int   `json:"signatures"`
+	Finalized  bool  `json:"finalized"`
+}
+
+type PSBTOutputSummary struct {
+	Address    string `json:"address,omitempty"`
+	ScriptType string `json:"script_type,omitempty"`
+	Value      int64  `json:"value"`
+	IsChange   bool   `json:"is_change"`
+}
+
+// PSBTSummary describes a PSBT for a confirmation screen
+type PSBTSummary struct {
+	Txid        string              `json:"txid"`
+	Inputs      []PSBTInputSummary  `json:"inputs"`
+	Outputs     []PSBTOutputSummary `json:"outputs"`
+	InputTotal  int64               `json:"input_total"`
+	OutputTotal int64               `json:"output_total"`
+	// Sent pays outputs outside the wallet, Change returns to one of the wallet's addresses
+	Sent     int64    `json:"sent"`
+	Change   int64    `json:"change"`
+	Fee      *int64   `json:"fee,omitempty"`
+	VSize    int64    `json:"vsize,omitempty"`
+	FeeRate  *float64 `json:"fee_rate,omitempty"`
+	RBF      bool     `json:"rbf"`
+	LockTime uint32   `json:"locktime"`
+	Complete bool     `json:"complete"`
+}
+
+type PSBTControllerResponse struct {
+	Success bool         `json:"success"`
+	PSBT    string       `json:"psbt,omitempty"`
+	Summary *PSBTSummary `json:"summary,omitempty"`
+	// Hex and Txid are the extracted transaction of a finalized PSBT
+	Hex     string                   `json:"hex,omitempty"`
+	Txid    string                   `json:"txid,omitempty"`
+	Error   *SendRawTransactionError `json:"error,omitempty"`
+	Message string                   `json:"message,omitempty"`
+}
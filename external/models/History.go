@@ -1,5 +1,14 @@
 package models
 
+import "time"
+
+// Quote is an amount of a coin (CoinGecko ID) at a point in time, waiting to be valued in fiat
+type Quote struct {
+	CoinID string
+	Amount float64
+	At     time.Time
+}
+
 type Transaction struct {
 	ID        string `json:"id"`
 	Type      string `json:"type"`
@@ -14,6 +23,28 @@ type Transaction struct {
 	Time      string `json:"time"`
 	Fee       string `json:"fee"`
 	Hash      string `json:"hash"`
+	// Leg identifies the transfer within its transaction when one transaction is listed as several entries
+	Leg string `json:"-"`
+	// ValueQuote and FeeQuote are priced into Value and Fee once a whole page is mapped
+	ValueQuote *Quote `json:"-"`
+	FeeQuote   *Quote `json:"-"`
+}
+
+// LegacyTransactionHistoryResponse is the unversioned address history body of the Esplora-backed chains
+type LegacyTransactionHistoryResponse struct {
+	Transactions []Transaction `json:"transactions"`
+	TotalCount   int           `json:"totalCount"`
+}
+
+// TransactionHistoryResponse represents the v2 response for the address history endpoint
+type TransactionHistoryResponse struct {
+	Success      bool          `json:"success"`
+	Address      string        `json:"address"`
+	Provider     string        `json:"provider"`
+	Transactions []Transaction `json:"transactions"`
+	TotalCount   int           `json:"totalCount"`
+	Cursor       string        `json:"cursor,omitempty"`
+	HasMore      bool          `json:"has_more"`
 }
 
 type Token struct {
@@ -0,0 +1,30 @@
+package models
+
+// PortfolioWallet is one address on one chain, CoinType is the SLIP-44 id used in /api/data/:id
+type PortfolioWallet struct {
+	CoinType string `json:"coinType" binding:"required"`
+	Address  string `json:"address" binding:"required"`
+}
+
+type PortfolioControllerRequest struct {
+	Wallets []PortfolioWallet `json:"wallets" binding:"required,min=1,dive"`
+}
+
+// PortfolioChainResult reports the outcome for one wallet, Error is set when its balances could not be fetched
+type PortfolioChainResult struct {
+	CoinType   string                   `json:"coinType"`
+	Address    string                   `json:"address"`
+	Provider   string                   `json:"provider,omitempty"`
+	TokenCount int                      `json:"tokenCount"`
+	UsdValue   float64                  `json:"usdValue"`
+	Error      *SendRawTransactionError `json:"error,omitempty"`
+}
+
+type PortfolioControllerResponse struct {
+	Success       bool                     `json:"success"`
+	TotalUsdValue float64                  `json:"totalUsdValue"`
+	Chains        []PortfolioChainResult   `json:"chains"`
+	Balances      []WalletTokenBalance     `json:"balances"`
+	Error         *SendRawTransactionError `json:"error,omitempty"`
+	Message       string                   `json:"message,omitempty"`
+}
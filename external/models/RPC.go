@@ -3,6 +3,8 @@ package models
 type SendRawTransactionError struct {
 	Code    int    `json:"code"`
 	Message string `json:"message"`
+	// Reason is a machine readable rejection cause, e.g. insufficient_fee, set by chains that classify rejections
+	Reason string `json:"reason,omitempty"`
 }
 
 type SendRawTransactionControllerRequest struct {
@@ -51,3 +53,40 @@ type GetGasPriceControllerResponse struct {
 	Error    *SendRawTransactionError `json:"error,omitempty"`
 	Message  string                   `json:"message,omitempty"`
 }
+
+// SolanaFeeEstimateControllerRequest asks for the fee of a compiled Solana message
+type SolanaFeeEstimateControllerRequest struct {
+	// Message is the base64 encoded transaction message, its blockhash must still be valid
+	Message string `json:"message" binding:"required"`
+}
+
+type RecentBlockhashControllerResponse struct {
+	Success              bool                     `json:"success"`
+	Blockhash            string                   `json:"blockhash,omitempty"`
+	LastValidBlockHeight uint64                   `json:"lastValidBlockHeight,omitempty"`
+	Slot                 uint64                   `json:"slot,omitempty"`
+	Error                *SendRawTransactionError `json:"error,omitempty"`
+	Message              string                   `json:"message,omitempty"`
+}
+
+// FeeTier is one fee suggestion. In eip1559 mode MaxFeePerGas and MaxPriorityFeePerGas are set, in legacy mode
+// GasPrice is. All values are hex quantities in wei.
+type FeeTier struct {
+	MaxFeePerGas         string `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas string `json:"maxPriorityFeePerGas,omitempty"`
+	GasPrice             string `json:"gasPrice,omitempty"`
+	// EstimatedSeconds is the expected time until inclusion, 0 when the block time is unknown
+	EstimatedSeconds int64 `json:"estimatedSeconds"`
+}
+
+type FeeSuggestionsControllerResponse struct {
+	Success bool `json:"success"`
+	// Mode is eip1559 or legacy
+	Mode          string                   `json:"mode,omitempty"`
+	BaseFeePerGas string                   `json:"baseFeePerGas,omitempty"`
+	Slow          *FeeTier                 `json:"slow,omitempty"`
+	Standard      *FeeTier                 `json:"standard,omitempty"`
+	Fast          *FeeTier                 `json:"fast,omitempty"`
+	Error         *SendRawTransactionError `json:"error,omitempty"`
+	Message       string                   `json:"message,omitempty"`
+}
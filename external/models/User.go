@@ -0,0 +1,78 @@
+package models
+
+import "time"
+
+// UserIdentity is a session subject linked to an account, e.g. google:1234 or eip155:0xabc
+type UserIdentity struct {
+	Subject   string    `json:"subject"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// UserProfile is an account as its owner sees it
+type UserProfile struct {
+	ID            string         `json:"id"`
+	Name          string         `json:"name"`
+	Email         string         `json:"email,omitempty"`
+	EmailVerified bool           `json:"emailVerified"`
+	HasPassword   bool           `json:"hasPassword"`
+	Identities    []UserIdentity `json:"identities"`
+	CreatedAt     time.Time      `json:"createdAt"`
+	UpdatedAt     time.Time      `json:"updatedAt"`
+}
+
+// PublicUserProfile is what other users may see of an account
+type PublicUserProfile struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// CreateUserControllerRequest opens an account for the current session, Password requires Email
+type CreateUserControllerRequest struct {
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// UpdateUserControllerRequest changes the fields that are present
+type UpdateUserControllerRequest struct {
+	Name     *string `json:"name"`
+	Email    *string `json:"email"`
+	Password *string `json:"password"`
+}
+
+type UserLoginControllerRequest struct {
+	Email    string `json:"email" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// VerifyEmailControllerRequest carries the token of an emailed verification link
+type VerifyEmailControllerRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// LinkIdentityControllerRequest proves ownership of another identity with an access token of its session
+type LinkIdentityControllerRequest struct {
+	AccessToken string `json:"accessToken" binding:"required"`
+}
+
+type UserControllerResponse struct {
+	Success bool                     `json:"success"`
+	User    *UserProfile             `json:"user,omitempty"`
+	Error   *SendRawTransactionError `json:"error,omitempty"`
+	Message string                   `json:"message,omitempty"`
+}
+
+type PublicUserControllerResponse struct {
+	Success bool               `json:"success"`
+	User    *PublicUserProfile `json:"user"`
+}
+
+type UserListControllerResponse struct {
+	Success bool                     `json:"success"`
+	Users   []PublicUserProfile      `json:"users"`
+	Limit   int                      `json:"limit"`
+	Offset  int                      `json:"offset"`
+	Error   *SendRawTransactionError `json:"error,omitempty"`
+	Message string                   `json:"message,omitempty"`
+}
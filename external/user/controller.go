@@ -1,16 +1,395 @@
 package user
 
-//import (
-//	"github.com/gin-gonic/gin"
-//	"net/http"
-//)
-//
-//func GetUser(c *gin.Context) {
-//	id := c.Param("id")
-//	user, err := GetUserByID(id)
-//	if err != nil {
-//		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
-//		return
-//	}
-//	c.JSON(http.StatusOK, user)
-//}
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tashunc/nugenesis-wallet-backend/external/auth"
+	"github.com/tashunc/nugenesis-wallet-backend/external/models"
+)
+
+const (
+	defaultListLimit = 50
+	maxListLimit     = 200
+)
+
+// errorStatus maps user errors to HTTP status codes
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrUserNotFound), errors.Is(err, ErrIdentityNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrEmailTaken), errors.Is(err, ErrAccountExists), errors.Is(err, ErrIdentityLinked), errors.Is(err, ErrLastSignInMethod):
+		return http.StatusConflict
+	case errors.Is(err, ErrInvalidInput), errors.Is(err, ErrVerificationInvalid):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrInvalidCredentials), errors.Is(err, auth.ErrInvalidToken), errors.Is(err, auth.ErrTokenRevoked):
+		return http.StatusUnauthorized
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func respondUserError(c *gin.Context, status int, message string, err error) {
+	c.JSON(status, models.UserControllerResponse{
+		Success: false,
+		Message: message,
+		Error: &models.SendRawTransactionError{
+			Code:    status,
+			Message: err.Error(),
+		},
+	})
+}
+
+func respondUser(c *gin.Context, status int, user *User, message string) {
+	c.JSON(status, models.UserControllerResponse{
+		Success: true,
+		User:    user.Profile(),
+		Message: message,
+	})
+}
+
+// getService responds 503 when accounts are unavailable because Postgres is not configured
+func getService(c *gin.Context) (*Service, bool) {
+	service, err := GetUserService()
+	if err != nil {
+		respondUserError(c, http.StatusServiceUnavailable, "User accounts are not available", err)
+		return nil, false
+	}
+	return service, true
+}
+
+// currentUser resolves the account of the authenticated session
+func currentUser(c *gin.Context, service *Service) (*User, bool) {
+	claims, ok := auth.CurrentClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+		return nil, false
+	}
+
+	user, err := service.Current(c.Request.Context(), claims.Subject)
+	if err != nil {
+		status := errorStatus(err)
+		message := "Failed to load account"
+		if status == http.StatusNotFound {
+			message = "No account for this session, create one with POST /api/users"
+		}
+		respondUserError(c, status, message, err)
+		return nil, false
+	}
+	return user, true
+}
+
+// ownAccount resolves the current account and checks it is the one addressed by :id
+func ownAccount(c *gin.Context, service *Service) (*User, bool) {
+	user, ok := currentUser(c, service)
+	if !ok {
+		return nil, false
+	}
+	if id, _ := parseID(c.Param("id")); id != user.ID {
+		respondUserError(c, http.StatusForbidden, "Users can only manage their own account", errors.New("forbidden"))
+		return nil, false
+	}
+	return user, true
+}
+
+// List returns public profiles, paginated with limit and offset, it is registered on the admin group
+func List(c *gin.Context) {
+	service, ok := getService(c)
+	if !ok {
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultListLimit)))
+	if err != nil || limit <= 0 || limit > maxListLimit {
+		respondUserError(c, http.StatusBadRequest, "Invalid limit", ErrInvalidInput)
+		return
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		respondUserError(c, http.StatusBadRequest, "Invalid offset", ErrInvalidInput)
+		return
+	}
+
+	users, err := service.List(c.Request.Context(), limit, offset)
+	if err != nil {
+		respondUserError(c, errorStatus(err), "Failed to list users", err)
+		return
+	}
+
+	profiles := make([]models.PublicUserProfile, 0, len(users))
+	for _, user := range users {
+		profiles = append(profiles, user.PublicProfile())
+	}
+	c.JSON(http.StatusOK, models.UserListControllerResponse{
+		Success: true,
+		Users:   profiles,
+		Limit:   limit,
+		Offset:  offset,
+	})
+}
+
+// Create opens an account linked to the identity of the current session
+func Create(c *gin.Context) {
+	service, ok := getService(c)
+	if !ok {
+		return
+	}
+	claims, ok := auth.CurrentClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+		return
+	}
+
+	var request models.CreateUserControllerRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondUserError(c, http.StatusBadRequest, "Invalid request format", err)
+		return
+	}
+
+	user, err := service.Create(c.Request.Context(), claims.Subject, request.Name, request.Email, request.Password)
+	if err != nil {
+		respondUserError(c, errorStatus(err), "Failed to create account", err)
+		return
+	}
+	message := "Account created successfully"
+	if user.Email != "" {
+		message = "Account created, verify your email to sign in with a password"
+	}
+	respondUser(c, http.StatusCreated, user, message)
+}
+
+// GetByID returns the public profile of a user, or the full profile of the caller's own account
+func GetByID(c *gin.Context) {
+	service, ok := getService(c)
+	if !ok {
+		return
+	}
+
+	user, err := service.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondUserError(c, errorStatus(err), "Failed to load user", err)
+		return
+	}
+
+	if claims, ok := auth.CurrentClaims(c); ok {
+		if current, err := service.Current(c.Request.Context(), claims.Subject); err == nil && current.ID == user.ID {
+			respondUser(c, http.StatusOK, current, "")
+			return
+		}
+	}
+	profile := user.PublicProfile()
+	c.JSON(http.StatusOK, models.PublicUserControllerResponse{Success: true, User: &profile})
+}
+
+// Me returns the account of the current session
+func Me(c *gin.Context) {
+	service, ok := getService(c)
+	if !ok {
+		return
+	}
+	if user, ok := currentUser(c, service); ok {
+		respondUser(c, http.StatusOK, user, "")
+	}
+}
+
+func UpdateMe(c *gin.Context) {
+	service, ok := getService(c)
+	if !ok {
+		return
+	}
+	if user, ok := currentUser(c, service); ok {
+		update(c, service, user)
+	}
+}
+
+func UpdateByID(c *gin.Context) {
+	service, ok := getService(c)
+	if !ok {
+		return
+	}
+	if user, ok := ownAccount(c, service); ok {
+		update(c, service, user)
+	}
+}
+
+func update(c *gin.Context, service *Service, user *User) {
+	var request models.UpdateUserControllerRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondUserError(c, http.StatusBadRequest, "Invalid request format", err)
+		return
+	}
+
+	updated, err := service.Update(c.Request.Context(), user, request.Name, request.Email, request.Password)
+	if err != nil {
+		respondUserError(c, errorStatus(err), "Failed to update account", err)
+		return
+	}
+	message := "Account updated successfully"
+	if request.Password != nil {
+		message = "Account updated, sessions started with the old password have been ended"
+	}
+	respondUser(c, http.StatusOK, updated, message)
+}
+
+// ResendVerification emails a new verification token for the current account's email
+func ResendVerification(c *gin.Context) {
+	service, ok := getService(c)
+	if !ok {
+		return
+	}
+	user, ok := currentUser(c, service)
+	if !ok {
+		return
+	}
+
+	if err := service.SendVerification(c.Request.Context(), user); err != nil {
+		respondUserError(c, errorStatus(err), "Failed to send verification email", err)
+		return
+	}
+	c.JSON(http.StatusAccepted, models.UserControllerResponse{
+		Success: true,
+		Message: "Verification email sent",
+	})
+}
+
+// VerifyEmail confirms an email with the token of its verification email, it needs no session
+func VerifyEmail(c *gin.Context) {
+	service, ok := getService(c)
+	if !ok {
+		return
+	}
+
+	var request models.VerifyEmailControllerRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondUserError(c, http.StatusBadRequest, "Invalid request format", err)
+		return
+	}
+
+	user, err := service.VerifyEmail(c.Request.Context(), request.Token)
+	if err != nil {
+		respondUserError(c, errorStatus(err), "Failed to verify email", err)
+		return
+	}
+	respondUser(c, http.StatusOK, user, "Email verified successfully")
+}
+
+func DeleteMe(c *gin.Context) {
+	service, ok := getService(c)
+	if !ok {
+		return
+	}
+	if user, ok := currentUser(c, service); ok {
+		remove(c, service, user)
+	}
+}
+
+func DeleteByID(c *gin.Context) {
+	service, ok := getService(c)
+	if !ok {
+		return
+	}
+	if user, ok := ownAccount(c, service); ok {
+		remove(c, service, user)
+	}
+}
+
+// remove deletes the account and ends the session that deleted it
+func remove(c *gin.Context, service *Service, user *User) {
+	if err := service.Delete(c.Request.Context(), user.ID); err != nil {
+		respondUserError(c, errorStatus(err), "Failed to delete account", err)
+		return
+	}
+	if claims, ok := auth.CurrentClaims(c); ok {
+		_ = auth.GetTokenService().Logout(c.Request.Context(), claims)
+	}
+	c.JSON(http.StatusOK, models.UserControllerResponse{
+		Success: true,
+		Message: "Account deleted successfully",
+	})
+}
+
+// LinkIdentity links the identity of another session, whose access token proves the caller owns it
+func LinkIdentity(c *gin.Context) {
+	service, ok := getService(c)
+	if !ok {
+		return
+	}
+	user, ok := currentUser(c, service)
+	if !ok {
+		return
+	}
+
+	var request models.LinkIdentityControllerRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondUserError(c, http.StatusBadRequest, "Invalid request format", err)
+		return
+	}
+
+	claims, err := auth.GetTokenService().ValidateAccessToken(c.Request.Context(), request.AccessToken)
+	if err != nil {
+		respondUserError(c, errorStatus(err), "Invalid identity token", err)
+		return
+	}
+
+	linked, err := service.LinkIdentity(c.Request.Context(), user, claims.Subject)
+	if err != nil {
+		respondUserError(c, errorStatus(err), "Failed to link identity", err)
+		return
+	}
+	respondUser(c, http.StatusOK, linked, "Identity linked successfully")
+}
+
+func UnlinkIdentity(c *gin.Context) {
+	service, ok := getService(c)
+	if !ok {
+		return
+	}
+	user, ok := currentUser(c, service)
+	if !ok {
+		return
+	}
+
+	unlinked, err := service.UnlinkIdentity(c.Request.Context(), user, c.Param("subject"))
+	if err != nil {
+		respondUserError(c, errorStatus(err), "Failed to unlink identity", err)
+		return
+	}
+	respondUser(c, http.StatusOK, unlinked, "Identity unlinked successfully")
+}
+
+// Login starts a session for an email and password account
+func Login(c *gin.Context) {
+	service, ok := getService(c)
+	if !ok {
+		return
+	}
+
+	var request models.UserLoginControllerRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondUserError(c, http.StatusBadRequest, "Invalid request format", err)
+		return
+	}
+
+	user, err := service.Authenticate(c.Request.Context(), request.Email, request.Password)
+	if err != nil {
+		respondUserError(c, errorStatus(err), "Failed to log in", err)
+		return
+	}
+
+	pair, err := auth.GetTokenService().IssueTokenPair(auth.Identity{
+		Subject: PasswordSubjectPrefix + user.ID,
+		Email:   user.Email,
+		Stamp:   PasswordStamp(user.PasswordHash),
+	})
+	if err != nil {
+		respondUserError(c, http.StatusInternalServerError, "Failed to create session", err)
+		return
+	}
+	auth.RespondSession(c, pair, map[string]interface{}{
+		"id":    user.ID,
+		"name":  user.Name,
+		"email": user.Email,
+	}, "Logged in successfully")
+}
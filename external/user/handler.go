@@ -1,10 +0,0 @@
-package user
-
-import (
-	"github.com/gin-gonic/gin"
-	"net/http"
-)
-
-func GetUsers(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{"users": []string{"Alice", "Bob"}})
-}
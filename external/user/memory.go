@@ -0,0 +1,266 @@
+package user
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// memoryVerification is a pending email verification
+type memoryVerification struct {
+	userID    string
+	email     string
+	expiresAt time.Time
+}
+
+// MemoryStore keeps accounts in process, for tests
+type MemoryStore struct {
+	users         map[string]*User
+	identities    map[string]Identity
+	owners        map[string]string
+	verifications map[string]memoryVerification
+	mutex         sync.Mutex
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		users:         make(map[string]*User),
+		identities:    make(map[string]Identity),
+		owners:        make(map[string]string),
+		verifications: make(map[string]memoryVerification),
+	}
+}
+
+// newID returns a random UUID like the gen_random_uuid default of the users table
+func newID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate user id: %w", err)
+	}
+	buf[6] = buf[6]&0x0f | 0x40
+	buf[8] = buf[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", buf[0:4], buf[4:6], buf[6:8], buf[8:10], buf[10:16]), nil
+}
+
+// stored returns a copy so callers cannot change the store, the caller holds the lock
+func (s *MemoryStore) stored(id string) (*User, error) {
+	user, exists := s.users[id]
+	if !exists {
+		return nil, ErrUserNotFound
+	}
+	copied := *user
+	copied.HasPassword = copied.PasswordHash != ""
+	copied.Identities = nil
+	return &copied, nil
+}
+
+func (s *MemoryStore) Create(ctx context.Context, user *User, subject string) (*User, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, linked := s.owners[subject]; subject != "" && linked {
+		return nil, ErrIdentityLinked
+	}
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	s.users[id] = &User{
+		ID:           id,
+		Name:         user.Name,
+		Email:        user.Email,
+		PasswordHash: user.PasswordHash,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	if subject != "" {
+		s.owners[subject] = id
+		s.identities[subject] = Identity{Subject: subject, CreatedAt: now}
+	}
+	return s.stored(id)
+}
+
+func (s *MemoryStore) GetByID(ctx context.Context, id string) (*User, error) {
+	id, ok := parseID(id)
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.stored(id)
+}
+
+func (s *MemoryStore) GetByEmail(ctx context.Context, email string) (*User, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for id, user := range s.users {
+		if user.EmailVerified && user.Email == email {
+			return s.stored(id)
+		}
+	}
+	return nil, ErrUserNotFound
+}
+
+func (s *MemoryStore) GetBySubject(ctx context.Context, subject string) (*User, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	id, linked := s.owners[subject]
+	if !linked {
+		return nil, ErrUserNotFound
+	}
+	return s.stored(id)
+}
+
+func (s *MemoryStore) List(ctx context.Context, limit int, offset int) ([]*User, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	users := make([]*User, 0, len(s.users))
+	for id := range s.users {
+		user, _ := s.stored(id)
+		users = append(users, user)
+	}
+	sort.Slice(users, func(i, j int) bool {
+		if !users[i].CreatedAt.Equal(users[j].CreatedAt) {
+			return users[i].CreatedAt.Before(users[j].CreatedAt)
+		}
+		return users[i].ID < users[j].ID
+	})
+	if offset >= len(users) {
+		return nil, nil
+	}
+	users = users[offset:]
+	if len(users) > limit {
+		users = users[:limit]
+	}
+	return users, nil
+}
+
+func (s *MemoryStore) Update(ctx context.Context, user *User) (*User, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	existing, exists := s.users[user.ID]
+	if !exists {
+		return nil, ErrUserNotFound
+	}
+	if existing.Email != user.Email {
+		existing.EmailVerified = false
+	}
+	existing.Name = user.Name
+	existing.Email = user.Email
+	existing.PasswordHash = user.PasswordHash
+	existing.UpdatedAt = time.Now()
+	return s.stored(user.ID)
+}
+
+func (s *MemoryStore) Delete(ctx context.Context, id string) error {
+	id, ok := parseID(id)
+	if !ok {
+		return ErrUserNotFound
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, exists := s.users[id]; !exists {
+		return ErrUserNotFound
+	}
+	delete(s.users, id)
+	for subject, owner := range s.owners {
+		if owner == id {
+			delete(s.owners, subject)
+			delete(s.identities, subject)
+		}
+	}
+	for tokenHash, verification := range s.verifications {
+		if verification.userID == id {
+			delete(s.verifications, tokenHash)
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) Identities(ctx context.Context, userID string) ([]Identity, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var identities []Identity
+	for subject, owner := range s.owners {
+		if owner == userID {
+			identities = append(identities, s.identities[subject])
+		}
+	}
+	sort.Slice(identities, func(i, j int) bool {
+		return identities[i].CreatedAt.Before(identities[j].CreatedAt)
+	})
+	return identities, nil
+}
+
+func (s *MemoryStore) LinkIdentity(ctx context.Context, userID string, subject string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if owner, linked := s.owners[subject]; linked {
+		if owner != userID {
+			return ErrIdentityLinked
+		}
+		return nil
+	}
+	s.owners[subject] = userID
+	s.identities[subject] = Identity{Subject: subject, CreatedAt: time.Now()}
+	return nil
+}
+
+func (s *MemoryStore) UnlinkIdentity(ctx context.Context, userID string, subject string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if owner, linked := s.owners[subject]; !linked || owner != userID {
+		return ErrIdentityNotFound
+	}
+	delete(s.owners, subject)
+	delete(s.identities, subject)
+	return nil
+}
+
+func (s *MemoryStore) AddVerification(ctx context.Context, userID string, email string, tokenHash string, expiresAt time.Time) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.verifications[tokenHash] = memoryVerification{userID: userID, email: email, expiresAt: expiresAt}
+	return nil
+}
+
+func (s *MemoryStore) Verify(ctx context.Context, tokenHash string) (*User, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	verification, exists := s.verifications[tokenHash]
+	if !exists || !time.Now().Before(verification.expiresAt) {
+		return nil, ErrVerificationInvalid
+	}
+
+	user, exists := s.users[verification.userID]
+	if !exists || user.Email != verification.email {
+		return nil, ErrVerificationInvalid
+	}
+	// Same as the partial unique index on verified emails
+	for id, other := range s.users {
+		if id != user.ID && other.EmailVerified && other.Email == user.Email {
+			return nil, ErrEmailTaken
+		}
+	}
+	delete(s.verifications, tokenHash)
+	if !user.EmailVerified {
+		user.EmailVerified = true
+		user.UpdatedAt = time.Now()
+	}
+	return s.stored(user.ID)
+}
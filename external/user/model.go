@@ -1,8 +1,49 @@
 package user
 
+import (
+	"time"
+
+	"github.com/tashunc/nugenesis-wallet-backend/external/models"
+)
+
 type User struct {
-	ID       string `json:"id"`
-	Name     string `json:"name"`
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	ID    string
+	Name  string
+	Email string
+	// EmailVerified is set once the user proved to own Email, password sign-in needs it
+	EmailVerified bool
+	// PasswordHash is the bcrypt hash, empty for accounts that only sign in with linked identities
+	PasswordHash string
+	HasPassword  bool
+	Identities   []Identity
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// Identity is a session subject linked to a user, e.g. google:1234 or eip155:0xabc
+type Identity struct {
+	Subject   string
+	CreatedAt time.Time
+}
+
+// Profile is the account as its owner sees it, the password hash is never exposed
+func (u *User) Profile() *models.UserProfile {
+	profile := &models.UserProfile{
+		ID:            u.ID,
+		Name:          u.Name,
+		Email:         u.Email,
+		EmailVerified: u.EmailVerified,
+		HasPassword:   u.HasPassword,
+		Identities:    make([]models.UserIdentity, 0, len(u.Identities)),
+		CreatedAt:     u.CreatedAt,
+		UpdatedAt:     u.UpdatedAt,
+	}
+	for _, identity := range u.Identities {
+		profile.Identities = append(profile.Identities, models.UserIdentity{Subject: identity.Subject, CreatedAt: identity.CreatedAt})
+	}
+	return profile
+}
+
+func (u *User) PublicProfile() models.PublicUserProfile {
+	return models.PublicUserProfile{ID: u.ID, Name: u.Name, CreatedAt: u.CreatedAt}
 }
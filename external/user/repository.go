@@ -0,0 +1,298 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/tashunc/nugenesis-wallet-backend/pkg/database"
+)
+
+var (
+	ErrUserNotFound     = errors.New("user not found")
+	ErrEmailTaken       = errors.New("email is already in use")
+	ErrIdentityLinked   = errors.New("identity is already linked to an account")
+	ErrIdentityNotFound = errors.New("identity is not linked to this account")
+	// ErrVerificationInvalid means the verification token is unknown, expired or for an email the user no longer has
+	ErrVerificationInvalid = errors.New("invalid or expired verification token")
+)
+
+// uniqueViolation is the Postgres error code of a unique constraint violation
+const uniqueViolation = "23505"
+
+const userColumns = `id, name, COALESCE(email, ''), COALESCE(password_hash, ''), email_verified_at IS NOT NULL, created_at, updated_at`
+
+// Store persists users, their linked identities and pending email verifications
+type Store interface {
+	// Create inserts the user and links subject to it, subject may be empty for password only accounts
+	Create(ctx context.Context, user *User, subject string) (*User, error)
+	GetByID(ctx context.Context, id string) (*User, error)
+	// GetByEmail only finds verified emails
+	GetByEmail(ctx context.Context, email string) (*User, error)
+	// GetBySubject returns the user a session subject is linked to
+	GetBySubject(ctx context.Context, subject string) (*User, error)
+	// List returns a page of users, oldest first
+	List(ctx context.Context, limit int, offset int) ([]*User, error)
+	// Update saves the name, email and password hash of the user, changing the email clears its verification
+	Update(ctx context.Context, user *User) (*User, error)
+	// Delete removes the user, its identities are removed with it
+	Delete(ctx context.Context, id string) error
+	Identities(ctx context.Context, userID string) ([]Identity, error)
+	// LinkIdentity links subject to the user, linking a subject the user already owns is a no-op
+	LinkIdentity(ctx context.Context, userID string, subject string) error
+	UnlinkIdentity(ctx context.Context, userID string, subject string) error
+	// AddVerification stores a pending verification of the user's email
+	AddVerification(ctx context.Context, userID string, email string, tokenHash string, expiresAt time.Time) error
+	// Verify consumes the verification and marks its email verified if the user still has it
+	Verify(ctx context.Context, tokenHash string) (*User, error)
+}
+
+// parseID returns the canonical form of a UUID user id, ok is false for anything else
+func parseID(id string) (string, bool) {
+	if len(id) != 36 {
+		return "", false
+	}
+	for i, char := range id {
+		switch i {
+		case 8, 13, 18, 23:
+			if char != '-' {
+				return "", false
+			}
+		default:
+			if !isHexChar(char) {
+				return "", false
+			}
+		}
+	}
+	return strings.ToLower(id), true
+}
+
+func isHexChar(char rune) bool {
+	return (char >= '0' && char <= '9') ||
+		(char >= 'a' && char <= 'f') ||
+		(char >= 'A' && char <= 'F')
+}
+
+// PostgresStore keeps users in the users, user_identities and email_verifications tables
+type PostgresStore struct {
+	db *sql.DB
+}
+
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanUser(row rowScanner) (*User, error) {
+	user := &User{}
+	err := row.Scan(&user.ID, &user.Name, &user.Email, &user.PasswordHash, &user.EmailVerified, &user.CreatedAt, &user.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read user: %w", err)
+	}
+	user.HasPassword = user.PasswordHash != ""
+	return user, nil
+}
+
+// translate maps unique violations to the matching domain error
+func translate(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		switch pqErr.Constraint {
+		case "users_verified_email_key":
+			return ErrEmailTaken
+		case "user_identities_pkey":
+			return ErrIdentityLinked
+		}
+	}
+	return err
+}
+
+func (s *PostgresStore) Create(ctx context.Context, user *User, subject string) (*User, error) {
+	var created *User
+	err := database.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		row := tx.QueryRowContext(ctx, `
+			INSERT INTO users (name, email, password_hash) VALUES ($1, NULLIF($2, ''), NULLIF($3, ''))
+			RETURNING `+userColumns,
+			user.Name, user.Email, user.PasswordHash)
+
+		var err error
+		if created, err = scanUser(row); err != nil {
+			return translate(err)
+		}
+
+		if subject != "" {
+			if _, err := tx.ExecContext(ctx, `INSERT INTO user_identities (subject, user_id) VALUES ($1, $2)`, subject, created.ID); err != nil {
+				return translate(err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+func (s *PostgresStore) GetByID(ctx context.Context, id string) (*User, error) {
+	// Malformed ids are not found rather than a database error
+	id, ok := parseID(id)
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+	row := s.db.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users WHERE id = $1`, id)
+	return scanUser(row)
+}
+
+func (s *PostgresStore) GetByEmail(ctx context.Context, email string) (*User, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users WHERE email = $1 AND email_verified_at IS NOT NULL`, email)
+	return scanUser(row)
+}
+
+func (s *PostgresStore) GetBySubject(ctx context.Context, subject string) (*User, error) {
+	row := s.db.QueryRowContext(ctx, `
+		SELECT `+userColumns+` FROM users
+		WHERE id = (SELECT user_id FROM user_identities WHERE subject = $1)`, subject)
+	return scanUser(row)
+}
+
+func (s *PostgresStore) List(ctx context.Context, limit int, offset int) ([]*User, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+userColumns+` FROM users ORDER BY created_at, id LIMIT $1 OFFSET $2`, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+	defer rows.Close()
+
+	var users []*User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+func (s *PostgresStore) Update(ctx context.Context, user *User) (*User, error) {
+	row := s.db.QueryRowContext(ctx, `
+		UPDATE users SET name = $2, email = NULLIF($3, ''), password_hash = NULLIF($4, ''), updated_at = now(),
+			email_verified_at = CASE WHEN email IS NOT DISTINCT FROM NULLIF($3, '') THEN email_verified_at END
+		WHERE id = $1
+		RETURNING `+userColumns,
+		user.ID, user.Name, user.Email, user.PasswordHash)
+
+	updated, err := scanUser(row)
+	if err != nil {
+		return nil, translate(err)
+	}
+	return updated, nil
+}
+
+func (s *PostgresStore) Delete(ctx context.Context, id string) error {
+	id, ok := parseID(id)
+	if !ok {
+		return ErrUserNotFound
+	}
+	result, err := s.db.ExecContext(ctx, `DELETE FROM users WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func (s *PostgresStore) Identities(ctx context.Context, userID string) ([]Identity, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT subject, created_at FROM user_identities WHERE user_id = $1 ORDER BY created_at`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list identities: %w", err)
+	}
+	defer rows.Close()
+
+	var identities []Identity
+	for rows.Next() {
+		var identity Identity
+		if err := rows.Scan(&identity.Subject, &identity.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to read identity: %w", err)
+		}
+		identities = append(identities, identity)
+	}
+	return identities, rows.Err()
+}
+
+func (s *PostgresStore) LinkIdentity(ctx context.Context, userID string, subject string) error {
+	var owner string
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO user_identities (subject, user_id) VALUES ($1, $2)
+		ON CONFLICT (subject) DO UPDATE SET subject = EXCLUDED.subject
+		RETURNING user_id`, subject, userID).Scan(&owner)
+	if err != nil {
+		return fmt.Errorf("failed to link identity: %w", err)
+	}
+	if owner != userID {
+		return ErrIdentityLinked
+	}
+	return nil
+}
+
+func (s *PostgresStore) UnlinkIdentity(ctx context.Context, userID string, subject string) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM user_identities WHERE subject = $1 AND user_id = $2`, subject, userID)
+	if err != nil {
+		return fmt.Errorf("failed to unlink identity: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return ErrIdentityNotFound
+	}
+	return nil
+}
+
+func (s *PostgresStore) AddVerification(ctx context.Context, userID string, email string, tokenHash string, expiresAt time.Time) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO email_verifications (token_hash, user_id, email, expires_at) VALUES ($1, $2, $3, $4)`,
+		tokenHash, userID, email, expiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to store email verification: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresStore) Verify(ctx context.Context, tokenHash string) (*User, error) {
+	var verified *User
+	err := database.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		var userID, email string
+		err := tx.QueryRowContext(ctx, `
+			DELETE FROM email_verifications WHERE token_hash = $1 AND expires_at > now()
+			RETURNING user_id, email`, tokenHash).Scan(&userID, &email)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrVerificationInvalid
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read email verification: %w", err)
+		}
+
+		row := tx.QueryRowContext(ctx, `
+			UPDATE users SET email_verified_at = COALESCE(email_verified_at, now()), updated_at = now()
+			WHERE id = $1 AND email = $2
+			RETURNING `+userColumns, userID, email)
+		verified, err = scanUser(row)
+		if errors.Is(err, ErrUserNotFound) {
+			return ErrVerificationInvalid
+		}
+		return translate(err)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return verified, nil
+}
@@ -0,0 +1,161 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/tashunc/nugenesis-wallet-backend/pkg/database"
+)
+
+func TestParseID(t *testing.T) {
+	if id, ok := parseID("0F8FAD5B-D9CB-469F-A165-70867728950E"); !ok || id != "0f8fad5b-d9cb-469f-a165-70867728950e" {
+		t.Errorf("Expected canonical id, got %q %v", id, ok)
+	}
+	for _, id := range []string{"", "1", "0f8fad5b-d9cb-469f-a165-70867728950", "0f8fad5bxd9cb-469f-a165-70867728950e", "0f8fad5b-d9cb-469f-a165-70867728950g"} {
+		if _, ok := parseID(id); ok {
+			t.Errorf("%q: expected malformed id to be rejected", id)
+		}
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	cases := map[string]error{
+		"users_verified_email_key": ErrEmailTaken,
+		"user_identities_pkey":     ErrIdentityLinked,
+	}
+	for constraint, expected := range cases {
+		if err := translate(&pq.Error{Code: uniqueViolation, Constraint: constraint}); !errors.Is(err, expected) {
+			t.Errorf("%s: expected %v, got %v", constraint, expected, err)
+		}
+	}
+	other := &pq.Error{Code: "23503", Constraint: "users_verified_email_key"}
+	if err := translate(other); err != other {
+		t.Errorf("Expected other errors to pass through, got %v", err)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, NewMemoryStore())
+}
+
+// TestPostgresStore runs against TEST_DATABASE_URL, the users migration is applied first
+func TestPostgresStore(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	db, err := database.Open(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	migration, err := os.ReadFile("../../scripts/migrations/0004_create_users.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(string(migration)); err != nil {
+		t.Fatal(err)
+	}
+	testStore(t, NewPostgresStore(db))
+}
+
+// testStore checks the behavior every Store implementation shares
+func testStore(t *testing.T, store Store) {
+	ctx := context.Background()
+	// Unique per run so a shared test database does not collide
+	suffix := time.Now().Format("150405.000000000")
+	email := "owner" + suffix + "@example.com"
+	subject := "google:" + suffix
+
+	owner, err := store.Create(ctx, &User{Name: "Owner", Email: email, PasswordHash: "hash"}, subject)
+	if err != nil {
+		t.Fatalf("Expected user to be created, got %v", err)
+	}
+	defer store.Delete(ctx, owner.ID)
+	if _, ok := parseID(owner.ID); !ok || !owner.HasPassword || owner.EmailVerified {
+		t.Errorf("Unexpected created user %+v", owner)
+	}
+
+	if _, err := store.Create(ctx, &User{Name: "Other"}, subject); !errors.Is(err, ErrIdentityLinked) {
+		t.Errorf("Expected linked subject to be rejected, got %v", err)
+	}
+	if found, err := store.GetBySubject(ctx, subject); err != nil || found.ID != owner.ID {
+		t.Errorf("Expected user by subject, got %v %v", found, err)
+	}
+	if _, err := store.GetByID(ctx, "not-a-uuid"); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("Expected malformed id to be not found, got %v", err)
+	}
+	if _, err := store.GetByEmail(ctx, email); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("Expected unverified email not to be found, got %v", err)
+	}
+
+	// A squatter with the same unverified email neither blocks nor steals the address
+	squatter, err := store.Create(ctx, &User{Name: "Squatter", Email: email}, "")
+	if err != nil {
+		t.Fatalf("Expected unverified duplicate email to be allowed, got %v", err)
+	}
+	defer store.Delete(ctx, squatter.ID)
+
+	expiresAt := time.Now().Add(time.Hour)
+	if err := store.AddVerification(ctx, owner.ID, email, "owner-token"+suffix, expiresAt); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.AddVerification(ctx, squatter.ID, email, "squatter-token"+suffix, expiresAt); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.AddVerification(ctx, owner.ID, email, "expired-token"+suffix, time.Now().Add(-time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.Verify(ctx, "expired-token"+suffix); !errors.Is(err, ErrVerificationInvalid) {
+		t.Errorf("Expected expired token to be rejected, got %v", err)
+	}
+	verified, err := store.Verify(ctx, "owner-token"+suffix)
+	if err != nil || !verified.EmailVerified {
+		t.Fatalf("Expected email to be verified, got %+v %v", verified, err)
+	}
+	if _, err := store.Verify(ctx, "owner-token"+suffix); !errors.Is(err, ErrVerificationInvalid) {
+		t.Errorf("Expected token to be single use, got %v", err)
+	}
+	if _, err := store.Verify(ctx, "squatter-token"+suffix); !errors.Is(err, ErrEmailTaken) {
+		t.Errorf("Expected verified email to stay unique, got %v", err)
+	}
+	if found, err := store.GetByEmail(ctx, email); err != nil || found.ID != owner.ID {
+		t.Errorf("Expected verified owner by email, got %v %v", found, err)
+	}
+
+	// Changing the email clears the verification, keeping it does not
+	owner.Name = "Renamed"
+	if updated, err := store.Update(ctx, owner); err != nil || !updated.EmailVerified {
+		t.Errorf("Expected verification to survive a rename, got %+v %v", updated, err)
+	}
+	owner.Email = "moved" + suffix + "@example.com"
+	if updated, err := store.Update(ctx, owner); err != nil || updated.EmailVerified {
+		t.Errorf("Expected new email to be unverified, got %+v %v", updated, err)
+	}
+
+	if err := store.LinkIdentity(ctx, owner.ID, "eip155:"+suffix); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.LinkIdentity(ctx, squatter.ID, "eip155:"+suffix); !errors.Is(err, ErrIdentityLinked) {
+		t.Errorf("Expected identity of another user to be rejected, got %v", err)
+	}
+	if identities, err := store.Identities(ctx, owner.ID); err != nil || len(identities) != 2 {
+		t.Errorf("Expected 2 identities, got %v %v", identities, err)
+	}
+	if err := store.UnlinkIdentity(ctx, squatter.ID, "eip155:"+suffix); !errors.Is(err, ErrIdentityNotFound) {
+		t.Errorf("Expected unlinking another user's identity to fail, got %v", err)
+	}
+
+	if err := store.Delete(ctx, squatter.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Delete(ctx, squatter.ID); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("Expected deleted user to be not found, got %v", err)
+	}
+}
@@ -1,8 +1,29 @@
 package user
 
-import "github.com/gin-gonic/gin"
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/tashunc/nugenesis-wallet-backend/external/auth"
+)
 
 func RegisterRoutes(rg *gin.RouterGroup) {
 	userGroup := rg.Group("/users")
-	userGroup.GET("/", GetUsers)
+	userGroup.POST("/login", Login)
+	userGroup.POST("/verify-email", VerifyEmail)
+
+	protected := userGroup.Group("", auth.RequireAuth())
+	protected.POST("/", Create)
+	protected.GET("/me", Me)
+	protected.PUT("/me", UpdateMe)
+	protected.DELETE("/me", DeleteMe)
+	protected.POST("/me/email/verification", ResendVerification)
+	protected.POST("/me/identities", LinkIdentity)
+	protected.DELETE("/me/identities/:subject", UnlinkIdentity)
+	protected.GET("/:id", GetByID)
+	protected.PUT("/:id", UpdateByID)
+	protected.DELETE("/:id", DeleteByID)
+}
+
+// RegisterAdminRoutes registers the account endpoints only admins may use on the admin group
+func RegisterAdminRoutes(rg *gin.RouterGroup) {
+	rg.GET("/users", List)
 }
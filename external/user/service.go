@@ -1,11 +1,270 @@
 package user
 
-//import (
-//	"github.com/tashunc/nugenesis-wallet-backend/pkg/database"
-//)
-//
-//func GetUserByID(id string) (*User, error) {
-//	var user1 User
-//	err := database.DB.Where("id = ?", id).First(&user).Error
-//	return &user1, err
-//}
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"sync"
+
+	"github.com/tashunc/nugenesis-wallet-backend/config"
+	"github.com/tashunc/nugenesis-wallet-backend/external/auth"
+	"github.com/tashunc/nugenesis-wallet-backend/pkg/database"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// PasswordSubjectPrefix marks sessions started with a password, the rest of the subject is the user id
+const PasswordSubjectPrefix = "user:"
+
+const minPasswordLength = 8
+
+var (
+	ErrInvalidCredentials = errors.New("invalid email or password")
+	ErrInvalidInput       = errors.New("invalid user data")
+	ErrAccountExists      = errors.New("an account already exists for this identity")
+	// ErrLastSignInMethod prevents unlinking the only way left to sign in to an account
+	ErrLastSignInMethod = errors.New("cannot remove the last sign-in method of an account")
+)
+
+// SessionRevoker ends sessions started with a credential, implemented by auth.TokenService
+type SessionRevoker interface {
+	RevokeCredential(ctx context.Context, subject string, stamp string) error
+}
+
+// Service holds the user account rules on top of the store
+type Service struct {
+	store    Store
+	mailer   Mailer
+	sessions SessionRevoker
+}
+
+var (
+	userService    *Service
+	userServiceErr error
+	userOnce       sync.Once
+)
+
+// GetUserService returns the shared user service, accounts need Postgres so it fails without DATABASE_URL
+func GetUserService() (*Service, error) {
+	userOnce.Do(func() {
+		db, err := database.GetDB()
+		if err != nil {
+			userServiceErr = err
+			return
+		}
+		userService = NewService(NewPostgresStore(db), logMailer{logTokens: config.Get().LogVerificationTokens}, auth.GetTokenService())
+	})
+	return userService, userServiceErr
+}
+
+func NewService(store Store, mailer Mailer, sessions SessionRevoker) *Service {
+	return &Service{store: store, mailer: mailer, sessions: sessions}
+}
+
+// HashPassword returns the bcrypt hash of password
+func HashPassword(password string) (string, error) {
+	if len(password) < minPasswordLength {
+		return "", fmt.Errorf("%w: password must be at least %d characters", ErrInvalidInput, minPasswordLength)
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+	return string(hash), nil
+}
+
+// PasswordStamp identifies a password in the sessions started with it, changing the password changes the stamp
+func PasswordStamp(hash string) string {
+	sum := sha256.Sum256([]byte(hash))
+	return hex.EncodeToString(sum[:8])
+}
+
+// CheckPassword reports whether password matches the bcrypt hash
+func CheckPassword(hash string, password string) bool {
+	return hash != "" && bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+func normalizeEmail(email string) (string, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email == "" {
+		return "", nil
+	}
+	if _, err := mail.ParseAddress(email); err != nil {
+		return "", fmt.Errorf("%w: invalid email", ErrInvalidInput)
+	}
+	return email, nil
+}
+
+// Current returns the account of a session subject with its linked identities
+func (s *Service) Current(ctx context.Context, subject string) (*User, error) {
+	var user *User
+	var err error
+	if id, ok := strings.CutPrefix(subject, PasswordSubjectPrefix); ok {
+		user, err = s.store.GetByID(ctx, id)
+	} else {
+		user, err = s.store.GetBySubject(ctx, subject)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.withIdentities(ctx, user)
+}
+
+func (s *Service) Get(ctx context.Context, id string) (*User, error) {
+	return s.store.GetByID(ctx, id)
+}
+
+func (s *Service) List(ctx context.Context, limit int, offset int) ([]*User, error) {
+	return s.store.List(ctx, limit, offset)
+}
+
+// Create opens an account for the session subject and emails a verification link,
+// a password lets the user also sign in by email once it is verified
+func (s *Service) Create(ctx context.Context, subject string, name string, email string, password string) (*User, error) {
+	if strings.HasPrefix(subject, PasswordSubjectPrefix) {
+		return nil, ErrAccountExists
+	}
+
+	email, err := normalizeEmail(email)
+	if err != nil {
+		return nil, err
+	}
+	user := &User{Name: strings.TrimSpace(name), Email: email}
+	if password != "" {
+		if email == "" {
+			return nil, fmt.Errorf("%w: a password requires an email", ErrInvalidInput)
+		}
+		if user.PasswordHash, err = HashPassword(password); err != nil {
+			return nil, err
+		}
+	}
+
+	created, err := s.store.Create(ctx, user, subject)
+	if errors.Is(err, ErrIdentityLinked) {
+		return nil, ErrAccountExists
+	}
+	if err != nil {
+		return nil, err
+	}
+	if created.Email != "" {
+		if err := s.SendVerification(ctx, created); err != nil {
+			return nil, err
+		}
+	}
+	return s.withIdentities(ctx, created)
+}
+
+// Update changes the non-nil fields, an empty email removes it.
+// A new email has to be verified again and a new password ends the sessions started with the old one.
+func (s *Service) Update(ctx context.Context, user *User, name *string, email *string, password *string) (*User, error) {
+	updated := *user
+	if name != nil {
+		updated.Name = strings.TrimSpace(*name)
+	}
+	if email != nil {
+		normalized, err := normalizeEmail(*email)
+		if err != nil {
+			return nil, err
+		}
+		updated.Email = normalized
+	}
+	if password != nil {
+		hash, err := HashPassword(*password)
+		if err != nil {
+			return nil, err
+		}
+		updated.PasswordHash = hash
+	}
+	if updated.PasswordHash != "" && updated.Email == "" {
+		return nil, fmt.Errorf("%w: a password requires an email", ErrInvalidInput)
+	}
+
+	saved, err := s.store.Update(ctx, &updated)
+	if err != nil {
+		return nil, err
+	}
+	if password != nil && user.PasswordHash != "" {
+		if err := s.sessions.RevokeCredential(ctx, PasswordSubjectPrefix+user.ID, PasswordStamp(user.PasswordHash)); err != nil {
+			return nil, fmt.Errorf("failed to end sessions of the old password: %w", err)
+		}
+	}
+	if saved.Email != "" && !saved.EmailVerified && saved.Email != user.Email {
+		if err := s.SendVerification(ctx, saved); err != nil {
+			return nil, err
+		}
+	}
+	return s.withIdentities(ctx, saved)
+}
+
+func (s *Service) Delete(ctx context.Context, id string) error {
+	return s.store.Delete(ctx, id)
+}
+
+// Authenticate checks an email and password login, only verified emails can sign in
+func (s *Service) Authenticate(ctx context.Context, email string, password string) (*User, error) {
+	email, err := normalizeEmail(email)
+	if err != nil || email == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	user, err := s.store.GetByEmail(ctx, email)
+	if errors.Is(err, ErrUserNotFound) {
+		// Compare anyway so unknown emails take as long as wrong passwords
+		CheckPassword(getDummyHash(), password)
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !CheckPassword(user.PasswordHash, password) {
+		return nil, ErrInvalidCredentials
+	}
+	return user, nil
+}
+
+// LinkIdentity links a subject the user proved to own
+func (s *Service) LinkIdentity(ctx context.Context, user *User, subject string) (*User, error) {
+	if strings.HasPrefix(subject, PasswordSubjectPrefix) {
+		return nil, fmt.Errorf("%w: password sessions cannot be linked", ErrInvalidInput)
+	}
+	if err := s.store.LinkIdentity(ctx, user.ID, subject); err != nil {
+		return nil, err
+	}
+	return s.withIdentities(ctx, user)
+}
+
+func (s *Service) UnlinkIdentity(ctx context.Context, user *User, subject string) (*User, error) {
+	if !(user.HasPassword && user.EmailVerified) && len(user.Identities) <= 1 {
+		return nil, ErrLastSignInMethod
+	}
+	if err := s.store.UnlinkIdentity(ctx, user.ID, subject); err != nil {
+		return nil, err
+	}
+	return s.withIdentities(ctx, user)
+}
+
+func (s *Service) withIdentities(ctx context.Context, user *User) (*User, error) {
+	identities, err := s.store.Identities(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	user.Identities = identities
+	return user, nil
+}
+
+var (
+	dummyHash     string
+	dummyHashOnce sync.Once
+)
+
+// getDummyHash returns a hash no password matches, see Authenticate
+func getDummyHash() string {
+	dummyHashOnce.Do(func() {
+		hash, _ := bcrypt.GenerateFromPassword([]byte("not a password"), bcrypt.DefaultCost)
+		dummyHash = string(hash)
+	})
+	return dummyHash
+}
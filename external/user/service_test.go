@@ -0,0 +1,187 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestPasswordHashing(t *testing.T) {
+	if _, err := HashPassword("short"); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("Expected short password to be rejected, got %v", err)
+	}
+
+	hash, err := HashPassword("correct horse battery")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash == "correct horse battery" || !CheckPassword(hash, "correct horse battery") {
+		t.Error("Expected hash to verify the password")
+	}
+	if CheckPassword(hash, "wrong password") || CheckPassword("", "correct horse battery") {
+		t.Error("Expected wrong password and empty hash to fail")
+	}
+
+	profile, _ := json.Marshal((&User{ID: "1", Email: "a@b.co", PasswordHash: hash, HasPassword: true}).Profile())
+	if strings.Contains(string(profile), hash) {
+		t.Error("Expected profile not to expose the password hash")
+	}
+}
+
+func TestNormalizeEmail(t *testing.T) {
+	if email, err := normalizeEmail("  Alice@Example.COM "); err != nil || email != "alice@example.com" {
+		t.Errorf("Expected normalized email, got %q %v", email, err)
+	}
+	if _, err := normalizeEmail("not an email"); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("Expected invalid email to be rejected, got %v", err)
+	}
+}
+
+// recordingMailer keeps the last token sent to each email
+type recordingMailer struct {
+	tokens map[string]string
+}
+
+func (m *recordingMailer) SendVerification(ctx context.Context, email string, token string) error {
+	m.tokens[email] = token
+	return nil
+}
+
+// recordingRevoker keeps the revoked credentials
+type recordingRevoker struct {
+	revoked []string
+}
+
+func (r *recordingRevoker) RevokeCredential(ctx context.Context, subject string, stamp string) error {
+	r.revoked = append(r.revoked, subject+"/"+stamp)
+	return nil
+}
+
+func newTestService() (*Service, *recordingMailer, *recordingRevoker) {
+	mailer := &recordingMailer{tokens: make(map[string]string)}
+	revoker := &recordingRevoker{}
+	return NewService(NewMemoryStore(), mailer, revoker), mailer, revoker
+}
+
+func TestSignupRequiresEmailVerification(t *testing.T) {
+	ctx := context.Background()
+	service, mailer, _ := newTestService()
+
+	created, err := service.Create(ctx, "google:1", "Alice", "Alice@Example.com", "correct horse battery")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.EmailVerified || len(created.Identities) != 1 {
+		t.Errorf("Unexpected account %+v", created)
+	}
+	if _, err := service.Authenticate(ctx, "alice@example.com", "correct horse battery"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Expected unverified email not to sign in, got %v", err)
+	}
+
+	token := mailer.tokens["alice@example.com"]
+	if token == "" {
+		t.Fatal("Expected a verification email")
+	}
+	if _, err := service.VerifyEmail(ctx, "forged"); !errors.Is(err, ErrVerificationInvalid) {
+		t.Errorf("Expected unknown token to be rejected, got %v", err)
+	}
+	if verified, err := service.VerifyEmail(ctx, token); err != nil || !verified.EmailVerified {
+		t.Fatalf("Expected email to be verified, got %+v %v", verified, err)
+	}
+	if user, err := service.Authenticate(ctx, "alice@example.com", "correct horse battery"); err != nil || user.ID != created.ID {
+		t.Errorf("Expected verified email to sign in, got %v %v", user, err)
+	}
+}
+
+func TestSignupCannotTakeVerifiedEmail(t *testing.T) {
+	ctx := context.Background()
+	service, mailer, _ := newTestService()
+
+	if _, err := service.Create(ctx, "google:1", "Owner", "shared@example.com", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.VerifyEmail(ctx, mailer.tokens["shared@example.com"]); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := service.Create(ctx, "google:2", "Squatter", "shared@example.com", "correct horse battery"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.VerifyEmail(ctx, mailer.tokens["shared@example.com"]); !errors.Is(err, ErrEmailTaken) {
+		t.Errorf("Expected verified email to stay with its owner, got %v", err)
+	}
+	if _, err := service.Authenticate(ctx, "shared@example.com", "correct horse battery"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Expected squatter password not to sign in, got %v", err)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	ctx := context.Background()
+	service, mailer, revoker := newTestService()
+
+	created, _ := service.Create(ctx, "google:1", "Alice", "alice@example.com", "correct horse battery")
+	user, err := service.VerifyEmail(ctx, mailer.tokens["alice@example.com"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldStamp := PasswordStamp(user.PasswordHash)
+
+	password := "another long password"
+	updated, err := service.Update(ctx, user, nil, nil, &password)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revoker.revoked) != 1 || revoker.revoked[0] != PasswordSubjectPrefix+created.ID+"/"+oldStamp {
+		t.Errorf("Expected sessions of the old password to be revoked, got %v", revoker.revoked)
+	}
+	if PasswordStamp(updated.PasswordHash) == oldStamp || !updated.EmailVerified {
+		t.Errorf("Unexpected account after password change %+v", updated)
+	}
+
+	email := "alice@new.example.com"
+	updated, err = service.Update(ctx, updated, nil, &email, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.EmailVerified || mailer.tokens[email] == "" {
+		t.Errorf("Expected new email to need verification, got %+v", updated)
+	}
+	if len(revoker.revoked) != 1 {
+		t.Errorf("Expected no revocation without a password change, got %v", revoker.revoked)
+	}
+
+	name := "Alice B"
+	if _, err := service.Update(ctx, &User{ID: created.ID, Name: name}, &name, nil, &password); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("Expected password without email to be rejected, got %v", err)
+	}
+}
+
+func TestCreateRejectsPasswordSessions(t *testing.T) {
+	service, _, _ := newTestService()
+	if _, err := service.Create(context.Background(), PasswordSubjectPrefix+"1", "", "", ""); !errors.Is(err, ErrAccountExists) {
+		t.Errorf("Expected password session to be rejected, got %v", err)
+	}
+}
+
+func TestUnlinkKeepsASignInMethod(t *testing.T) {
+	ctx := context.Background()
+	service, mailer, _ := newTestService()
+
+	created, err := service.Create(ctx, "google:1", "Alice", "alice@example.com", "correct horse battery")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.UnlinkIdentity(ctx, created, "google:1"); !errors.Is(err, ErrLastSignInMethod) {
+		t.Errorf("Expected the only identity of an unverified password account to stay, got %v", err)
+	}
+
+	verified, err := service.VerifyEmail(ctx, mailer.tokens["alice@example.com"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unlinked, err := service.UnlinkIdentity(ctx, verified, "google:1"); err != nil || len(unlinked.Identities) != 0 {
+		t.Errorf("Expected a verified password to allow unlinking, got %+v %v", unlinked, err)
+	}
+}
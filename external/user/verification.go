@@ -0,0 +1,73 @@
+package user
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"time"
+)
+
+// verificationTTL is how long an emailed verification token can be used
+const verificationTTL = 24 * time.Hour
+
+// Mailer delivers email verification tokens to the address being verified
+type Mailer interface {
+	SendVerification(ctx context.Context, email string, token string) error
+}
+
+// logMailer stands in until a mail provider is configured. It only logs that an email was queued,
+// the token itself is printed when logTokens is set for local development, see config.LogVerificationTokens.
+type logMailer struct {
+	logTokens bool
+}
+
+func (m logMailer) SendVerification(ctx context.Context, email string, token string) error {
+	if m.logTokens {
+		log.Printf("Email verification for %s: token %s", email, token)
+		return nil
+	}
+	log.Printf("Email verification queued for %s", email)
+	return nil
+}
+
+// hashVerificationToken is what the store keeps, so a leaked table cannot verify emails
+func hashVerificationToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// SendVerification emails a new verification token for the user's current email
+func (s *Service) SendVerification(ctx context.Context, user *User) error {
+	if user.Email == "" {
+		return fmt.Errorf("%w: the account has no email", ErrInvalidInput)
+	}
+	if user.EmailVerified {
+		return fmt.Errorf("%w: the email is already verified", ErrInvalidInput)
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Errorf("failed to generate verification token: %w", err)
+	}
+	token := hex.EncodeToString(buf)
+
+	if err := s.store.AddVerification(ctx, user.ID, user.Email, hashVerificationToken(token), time.Now().Add(verificationTTL)); err != nil {
+		return err
+	}
+	if err := s.mailer.SendVerification(ctx, user.Email, token); err != nil {
+		return fmt.Errorf("failed to send verification email: %w", err)
+	}
+	return nil
+}
+
+// VerifyEmail marks the email the token was sent to as verified
+func (s *Service) VerifyEmail(ctx context.Context, token string) (*User, error) {
+	user, err := s.store.Verify(ctx, hashVerificationToken(token))
+	if err != nil {
+		return nil, err
+	}
+	return s.withIdentities(ctx, user)
+}
@@ -2,13 +2,14 @@ package utils
 
 import (
 	"fmt"
-	"github.com/tashunc/nugenesis-wallet-backend/external/data/historical/thrirdParty/Blockchain_info/blockchain_info_models"
+	"github.com/tashunc/nugenesis-wallet-backend/external/data/fmv/pricing"
+	"github.com/tashunc/nugenesis-wallet-backend/external/data/historical/thrirdParty/blockchain_info/blockchain_info_models"
 	"github.com/tashunc/nugenesis-wallet-backend/external/models"
 	"time"
 )
 
 // # Utility functions for the project (e.g., date formatting, error handling)
-func MapBlockchainInfoTxToTransaction(tx blockchain_info_models.Tx, userAddress string, txID string) models.Transaction {
+func MapBlockchainInfoTxToTransaction(tx blockchain_info_models.Tx, userAddress string, txID string, currency string) models.Transaction {
 	// Convert timestamp to readable date/time
 	txTime := time.Unix(tx.Time, 0)
 
@@ -51,12 +52,11 @@ func MapBlockchainInfoTxToTransaction(tx blockchain_info_models.Tx, userAddress
 	// Convert satoshis to BTC (assuming Bitcoin)
 	btcAmount := float64(amount) / 100000000.0
 
-	// Calculate approximate USD value (you'd want to use real exchange rates)
-	usdValue := btcAmount * 50000.0 // Example rate
-
-	// Convert fee from satoshis to USD
+	// Value amount and fee at the block time in the requested fiat currency
 	feeInBTC := float64(tx.Fee) / 100000000.0
-	feeInUSD := feeInBTC * 50000.0
+	prices := pricing.GetPriceService()
+	value := prices.FormatValue("BTC", btcAmount, txTime, currency)
+	fee := prices.FormatValue("BTC", feeInBTC, txTime, currency)
 
 	// Determine status based on block confirmation
 	status := "pending"
@@ -73,11 +73,11 @@ func MapBlockchainInfoTxToTransaction(tx blockchain_info_models.Tx, userAddress
 		Status:  status,
 		Token:   "BTC", // You might want to make this configurable
 		Amount:  fmt.Sprintf("%.8f", btcAmount),
-		Value:   fmt.Sprintf("$%.2f", usdValue),
+		Value:   value,
 		Address: truncateAddress(relevantAddress),
 		Date:    txTime.Format("2006-01-02"),
 		Time:    txTime.Format("15:04"),
-		Fee:     fmt.Sprintf("$%.2f", feeInUSD),
+		Fee:     fee,
 		Hash:    truncateHash(tx.Hash),
 	}
 }
@@ -1 +1,72 @@
 package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	_ "github.com/lib/pq"
+)
+
+// ErrNotConfigured is returned when DATABASE_URL is not set, features backed by Postgres are then disabled
+var ErrNotConfigured = errors.New("DATABASE_URL is not set")
+
+const connectTimeout = 5 * time.Second
+
+var (
+	db    *sql.DB
+	dbErr error
+	once  sync.Once
+)
+
+// GetDB returns the shared connection pool for DATABASE_URL, the connection is attempted once
+func GetDB() (*sql.DB, error) {
+	once.Do(func() {
+		db, dbErr = Open(os.Getenv("DATABASE_URL"))
+	})
+	return db, dbErr
+}
+
+// Open creates a connection pool and verifies the database is reachable
+func Open(dsn string) (*sql.DB, error) {
+	if dsn == "" {
+		return nil, ErrNotConfigured
+	}
+
+	conn, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	conn.SetMaxOpenConns(20)
+	conn.SetMaxIdleConns(5)
+	conn.SetConnMaxLifetime(30 * time.Minute)
+
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout)
+	defer cancel()
+	if err := conn.PingContext(ctx); err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	return conn, nil
+}
+
+// WithTx runs fn in a transaction, committing when it returns nil and rolling back otherwise
+func WithTx(ctx context.Context, conn *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+// Package deadline bounds calls to upstream providers
+package deadline
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Call runs fn with a context cancelled after timeout. Providers stop their requests when that context ends; fn runs
+// in its own goroutine so that one which does not still releases the caller at the timeout.
+func Call[T any](ctx context.Context, timeout time.Duration, fn func(ctx context.Context) (T, error)) (T, error) {
+	callCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type result struct {
+		value T
+		err   error
+	}
+
+	// Buffered so the goroutine can deliver a result nobody waits for anymore and exit once fn returns
+	done := make(chan result, 1)
+	go func() {
+		value, err := fn(callCtx)
+		done <- result{value: value, err: err}
+	}()
+
+	select {
+	case res := <-done:
+		return res.value, res.err
+	case <-callCtx.Done():
+		var zero T
+		if err := ctx.Err(); err != nil {
+			return zero, err
+		}
+		return zero, fmt.Errorf("timed out after %s: %w", timeout, callCtx.Err())
+	}
+}
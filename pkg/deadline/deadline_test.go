@@ -0,0 +1,60 @@
+package deadline
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCall_ReturnsResult(t *testing.T) {
+	value, err := Call(context.Background(), time.Second, func(ctx context.Context) (int, error) {
+		return 42, nil
+	})
+	if err != nil || value != 42 {
+		t.Errorf("Expected 42, got %d (%v)", value, err)
+	}
+}
+
+func TestCall_CancelsAfterTimeout(t *testing.T) {
+	cancelled := make(chan struct{})
+	_, err := Call(context.Background(), 10*time.Millisecond, func(ctx context.Context) (int, error) {
+		<-ctx.Done()
+		close(cancelled)
+		return 0, ctx.Err()
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected a deadline error, got %v", err)
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Error("Expected the callee context to be cancelled")
+	}
+}
+
+func TestCall_ReleasesCallerOfIgnoringCallee(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	start := time.Now()
+	_, err := Call(context.Background(), 10*time.Millisecond, func(ctx context.Context) (int, error) {
+		<-release
+		return 0, nil
+	})
+	if !errors.Is(err, context.DeadlineExceeded) || time.Since(start) > time.Second {
+		t.Errorf("Expected the caller to be released at the timeout, got %v after %s", err, time.Since(start))
+	}
+}
+
+func TestCall_ReportsCallerCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := Call(ctx, time.Second, func(ctx context.Context) (int, error) {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the caller cancellation, got %v", err)
+	}
+}
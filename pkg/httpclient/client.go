@@ -0,0 +1,95 @@
+package httpclient
+
+import (
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	DefaultTimeout          = 10 * time.Second
+	DefaultMaxRetries       = 3
+	DefaultBaseBackoff      = 200 * time.Millisecond
+	DefaultMaxBackoff       = 5 * time.Second
+	DefaultFailureThreshold = 5
+	DefaultOpenTimeout      = 30 * time.Second
+)
+
+// Config describes how requests to one upstream provider are made
+type Config struct {
+	// Name identifies the provider on the health endpoint, e.g. alchemy
+	Name string
+	// Timeout bounds a whole call including retries
+	Timeout time.Duration
+	// MaxRetries is the number of retries after the first attempt on 429, 5xx and network errors.
+	// Zero uses DefaultMaxRetries, a negative value disables retries. Only idempotent requests are retried,
+	// see MarkIdempotent.
+	MaxRetries  int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	// RatePerSecond and Burst size the token bucket to the vendor quota, zero disables rate limiting
+	RatePerSecond float64
+	Burst         int
+	// MaxWait fails a call with ErrRateLimited instead of queueing it longer than this, zero queues until Timeout
+	MaxWait time.Duration
+	// FailureThreshold consecutive failed calls open the circuit for OpenTimeout
+	FailureThreshold int
+	OpenTimeout      time.Duration
+	// Transport is the underlying transport, http.DefaultTransport when nil
+	Transport http.RoundTripper
+}
+
+func (c Config) withDefaults() Config {
+	if c.Timeout <= 0 {
+		c.Timeout = DefaultTimeout
+	}
+	if c.MaxRetries == 0 {
+		c.MaxRetries = DefaultMaxRetries
+	} else if c.MaxRetries < 0 {
+		c.MaxRetries = 0
+	}
+	if c.BaseBackoff <= 0 {
+		c.BaseBackoff = DefaultBaseBackoff
+	}
+	if c.MaxBackoff <= 0 {
+		c.MaxBackoff = DefaultMaxBackoff
+	}
+	if c.FailureThreshold <= 0 {
+		c.FailureThreshold = DefaultFailureThreshold
+	}
+	if c.OpenTimeout <= 0 {
+		c.OpenTimeout = DefaultOpenTimeout
+	}
+	if c.Transport == nil {
+		c.Transport = http.DefaultTransport
+	}
+	return c
+}
+
+// New returns an *http.Client for one provider with timeout, retries, rate limiting and circuit breaking.
+// Clients created with the same name share one breaker and one rate limiter, so the quota holds across services;
+// the first config registered for a name sizes them.
+func New(config Config) *http.Client {
+	config = config.withDefaults()
+	return &http.Client{
+		Timeout:   config.Timeout,
+		Transport: newTransport(config, registry.upstream(config)),
+	}
+}
+
+// MarkIdempotent opts a request with a non-idempotent method, e.g. a JSON-RPC read sent as POST, into retries.
+// As in net/http, the empty Idempotency-Key header marks the request without being sent.
+func MarkIdempotent(req *http.Request) {
+	req.Header["Idempotency-Key"] = nil
+}
+
+// PostIdempotent is client.Post for a request that is safe to repeat
+func PostIdempotent(client *http.Client, url string, contentType string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodPost, url, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	MarkIdempotent(req)
+	return client.Do(req)
+}
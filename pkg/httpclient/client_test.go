@@ -0,0 +1,171 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClient_RetriesOnServerError(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := make([]byte, 5)
+		r.Body.Read(body)
+		if string(body) != "hello" {
+			t.Errorf("Expected replayed body hello, got %q", string(body))
+		}
+		if _, sent := r.Header["Idempotency-Key"]; sent {
+			t.Error("Expected the idempotency marker to stay off the wire")
+		}
+		if atomic.AddInt32(&attempts, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := New(Config{Name: "test-retry", BaseBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond})
+
+	resp, err := PostIdempotent(client, server.URL, "text/plain", strings.NewReader("hello"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", resp.StatusCode)
+	}
+	if attempts != 3 {
+		t.Errorf("Expected 3 attempts, got %d", attempts)
+	}
+}
+
+func TestClient_DoesNotRetryPlainPost(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client := New(Config{Name: "test-post", BaseBackoff: time.Millisecond})
+
+	resp, err := client.Post(server.URL, "text/plain", strings.NewReader("rawtx"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	resp.Body.Close()
+
+	if attempts != 1 {
+		t.Errorf("Expected a broadcast to be sent once, got %d attempts", attempts)
+	}
+}
+
+func TestClient_DoesNotRetryClientError(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	client := New(Config{Name: "test-no-retry", BaseBackoff: time.Millisecond})
+
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	resp.Body.Close()
+
+	if attempts != 1 {
+		t.Errorf("Expected 1 attempt, got %d", attempts)
+	}
+}
+
+func TestClient_CircuitOpensAfterFailures(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	client := New(Config{
+		Name:             "test-breaker",
+		MaxRetries:       -1,
+		FailureThreshold: 2,
+		OpenTimeout:      time.Hour,
+	})
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatalf("Expected no error on call %d, got %v", i, err)
+		}
+		resp.Body.Close()
+	}
+
+	_, err := client.Get(server.URL)
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Expected ErrCircuitOpen, got %v", err)
+	}
+	if attempts != 2 {
+		t.Errorf("Expected the open circuit to short-circuit the third call, got %d attempts", attempts)
+	}
+
+	found := false
+	for _, status := range Statuses() {
+		if status.Name == "test-breaker" {
+			found = true
+			if status.State != StateOpen {
+				t.Errorf("Expected state open, got %s", status.State)
+			}
+		}
+	}
+	if !found {
+		t.Error("Expected test-breaker in Statuses")
+	}
+}
+
+func TestCircuitBreaker_HalfOpenProbe(t *testing.T) {
+	breaker := NewCircuitBreaker("probe", 1, time.Millisecond)
+	breaker.Failure(errors.New("boom"))
+
+	if err := breaker.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Expected open circuit, got %v", err)
+	}
+
+	time.Sleep(2 * time.Millisecond)
+
+	if err := breaker.Allow(); err != nil {
+		t.Fatalf("Expected the probe to be allowed, got %v", err)
+	}
+	if err := breaker.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Expected a second concurrent probe to be rejected, got %v", err)
+	}
+
+	breaker.Success()
+	if breaker.State() != StateClosed {
+		t.Errorf("Expected closed after a successful probe, got %s", breaker.State())
+	}
+}
+
+func TestRateLimiter_Throttles(t *testing.T) {
+	limiter := NewRateLimiter(100, 1)
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := limiter.Wait(context.Background()); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 15*time.Millisecond {
+		t.Errorf("Expected about 20ms of throttling, got %s", elapsed)
+	}
+}
@@ -0,0 +1,81 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrRateLimited is returned instead of waiting longer than the configured maximum for a token
+var ErrRateLimited = errors.New("rate limit exceeded")
+
+// RateLimiter is a token bucket refilled at a fixed rate
+type RateLimiter struct {
+	// maxWait caps how long Wait blocks, zero waits as long as the context allows
+	maxWait  time.Duration
+	rate     float64
+	burst    float64
+	tokens   float64
+	lastFill time.Time
+	mutex    sync.Mutex
+}
+
+// NewRateLimiter creates a full bucket, nil when rate is not positive
+func NewRateLimiter(ratePerSecond float64, burst int) *RateLimiter {
+	if ratePerSecond <= 0 {
+		return nil
+	}
+	if burst <= 0 {
+		burst = 1
+	}
+	return &RateLimiter{
+		rate:     ratePerSecond,
+		burst:    float64(burst),
+		tokens:   float64(burst),
+		lastFill: time.Now(),
+	}
+}
+
+// Wait blocks until a token is available or the context is done
+func (r *RateLimiter) Wait(ctx context.Context) error {
+	if r == nil {
+		return nil
+	}
+	for {
+		delay := r.reserve()
+		if delay == 0 {
+			return nil
+		}
+		if r.maxWait > 0 && delay > r.maxWait {
+			return ErrRateLimited
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// reserve takes a token and returns 0, or returns how long until the next token
+func (r *RateLimiter) reserve() time.Duration {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := time.Now()
+	r.tokens += now.Sub(r.lastFill).Seconds() * r.rate
+	if r.tokens > r.burst {
+		r.tokens = r.burst
+	}
+	r.lastFill = now
+
+	if r.tokens >= 1 {
+		r.tokens--
+		return 0
+	}
+	return time.Duration((1 - r.tokens) / r.rate * float64(time.Second))
+}
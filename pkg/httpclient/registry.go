@@ -0,0 +1,80 @@
+package httpclient
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// UpstreamStatus is the circuit state of one provider as reported on the health endpoint
+type UpstreamStatus struct {
+	Name                string     `json:"name"`
+	State               State      `json:"state"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	LastError           string     `json:"last_error,omitempty"`
+	OpenedAt            *time.Time `json:"opened_at,omitempty"`
+}
+
+// upstream is the state shared by every client of one provider
+type upstream struct {
+	breaker *CircuitBreaker
+	limiter *RateLimiter
+}
+
+func newUpstream(name string, config Config) *upstream {
+	limiter := NewRateLimiter(config.RatePerSecond, config.Burst)
+	if limiter != nil {
+		limiter.maxWait = config.MaxWait
+	}
+	return &upstream{
+		breaker: NewCircuitBreaker(name, config.FailureThreshold, config.OpenTimeout),
+		limiter: limiter,
+	}
+}
+
+type upstreamRegistry struct {
+	upstreams map[string]*upstream
+	mutex     sync.Mutex
+}
+
+var registry = &upstreamRegistry{upstreams: make(map[string]*upstream)}
+
+// upstream returns the shared state for the config name, created by the first client of that provider
+func (r *upstreamRegistry) upstream(config Config) *upstream {
+	if config.Name == "" {
+		return newUpstream("unnamed", config)
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if existing, exists := r.upstreams[config.Name]; exists {
+		return existing
+	}
+	created := newUpstream(config.Name, config)
+	r.upstreams[config.Name] = created
+	return created
+}
+
+// Statuses returns the circuit state of every named provider, sorted by name
+func Statuses() []UpstreamStatus {
+	registry.mutex.Lock()
+	statuses := make([]UpstreamStatus, 0, len(registry.upstreams))
+	for _, entry := range registry.upstreams {
+		statuses = append(statuses, entry.breaker.status())
+	}
+	registry.mutex.Unlock()
+
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+// Healthy reports whether no provider circuit is open
+func Healthy() bool {
+	for _, status := range Statuses() {
+		if status.State == StateOpen {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,149 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// transport applies the circuit breaker, rate limiter and retries around the underlying transport
+type transport struct {
+	config   Config
+	upstream *upstream
+}
+
+func newTransport(config Config, upstream *upstream) *transport {
+	return &transport{
+		config:   config,
+		upstream: upstream,
+	}
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.upstream.breaker.Allow(); err != nil {
+		return nil, err
+	}
+
+	resp, err := t.roundTripWithRetries(req)
+
+	switch {
+	case errors.Is(err, context.Canceled), errors.Is(err, ErrRateLimited):
+		// The caller gave up or our own quota refused the call, this says nothing about the provider
+		t.upstream.breaker.release()
+	case err != nil:
+		t.upstream.breaker.Failure(err)
+	case retryableStatus(resp.StatusCode):
+		t.upstream.breaker.Failure(errors.New(resp.Status))
+	default:
+		t.upstream.breaker.Success()
+	}
+	return resp, err
+}
+
+func (t *transport) roundTripWithRetries(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	maxRetries := t.config.MaxRetries
+	if !isIdempotent(req) || (req.Body != nil && req.Body != http.NoBody && req.GetBody == nil) {
+		// Repeating a broadcast can resend a rejected transaction, and a streamed body is consumed by the first attempt
+		maxRetries = 0
+	}
+
+	for attempt := 0; ; attempt++ {
+		if err := t.upstream.limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+
+		attemptReq := req
+		if attempt > 0 {
+			var err error
+			if attemptReq, err = rewind(req); err != nil {
+				return nil, err
+			}
+		}
+
+		resp, err := t.config.Transport.RoundTrip(attemptReq)
+		if attempt >= maxRetries || !shouldRetry(ctx, resp, err) {
+			return resp, err
+		}
+
+		delay := t.backoff(attempt, resp)
+		if resp != nil {
+			// Drain so the connection can be reused
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff doubles from BaseBackoff with jitter, honouring Retry-After when the provider sends one
+func (t *transport) backoff(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds >= 0 {
+			return min(time.Duration(seconds)*time.Second, t.config.MaxBackoff)
+		}
+	}
+
+	delay := t.config.BaseBackoff << attempt
+	if delay <= 0 || delay > t.config.MaxBackoff {
+		delay = t.config.MaxBackoff
+	}
+	// Up to 20% jitter so clients throttled together do not retry together
+	return delay - time.Duration(rand.Int63n(int64(delay)/5+1))
+}
+
+// isIdempotent follows net/http: safe methods, PUT and DELETE, or a request carrying an Idempotency-Key header
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	if _, ok := req.Header["Idempotency-Key"]; ok {
+		return true
+	}
+	_, ok := req.Header["X-Idempotency-Key"]
+	return ok
+}
+
+func shouldRetry(ctx context.Context, resp *http.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		return true
+	}
+	return retryableStatus(resp.StatusCode)
+}
+
+func retryableStatus(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode >= http.StatusInternalServerError
+}
+
+// rewind clones the request with a fresh body for another attempt
+func rewind(req *http.Request) (*http.Request, error) {
+	clone := req.Clone(req.Context())
+	if req.Body == nil || req.Body == http.NoBody {
+		return clone, nil
+	}
+	if req.GetBody == nil {
+		return nil, errors.New("request body cannot be replayed for retry")
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	clone.Body = body
+	return clone, nil
+}
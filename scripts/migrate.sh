@@ -0,0 +1,39 @@
+#!/usr/bin/env sh
+# Applies scripts/migrations/*.sql to DATABASE_URL in file name order.
+# Applied files are recorded in schema_migrations, so the script can be re-run safely.
+set -eu
+
+if [ -z "${DATABASE_URL:-}" ]; then
+  echo "DATABASE_URL is not set" >&2
+  exit 1
+fi
+
+MIGRATIONS_DIR="$(cd "$(dirname "$0")" && pwd)/migrations"
+
+psql "$DATABASE_URL" -v ON_ERROR_STOP=1 -q <<'SQL'
+CREATE TABLE IF NOT EXISTS schema_migrations (
+    version    TEXT        PRIMARY KEY,
+    applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+SQL
+
+for file in "$MIGRATIONS_DIR"/*.sql; do
+  [ -e "$file" ] || continue
+  version="$(basename "$file" .sql)"
+
+  applied="$(psql "$DATABASE_URL" -tA -v ON_ERROR_STOP=1 \
+    -c "SELECT 1 FROM schema_migrations WHERE version = '$version'")"
+  if [ "$applied" = "1" ]; then
+    continue
+  fi
+
+  echo "applying $version"
+  {
+    echo "BEGIN;"
+    cat "$file"
+    echo "INSERT INTO schema_migrations (version) VALUES ('$version');"
+    echo "COMMIT;"
+  } | psql "$DATABASE_URL" -v ON_ERROR_STOP=1 -q
+done
+
+echo "migrations up to date"
@@ -0,0 +1,43 @@
+-- Normalized transactions indexed per watched address, served by the history index provider.
+-- A transaction listed as several entries, e.g. a swap, has one row per leg.
+CREATE TABLE IF NOT EXISTS transactions (
+    coin_type      TEXT        NOT NULL,
+    wallet_address TEXT        NOT NULL,
+    tx_id          TEXT        NOT NULL,
+    leg            TEXT        NOT NULL DEFAULT '',
+    hash           TEXT        NOT NULL DEFAULT '',
+    type           TEXT        NOT NULL DEFAULT '',
+    category       TEXT        NOT NULL DEFAULT '',
+    status         TEXT        NOT NULL DEFAULT '',
+    token          TEXT        NOT NULL DEFAULT '',
+    amount         TEXT        NOT NULL DEFAULT '',
+    value          TEXT        NOT NULL DEFAULT '',
+    tx_address     TEXT        NOT NULL DEFAULT '',
+    to_address     TEXT        NOT NULL DEFAULT '',
+    date           TEXT        NOT NULL DEFAULT '',
+    time           TEXT        NOT NULL DEFAULT '',
+    fee            TEXT        NOT NULL DEFAULT '',
+    occurred_at    TIMESTAMPTZ NOT NULL,
+    provider       TEXT        NOT NULL DEFAULT '',
+    indexed_at     TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (coin_type, wallet_address, tx_id, leg)
+);
+
+-- Keyset pagination, newest first
+CREATE INDEX IF NOT EXISTS transactions_page_idx
+    ON transactions (coin_type, wallet_address, occurred_at DESC, tx_id DESC, leg DESC);
+
+-- Addresses the sync worker keeps up to date until expires_at. History is served from the index once backfilled is
+-- true. gap_cursor resumes new transactions that did not fit into one sync run.
+CREATE TABLE IF NOT EXISTS watched_addresses (
+    coin_type       TEXT        NOT NULL,
+    address         TEXT        NOT NULL,
+    backfill_cursor TEXT        NOT NULL DEFAULT '',
+    gap_cursor      TEXT        NOT NULL DEFAULT '',
+    backfilled      BOOLEAN     NOT NULL DEFAULT FALSE,
+    last_synced_at  TIMESTAMPTZ,
+    last_error      TEXT        NOT NULL DEFAULT '',
+    expires_at      TIMESTAMPTZ NOT NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (coin_type, address)
+);
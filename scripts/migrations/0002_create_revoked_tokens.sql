@@ -0,0 +1,7 @@
+-- Revoked token ids and session families, kept until the revoked tokens would have expired
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+    key        TEXT        PRIMARY KEY,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS revoked_tokens_expires_at_idx ON revoked_tokens (expires_at);
@@ -0,0 +1,8 @@
+-- Client registered nonce hashes and server issued sign-in nonces, shared by all replicas
+CREATE TABLE IF NOT EXISTS nonces (
+    key        TEXT        PRIMARY KEY,
+    used       BOOLEAN     NOT NULL DEFAULT FALSE,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS nonces_expires_at_idx ON nonces (expires_at);
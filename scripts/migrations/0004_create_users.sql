@@ -0,0 +1,33 @@
+-- User accounts, a user signs in with a password or any of its linked identities
+CREATE TABLE IF NOT EXISTS users (
+    id                UUID        PRIMARY KEY DEFAULT gen_random_uuid(),
+    name              TEXT        NOT NULL DEFAULT '',
+    email             TEXT,
+    password_hash     TEXT,
+    -- email_verified_at is cleared whenever the email changes, password sign-in needs a verified email
+    email_verified_at TIMESTAMPTZ,
+    created_at        TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at        TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- Only verified emails are unique, so an unverified signup cannot hold an address its owner wants to use
+CREATE UNIQUE INDEX IF NOT EXISTS users_verified_email_key ON users (email) WHERE email_verified_at IS NOT NULL;
+
+-- Session subjects linked to a user, e.g. google:<id>, eip155:<address>, solana:<address>
+CREATE TABLE IF NOT EXISTS user_identities (
+    subject    TEXT        PRIMARY KEY,
+    user_id    UUID        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS user_identities_user_id_idx ON user_identities (user_id);
+
+-- Pending email verifications, only the SHA-256 of the emailed token is stored
+CREATE TABLE IF NOT EXISTS email_verifications (
+    token_hash TEXT        PRIMARY KEY,
+    user_id    UUID        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    email      TEXT        NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS email_verifications_user_id_idx ON email_verifications (user_id);
@@ -0,0 +1,18 @@
+-- Partner API keys, only the SHA-256 hash of a key is stored
+CREATE TABLE IF NOT EXISTS api_keys (
+    id           TEXT        PRIMARY KEY,
+    name         TEXT        NOT NULL,
+    key_hash     TEXT        NOT NULL,
+    scopes       TEXT[]      NOT NULL,
+    daily_quota  INTEGER     NOT NULL DEFAULT 0,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    revoked_at   TIMESTAMPTZ
+);
+
+-- Requests per key and UTC day, checked against daily_quota
+CREATE TABLE IF NOT EXISTS api_key_usage (
+    key_id TEXT    NOT NULL REFERENCES api_keys (id) ON DELETE CASCADE,
+    day    DATE    NOT NULL,
+    count  INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (key_id, day)
+);
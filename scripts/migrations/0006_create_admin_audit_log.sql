@@ -0,0 +1,15 @@
+-- Who triggered each admin action, with the asset ID mapping counts around it where relevant
+CREATE TABLE IF NOT EXISTS admin_audit_log (
+    id           BIGSERIAL   PRIMARY KEY,
+    actor        TEXT        NOT NULL,
+    remote_addr  TEXT        NOT NULL DEFAULT '',
+    action       TEXT        NOT NULL,
+    target       TEXT        NOT NULL DEFAULT '',
+    before_count INTEGER,
+    after_count  INTEGER,
+    success      BOOLEAN     NOT NULL,
+    error        TEXT        NOT NULL DEFAULT '',
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS admin_audit_log_created_at_idx ON admin_audit_log (created_at DESC);
@@ -2,12 +2,14 @@ package static
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/tashunc/nugenesis-wallet-backend/external/apikey"
 	"github.com/tashunc/nugenesis-wallet-backend/static/staticControllers"
 	"github.com/tashunc/nugenesis-wallet-backend/static/staticServices"
 )
 
 var assetController *staticControllers.AssetController
 var blockchainController *staticControllers.BlockchainController
+var nonceController *staticControllers.NonceController
 
 func initControllers() {
 	if assetController == nil {
@@ -15,31 +17,37 @@ func initControllers() {
 		blockchainService := staticServices.NewBlockchainService(assetService)
 		assetController = staticControllers.NewAssetController(assetService)
 		blockchainController = staticControllers.NewBlockchainController(blockchainService)
+		nonceController = staticControllers.NewNonceController(staticServices.GetNonceStore())
 	}
 }
 
 func RegisterRoutes(rg *gin.RouterGroup) {
 	initControllers()
 
+	// Nonces are registered before a key is known to hold tx:send, any valid key may register them
+	nonceGroup := rg.Group("/nonce", apikey.RequireKey())
+	{
+		nonceGroup.GET("/info", nonceController.Info)
+		nonceGroup.POST("/register", nonceController.Register)
+	}
+
 	staticGroup := rg.Group("/static")
 	{
-		// Serve static asset files (logos, etc.)
+		// Serve static asset files (logos, etc.), public since images cannot send an API key
 		staticGroup.Static("/assetsLogo", "./assets")
 
 		// API routes for asset data
-		assetGroup := staticGroup.Group("/assets")
+		assetGroup := staticGroup.Group("/assets", apikey.RequireScope(apikey.ScopeAssetsRead))
 		{
 			assetGroup.GET("/symbols", assetController.GetAllSymbols)
 			assetGroup.GET("/", assetController.GetAllAssets)
 			assetGroup.GET("/symbol/:symbol", assetController.GetByCoinSymbol)
-			assetGroup.POST("/refresh", assetController.ForceRefresh)
-			assetGroup.POST("/generate-ids", assetController.GenerateAllIDs)
 			assetGroup.GET("/cache/stats", assetController.GetCacheStats)
 			assetGroup.GET("/health", assetController.HealthCheck)
 		}
 
 		// API routes for blockchain data
-		blockchainGroup := staticGroup.Group("/blockchains")
+		blockchainGroup := staticGroup.Group("/blockchains", apikey.RequireScope(apikey.ScopeAssetsRead))
 		{
 			blockchainGroup.GET("/", blockchainController.GetAllBlockchains)
 			blockchainGroup.GET("/id/:id", blockchainController.GetBlockchainByID)
@@ -47,3 +55,14 @@ func RegisterRoutes(rg *gin.RouterGroup) {
 		}
 	}
 }
+
+// RegisterAdminRoutes mounts the asset endpoints that mutate the cache and ID mappings on the admin group
+func RegisterAdminRoutes(adminGroup *gin.RouterGroup) {
+	initControllers()
+
+	assetGroup := adminGroup.Group("/assets")
+	{
+		assetGroup.POST("/refresh", assetController.ForceRefresh)
+		assetGroup.POST("/generate-ids", assetController.GenerateAllIDs)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/tashunc/nugenesis-wallet-backend/external/admin"
 	"github.com/tashunc/nugenesis-wallet-backend/static/staticModels"
 	"github.com/tashunc/nugenesis-wallet-backend/static/staticServices"
 )
@@ -106,7 +107,10 @@ func (c *AssetController) GetAllAssets(ctx *gin.Context) {
 
 // ForceRefresh handles POST requests to force cache refresh
 func (c *AssetController) ForceRefresh(ctx *gin.Context) {
+	before := c.assetService.IDMappingCount()
 	err := c.assetService.ForceRefresh()
+	after := c.assetService.IDMappingCount()
+	admin.Audit(ctx, "assets.refresh", "", &before, &after, err)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, staticModels.ErrorResponse{
 			Error: err.Error(),
@@ -131,7 +135,10 @@ func (c *AssetController) GetCacheStats(ctx *gin.Context) {
 
 // GenerateAllIDs handles POST requests to generate IDs for all assets
 func (c *AssetController) GenerateAllIDs(ctx *gin.Context) {
+	before := c.assetService.IDMappingCount()
 	err := c.assetService.GenerateAllIDs()
+	after := c.assetService.IDMappingCount()
+	admin.Audit(ctx, "assets.generate_ids", "", &before, &after, err)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, staticModels.ErrorResponse{
 			Error: err.Error(),
@@ -0,0 +1,64 @@
+package staticControllers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tashunc/nugenesis-wallet-backend/static/staticModels"
+	"github.com/tashunc/nugenesis-wallet-backend/static/staticServices"
+)
+
+// NonceController registers client nonces for routes protected by ClientNonceAuthMiddleware
+type NonceController struct {
+	nonceStore staticServices.NonceStore
+}
+
+// NewNonceController creates a new NonceController instance
+func NewNonceController(nonceStore staticServices.NonceStore) *NonceController {
+	return &NonceController{
+		nonceStore: nonceStore,
+	}
+}
+
+// Info handles GET requests describing the nonce scheme
+func (c *NonceController) Info(ctx *gin.Context) {
+	policy := c.nonceStore.Policy()
+	ctx.JSON(http.StatusOK, staticModels.NonceInfoResponse{
+		NonceLengthMin:   32,
+		TTLSeconds:       int64(policy.TTL.Seconds()),
+		ClockSkewSeconds: int64(policy.ClockSkew.Seconds()),
+		HashAlgorithm:    "SHA256",
+		Format:           "nonce + timestamp -> SHA256",
+	})
+}
+
+// Register handles POST requests registering a nonce and returns the hash to send with the protected request
+func (c *NonceController) Register(ctx *gin.Context) {
+	var request staticModels.NonceRegisterRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, staticModels.ErrorResponse{Error: "Invalid request format"})
+		return
+	}
+
+	timestamp := time.Unix(request.Timestamp, 0)
+	hash, err := c.nonceStore.RegisterNonce(ctx.Request.Context(), request.Nonce, timestamp)
+	switch {
+	case errors.Is(err, staticServices.ErrNonceTimestampOutOfRange):
+		ctx.JSON(http.StatusBadRequest, staticModels.ErrorResponse{Error: "Invalid timestamp"})
+		return
+	case errors.Is(err, staticServices.ErrNonceAlreadyRegistered):
+		ctx.JSON(http.StatusConflict, staticModels.ErrorResponse{Error: err.Error()})
+		return
+	case err != nil:
+		ctx.JSON(http.StatusInternalServerError, staticModels.ErrorResponse{Error: "Failed to register nonce"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, staticModels.NonceRegisterResponse{
+		Hash:      hash,
+		Timestamp: request.Timestamp,
+		Expires:   timestamp.Add(c.nonceStore.Policy().TTL).Unix(),
+	})
+}
@@ -0,0 +1,23 @@
+package staticModels
+
+// NonceRegisterRequest registers a client generated nonce, Timestamp is in unix seconds
+type NonceRegisterRequest struct {
+	Nonce     string `json:"nonce" binding:"required,min=32"`
+	Timestamp int64  `json:"timestamp" binding:"required"`
+}
+
+// NonceRegisterResponse carries the hash the client sends back in X-Nonce-Hash
+type NonceRegisterResponse struct {
+	Hash      string `json:"hash"`
+	Timestamp int64  `json:"timestamp"`
+	Expires   int64  `json:"expires"`
+}
+
+// NonceInfoResponse describes how clients build nonce headers
+type NonceInfoResponse struct {
+	NonceLengthMin   int    `json:"nonce_length_min"`
+	TTLSeconds       int64  `json:"ttl_seconds"`
+	ClockSkewSeconds int64  `json:"clock_skew_seconds"`
+	HashAlgorithm    string `json:"hash_algorithm"`
+	Format           string `json:"format"`
+}
@@ -25,7 +25,7 @@ type AssetIDMapping struct {
 type AssetService struct {
 	assetCache       map[string][]staticModels.AssetResponse
 	idMappings       map[string]string           // asset_key -> id
-	solanaTokenCache map[string]string           // Solana mint address -> token symbol
+	solanaTokenCache map[string]SolanaToken      // Solana mint address -> token symbol and decimals
 	nextID           int                         // counter for generating new IDs
 	blockchainMap    map[string]general.CoinType // blockchain name -> CoinType ID
 	mutex            sync.RWMutex
@@ -42,7 +42,7 @@ func NewAssetService() *AssetService {
 	service := &AssetService{
 		assetCache:       make(map[string][]staticModels.AssetResponse),
 		idMappings:       make(map[string]string),
-		solanaTokenCache: make(map[string]string),
+		solanaTokenCache: make(map[string]SolanaToken),
 		blockchainMap:    make(map[string]general.CoinType),
 		nextID:           1,
 		cacheTTL:         30 * time.Minute, // Cache for 30 minutes
@@ -444,6 +444,13 @@ func (s *AssetService) ForceRefresh() error {
 	return s.loadAllAssets()
 }
 
+// IDMappingCount returns how many assets have an ID assigned
+func (s *AssetService) IDMappingCount() int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return len(s.idMappings)
+}
+
 // GetCacheStats returns cache statistics
 func (s *AssetService) GetCacheStats() map[string]interface{} {
 	s.mutex.RLock()
@@ -528,9 +535,10 @@ func (s *AssetService) GenerateAllIDs() error {
 
 // TokenListItem represents a single token from the tokenlist.json
 type TokenListItem struct {
-	Address string `json:"address"`
-	Symbol  string `json:"symbol"`
-	Name    string `json:"name"`
+	Address  string `json:"address"`
+	Symbol   string `json:"symbol"`
+	Name     string `json:"name"`
+	Decimals int    `json:"decimals"`
 }
 
 // TokenList represents the structure of tokenlist.json
@@ -538,6 +546,12 @@ type TokenList struct {
 	Tokens []TokenListItem `json:"tokens"`
 }
 
+// SolanaToken is the symbol and decimals of a Solana mint
+type SolanaToken struct {
+	Symbol   string
+	Decimals int
+}
+
 // loadSolanaTokenCache loads Solana token symbols from asset files into a static cache
 func (s *AssetService) loadSolanaTokenCache() {
 	s.tokenCacheMutex.Lock()
@@ -548,7 +562,7 @@ func (s *AssetService) loadSolanaTokenCache() {
 		return
 	}
 
-	s.solanaTokenCache = make(map[string]string)
+	s.solanaTokenCache = make(map[string]SolanaToken)
 	solanaPath := filepath.Join(s.assetsPath, "solana")
 
 	// Load from asset info.json files
@@ -563,7 +577,7 @@ func (s *AssetService) loadSolanaTokenCache() {
 					var assetInfo staticModels.AssetInfo
 					if err := json.Unmarshal(assetData, &assetInfo); err == nil {
 						// Map mint address to symbol (case-insensitive)
-						s.solanaTokenCache[strings.ToLower(mintAddress)] = assetInfo.Symbol
+						s.solanaTokenCache[strings.ToLower(mintAddress)] = SolanaToken{Symbol: assetInfo.Symbol, Decimals: assetInfo.Decimals}
 					}
 				}
 			}
@@ -579,7 +593,7 @@ func (s *AssetService) loadSolanaTokenCache() {
 			for _, token := range tokenList.Tokens {
 				normalizedAddr := strings.ToLower(token.Address)
 				if _, exists := s.solanaTokenCache[normalizedAddr]; !exists {
-					s.solanaTokenCache[normalizedAddr] = token.Symbol
+					s.solanaTokenCache[normalizedAddr] = SolanaToken{Symbol: token.Symbol, Decimals: token.Decimals}
 				}
 			}
 		}
@@ -589,9 +603,9 @@ func (s *AssetService) loadSolanaTokenCache() {
 	fmt.Printf("Loaded %d Solana tokens into cache from asset files\n", len(s.solanaTokenCache))
 }
 
-// GetTokenSymbolByMint returns the token symbol for a given Solana mint address
-// Uses a static cache loaded from tokenlist.json
-func (s *AssetService) GetTokenSymbolByMint(mint string) string {
+// GetTokenSymbolByMint returns the token symbol and decimals for a given Solana mint address
+// Uses a static cache loaded from the asset files and tokenlist.json, found is false for unknown mints
+func (s *AssetService) GetTokenSymbolByMint(mint string) (symbol string, decimals int, found bool) {
 	// Ensure cache is loaded (happens only once)
 	if !s.tokenCacheLoaded {
 		s.loadSolanaTokenCache()
@@ -604,13 +618,13 @@ func (s *AssetService) GetTokenSymbolByMint(mint string) string {
 	normalizedMint := strings.ToLower(mint)
 
 	// Lookup in cache
-	if symbol, exists := s.solanaTokenCache[normalizedMint]; exists {
-		return symbol
+	if token, exists := s.solanaTokenCache[normalizedMint]; exists {
+		return token.Symbol, token.Decimals, true
 	}
 
 	// Return the first 4 characters of the mint as fallback
 	if len(mint) >= 4 {
-		return mint[:4] + "..."
+		return mint[:4] + "...", 0, false
 	}
-	return "UNKNOWN"
+	return "UNKNOWN", 0, false
 }
@@ -1,105 +1,248 @@
 package staticServices
 
 import (
+	"context"
+	"crypto/rand"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
 	"net/http"
 	"strconv"
 	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/tashunc/nugenesis-wallet-backend/config"
+	"github.com/tashunc/nugenesis-wallet-backend/pkg/database"
 )
 
+var (
+	// ErrNonceTimestampOutOfRange means the client clock is further from the server clock than the allowed skew
+	ErrNonceTimestampOutOfRange = errors.New("nonce timestamp is outside the allowed window")
+	ErrNonceAlreadyRegistered   = errors.New("nonce is already registered")
+)
+
+// NonceStore keeps client registered nonce hashes and server issued nonces, each can be consumed once.
+// Consumed client nonces are kept until they expire so the same nonce cannot be registered and replayed again.
+type NonceStore interface {
+	// RegisterNonce stores the hash of nonce and timestamp and returns it
+	RegisterNonce(ctx context.Context, nonce string, timestamp time.Time) (string, error)
+	ValidateAndConsumeNonce(ctx context.Context, nonce string, timestamp time.Time, providedHash string) (bool, error)
+	// IssueNonce generates a random single-use nonce for a message the client signs, e.g. a sign-in message
+	IssueNonce(ctx context.Context) (string, time.Time, error)
+	ConsumeIssuedNonce(ctx context.Context, nonce string) (bool, error)
+	CleanupExpired(ctx context.Context) error
+	Policy() NoncePolicy
+}
+
+// NoncePolicy is how long nonces live and how far client timestamps may be off
+type NoncePolicy struct {
+	TTL       time.Duration
+	ClockSkew time.Duration
+}
+
+// checkTimestamp accepts timestamps up to ClockSkew ahead of now and no older than window
+func (p NoncePolicy) checkTimestamp(timestamp time.Time, window time.Duration, now time.Time) error {
+	if timestamp.After(now.Add(p.ClockSkew)) || timestamp.Before(now.Add(-window)) {
+		return ErrNonceTimestampOutOfRange
+	}
+	return nil
+}
+
 type NonceRecord struct {
 	Hash      string    `json:"hash"`
 	Used      bool      `json:"used"`
 	Timestamp time.Time `json:"timestamp"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+var (
+	nonceStore     NonceStore
+	nonceStoreOnce sync.Once
+)
+
+// GetNonceStore returns the shared nonce store, backed by Postgres when DATABASE_URL is configured
+// so that all replicas see the same nonces, and by memory otherwise
+func GetNonceStore() NonceStore {
+	nonceStoreOnce.Do(func() {
+		cfg := config.Get()
+		policy := NoncePolicy{TTL: cfg.NonceTTL, ClockSkew: cfg.NonceClockSkew}
+
+		db, err := database.GetDB()
+		if err == nil {
+			nonceStore = NewPostgresNonceStore(db, policy)
+			return
+		}
+		if !errors.Is(err, database.ErrNotConfigured) {
+			log.Printf("Warning: nonces are kept in memory: %v", err)
+		}
+		nonceStore = NewMemoryNonceStore(policy)
+	})
+	return nonceStore
+}
+
+// StartNonceCleanup drops expired nonces every interval until ctx is done
+func StartNonceCleanup(ctx context.Context, store NonceStore, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := store.CleanupExpired(ctx); err != nil {
+					log.Printf("nonce cleanup: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// hashNonce is the hash clients send back in X-Nonce-Hash
+func hashNonce(nonce string, timestamp time.Time) string {
+	hasher := sha256.New()
+	hasher.Write([]byte(nonce + strconv.FormatInt(timestamp.Unix(), 10)))
+	return hex.EncodeToString(hasher.Sum(nil))
 }
 
-type NonceStore struct {
+// issuedNonceAlphabet keeps server nonces alphanumeric as EIP-4361 requires
+const issuedNonceAlphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+
+func generateIssuedNonce() (string, error) {
+	buf := make([]byte, 24)
+	for i := range buf {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(issuedNonceAlphabet))))
+		if err != nil {
+			return "", fmt.Errorf("failed to generate nonce: %w", err)
+		}
+		buf[i] = issuedNonceAlphabet[n.Int64()]
+	}
+	return string(buf), nil
+}
+
+// issuedKey keeps server issued nonces apart from the client nonce hashes
+func issuedKey(nonce string) string {
+	return "issued:" + nonce
+}
+
+type MemoryNonceStore struct {
 	nonces map[string]*NonceRecord
+	policy NoncePolicy
 	mutex  sync.RWMutex
 }
 
-func NewNonceStore() *NonceStore {
-	return &NonceStore{
+func NewMemoryNonceStore(policy NoncePolicy) *MemoryNonceStore {
+	return &MemoryNonceStore{
 		nonces: make(map[string]*NonceRecord),
+		policy: policy,
 	}
 }
 
-// Register a nonce hash for future use
-func (ns *NonceStore) RegisterNonce(nonce string, timestamp time.Time) string {
-	// Create hash of nonce + timestamp for validation
-	hasher := sha256.New()
-	hasher.Write([]byte(nonce + strconv.FormatInt(timestamp.Unix(), 10)))
-	hash := hex.EncodeToString(hasher.Sum(nil))
+func (ns *MemoryNonceStore) Policy() NoncePolicy {
+	return ns.policy
+}
+
+func (ns *MemoryNonceStore) IssueNonce(ctx context.Context) (string, time.Time, error) {
+	nonce, err := generateIssuedNonce()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	issuedAt := time.Now()
+	expiresAt := issuedAt.Add(ns.policy.TTL)
 
 	ns.mutex.Lock()
-	ns.nonces[hash] = &NonceRecord{
-		Hash:      hash,
+	ns.nonces[issuedKey(nonce)] = &NonceRecord{
+		Hash:      nonce,
 		Used:      false,
-		Timestamp: timestamp,
+		Timestamp: issuedAt,
+		ExpiresAt: expiresAt,
 	}
 	ns.mutex.Unlock()
 
-	return hash
+	return nonce, expiresAt, nil
 }
 
-// Validate and consume a nonce
-func (ns *NonceStore) ValidateAndConsumeNonce(nonce string, timestamp time.Time, providedHash string) bool {
-	// Recreate expected hash
-	hasher := sha256.New()
-	hasher.Write([]byte(nonce + strconv.FormatInt(timestamp.Unix(), 10)))
-	expectedHash := hex.EncodeToString(hasher.Sum(nil))
+// ConsumeIssuedNonce validates a nonce returned by IssueNonce and removes it, so it can be used only once
+func (ns *MemoryNonceStore) ConsumeIssuedNonce(ctx context.Context, nonce string) (bool, error) {
+	ns.mutex.Lock()
+	defer ns.mutex.Unlock()
 
-	// Check if provided hash matches expected
-	if expectedHash != providedHash {
-		return false
+	record, exists := ns.nonces[issuedKey(nonce)]
+	if !exists {
+		return false, nil
 	}
+	delete(ns.nonces, issuedKey(nonce))
+
+	return !record.Used && time.Now().Before(record.ExpiresAt), nil
+}
+
+// Register a nonce hash for future use
+func (ns *MemoryNonceStore) RegisterNonce(ctx context.Context, nonce string, timestamp time.Time) (string, error) {
+	if err := ns.policy.checkTimestamp(timestamp, ns.policy.ClockSkew, time.Now()); err != nil {
+		return "", err
+	}
+	hash := hashNonce(nonce, timestamp)
 
 	ns.mutex.Lock()
 	defer ns.mutex.Unlock()
 
-	record, exists := ns.nonces[expectedHash]
-	if !exists {
-		return false
+	if _, exists := ns.nonces[hash]; exists {
+		return "", ErrNonceAlreadyRegistered
 	}
+	ns.nonces[hash] = &NonceRecord{
+		Hash:      hash,
+		Used:      false,
+		Timestamp: timestamp,
+		ExpiresAt: timestamp.Add(ns.policy.TTL),
+	}
+	return hash, nil
+}
 
-	// Check if already used
-	if record.Used {
-		return false
+// Validate and consume a nonce
+func (ns *MemoryNonceStore) ValidateAndConsumeNonce(ctx context.Context, nonce string, timestamp time.Time, providedHash string) (bool, error) {
+	// Recreate expected hash
+	expectedHash := hashNonce(nonce, timestamp)
+	if expectedHash != providedHash {
+		return false, nil
 	}
 
-	// Check timestamp validity (within 5 minutes)
-	if time.Since(timestamp) > 5*time.Minute {
-		delete(ns.nonces, expectedHash)
-		return false
+	if err := ns.policy.checkTimestamp(timestamp, ns.policy.TTL, time.Now()); err != nil {
+		return false, nil
 	}
 
-	// Mark as used
-	record.Used = true
+	ns.mutex.Lock()
+	defer ns.mutex.Unlock()
 
-	// Remove after use (prevents replay)
-	delete(ns.nonces, expectedHash)
+	record, exists := ns.nonces[expectedHash]
+	if !exists || record.Used || !time.Now().Before(record.ExpiresAt) {
+		return false, nil
+	}
 
-	return true
+	// Mark as used, the record stays until it expires so the nonce cannot be registered again
+	record.Used = true
+	return true, nil
 }
 
-func (ns *NonceStore) CleanupExpired() {
+func (ns *MemoryNonceStore) CleanupExpired(ctx context.Context) error {
 	ns.mutex.Lock()
 	defer ns.mutex.Unlock()
 
-	cutoff := time.Now().Add(-5 * time.Minute)
+	now := time.Now()
 	for hash, record := range ns.nonces {
-		if record.Timestamp.Before(cutoff) {
+		if !now.Before(record.ExpiresAt) {
 			delete(ns.nonces, hash)
 		}
 	}
+	return nil
 }
 
 // Middleware for client-generated nonce authentication
-func ClientNonceAuthMiddleware(store *NonceStore) gin.HandlerFunc {
+func ClientNonceAuthMiddleware(store NonceStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		nonce := c.GetHeader("X-Nonce")
 		timestampStr := c.GetHeader("X-Nonce-Timestamp")
@@ -123,7 +266,13 @@ func ClientNonceAuthMiddleware(store *NonceStore) gin.HandlerFunc {
 
 		timestamp := time.Unix(timestampInt, 0)
 
-		if !store.ValidateAndConsumeNonce(nonce, timestamp, hash) {
+		valid, err := store.ValidateAndConsumeNonce(c.Request.Context(), nonce, timestamp, hash)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate nonce"})
+			c.Abort()
+			return
+		}
+		if !valid {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"error": "Invalid, expired, or already used nonce",
 			})
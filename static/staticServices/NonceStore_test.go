@@ -0,0 +1,54 @@
+package staticServices
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMemoryNonceStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryNonceStore(NoncePolicy{TTL: 5 * time.Minute, ClockSkew: time.Minute})
+	nonce := "0123456789abcdef0123456789abcdef"
+	timestamp := time.Unix(time.Now().Unix(), 0)
+
+	if _, err := store.RegisterNonce(ctx, nonce, timestamp.Add(-2*time.Minute)); !errors.Is(err, ErrNonceTimestampOutOfRange) {
+		t.Errorf("Expected skewed timestamp to be rejected, got %v", err)
+	}
+
+	hash, err := store.RegisterNonce(ctx, nonce, timestamp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if valid, _ := store.ValidateAndConsumeNonce(ctx, nonce, timestamp, "bad"); valid {
+		t.Error("Expected a wrong hash to be rejected")
+	}
+	if valid, _ := store.ValidateAndConsumeNonce(ctx, nonce, timestamp, hash); !valid {
+		t.Error("Expected registered nonce to be valid")
+	}
+	if valid, _ := store.ValidateAndConsumeNonce(ctx, nonce, timestamp, hash); valid {
+		t.Error("Expected nonce to be single use")
+	}
+	if _, err := store.RegisterNonce(ctx, nonce, timestamp); !errors.Is(err, ErrNonceAlreadyRegistered) {
+		t.Errorf("Expected a used nonce to stay registered until it expires, got %v", err)
+	}
+
+	// Expired records are swept, the nonce cannot be replayed past its TTL either way
+	store.nonces[hash].ExpiresAt = time.Now().Add(-time.Second)
+	_ = store.CleanupExpired(ctx)
+	if len(store.nonces) != 0 {
+		t.Errorf("Expected expired nonces to be removed, %d left", len(store.nonces))
+	}
+
+	issued, _, err := store.IssueNonce(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if consumed, _ := store.ConsumeIssuedNonce(ctx, issued); !consumed {
+		t.Error("Expected issued nonce to be consumed")
+	}
+	if consumed, _ := store.ConsumeIssuedNonce(ctx, issued); consumed {
+		t.Error("Expected issued nonce to be single use")
+	}
+}
@@ -0,0 +1,95 @@
+package staticServices
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// PostgresNonceStore keeps nonces in the nonces table, consuming is a single conditional update
+// so two replicas cannot accept the same nonce
+type PostgresNonceStore struct {
+	db     *sql.DB
+	policy NoncePolicy
+}
+
+func NewPostgresNonceStore(db *sql.DB, policy NoncePolicy) *PostgresNonceStore {
+	return &PostgresNonceStore{db: db, policy: policy}
+}
+
+func (s *PostgresNonceStore) Policy() NoncePolicy {
+	return s.policy
+}
+
+func (s *PostgresNonceStore) IssueNonce(ctx context.Context) (string, time.Time, error) {
+	nonce, err := generateIssuedNonce()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	expiresAt := time.Now().Add(s.policy.TTL)
+
+	if _, err := s.db.ExecContext(ctx, `INSERT INTO nonces (key, expires_at) VALUES ($1, $2)`, issuedKey(nonce), expiresAt); err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to store nonce: %w", err)
+	}
+	return nonce, expiresAt, nil
+}
+
+func (s *PostgresNonceStore) ConsumeIssuedNonce(ctx context.Context, nonce string) (bool, error) {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM nonces WHERE key = $1 AND NOT used AND expires_at > now()`, issuedKey(nonce))
+	if err != nil {
+		return false, fmt.Errorf("failed to consume nonce: %w", err)
+	}
+	return affectedOne(result)
+}
+
+func (s *PostgresNonceStore) RegisterNonce(ctx context.Context, nonce string, timestamp time.Time) (string, error) {
+	if err := s.policy.checkTimestamp(timestamp, s.policy.ClockSkew, time.Now()); err != nil {
+		return "", err
+	}
+	hash := hashNonce(nonce, timestamp)
+
+	result, err := s.db.ExecContext(ctx, `INSERT INTO nonces (key, expires_at) VALUES ($1, $2) ON CONFLICT (key) DO NOTHING`,
+		hash, timestamp.Add(s.policy.TTL))
+	if err != nil {
+		return "", fmt.Errorf("failed to register nonce: %w", err)
+	}
+	if inserted, err := affectedOne(result); err != nil {
+		return "", err
+	} else if !inserted {
+		return "", ErrNonceAlreadyRegistered
+	}
+	return hash, nil
+}
+
+func (s *PostgresNonceStore) ValidateAndConsumeNonce(ctx context.Context, nonce string, timestamp time.Time, providedHash string) (bool, error) {
+	expectedHash := hashNonce(nonce, timestamp)
+	if expectedHash != providedHash {
+		return false, nil
+	}
+	if err := s.policy.checkTimestamp(timestamp, s.policy.TTL, time.Now()); err != nil {
+		return false, nil
+	}
+
+	// The row is kept as used until it expires so the nonce cannot be registered again
+	result, err := s.db.ExecContext(ctx, `UPDATE nonces SET used = TRUE WHERE key = $1 AND NOT used AND expires_at > now()`, expectedHash)
+	if err != nil {
+		return false, fmt.Errorf("failed to consume nonce: %w", err)
+	}
+	return affectedOne(result)
+}
+
+func (s *PostgresNonceStore) CleanupExpired(ctx context.Context) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM nonces WHERE expires_at <= now()`); err != nil {
+		return fmt.Errorf("failed to clean up nonces: %w", err)
+	}
+	return nil
+}
+
+func affectedOne(result sql.Result) (bool, error) {
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to read affected rows: %w", err)
+	}
+	return rows == 1, nil
+}